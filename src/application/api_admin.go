@@ -0,0 +1,234 @@
+package application
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	adminPathPrefix = "/admin"
+	bearerPrefix    = "Bearer "
+)
+
+// configurationStatusResponse contains the information of the configuration currently applied
+type configurationStatusResponse struct {
+	Version           string                            // Version of the configuration applied
+	LastExecutionDate time.Time                         // Date of the last execution of the update process
+	LastUpdatedDate   time.Time                         // Date of the last successful update
+	UpdateMessages    []models.ConfigurationUpdateError // Errors found during the last update process
+	PinState          *ConfigurationPinState            // Pinned configuration version, nil if not pinned
+}
+
+// registerAdminRoutes Registers the administration routes on the router
+//
+// Parameters:
+//   - r: Router where the routes will be registered
+//
+// Returns:
+func (as *APIServer) registerAdminRoutes(r *mux.Router) {
+	if !as.cm.IsAdminEnabled() {
+		return
+	}
+
+	as.logger.Info("Registering administration routes", as.pack, "registerAdminRoutes")
+	admin := r.PathPrefix(adminPathPrefix).Subrouter()
+	admin.Use(as.adminAuthentication)
+	admin.HandleFunc("/config", as.handleGetConfiguration).Name("AdminConfiguration").Methods("GET")
+	admin.HandleFunc("/config/refresh", as.handleRefreshConfiguration).Name("AdminConfigurationRefresh").Methods("POST")
+	admin.HandleFunc("/config/history", as.handleGetConfigurationHistory).Name("AdminConfigurationHistory").Methods("GET")
+	admin.HandleFunc("/config/pin/{version}", as.handlePinConfiguration).Name("AdminConfigurationPin").Methods("POST")
+	admin.HandleFunc("/config/pin", as.handleUnpinConfiguration).Name("AdminConfigurationUnpin").Methods("DELETE")
+	admin.HandleFunc("/endpoints", as.handleGetEndpoints).Name("AdminEndpoints").Methods("GET")
+	admin.HandleFunc("/endpoints/{name:.+}/schema", as.handleGetEndpointSchema).Name("AdminEndpointSchema").Methods("GET")
+}
+
+// adminAuthentication Middleware that validates the API key of the administration requests
+//
+// Parameters:
+//   - next: Handler to be executed if the request is authorized
+//
+// Returns:
+//   - http.Handler: Handler with the authentication
+func (as *APIServer) adminAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		key := strings.TrimPrefix(authorization, bearerPrefix)
+		if !strings.HasPrefix(authorization, bearerPrefix) || subtle.ConstantTimeCompare([]byte(key), []byte(as.cm.GetAdminAPIKey())) != 1 {
+			as.logger.Warning("Unauthorized administration request: "+r.URL.Path, as.pack, "adminAuthentication")
+			as.updateResponseError(w, GenericError{Message: "Authorization: Not found or invalid."}, http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSONResponse Writes a JSON response
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - data: Object to be serialized
+//   - responseCode: HTTP response code
+//
+// Returns:
+func (as *APIServer) writeJSONResponse(w http.ResponseWriter, data interface{}, responseCode int) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		as.logger.Error(err, "Error creating JSON response", as.pack, "writeJSONResponse")
+		as.updateResponseError(w, GenericError{Message: "Error creating response."}, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseCode)
+	_, err = w.Write(jsonData)
+	if err != nil {
+		as.logger.Error(err, "Error writing JSON response:", as.pack, "writeJSONResponse")
+	}
+}
+
+// handleGetConfiguration Returns the status of the configuration currently applied
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleGetConfiguration(w http.ResponseWriter, r *http.Request) {
+	updateStatus := as.cm.GetUpdateStatus()
+	response := configurationStatusResponse{
+		Version:           as.cm.GetConfigurationVersion(),
+		LastExecutionDate: updateStatus.LastExecutionDate,
+		LastUpdatedDate:   updateStatus.LastUpdatedDate,
+		UpdateMessages:    updateStatus.UpdateMessages,
+		PinState:          as.cm.GetPinState(),
+	}
+
+	as.writeJSONResponse(w, response, http.StatusOK)
+}
+
+// handleRefreshConfiguration Executes the configuration update immediately and returns its outcome
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleRefreshConfiguration(w http.ResponseWriter, r *http.Request) {
+	if as.cm.GetPinState() != nil {
+		as.updateResponseError(w, GenericError{Message: errConfigurationPinned.Error()}, http.StatusConflict)
+		return
+	}
+
+	as.handleRefreshResult(w, as.cm.RefreshConfiguration())
+}
+
+// handleRefreshResult Writes the outcome of a configuration update
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - result: Outcome of the configuration update
+//
+// Returns:
+func (as *APIServer) handleRefreshResult(w http.ResponseWriter, result ConfigurationRefreshResult) {
+	if result.Error != "" {
+		as.writeJSONResponse(w, result, http.StatusBadGateway)
+		return
+	}
+
+	as.writeJSONResponse(w, result, http.StatusOK)
+}
+
+// handleGetConfigurationHistory Returns the list of configuration versions stored in the history
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleGetConfigurationHistory(w http.ResponseWriter, r *http.Request) {
+	as.writeJSONResponse(w, as.cm.GetConfigurationHistory(), http.StatusOK)
+}
+
+// handlePinConfiguration Applies a previous configuration version and suspends automatic updates
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handlePinConfiguration(w http.ResponseWriter, r *http.Request) {
+	version := mux.Vars(r)["version"]
+	err := as.cm.PinConfiguration(version)
+	if errors.Is(err, errVersionNotInHistory) {
+		as.updateResponseError(w, GenericError{Message: "version: Not found in history."}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		as.updateResponseError(w, GenericError{Message: "Error saving the pin state."}, http.StatusInternalServerError)
+		return
+	}
+
+	as.writeJSONResponse(w, as.cm.GetPinState(), http.StatusOK)
+}
+
+// handleUnpinConfiguration Removes the pinned version and executes the configuration update
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleUnpinConfiguration(w http.ResponseWriter, r *http.Request) {
+	as.handleRefreshResult(w, as.cm.UnpinConfiguration())
+}
+
+// handleGetEndpoints Returns the list of endpoints accepted by the current configuration
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleGetEndpoints(w http.ResponseWriter, r *http.Request) {
+	as.writeJSONResponse(w, as.cm.GetEndpointCatalogue(), http.StatusOK)
+}
+
+// handleGetEndpointSchema Returns the active JSON schema for a specific endpoint
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleGetEndpointSchema(w http.ResponseWriter, r *http.Request) {
+	endpointName := mux.Vars(r)["name"]
+	if !strings.HasPrefix(endpointName, "/") {
+		endpointName = "/" + endpointName
+	}
+
+	validationSettings := as.cm.GetEndpointSettingFromAPI(endpointName, as.logger)
+	if validationSettings == nil {
+		as.updateResponseError(w, GenericError{Message: "endpointName: Not found."}, http.StatusNotFound)
+		return
+	}
+
+	schema := validationSettings.EndpointSettings.JSONBodySchema
+	if !json.Valid([]byte(schema)) {
+		as.updateResponseError(w, GenericError{Message: "schema: Not available for endpoint: " + endpointName}, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte(schema))
+	if err != nil {
+		as.logger.Error(err, "Error writing schema response:", as.pack, "handleGetEndpointSchema")
+	}
+}
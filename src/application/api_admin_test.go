@@ -0,0 +1,132 @@
+package application
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveAdminRequest executes a request on the handler of the application with the specified authorization
+func serveAdminRequest(handler http.Handler, method string, path string, authorization string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAdminAuthentication(t *testing.T) {
+	app, _ := newTestApp(t, newTestSettings(t))
+	handler := app.Handler()
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "missing header", authorization: "", want: http.StatusUnauthorized},
+		{name: "wrong key", authorization: "Bearer other-key", want: http.StatusUnauthorized},
+		{name: "missing bearer prefix", authorization: testAdminKey, want: http.StatusUnauthorized},
+		{name: "valid key", authorization: "Bearer " + testAdminKey, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveAdminRequest(handler, http.MethodGet, "/admin/config", tt.authorization)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminRoutesDisabled(t *testing.T) {
+	settings := newTestSettings(t)
+	settings.AdminSettings.Enabled = false
+	app, _ := newTestApp(t, settings)
+
+	recorder := serveAdminRequest(app.Handler(), http.MethodGet, "/admin/config", "Bearer "+testAdminKey)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleGetConfiguration(t *testing.T) {
+	app, _ := newTestApp(t, newTestSettings(t))
+
+	recorder := serveAdminRequest(app.Handler(), http.MethodGet, "/admin/config", "Bearer "+testAdminKey)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	var response configurationStatusResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	if response.Version != "1.0.0" {
+		t.Errorf("Version = %q, want %q", response.Version, "1.0.0")
+	}
+
+	if response.LastUpdatedDate.IsZero() || response.PinState != nil {
+		t.Errorf("unexpected status: %+v", response)
+	}
+}
+
+func TestHandleGetEndpoints(t *testing.T) {
+	app, _ := newTestApp(t, newTestSettings(t))
+
+	recorder := serveAdminRequest(app.Handler(), http.MethodGet, "/admin/endpoints", "Bearer "+testAdminKey)
+	var entries []EndpointCatalogueEntry
+	if err := json.Unmarshal(recorder.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	want := map[string]string{
+		"/accounts/v2/accounts":             "HIGH",
+		"/accounts/v2/accounts/{accountId}": "MEDIUM",
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %d, want %d", len(entries), len(want))
+	}
+
+	for _, entry := range entries {
+		if want[entry.EndpointName] != entry.Throughput || entry.APIVersion != "2.0.1" || entry.APIGroup != "Accounts" {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	}
+}
+
+func TestHandleGetEndpointSchema(t *testing.T) {
+	app, _ := newTestApp(t, newTestSettings(t))
+	handler := app.Handler()
+
+	tests := []struct {
+		name        string
+		path        string
+		want        int
+		contentType string
+	}{
+		{name: "known endpoint", path: "/admin/endpoints/accounts/v2/accounts/schema", want: http.StatusOK, contentType: "application/schema+json"},
+		{name: "endpoint with parameter", path: "/admin/endpoints/accounts/v2/accounts/{accountId}/schema", want: http.StatusOK, contentType: "application/schema+json"},
+		{name: "unknown endpoint", path: "/admin/endpoints/payments/v1/pix/schema", want: http.StatusNotFound, contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveAdminRequest(handler, http.MethodGet, tt.path, "Bearer "+testAdminKey)
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.want)
+			}
+
+			if got := recorder.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+const (
+	apiKeyHeader        = "x-api-key"
+	hmacKeyIDHeader     = "x-mqd-key-id"
+	hmacTimestampHeader = "x-mqd-timestamp"
+	hmacSignatureHeader = "x-mqd-signature"
+)
+
+// contextKey type of the keys stored in the request context
+type contextKey string
+
+// credentialContextKey Key of the authenticated credential ID in the request context
+const credentialContextKey contextKey = "credentialID"
+
+// InboundAuthenticator validates the credentials of the requests received by the validation API
+type InboundAuthenticator struct {
+	mode            string            // Authentication mode (NONE / API_KEY / HMAC / MTLS)
+	credentials     map[string]string // Secrets by credential ID, for API_KEY and HMAC modes
+	allowedSubjects []string          // Subjects accepted in MTLS mode, empty to accept any certificate signed by the CA
+	maxClockSkew    time.Duration     // Maximum difference accepted for the timestamp of signed requests
+}
+
+// NewInboundAuthenticator creates a new authenticator, loading the credentials required by the configured mode
+//
+// Parameters:
+//   - settings: Application settings
+//
+// Returns:
+//   - *InboundAuthenticator: authenticator created
+//   - error: error if the credentials cannot be loaded
+func NewInboundAuthenticator(settings *configuration.Settings) (*InboundAuthenticator, error) {
+	authSettings := settings.InboundAuthSettings
+	ia := &InboundAuthenticator{
+		mode:            authSettings.Mode,
+		allowedSubjects: authSettings.AllowedSubjects,
+		maxClockSkew:    time.Duration(authSettings.MaxClockSkew) * time.Second,
+	}
+
+	if ia.mode == configuration.InboundAuthAPIKey || ia.mode == configuration.InboundAuthHMAC {
+		credentials, err := loadInboundCredentials(authSettings.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+
+		ia.credentials = credentials
+	}
+
+	return ia, nil
+}
+
+// loadInboundCredentials Loads the credentials from a file, each line has the format <credentialID>:<secret>,
+// empty lines and lines starting with # are ignored
+//
+// Parameters:
+//   - path: Path of the keys file
+//
+// Returns:
+//   - map[string]string: Secrets by credential ID
+//   - error: error if the file cannot be read or has no valid credentials
+func loadInboundCredentials(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	credentials := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, secret, found := strings.Cut(line, ":")
+		id = strings.TrimSpace(id)
+		secret = strings.TrimSpace(secret)
+		if !found || id == "" || secret == "" {
+			return nil, errors.New("invalid credential on line " + strconv.Itoa(lineNumber) + " of keys file: " + path)
+		}
+
+		credentials[id] = secret
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(credentials) == 0 {
+		return nil, errors.New("no credentials found in keys file: " + path)
+	}
+
+	return credentials, nil
+}
+
+// Authenticate validates the credentials of a request
+//
+// Parameters:
+//   - r: Request received
+//
+// Returns:
+//   - string: ID of the credential used by the request
+//   - error: error if the request is not authenticated
+func (ia *InboundAuthenticator) Authenticate(r *http.Request) (string, error) {
+	switch ia.mode {
+	case configuration.InboundAuthAPIKey:
+		return ia.authenticateAPIKey(r)
+	case configuration.InboundAuthHMAC:
+		return ia.authenticateHMAC(r)
+	case configuration.InboundAuthMTLS:
+		return ia.authenticateClientCertificate(r)
+	}
+
+	return "", nil
+}
+
+// authenticateAPIKey validates the API key of the request
+//
+// Parameters:
+//   - r: Request received
+//
+// Returns:
+//   - string: ID of the credential of the key
+//   - error: error if the key is not valid
+func (ia *InboundAuthenticator) authenticateAPIKey(r *http.Request) (string, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		return "", errors.New(apiKeyHeader + ": Not found.")
+	}
+
+	for id, secret := range ia.credentials {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(secret)) == 1 {
+			return id, nil
+		}
+	}
+
+	return "", errors.New(apiKeyHeader + ": Invalid key.")
+}
+
+// authenticateHMAC validates the signature of the request, calculated as the hex HMAC-SHA256 of
+// <method>\n<path>\n<timestamp>\n<hex SHA256 of the body>
+//
+// Parameters:
+//   - r: Request received
+//
+// Returns:
+//   - string: ID of the credential used to sign the request
+//   - error: error if the signature is not valid
+func (ia *InboundAuthenticator) authenticateHMAC(r *http.Request) (string, error) {
+	id := r.Header.Get(hmacKeyIDHeader)
+	secret, found := ia.credentials[id]
+	if !found {
+		return "", errors.New(hmacKeyIDHeader + ": Not found or invalid.")
+	}
+
+	timestamp := r.Header.Get(hmacTimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return id, errors.New(hmacTimestampHeader + ": Not found or bad format.")
+	}
+
+	skew := time.Since(time.Unix(seconds, 0))
+	if skew > ia.maxClockSkew || skew < -ia.maxClockSkew {
+		return id, errors.New(hmacTimestampHeader + ": Out of the accepted window.")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return id, errors.New("body: Failed to read request body.")
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.Method + "\n" + r.URL.Path + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(strings.ToLower(r.Header.Get(hmacSignatureHeader))), []byte(expected)) {
+		return id, errors.New(hmacSignatureHeader + ": Invalid signature.")
+	}
+
+	return id, nil
+}
+
+// authenticateClientCertificate validates the client certificate of the request against the allowed subjects,
+// the certificate chain is verified by the TLS handshake
+//
+// Parameters:
+//   - r: Request received
+//
+// Returns:
+//   - string: Subject of the certificate
+//   - error: error if there is no certificate or the subject is not allowed
+func (ia *InboundAuthenticator) authenticateClientCertificate(r *http.Request) (string, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return "", errors.New("certificate: Client certificate not found or not trusted.")
+	}
+
+	certificate := r.TLS.VerifiedChains[0][0]
+	subject := certificate.Subject.String()
+	if len(ia.allowedSubjects) == 0 {
+		return subject, nil
+	}
+
+	for _, allowed := range ia.allowedSubjects {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == subject || allowed == certificate.Subject.CommonName {
+			return subject, nil
+		}
+	}
+
+	return subject, errors.New("certificate: Subject not allowed.")
+}
+
+// inboundAuthentication Middleware that validates the credentials of the requests and records an audit log entry,
+// the rejected requests are logged as warnings and the accepted requests only in DEBUG level
+//
+// Parameters:
+//   - next: Handler to be executed if the request is authenticated
+//
+// Returns:
+//   - http.Handler: Handler with the authentication
+func (as *APIServer) inboundAuthentication(next http.Handler) http.Handler {
+	if as.ia == nil || as.ia.mode == configuration.InboundAuthNone {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentialID, err := as.ia.Authenticate(r)
+		if err != nil {
+			as.logger.Warning("Audit: rejected request to ["+r.URL.Path+"] from ["+r.RemoteAddr+"] with credential ["+credentialID+"]: "+err.Error(), as.pack, "inboundAuthentication")
+			as.updateResponseError(w, GenericError{Message: err.Error()}, http.StatusUnauthorized)
+			return
+		}
+
+		as.logger.Debug("Audit: accepted request to ["+r.URL.Path+"] from ["+r.RemoteAddr+"] with credential ["+credentialID+"] for serverOrgId ["+r.Header.Get(srvOrgID)+"]", as.pack, "inboundAuthentication")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), credentialContextKey, credentialID)))
+	})
+}
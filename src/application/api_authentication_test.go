@@ -0,0 +1,251 @@
+package application
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+const testKeysFile = "# credentials\nservice-a:secret-a\n\nservice-b: secret-b\n"
+
+// writeTestKeysFile writes a keys file in a temporary folder and returns its path
+func writeTestKeysFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// signTestRequest signs a request with the HMAC of the method, path, timestamp and body
+func signTestRequest(request *http.Request, id string, secret string, timestamp string, body string) {
+	bodyHash := sha256.Sum256([]byte(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(request.Method + "\n" + request.URL.Path + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	request.Header.Set(hmacKeyIDHeader, id)
+	request.Header.Set(hmacTimestampHeader, timestamp)
+	request.Header.Set(hmacSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestLoadInboundCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "comments and empty lines are ignored", content: testKeysFile, want: map[string]string{"service-a": "secret-a", "service-b": "secret-b"}},
+		{name: "secret with separator", content: "service-a:secret:a", want: map[string]string{"service-a": "secret:a"}},
+		{name: "line without separator", content: "service-a", wantErr: true},
+		{name: "empty secret", content: "service-a:", wantErr: true},
+		{name: "no credentials", content: "# empty\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadInboundCredentials(writeTestKeysFile(t, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadInboundCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("loadInboundCredentials() = %v, want %v", got, tt.want)
+			}
+
+			for id, secret := range tt.want {
+				if got[id] != secret {
+					t.Errorf("credential %q = %q, want %q", id, got[id], secret)
+				}
+			}
+		})
+	}
+
+	if _, err := loadInboundCredentials(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("loadInboundCredentials() with missing file, want error")
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantID  string
+		wantErr bool
+	}{
+		{name: "valid key", key: "secret-b", wantID: "service-b"},
+		{name: "missing key", wantErr: true},
+		{name: "invalid key", key: "secret-c", wantErr: true},
+		{name: "credential ID is not a key", key: "service-a", wantErr: true},
+	}
+
+	settings := newTestSettings(t)
+	settings.InboundAuthSettings.Mode = configuration.InboundAuthAPIKey
+	settings.InboundAuthSettings.KeysFile = writeTestKeysFile(t, testKeysFile)
+	ia, err := NewInboundAuthenticator(&settings)
+	if err != nil {
+		t.Fatalf("NewInboundAuthenticator() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/ValidateResponse", nil)
+			if tt.key != "" {
+				request.Header.Set(apiKeyHeader, tt.key)
+			}
+
+			id, err := ia.Authenticate(request)
+			if (err != nil) != tt.wantErr || id != tt.wantID {
+				t.Errorf("Authenticate() = %q, %v, want %q, wantErr %v", id, err, tt.wantID, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticateHMAC(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+	body := `{"data":[]}`
+
+	tests := []struct {
+		name    string
+		sign    func(request *http.Request)
+		wantErr bool
+	}{
+		{name: "valid signature", sign: func(r *http.Request) { signTestRequest(r, "service-a", "secret-a", now, body) }},
+		{name: "upper case signature", sign: func(r *http.Request) {
+			signTestRequest(r, "service-a", "secret-a", now, body)
+			r.Header.Set(hmacSignatureHeader, strings.ToUpper(r.Header.Get(hmacSignatureHeader)))
+		}},
+		{name: "unknown credential", sign: func(r *http.Request) { signTestRequest(r, "service-c", "secret-a", now, body) }, wantErr: true},
+		{name: "wrong secret", sign: func(r *http.Request) { signTestRequest(r, "service-a", "secret-b", now, body) }, wantErr: true},
+		{name: "different body", sign: func(r *http.Request) { signTestRequest(r, "service-a", "secret-a", now, `{}`) }, wantErr: true},
+		{name: "timestamp too old", sign: func(r *http.Request) { signTestRequest(r, "service-a", "secret-a", old, body) }, wantErr: true},
+		{name: "timestamp in the future", sign: func(r *http.Request) { signTestRequest(r, "service-a", "secret-a", future, body) }, wantErr: true},
+		{name: "invalid timestamp", sign: func(r *http.Request) { signTestRequest(r, "service-a", "secret-a", "yesterday", body) }, wantErr: true},
+		{name: "missing headers", sign: func(r *http.Request) {}, wantErr: true},
+	}
+
+	settings := newTestSettings(t)
+	settings.InboundAuthSettings.Mode = configuration.InboundAuthHMAC
+	settings.InboundAuthSettings.KeysFile = writeTestKeysFile(t, testKeysFile)
+	settings.InboundAuthSettings.MaxClockSkew = 300
+	ia, err := NewInboundAuthenticator(&settings)
+	if err != nil {
+		t.Fatalf("NewInboundAuthenticator() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/ValidateResponse", strings.NewReader(body))
+			tt.sign(request)
+
+			_, err := ia.Authenticate(request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// The body is still available for the validation
+			if !tt.wantErr {
+				read, _ := io.ReadAll(request.Body)
+				if string(read) != body {
+					t.Errorf("body after authentication = %q, want %q", read, body)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticateClientCertificate(t *testing.T) {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "service-a", Organization: []string{"Bank"}}}
+	subject := certificate.Subject.String()
+
+	tests := []struct {
+		name    string
+		allowed []string
+		state   *tls.ConnectionState
+		wantErr bool
+		wantID  string
+	}{
+		{name: "any certificate", state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}, wantID: subject},
+		{name: "allowed common name", allowed: []string{" service-a "}, state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}, wantID: subject},
+		{name: "allowed subject", allowed: []string{subject}, state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}, wantID: subject},
+		{name: "subject not allowed", allowed: []string{"service-b"}, state: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}, wantID: subject, wantErr: true},
+		{name: "certificate not verified", state: &tls.ConnectionState{}, wantErr: true},
+		{name: "plain HTTP", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ia := &InboundAuthenticator{mode: configuration.InboundAuthMTLS, allowedSubjects: tt.allowed}
+			request := httptest.NewRequest(http.MethodPost, "/ValidateResponse", nil)
+			request.TLS = tt.state
+
+			id, err := ia.Authenticate(request)
+			if (err != nil) != tt.wantErr || id != tt.wantID {
+				t.Errorf("Authenticate() = %q, %v, want %q, wantErr %v", id, err, tt.wantID, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInboundAuthentication(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		path           string
+		key            string
+		protectHealth  bool
+		protectMetrics bool
+		want           int
+	}{
+		{name: "no authentication", mode: configuration.InboundAuthNone, path: "/ValidateResponse", want: http.StatusOK},
+		{name: "valid key", mode: configuration.InboundAuthAPIKey, path: "/ValidateResponse", key: "secret-a", want: http.StatusOK},
+		{name: "invalid key", mode: configuration.InboundAuthAPIKey, path: "/ValidateResponse", key: "secret-c", want: http.StatusUnauthorized},
+		{name: "missing key", mode: configuration.InboundAuthAPIKey, path: "/ValidateResponse", want: http.StatusUnauthorized},
+		{name: "health not protected", mode: configuration.InboundAuthAPIKey, path: "/health", want: http.StatusOK},
+		{name: "health protected", mode: configuration.InboundAuthAPIKey, path: "/health", protectHealth: true, want: http.StatusUnauthorized},
+		{name: "metrics protected", mode: configuration.InboundAuthAPIKey, path: "/metrics", protectMetrics: true, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.InboundAuthSettings.Mode = tt.mode
+			settings.InboundAuthSettings.KeysFile = writeTestKeysFile(t, testKeysFile)
+			settings.InboundAuthSettings.ProtectHealth = tt.protectHealth
+			settings.InboundAuthSettings.ProtectMetrics = tt.protectMetrics
+			app, _ := newTestApp(t, settings)
+
+			request := newValidateRequest("/accounts/v2/accounts", `{"data":[]}`)
+			if tt.path != "/ValidateResponse" {
+				request = httptest.NewRequest(http.MethodGet, tt.path, nil)
+			}
+
+			if tt.key != "" {
+				request.Header.Set(apiKeyHeader, tt.key)
+			}
+
+			recorder := serveTestRequest(app.Handler(), request)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d, body: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -1,18 +1,14 @@
 package application
 
 import (
-	"crypto/rand"
 	"encoding/json"
 	"fmt"
-	"io"
-	"math/big"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
-	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
@@ -21,6 +17,8 @@ const (
 	xFAPIInteractionID = "x-fapi-interaction-id"
 	srvOrgID           = "serverOrgId"
 	transmitterID      = "transmitterID"
+	applicationRole    = "role"
+	dataOwnerID        = "dataOwnerID"
 )
 
 // GenericError contains information message when error needs to be returned
@@ -35,58 +33,133 @@ type APIServer struct {
 	metricsHandler http.Handler          // Handler for the metric endpoint
 	qm             *QueueManager         // Manager for the message queue
 	cm             *ConfigurationManager // Manager for application settings
+	sp             *SamplingPolicy       // Policy to select the messages to validate
+	lc             *LoadController       // Controller to queue the messages under load
+	ia             *InboundAuthenticator // Authenticator for the requests to the validation API
+	rl             *RateLimiter          // Rate limiter for the requests to the validation API
+	metrics        *monitoring.Metrics   // Metrics of the application instance
 }
 
-// GetAPIServer Creates a new APIServer
+// NewAPIServer Creates a new APIServer
 //
 // Parameters:
 //   - logger: Logger to be used
-//   - metricsHandler: Metric handler to expose \metrics
+//   - metrics: Metrics of the application instance, exposed in \metrics
 //   - qm: Queue manager to queue the requests
 //   - cm: ConfigurationManager to handle the configuration
+//   - sp: SamplingPolicy to select the messages to validate
+//   - lc: LoadController to queue the messages under load
 //
 // Returns:
 //   - *APIServer: APIServer created
-func GetAPIServer(logger log.Logger, metricsHandler http.Handler, qm *QueueManager, cm *ConfigurationManager) *APIServer {
+func NewAPIServer(logger log.Logger, metrics *monitoring.Metrics, qm *QueueManager, cm *ConfigurationManager, sp *SamplingPolicy, lc *LoadController) *APIServer {
 	return &APIServer{
 		pack:           "API",
 		logger:         logger,
-		metricsHandler: metricsHandler,
+		metrics:        metrics,
+		metricsHandler: metrics.GetOpentelemetryHandler(),
 		qm:             qm,
 		cm:             cm,
+		sp:             sp,
+		lc:             lc,
 	}
 }
 
-// StartServing Starts the APIServer
+// Handler returns the handler with the routes of the server, loading the inbound authentication and the rate limiter
 //
 // Parameters:
+//
 // Returns:
-func (as *APIServer) StartServing() {
+//   - http.Handler: Handler of the server
+func (as *APIServer) Handler() http.Handler {
+	ia, err := NewInboundAuthenticator(&as.cm.settings)
+	if err != nil {
+		as.logger.Fatal(err, "Error loading inbound authentication credentials", as.pack, "Handler")
+	}
+
+	as.ia = ia
+	as.rl = NewRateLimiter(as.logger, &as.cm.settings)
+	authSettings := as.cm.settings.InboundAuthSettings
+	as.logger.Info("Inbound authentication mode: "+authSettings.Mode, as.pack, "Handler")
+
 	r := mux.NewRouter()
-	r.Handle("/metrics", as.metricsHandler)
+	r.Handle("/metrics", as.protectRoute(as.metricsHandler, authSettings.ProtectMetrics))
+	r.Handle("/health", as.protectRoute(http.HandlerFunc(as.handleHealth), authSettings.ProtectHealth)).Name("Health").Methods("GET")
 
 	// Validator for Responses
-	r.HandleFunc("/ValidateResponse", as.handleValidateResponseMessage).Name("ValidateResponse").Methods("POST")
+	validation := r.NewRoute().Subrouter()
+	validation.Use(as.limitRequestBody, as.inboundAuthentication, as.rateLimit)
+	validation.HandleFunc("/ValidateResponse", as.handleValidateResponseMessage).Name("ValidateResponse").Methods("POST")
 
+	// Administration routes
+	as.registerAdminRoutes(r)
+	return r
+}
+
+// StartServing Starts the APIServer
+//
+// Parameters:
+// Returns:
+func (as *APIServer) StartServing() {
+	handler := as.Handler()
 	port := as.cm.settings.ConfigurationSettings.APIPort
 	// Remove ":" if found
 	port = strings.Replace(port, ":", "", -1)
 
 	server := &http.Server{
 		Addr:         ":" + port,
-		Handler:      r,
+		Handler:      handler,
 		ReadTimeout:  20 * time.Second,
 		WriteTimeout: 20 * time.Second,
 	}
 
 	as.logger.Log("Starting the server on port "+port, as.pack, "StartServing")
 	if as.cm.IsHTTPS() {
-		as.logger.Fatal(server.ListenAndServeTLS(as.cm.GetCertFilePath(), as.cm.GetKeyFilePath()), "", as.pack, "StartServing")
+		var err error
+		server.TLSConfig, err = as.getTLSConfig()
+		if err != nil {
+			as.logger.Fatal(err, "Error loading TLS configuration", as.pack, "StartServing")
+		}
+
+		// Certificates are provided by TLSConfig.GetCertificate, to allow the reload of the files
+		as.logger.Fatal(server.ListenAndServeTLS("", ""), "", as.pack, "StartServing")
 	} else {
 		as.logger.Fatal(server.ListenAndServe(), "", as.pack, "StartServing")
 	}
 }
 
+// protectRoute Applies the inbound authentication to a route when required
+//
+// Parameters:
+//   - handler: Handler of the route
+//   - protect: Indicates if the route requires authentication
+//
+// Returns:
+//   - http.Handler: Handler of the route
+func (as *APIServer) protectRoute(handler http.Handler, protect bool) http.Handler {
+	if !protect {
+		return handler
+	}
+
+	return as.inboundAuthentication(handler)
+}
+
+// handleHealth Returns the status of the application
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (as *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprintf(w, `{"Status":"UP","Version":"%s"}`, monitoring.Version)
+	if err != nil {
+		as.logger.Error(err, "Error writing response:", as.pack, "handleHealth")
+	}
+}
+
 // updateResponseError Handles requests to the specified urls in the settings
 //
 // Parameters:
@@ -117,50 +190,32 @@ func (as *APIServer) updateResponseError(w http.ResponseWriter, genericError Gen
 	}
 }
 
-// mustValidate indicates if the endpoint should be validated or not base on the validation rate configured
+// getMessageRole returns the role of the message based on the role header and the application mode, the header is
+// only used in DUAL mode, in the other modes the role is always the configured mode
 //
 // Parameters:
-//   - endpointSettings: Endpoint settings with the configuration information
-//
-// Returns:
-//   - bool: true if the endpoint should be validated
-func (as *APIServer) mustValidate(endpointSetting *models.APIEndpointSetting) bool {
-	value := as.getRandomNumber()
-	switch endpointSetting.Throughput {
-	case models.ExtremelyHighTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.ExtremelyHighTroughputValidationRate
-	case models.HighTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.HighTroughputValidationRate
-	case models.MediumTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.MediumTroughputValidationRate
-	case models.LowTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.LowTroughputValidationRate
-	case models.VeryLowTroughput:
-		return value < as.cm.ConfigurationSettings.ValidationSettings.VeryLowTroughputValidationRate
-	}
-
-	return true
-}
-
-// getRandomNumber generates a new random number using Cryptographic Randomness
+//   - roleHeader: Value of the role header
 //
 // Returns:
-//   - int: Random number generated
-func (as *APIServer) getRandomNumber() int {
-	// Define the upper limit (101 for inclusive range of 0-100)
-	maxRandomNumber := big.NewInt(101)
+//   - string: Role of the message, empty if the role is not supported
+func (as *APIServer) getMessageRole(roleHeader string) string {
+	roleHeader = strings.ToUpper(strings.TrimSpace(roleHeader))
+	roles := as.cm.GetApplicationRoles()
+	if !as.cm.IsDualMode() {
+		if roleHeader != "" && roleHeader != roles[0] {
+			as.logger.Debug("Role header ignored: "+roleHeader+", application mode: "+roles[0], as.pack, "getMessageRole")
+		}
 
-	// Generate a random number between 0 (inclusive) and maxRandomNumber (exclusive)
-	num, err := rand.Int(rand.Reader, maxRandomNumber)
-	if err != nil {
-		as.logger.Error(err, "Error generating random number:", as.pack, "getRandomNumber")
-		return 100
+		return roles[0]
 	}
 
-	// Convert the big.Int to an int for easier use
-	number := int(num.Int64())
+	for _, role := range roles {
+		if role == roleHeader {
+			return role
+		}
+	}
 
-	return number
+	return ""
 }
 
 func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message) *GenericError {
@@ -169,7 +224,7 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 	serverOrgID := r.Header.Get(srvOrgID)
 	_, err := uuid.Parse(serverOrgID)
 	if err != nil {
-		monitoring.IncreaseBadRequestsReceived()
+		as.metrics.IncreaseBadRequestsReceived()
 		genericError.Message = srvOrgID + ": Not found or bad format."
 		return genericError
 	}
@@ -177,7 +232,7 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 	xFapiID := r.Header.Get(xFAPIInteractionID)
 	_, err = uuid.Parse(xFapiID)
 	if err != nil {
-		monitoring.IncreaseBadRequestsReceived()
+		as.metrics.IncreaseBadRequestsReceived()
 		genericError.Message = xFAPIInteractionID + ": Not found or bad format."
 		return genericError
 	}
@@ -186,12 +241,26 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 	if txServerID != "" {
 		_, err = uuid.Parse(txServerID)
 		if err != nil {
-			monitoring.IncreaseBadRequestsReceived()
+			as.metrics.IncreaseBadRequestsReceived()
 			genericError.Message = transmitterID + ": bad format."
 			return genericError
 		}
 	}
 
+	role := as.getMessageRole(r.Header.Get(applicationRole))
+	if role == "" {
+		as.metrics.IncreaseBadRequestsReceived()
+		genericError.Message = applicationRole + ": Not found or not supported."
+		return genericError
+	}
+
+	dataOwner := as.cm.GetDataOwnerID(r.Header.Get(dataOwnerID), r.Host)
+	if dataOwner == "" {
+		as.metrics.IncreaseBadRequestsReceived()
+		genericError.Message = dataOwnerID + ": Not found or not supported."
+		return genericError
+	}
+
 	// Read the Server Organization ID from the header
 	endpointName := r.Header.Get("endpointName")
 
@@ -207,6 +276,8 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 	message.XFapiInteractionID = xFapiID
 	message.TransmitterID = txServerID
 	message.ConsentID = consentID
+	message.Role = role
+	message.DataOwnerID = dataOwner
 	return nil
 }
 
@@ -220,7 +291,7 @@ func (as *APIServer) loadMessageHeaderValues(r *http.Request, message *Message)
 func (as *APIServer) handleValidateResponseMessage(w http.ResponseWriter, r *http.Request) {
 	genericError := &GenericError{}
 	startTime := time.Now()
-	monitoring.IncreaseRequestsReceived()
+	as.metrics.IncreaseRequestsReceived()
 	var msg Message
 
 	loadError := as.loadMessageHeaderValues(r, &msg)
@@ -230,17 +301,16 @@ func (as *APIServer) handleValidateResponseMessage(w http.ResponseWriter, r *htt
 	}
 
 	// Read the body of the message
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		genericError.Message = "Failed to read request body."
-		as.updateResponseError(w, *genericError, http.StatusInternalServerError)
+	body, readError, responseCode := as.readRequestBody(r)
+	if readError != nil {
+		as.updateResponseError(w, *readError, responseCode)
 		return
 	}
 
 	var js json.RawMessage
 	validJSON := json.Unmarshal(body, &js) == nil
 	if !validJSON {
-		monitoring.IncreaseBadRequestsReceived()
+		as.metrics.IncreaseBadRequestsReceived()
 		genericError.Message = "body: Not a Valid JSON Message."
 		as.updateResponseError(w, *genericError, http.StatusBadRequest)
 		return
@@ -250,27 +320,30 @@ func (as *APIServer) handleValidateResponseMessage(w http.ResponseWriter, r *htt
 	validationSettings := as.cm.GetEndpointSettingFromAPI(msg.Endpoint, as.logger)
 
 	if validationSettings == nil {
-		monitoring.IncreaseBadEndpointsReceived(msg.Endpoint, "N.A.", "Endpoint not supported")
+		as.metrics.IncreaseBadEndpointsReceived(msg.Endpoint, "N.A.", "Endpoint not supported")
 		genericError.Message = "endpointName: Not found or bad format."
 		as.updateResponseError(w, *genericError, http.StatusBadRequest)
 		return
 	} else if msg.APIVersion != "" && msg.APIVersion != validationSettings.APIVersion {
-		monitoring.IncreaseBadEndpointsReceived(msg.Endpoint, msg.APIVersion, "Version not supported")
+		as.metrics.IncreaseBadEndpointsReceived(msg.Endpoint, msg.APIVersion, "Version not supported")
 		genericError.Message = "version: not supported for as endpoint: " + msg.Endpoint
 		as.updateResponseError(w, *genericError, http.StatusBadRequest)
 		return
 	}
 
-	if as.mustValidate(validationSettings.EndpointSettings) {
+	// The size is recorded once the endpoint is resolved, to use only the configured names as labels
+	as.metrics.RecordPayloadSize(validationSettings.EndpointName, getContentEncoding(r.Header.Get("Content-Encoding")), len(body))
+
+	// Enqueue the message for processing, under load the message may be discarded
+	as.sp.ValidateAndEnqueue(&msg, validationSettings, func(msg *Message) bool {
 		msg.Message = string(body)
 		msg.HTTPMethod = r.Method
+		msg.ReceivedAt = startTime
+		return as.lc.EnqueueMessage(msg)
+	})
 
-		// Enqueue the message for processing using worker's enqueueMessage
-		as.qm.EnqueueMessage(&msg)
-	}
-
-	monitoring.RecordResponseDuration(startTime)
-	_, err = fmt.Fprintf(w, "Message enqueued for processing!")
+	as.metrics.RecordResponseDuration(startTime)
+	_, err := fmt.Fprintf(w, "Message enqueued for processing!")
 	if err != nil {
 		as.logger.Error(err, "Error writing response:", as.pack, "handleValidateResponseMessage")
 	}
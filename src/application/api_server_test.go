@@ -0,0 +1,130 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// newValidateRequest returns a request to the validation API with valid headers
+func newValidateRequest(endpoint string, body string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/ValidateResponse", strings.NewReader(body))
+	request.Header.Set(srvOrgID, testServerOrgID)
+	request.Header.Set(xFAPIInteractionID, testInteractionID)
+	request.Header.Set("endpointName", endpoint)
+	return request
+}
+
+func TestGetMessageRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		header string
+		want   string
+	}{
+		{name: "transmitter without header", mode: configuration.TransmitterMode, want: configuration.TransmitterMode},
+		{name: "transmitter with own role", mode: configuration.TransmitterMode, header: "transmitter", want: configuration.TransmitterMode},
+		{name: "transmitter ignores other role", mode: configuration.TransmitterMode, header: configuration.ReceiverMode, want: configuration.TransmitterMode},
+		{name: "receiver ignores invalid role", mode: configuration.ReceiverMode, header: "OTHER", want: configuration.ReceiverMode},
+		{name: "dual without header", mode: configuration.DualMode, want: ""},
+		{name: "dual with receiver", mode: configuration.DualMode, header: " receiver ", want: configuration.ReceiverMode},
+		{name: "dual with transmitter", mode: configuration.DualMode, header: configuration.TransmitterMode, want: configuration.TransmitterMode},
+		{name: "dual with invalid role", mode: configuration.DualMode, header: "OTHER", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.ApplicationSettings.Mode = tt.mode
+			app, _ := newTestApp(t, settings)
+			as := NewAPIServer(app.Logger, app.metrics, app.qm, app.cm, app.sp, app.lc)
+
+			if got := as.getMessageRole(tt.header); got != tt.want {
+				t.Errorf("getMessageRole(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRoleValidationRate(t *testing.T) {
+	rate := func(value int) *int {
+		return &value
+	}
+
+	tests := []struct {
+		name            string
+		transmitterRate *int
+		receiverRate    *int
+		role            string
+		want            int
+	}{
+		{name: "not configured", role: configuration.TransmitterMode, want: 100},
+		{name: "configured", transmitterRate: rate(40), role: configuration.TransmitterMode, want: 40},
+		{name: "zero disables the role", transmitterRate: rate(0), role: configuration.TransmitterMode, want: 0},
+		{name: "receiver rate", transmitterRate: rate(10), receiverRate: rate(70), role: configuration.ReceiverMode, want: 70},
+		{name: "above range", receiverRate: rate(150), role: configuration.ReceiverMode, want: 100},
+		{name: "below range", receiverRate: rate(-5), role: configuration.ReceiverMode, want: 0},
+		{name: "unknown role", transmitterRate: rate(10), role: "OTHER", want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			server.updateSettings(func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.TransmitterValidationRate = tt.transmitterRate
+				settings.ValidationSettings.ReceiverValidationRate = tt.receiverRate
+			})
+
+			cm := newTestConfigurationManager(t, newTestSettings(t), server)
+			if got := cm.GetRoleValidationRate(tt.role); got != tt.want {
+				t.Errorf("GetRoleValidationRate(%q) = %d, want %d", tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleValidateResponseRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		header   string
+		want     int
+		wantRole string
+	}{
+		{name: "transmitter without header", mode: configuration.TransmitterMode, want: http.StatusOK, wantRole: configuration.TransmitterMode},
+		{name: "transmitter with other role", mode: configuration.TransmitterMode, header: configuration.ReceiverMode, want: http.StatusOK, wantRole: configuration.TransmitterMode},
+		{name: "dual without header", mode: configuration.DualMode, want: http.StatusBadRequest},
+		{name: "dual with receiver", mode: configuration.DualMode, header: configuration.ReceiverMode, want: http.StatusOK, wantRole: configuration.ReceiverMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.ApplicationSettings.Mode = tt.mode
+			app, _ := newTestApp(t, settings)
+
+			request := newValidateRequest("/accounts/v2/accounts", `{"data":[]}`)
+			if tt.header != "" {
+				request.Header.Set(applicationRole, tt.header)
+			}
+
+			recorder := serveTestRequest(app.Handler(), request)
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+
+			if tt.wantRole == "" {
+				return
+			}
+
+			msg := dequeueTestMessage(app.qm)
+			if msg == nil || msg.Role != tt.wantRole {
+				t.Errorf("queued message = %+v, want role %q", msg, tt.wantRole)
+			}
+		})
+	}
+}
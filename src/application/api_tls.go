@@ -0,0 +1,68 @@
+package application
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/certificates"
+)
+
+// getTLSConfig Creates the TLS configuration of the server, the certificate is reloaded when its files change
+//
+// Parameters:
+//
+// Returns:
+//   - *tls.Config: TLS configuration of the server
+//   - error: error if the certificates cannot be loaded
+func (as *APIServer) getTLSConfig() (*tls.Config, error) {
+	security := as.cm.settings.SecuritySettings
+	reloader, err := certificates.NewReloader(as.logger, as.metrics, "server", security.CertFilePath, security.KeyFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	go reloader.Watch(time.Duration(security.ReloadInterval) * time.Second)
+
+	minVersion, err := certificates.ParseTLSVersion(security.MinTLSVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := certificates.ParseCipherSuites(security.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+	}
+
+	// Inbound authentication by certificate requires the verification of the client certificates, the
+	// authentication middleware rejects the requests without certificate
+	clientAuth := security.ClientAuth
+	caFile := security.ClientCAFile
+	if as.cm.settings.InboundAuthSettings.Mode == configuration.InboundAuthMTLS && clientAuth == configuration.NoClientAuth {
+		clientAuth = configuration.OptionalClientAuth
+		caFile = as.cm.settings.InboundAuthSettings.ClientCAFile
+	}
+
+	switch clientAuth {
+	case configuration.OptionalClientAuth:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case configuration.RequiredClientAuth:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return config, nil
+	}
+
+	config.ClientCAs, err = certificates.LoadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	as.logger.Info("Client certificate verification: "+clientAuth, as.pack, "getTLSConfig")
+	return config, nil
+}
@@ -0,0 +1,198 @@
+package application
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+// writeTestCertificate writes a self-signed certificate for localhost and its key, and returns the paths of the files
+func writeTestCertificate(t *testing.T, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folder := t.TempDir()
+	certFile := filepath.Join(folder, name+".crt")
+	keyFile := filepath.Join(folder, name+".key")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestGetTLSConfig(t *testing.T) {
+	serverCert, serverKey := writeTestCertificate(t, "server")
+	clientCert, clientKey := writeTestCertificate(t, "client")
+
+	tests := []struct {
+		name           string
+		minVersion     string
+		clientAuth     string
+		inboundMode    string
+		caFile         string
+		wantErr        bool
+		wantMinVersion uint16
+		wantClientAuth tls.ClientAuthType
+	}{
+		{name: "TLS 1.2 without client certificates", minVersion: "1.2", clientAuth: configuration.NoClientAuth, wantMinVersion: tls.VersionTLS12, wantClientAuth: tls.NoClientCert},
+		{name: "TLS 1.3", minVersion: "1.3", clientAuth: configuration.NoClientAuth, wantMinVersion: tls.VersionTLS13, wantClientAuth: tls.NoClientCert},
+		{name: "optional client certificate", minVersion: "1.2", clientAuth: configuration.OptionalClientAuth, caFile: clientCert, wantMinVersion: tls.VersionTLS12, wantClientAuth: tls.VerifyClientCertIfGiven},
+		{name: "required client certificate", minVersion: "1.2", clientAuth: configuration.RequiredClientAuth, caFile: clientCert, wantMinVersion: tls.VersionTLS12, wantClientAuth: tls.RequireAndVerifyClientCert},
+		{name: "inbound MTLS enables the verification", minVersion: "1.2", clientAuth: configuration.NoClientAuth, inboundMode: configuration.InboundAuthMTLS, wantMinVersion: tls.VersionTLS12, wantClientAuth: tls.VerifyClientCertIfGiven},
+		{name: "invalid TLS version", minVersion: "1.0", clientAuth: configuration.NoClientAuth, wantErr: true},
+		{name: "missing CA file", minVersion: "1.2", clientAuth: configuration.RequiredClientAuth, caFile: "missing.crt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SecuritySettings.CertFilePath = serverCert
+			settings.SecuritySettings.KeyFilePath = serverKey
+			settings.SecuritySettings.MinTLSVersion = tt.minVersion
+			settings.SecuritySettings.ClientAuth = tt.clientAuth
+			settings.SecuritySettings.ClientCAFile = tt.caFile
+			settings.SecuritySettings.ReloadInterval = 3600
+			settings.InboundAuthSettings.ClientCAFile = clientCert
+			if tt.inboundMode != "" {
+				settings.InboundAuthSettings.Mode = tt.inboundMode
+			}
+
+			app, _ := newTestApp(t, settings)
+			as := NewAPIServer(app.Logger, app.metrics, app.qm, app.cm, app.sp, app.lc)
+			config, err := as.getTLSConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if config.MinVersion != tt.wantMinVersion || config.ClientAuth != tt.wantClientAuth {
+				t.Errorf("getTLSConfig() MinVersion = %x, ClientAuth = %v, want %x, %v", config.MinVersion, config.ClientAuth, tt.wantMinVersion, tt.wantClientAuth)
+			}
+
+			if (config.ClientCAs != nil) != (tt.wantClientAuth != tls.NoClientCert) {
+				t.Errorf("ClientCAs = %v, want loaded %v", config.ClientCAs, tt.wantClientAuth != tls.NoClientCert)
+			}
+		})
+	}
+
+	t.Run("handshake", func(t *testing.T) {
+		pool := x509.NewCertPool()
+		content, _ := os.ReadFile(serverCert)
+		pool.AppendCertsFromPEM(content)
+		certificate, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handshakes := []struct {
+			name              string
+			maxVersion        uint16
+			clientCertificate bool
+			wantErr           bool
+		}{
+			{name: "TLS 1.3 with client certificate", maxVersion: tls.VersionTLS13, clientCertificate: true},
+			{name: "TLS 1.2 is rejected", maxVersion: tls.VersionTLS12, clientCertificate: true, wantErr: true},
+			{name: "missing client certificate is rejected", maxVersion: tls.VersionTLS13, wantErr: true},
+		}
+
+		settings := newTestSettings(t)
+		settings.SecuritySettings.CertFilePath = serverCert
+		settings.SecuritySettings.KeyFilePath = serverKey
+		settings.SecuritySettings.MinTLSVersion = "1.3"
+		settings.SecuritySettings.ClientAuth = configuration.RequiredClientAuth
+		settings.SecuritySettings.ClientCAFile = clientCert
+		settings.SecuritySettings.ReloadInterval = 3600
+		app, _ := newTestApp(t, settings)
+		as := NewAPIServer(app.Logger, app.metrics, app.qm, app.cm, app.sp, app.lc)
+		serverConfig, err := as.getTLSConfig()
+		if err != nil {
+			t.Fatalf("getTLSConfig() error = %v", err)
+		}
+
+		for _, hs := range handshakes {
+			t.Run(hs.name, func(t *testing.T) {
+				clientConfig := &tls.Config{RootCAs: pool, ServerName: "localhost", MaxVersion: hs.maxVersion}
+				if hs.clientCertificate {
+					clientConfig.Certificates = []tls.Certificate{certificate}
+				}
+
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				defer listener.Close()
+				serverErr := make(chan error, 1)
+				go func() {
+					conn, err := listener.Accept()
+					if err != nil {
+						serverErr <- err
+						return
+					}
+
+					defer conn.Close()
+					_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+					serverErr <- tls.Server(conn, serverConfig).Handshake()
+				}()
+
+				dialer := &net.Dialer{Timeout: 5 * time.Second}
+				client, err := tls.DialWithDialer(dialer, "tcp", listener.Addr().String(), clientConfig)
+				if err == nil {
+					// In TLS 1.3 the client certificate is verified by the server after the client handshake
+					err = <-serverErr
+					client.Close()
+				}
+
+				if (err != nil) != hs.wantErr {
+					t.Errorf("handshake error = %v, wantErr %v", err, hs.wantErr)
+				}
+			})
+		}
+	})
+}
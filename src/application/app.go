@@ -0,0 +1,215 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+var (
+	// ErrEndpointNotSupported is returned when the endpoint is not found in the validation settings
+	ErrEndpointNotSupported = errors.New("endpoint not supported")
+
+	// ErrVersionNotSupported is returned when the version is different from the version of the validation settings
+	ErrVersionNotSupported = errors.New("version not supported")
+)
+
+// App is the container of an application instance, it owns the queue, the results, the metrics and the logger of
+// the instance, so several independent instances can run in the same process
+type App struct {
+	crosscutting.OFBStruct
+	settings configuration.Settings  // Settings of the instance
+	metrics  *monitoring.Metrics     // Metrics of the instance
+	cm       *ConfigurationManager   // Manager for application settings
+	qm       *QueueManager           // Manager for the message queue
+	lc       *LoadController         // Controller to queue the messages under load
+	sp       *SamplingPolicy         // Policy to select the messages to validate
+	rp       *ResultProcessor        // Processor that sends the reports
+	lrm      *LocalResultManager     // Manager of the local results
+	cc       *ConsistencyChecker     // Checker of the consistency between the messages of a consent, nil if disabled
+	mp       *MessageProcessorWorker // Worker that validates the queued messages
+	capture  *ResponseCapture        // Capture that queues the responses for validation
+}
+
+// NewApp creates a new application instance and loads its validation settings, the workers are started by Start
+//
+// Parameters:
+//   - logger: Logger of the instance
+//   - settings: Settings of the instance
+//   - reportServer: Server that receives settings and reports, nil to use the central MQD server
+//
+// Returns:
+//   - *App: Application instance created
+//   - error: error if the metrics or the validation settings cannot be loaded
+func NewApp(logger log.Logger, settings configuration.Settings, reportServer services.ReportServer) (*App, error) {
+	metrics, err := monitoring.NewMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	if reportServer == nil {
+		reportServer = services.NewReportServer(logger, metrics, settings.SecuritySettings.ProxyURL, settings)
+	}
+
+	cm := NewConfigurationManager(logger, reportServer, settings)
+	err = cm.Initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	app := &App{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.App",
+			Logger: logger,
+		},
+		settings: settings,
+		metrics:  metrics,
+		cm:       cm,
+		qm:       NewQueueManager(defaultQueueSize),
+	}
+
+	app.lc = NewLoadController(logger, cm, app.qm, metrics)
+	app.sp = NewSamplingPolicy(logger, cm, app.lc)
+	app.sp.checkEndpointRates()
+	app.rp = NewResultProcessor(logger, reportServer, cm, app.sp, app.lc, metrics)
+	app.lrm = NewLocalResultManager(logger, cm)
+	if settings.ConsistencySettings.Enabled {
+		app.cc = NewConsistencyChecker(logger, time.Duration(settings.ConsistencySettings.TTL)*time.Minute, settings.ConsistencySettings.MaxJourneys,
+			settings.ConsistencySettings.MaxValues)
+	}
+
+	app.mp = NewMessageProcessorWorker(logger, app.rp, app.qm, cm, app.lrm, app.lc, app.cc, metrics)
+	app.capture = NewResponseCapture(logger, cm, app.sp, app.lc, metrics)
+	return app, nil
+}
+
+// Start Starts the workers that update the settings, validate the queued messages and send the reports
+//
+// Parameters:
+//
+// Returns:
+func (app *App) Start() {
+	go app.metrics.StartMemoryCalculator()
+	go app.cm.StartUpdateProcess()
+	go app.mp.StartWorker()
+	go app.rp.StartResultsProcessor()
+	go app.lrm.StartResultProcess()
+	go app.lc.StartController()
+	go app.capture.StartWorker()
+}
+
+// Serve Starts the servers of the standalone application: the refresh signal handler, the proxy if enabled, and the
+// API server, this function does not return
+//
+// Parameters:
+//
+// Returns:
+func (app *App) Serve() {
+	go app.cm.StartRefreshSignalHandler()
+	go NewProxyServer(app.Logger, app.cm, app.capture, app.metrics).StartServing()
+
+	NewAPIServer(app.Logger, app.metrics, app.qm, app.cm, app.sp, app.lc).StartServing()
+}
+
+// Handler returns the handler of the API server, to serve the instance in-process without listening on a port
+//
+// Parameters:
+//
+// Returns:
+//   - http.Handler: Handler of the API server
+func (app *App) Handler() http.Handler {
+	return NewAPIServer(app.Logger, app.metrics, app.qm, app.cm, app.sp, app.lc).Handler()
+}
+
+// GetQueueDepth returns the number of messages waiting for validation
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of messages in the queue
+func (app *App) GetQueueDepth() int {
+	return app.qm.GetDepth()
+}
+
+// GetProcessedCount returns the number of messages validated by the worker
+//
+// Parameters:
+//
+// Returns:
+//   - int64: Number of messages processed
+func (app *App) GetProcessedCount() int64 {
+	return app.mp.GetProcessedCount()
+}
+
+// GetMetrics returns the metrics of the instance
+//
+// Parameters:
+//
+// Returns:
+//   - *monitoring.Metrics: Metrics of the instance
+func (app *App) GetMetrics() *monitoring.Metrics {
+	return app.metrics
+}
+
+// GetResponseCapture returns the capture that queues responses for validation
+//
+// Parameters:
+//
+// Returns:
+//   - *ResponseCapture: Response capture of the instance
+func (app *App) GetResponseCapture() *ResponseCapture {
+	return app.capture
+}
+
+// GetEndpointSchema returns the JSON schema of the body of an endpoint
+//
+// Parameters:
+//   - endpoint: Name of the endpoint (e.g. /accounts/v2/accounts)
+//
+// Returns:
+//   - string: JSON schema of the body
+//   - error: ErrEndpointNotSupported if the endpoint is not found in the validation settings
+func (app *App) GetEndpointSchema(endpoint string) (string, error) {
+	validationSettings := app.cm.GetEndpointSettingFromAPI(endpoint, app.Logger)
+	if validationSettings == nil {
+		return "", errors.Join(ErrEndpointNotSupported, errors.New("endpoint: "+endpoint))
+	}
+
+	return validationSettings.EndpointSettings.JSONBodySchema, nil
+}
+
+// Validate Validates a message synchronously, the result is returned to the caller and is not reported
+//
+// Parameters:
+//   - endpoint: Name of the endpoint (e.g. /accounts/v2/accounts)
+//   - version: Version of the API, empty to accept the configured version
+//   - body: JSON content of the message
+//
+// Returns:
+//   - *validation.Result: Result of the validation
+//   - error: error if the endpoint or version are not supported, or the message cannot be validated
+func (app *App) Validate(endpoint string, version string, body []byte) (*validation.Result, error) {
+	validationSettings := app.cm.GetEndpointSettingFromAPI(endpoint, app.Logger)
+	if validationSettings == nil {
+		return nil, errors.Join(ErrEndpointNotSupported, errors.New("endpoint: "+endpoint))
+	}
+
+	if version != "" && version != validationSettings.APIVersion {
+		return nil, errors.Join(ErrVersionNotSupported, errors.New("version: "+version+", expected: "+validationSettings.APIVersion))
+	}
+
+	msg := &Message{
+		Message:    string(body),
+		Endpoint:   endpoint,
+		APIVersion: version,
+	}
+
+	return app.mp.ValidateMessage(msg, validationSettings.EndpointSettings)
+}
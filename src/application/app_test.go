@@ -0,0 +1,107 @@
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// requestCountPattern finds the value of the request counter in the metrics exposed by an instance
+var requestCountPattern = regexp.MustCompile(`(?m)^request_count\S*total(?:\{[^}]*\})? (\S+)$`)
+
+// getTestRequestCount returns the value of the request counter exposed by the metrics handler of an instance
+func getTestRequestCount(t *testing.T, app *App) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	app.GetMetrics().GetOpentelemetryHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	match := requestCountPattern.FindStringSubmatch(recorder.Body.String())
+	if match == nil {
+		return 0
+	}
+
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(value)
+}
+
+func TestAppInstances(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests []int // Requests received by each instance
+	}{
+		{name: "one instance receives requests", requests: []int{3, 0}},
+		{name: "both instances receive requests", requests: []int{2, 5}},
+		{name: "several instances", requests: []int{1, 4, 2, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps := make([]*App, len(tt.requests))
+			servers := make([]*stubReportServer, len(tt.requests))
+			organisations := make([]string, len(tt.requests))
+			for i := range tt.requests {
+				organisations[i] = uuid.NewString()
+				settings := newTestSettings(t)
+				settings.ApplicationSettings.OrganisationID = organisations[i]
+				settings.ApplicationSettings.Organisations[0].OrganisationID = organisations[i]
+				apps[i], servers[i] = newTestApp(t, settings)
+			}
+
+			// The instances receive their requests at the same time
+			var wg sync.WaitGroup
+			for i, app := range apps {
+				handler := app.Handler()
+				for j := 0; j < tt.requests[i]; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						serveTestRequest(handler, newValidateRequest("/accounts/v2/accounts", `{"data":[{"accountId":"1"}]}`))
+					}()
+				}
+			}
+
+			wg.Wait()
+			for i, app := range apps {
+				if depth := app.GetQueueDepth(); depth != tt.requests[i] {
+					t.Fatalf("instance %d queue depth = %d, want %d", i, depth, tt.requests[i])
+				}
+
+				if count := getTestRequestCount(t, app); count != tt.requests[i] {
+					t.Errorf("instance %d request count = %d, want %d", i, count, tt.requests[i])
+				}
+
+				for msg := dequeueTestMessage(app.qm); msg != nil; msg = dequeueTestMessage(app.qm) {
+					app.mp.processMessage(msg)
+				}
+
+				app.rp.processAndSendResults()
+			}
+
+			// Each instance reports its own results to its own server
+			for i, server := range servers {
+				total := 0
+				for _, report := range server.getReports() {
+					if report.DataOwnerID != organisations[i] {
+						t.Errorf("instance %d report DataOwnerID = %s, want %s", i, report.DataOwnerID, organisations[i])
+					}
+
+					for _, summary := range report.ServerSummary {
+						total += summary.TotalRequests
+					}
+				}
+
+				if total != tt.requests[i] {
+					t.Errorf("instance %d reported requests = %d, want %d", i, total, tt.requests[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+const (
+	historyFileExtension = ".json"
+	pinStateFileName     = "pinned.state"
+)
+
+var invalidFileNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]`) // Characters not allowed in history file names
+
+// ConfigurationHistoryEntry stores a configuration applied in the past
+type ConfigurationHistoryEntry struct {
+	Version     string                        // Version of the configuration
+	AppliedDate time.Time                     // Date when the configuration was applied
+	Settings    *models.ConfigurationSettings // Configuration settings including the endpoint lists
+}
+
+// ConfigurationHistorySummary contains the information of a configuration history entry without its settings
+type ConfigurationHistorySummary struct {
+	Version     string    // Version of the configuration
+	AppliedDate time.Time // Date when the configuration was applied
+}
+
+// ConfigurationPinState stores the information of a pinned configuration version
+type ConfigurationPinState struct {
+	Version    string    // Version of the configuration pinned
+	PinnedDate time.Time // Date when the version was pinned
+}
+
+// ConfigurationDiff contains the differences between two configuration versions
+type ConfigurationDiff struct {
+	PreviousVersion  string   // Version of the previous configuration
+	NewVersion       string   // Version of the new configuration
+	AddedAPIs        []string // APIs included in the new configuration
+	RemovedAPIs      []string // APIs not present in the new configuration
+	ChangedAPIs      []string // APIs with a different version in the new configuration
+	AddedEndpoints   []string // Endpoints included in the new configuration
+	RemovedEndpoints []string // Endpoints not present in the new configuration
+	ChangedEndpoints []string // Endpoints with different schemas, rules or throughput in the new configuration
+}
+
+// ConfigurationHistory keeps the last applied configurations in memory and on disk
+type ConfigurationHistory struct {
+	crosscutting.OFBStruct
+	path    string                      // Folder where the history is stored
+	size    int                         // Number of configurations to keep
+	entries []ConfigurationHistoryEntry // Configurations stored, oldest first
+	mutex   sync.Mutex                  // Mutex for thread-safe access to the entries
+}
+
+// NewConfigurationHistory creates a new configuration history
+//
+// Parameters:
+//   - logger: logger to be used
+//   - path: folder where the history will be stored
+//   - size: number of configurations to keep
+//
+// Returns:
+//   - *ConfigurationHistory: new created configuration history
+func NewConfigurationHistory(logger log.Logger, path string, size int) *ConfigurationHistory {
+	return &ConfigurationHistory{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ConfigurationHistory",
+			Logger: logger,
+		},
+		path:    path,
+		size:    size,
+		entries: make([]ConfigurationHistoryEntry, 0),
+	}
+}
+
+// Load reads the configurations stored on disk
+//
+// Parameters:
+//
+// Returns:
+//   - error: error if any
+func (ch *ConfigurationHistory) Load() error {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(ch.path, "*"+historyFileExtension))
+	if err != nil {
+		return err
+	}
+
+	entries := make([]ConfigurationHistoryEntry, 0)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			ch.Logger.Error(err, "Error reading configuration history file: "+file, ch.Pack, "Load")
+			continue
+		}
+
+		var entry ConfigurationHistoryEntry
+		err = json.Unmarshal(data, &entry)
+		if err != nil || entry.Settings == nil {
+			ch.Logger.Error(err, "Error reading configuration history file: "+file, ch.Pack, "Load")
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AppliedDate.Before(entries[j].AppliedDate)
+	})
+
+	ch.entries = entries
+	ch.trim()
+	ch.Logger.Info(fmt.Sprintf("Configuration history loaded, versions found: %d", len(ch.entries)), ch.Pack, "Load")
+	return nil
+}
+
+// Add includes a new applied configuration in the history
+//
+// Parameters:
+//   - settings: configuration settings applied
+//   - appliedDate: date when the configuration was applied
+//
+// Returns:
+func (ch *ConfigurationHistory) Add(settings *models.ConfigurationSettings, appliedDate time.Time) {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	entry := ConfigurationHistoryEntry{
+		Version:     settings.Version,
+		AppliedDate: appliedDate,
+		Settings:    settings,
+	}
+
+	// The same version is only kept once, with the latest applied date
+	for i, existing := range ch.entries {
+		if existing.Version == settings.Version {
+			ch.entries = append(ch.entries[:i], ch.entries[i+1:]...)
+			break
+		}
+	}
+
+	ch.entries = append(ch.entries, entry)
+	err := ch.saveEntry(entry)
+	if err != nil {
+		ch.Logger.Error(err, "Error saving configuration history for version: "+settings.Version, ch.Pack, "Add")
+	}
+
+	ch.trim()
+}
+
+// Get returns the configuration stored for a specific version
+//
+// Parameters:
+//   - version: version of the configuration
+//
+// Returns:
+//   - *ConfigurationHistoryEntry: entry found, nil if the version is not in the history
+func (ch *ConfigurationHistory) Get(version string) *ConfigurationHistoryEntry {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	for i := range ch.entries {
+		if ch.entries[i].Version == version {
+			entry := ch.entries[i]
+			return &entry
+		}
+	}
+
+	return nil
+}
+
+// List returns the summary of the configurations stored, newest first
+//
+// Parameters:
+//
+// Returns:
+//   - []ConfigurationHistorySummary: list of configurations stored
+func (ch *ConfigurationHistory) List() []ConfigurationHistorySummary {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+
+	result := make([]ConfigurationHistorySummary, 0, len(ch.entries))
+	for i := len(ch.entries) - 1; i >= 0; i-- {
+		result = append(result, ConfigurationHistorySummary{
+			Version:     ch.entries[i].Version,
+			AppliedDate: ch.entries[i].AppliedDate,
+		})
+	}
+
+	return result
+}
+
+// LoadPinState reads the pin state stored on disk
+//
+// Parameters:
+//
+// Returns:
+//   - *ConfigurationPinState: pin state found, nil if no version is pinned
+func (ch *ConfigurationHistory) LoadPinState() *ConfigurationPinState {
+	data, err := os.ReadFile(filepath.Join(ch.path, pinStateFileName))
+	if err != nil {
+		return nil
+	}
+
+	var state ConfigurationPinState
+	err = json.Unmarshal(data, &state)
+	if err != nil || state.Version == "" {
+		ch.Logger.Error(err, "Error reading configuration pin state", ch.Pack, "LoadPinState")
+		return nil
+	}
+
+	return &state
+}
+
+// SavePinState stores the pin state on disk, a nil state removes it
+//
+// Parameters:
+//   - state: pin state to be stored
+//
+// Returns:
+//   - error: error if any
+func (ch *ConfigurationHistory) SavePinState(state *ConfigurationPinState) error {
+	fileName := filepath.Join(ch.path, pinStateFileName)
+	if state == nil {
+		err := os.Remove(fileName)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	return ch.writeFile(fileName, state)
+}
+
+// trim removes the oldest entries that exceed the history size
+//
+// Parameters:
+//
+// Returns:
+func (ch *ConfigurationHistory) trim() {
+	for len(ch.entries) > ch.size {
+		removed := ch.entries[0]
+		ch.entries = ch.entries[1:]
+		err := os.Remove(ch.getEntryFileName(removed.Version))
+		if err != nil && !os.IsNotExist(err) {
+			ch.Logger.Error(err, "Error removing configuration history for version: "+removed.Version, ch.Pack, "trim")
+		}
+	}
+}
+
+// saveEntry stores an entry on disk
+//
+// Parameters:
+//   - entry: entry to be stored
+//
+// Returns:
+//   - error: error if any
+func (ch *ConfigurationHistory) saveEntry(entry ConfigurationHistoryEntry) error {
+	return ch.writeFile(ch.getEntryFileName(entry.Version), entry)
+}
+
+// writeFile serializes data as JSON into the specified file
+//
+// Parameters:
+//   - fileName: name of the file
+//   - data: data to be serialized
+//
+// Returns:
+//   - error: error if any
+func (ch *ConfigurationHistory) writeFile(fileName string, data interface{}) error {
+	if err := os.MkdirAll(ch.path, 0750); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", ch.path, err)
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	if err := os.WriteFile(fileName, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", fileName, err)
+	}
+
+	return nil
+}
+
+// getEntryFileName returns the file name used to store a specific version
+//
+// Parameters:
+//   - version: version of the configuration
+//
+// Returns:
+//   - string: file name for the version
+func (ch *ConfigurationHistory) getEntryFileName(version string) string {
+	return filepath.Join(ch.path, invalidFileNameCharacters.ReplaceAllString(version, "_")+historyFileExtension)
+}
+
+// diffConfigurations returns the differences between two configurations
+//
+// Parameters:
+//   - previous: configuration previously applied, can be nil
+//   - current: new configuration
+//
+// Returns:
+//   - ConfigurationDiff: differences found
+func diffConfigurations(previous *models.ConfigurationSettings, current *models.ConfigurationSettings) ConfigurationDiff {
+	diff := ConfigurationDiff{NewVersion: current.Version}
+	previousAPIs := make(map[string]models.APISetting)
+	if previous != nil {
+		diff.PreviousVersion = previous.Version
+		previousAPIs = getAPIsByName(previous)
+	}
+
+	currentAPIs := getAPIsByName(current)
+	for name, api := range currentAPIs {
+		oldAPI, found := previousAPIs[name]
+		if !found {
+			diff.AddedAPIs = append(diff.AddedAPIs, name)
+			for _, endpoint := range api.EndpointList {
+				diff.AddedEndpoints = append(diff.AddedEndpoints, getEndpointName(api, endpoint))
+			}
+
+			continue
+		}
+
+		if oldAPI.Version != api.Version {
+			diff.ChangedAPIs = append(diff.ChangedAPIs, name+" ("+oldAPI.Version+" -> "+api.Version+")")
+		}
+
+		diffEndpoints(&diff, oldAPI, api)
+	}
+
+	for name, api := range previousAPIs {
+		if _, found := currentAPIs[name]; !found {
+			diff.RemovedAPIs = append(diff.RemovedAPIs, name)
+			for _, endpoint := range api.EndpointList {
+				diff.RemovedEndpoints = append(diff.RemovedEndpoints, getEndpointName(api, endpoint))
+			}
+		}
+	}
+
+	for _, list := range [][]string{diff.AddedAPIs, diff.RemovedAPIs, diff.ChangedAPIs, diff.AddedEndpoints, diff.RemovedEndpoints, diff.ChangedEndpoints} {
+		sort.Strings(list)
+	}
+
+	return diff
+}
+
+// diffEndpoints includes the endpoint differences between two versions of the same API
+//
+// Parameters:
+//   - diff: diff to be updated
+//   - previous: API settings previously applied
+//   - current: new API settings
+//
+// Returns:
+func diffEndpoints(diff *ConfigurationDiff, previous models.APISetting, current models.APISetting) {
+	previousEndpoints := make(map[string]models.APIEndpointSetting)
+	for _, endpoint := range previous.EndpointList {
+		previousEndpoints[getEndpointName(previous, endpoint)] = endpoint
+	}
+
+	currentEndpoints := make(map[string]bool)
+	for _, endpoint := range current.EndpointList {
+		name := getEndpointName(current, endpoint)
+		currentEndpoints[name] = true
+		oldEndpoint, found := previousEndpoints[name]
+		if !found {
+			diff.AddedEndpoints = append(diff.AddedEndpoints, name)
+		} else if oldEndpoint != endpoint {
+			diff.ChangedEndpoints = append(diff.ChangedEndpoints, name)
+		}
+	}
+
+	for name := range previousEndpoints {
+		if !currentEndpoints[name] {
+			diff.RemovedEndpoints = append(diff.RemovedEndpoints, name)
+		}
+	}
+}
+
+// getAPIsByName returns the APIs of a configuration indexed by group and name
+//
+// Parameters:
+//   - settings: configuration settings
+//
+// Returns:
+//   - map: map[string]models.APISetting APIs by name
+func getAPIsByName(settings *models.ConfigurationSettings) map[string]models.APISetting {
+	result := make(map[string]models.APISetting)
+	for _, group := range settings.ValidationSettings.APIGroupSettings {
+		for _, api := range group.APIList {
+			result[group.Group+"/"+api.API] = api
+		}
+	}
+
+	return result
+}
+
+// getEndpointName returns the full name of an endpoint
+//
+// Parameters:
+//   - api: API of the endpoint
+//   - endpoint: endpoint settings
+//
+// Returns:
+//   - string: full endpoint name
+func getEndpointName(api models.APISetting, endpoint models.APIEndpointSetting) string {
+	return strings.TrimSpace(api.EndpointBase) + strings.TrimSpace(endpoint.Endpoint)
+}
+
+// IsEmpty indicates if the diff does not contain changes
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if there are no changes
+func (cd *ConfigurationDiff) IsEmpty() bool {
+	return len(cd.AddedAPIs) == 0 && len(cd.RemovedAPIs) == 0 && len(cd.ChangedAPIs) == 0 &&
+		len(cd.AddedEndpoints) == 0 && len(cd.RemovedEndpoints) == 0 && len(cd.ChangedEndpoints) == 0
+}
@@ -0,0 +1,243 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// newTestHistorySettings returns configuration settings with the specified version and endpoints by API name
+func newTestHistorySettings(version string, apis map[string][]string) *models.ConfigurationSettings {
+	group := models.APIGroupSetting{Group: "Accounts"}
+	for name, endpoints := range apis {
+		api := models.APISetting{API: name, Version: "1.0.0", EndpointBase: "/" + name + "/v1"}
+		for _, endpoint := range endpoints {
+			api.EndpointList = append(api.EndpointList, models.APIEndpointSetting{Endpoint: endpoint})
+		}
+
+		group.APIList = append(group.APIList, api)
+	}
+
+	return &models.ConfigurationSettings{
+		Version:            version,
+		ValidationSettings: models.ValidationSettings{APIGroupSettings: []models.APIGroupSetting{group}},
+	}
+}
+
+func TestConfigurationHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		versions []string
+		want     []string
+	}{
+		{name: "newest first", size: 5, versions: []string{"1", "2", "3"}, want: []string{"3", "2", "1"}},
+		{name: "oldest versions are removed", size: 2, versions: []string{"1", "2", "3"}, want: []string{"3", "2"}},
+		{name: "same version is kept once", size: 5, versions: []string{"1", "2", "1"}, want: []string{"1", "2"}},
+		{name: "versions with invalid file characters", size: 5, versions: []string{"1/../2", "3 4"}, want: []string{"3 4", "1/../2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir()
+			history := NewConfigurationHistory(newTestLogger(), path, tt.size)
+			date := time.Now()
+			for i, version := range tt.versions {
+				history.Add(newTestHistorySettings(version, nil), date.Add(time.Duration(i)*time.Second))
+			}
+
+			// The versions are read again from disk
+			loaded := NewConfigurationHistory(newTestLogger(), path, tt.size)
+			if err := loaded.Load(); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			for _, h := range []*ConfigurationHistory{history, loaded} {
+				got := make([]string, 0)
+				for _, summary := range h.List() {
+					got = append(got, summary.Version)
+				}
+
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("List() = %v, want %v", got, tt.want)
+				}
+			}
+
+			files, _ := filepath.Glob(filepath.Join(path, "*"+historyFileExtension))
+			if len(files) != len(tt.want) {
+				t.Errorf("files = %d, want %d", len(files), len(tt.want))
+			}
+
+			if entry := loaded.Get(tt.want[0]); entry == nil || entry.Settings.Version != tt.want[0] {
+				t.Errorf("Get(%q) = %+v", tt.want[0], entry)
+			}
+		})
+	}
+}
+
+func TestConfigurationHistoryPinState(t *testing.T) {
+	history := NewConfigurationHistory(newTestLogger(), t.TempDir(), 5)
+	if state := history.LoadPinState(); state != nil {
+		t.Fatalf("LoadPinState() = %+v, want nil", state)
+	}
+
+	state := &ConfigurationPinState{Version: "1.0.0", PinnedDate: time.Now().Truncate(time.Second)}
+	if err := history.SavePinState(state); err != nil {
+		t.Fatalf("SavePinState() error = %v", err)
+	}
+
+	if loaded := history.LoadPinState(); loaded == nil || loaded.Version != state.Version || !loaded.PinnedDate.Equal(state.PinnedDate) {
+		t.Fatalf("LoadPinState() = %+v, want %+v", loaded, state)
+	}
+
+	if err := history.SavePinState(nil); err != nil {
+		t.Fatalf("SavePinState(nil) error = %v", err)
+	}
+
+	if loaded := history.LoadPinState(); loaded != nil {
+		t.Errorf("LoadPinState() = %+v, want nil", loaded)
+	}
+}
+
+func TestDiffConfigurations(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous *models.ConfigurationSettings
+		current  *models.ConfigurationSettings
+		want     ConfigurationDiff
+	}{
+		{
+			name:    "first load",
+			current: newTestHistorySettings("2", map[string][]string{"accounts": {"/accounts"}}),
+			want:    ConfigurationDiff{NewVersion: "2", AddedAPIs: []string{"Accounts/accounts"}, AddedEndpoints: []string{"/accounts/v1/accounts"}},
+		},
+		{
+			name:     "no changes",
+			previous: newTestHistorySettings("1", map[string][]string{"accounts": {"/accounts"}}),
+			current:  newTestHistorySettings("2", map[string][]string{"accounts": {"/accounts"}}),
+			want:     ConfigurationDiff{PreviousVersion: "1", NewVersion: "2"},
+		},
+		{
+			name:     "endpoints added and removed",
+			previous: newTestHistorySettings("1", map[string][]string{"accounts": {"/accounts", "/accounts/{accountId}"}}),
+			current:  newTestHistorySettings("2", map[string][]string{"accounts": {"/accounts", "/accounts/{accountId}/balances"}}),
+			want: ConfigurationDiff{
+				PreviousVersion:  "1",
+				NewVersion:       "2",
+				AddedEndpoints:   []string{"/accounts/v1/accounts/{accountId}/balances"},
+				RemovedEndpoints: []string{"/accounts/v1/accounts/{accountId}"},
+			},
+		},
+		{
+			name:     "API removed",
+			previous: newTestHistorySettings("1", map[string][]string{"accounts": {"/accounts"}, "loans": {"/contracts"}}),
+			current:  newTestHistorySettings("2", map[string][]string{"accounts": {"/accounts"}}),
+			want:     ConfigurationDiff{PreviousVersion: "1", NewVersion: "2", RemovedAPIs: []string{"Accounts/loans"}, RemovedEndpoints: []string{"/loans/v1/contracts"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffConfigurations(tt.previous, tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffConfigurations() = %+v, want %+v", got, tt.want)
+			}
+
+			if got.IsEmpty() != (tt.name == "no changes") {
+				t.Errorf("IsEmpty() = %v", got.IsEmpty())
+			}
+		})
+	}
+}
+
+func TestPinConfiguration(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		readOnly   bool
+		wantErr    error
+		wantPinned bool
+		wantStatus int
+	}{
+		{name: "version in history", version: "1.0.0", wantPinned: true, wantStatus: http.StatusOK},
+		{name: "version not in history", version: "0.9.0", wantErr: errVersionNotInHistory, wantStatus: http.StatusNotFound},
+		{name: "pin state cannot be saved", version: "1.0.0", readOnly: true, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			app, server := newTestApp(t, settings)
+			server.setVersion("1.1.0")
+			if result := app.cm.RefreshConfiguration(); !result.Updated {
+				t.Fatalf("RefreshConfiguration() = %+v", result)
+			}
+
+			if tt.readOnly {
+				// A file in the place of the pin state makes the write fail
+				pinFile := filepath.Join(settings.UpdateSettings.HistoryPath, pinStateFileName)
+				if err := os.Mkdir(pinFile, 0750); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			recorder := serveAdminRequest(app.Handler(), http.MethodPost, "/admin/config/pin/"+tt.version, "Bearer "+testAdminKey)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+
+			err := app.cm.PinConfiguration(tt.version)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("PinConfiguration() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if (app.cm.GetPinState() != nil) != tt.wantPinned {
+				t.Fatalf("GetPinState() = %+v, want pinned %v", app.cm.GetPinState(), tt.wantPinned)
+			}
+
+			wantVersion := "1.1.0"
+			if tt.wantPinned {
+				wantVersion = tt.version
+			}
+
+			if got := app.cm.GetConfigurationVersion(); got != wantVersion {
+				t.Errorf("GetConfigurationVersion() = %q, want %q", got, wantVersion)
+			}
+		})
+	}
+}
+
+func TestPinConfigurationRestart(t *testing.T) {
+	settings := newTestSettings(t)
+	server := newStubReportServer("1.0.0")
+	cm := newTestConfigurationManager(t, settings, server)
+	server.setVersion("1.1.0")
+	cm.RefreshConfiguration()
+	if err := cm.PinConfiguration("1.0.0"); err != nil {
+		t.Fatalf("PinConfiguration() error = %v", err)
+	}
+
+	if result := cm.RefreshConfiguration(); result.Error != errConfigurationPinned.Error() {
+		t.Errorf("RefreshConfiguration() = %+v, want pinned error", result)
+	}
+
+	// A new instance with the same history keeps the pinned version
+	restarted := newTestConfigurationManager(t, settings, server)
+	if restarted.GetPinState() == nil || restarted.GetConfigurationVersion() != "1.0.0" {
+		t.Fatalf("pin state not restored, version = %q", restarted.GetConfigurationVersion())
+	}
+
+	if result := restarted.UnpinConfiguration(); !result.Updated || result.Version != "1.1.0" {
+		t.Errorf("UnpinConfiguration() = %+v", result)
+	}
+
+	if restarted.GetPinState() != nil {
+		t.Errorf("GetPinState() = %+v, want nil", restarted.GetPinState())
+	}
+}
@@ -1,9 +1,16 @@
 package application
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
@@ -11,29 +18,59 @@ import (
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+const (
+	maxUpdateMessages = 100 // Maximum number of update messages kept between successful updates
 )
 
 var (
-	configurationManagerSingleton *ConfigurationManager // Singleton for configuration management
-	configurationManagerMutex     = sync.Mutex{}        // Mutex for multiprocessing locks
+	errConfigurationPinned = errors.New("configuration is pinned to a previous version, automatic updates are suspended")
+	errVersionNotInHistory = errors.New("configuration version not found in history")
 )
 
 // ConfigurationUpdateStatus stores the information of the configuration update process
 type ConfigurationUpdateStatus struct {
-	LastExecutionDate time.Time            // Indicates the data execution of the configuration update
-	LastUpdatedDate   time.Time            // Indicates the data of the las successful configuration update
-	UpdateMessages    map[time.Time]string // List of error messages if any during the update process
+	LastExecutionDate time.Time                         // Indicates the data execution of the configuration update
+	LastUpdatedDate   time.Time                         // Indicates the data of the las successful configuration update
+	UpdateMessages    []models.ConfigurationUpdateError // List of error messages if any during the update process, oldest first
 }
 
 // APIValidationSettings groups the validation settings for a specific API
 type APIValidationSettings struct {
 	EndpointSettings *models.APIEndpointSetting
+	EndpointName     string // Name of the endpoint in the configuration (endpoint base + endpoint)
 	APIGroup         string
 	API              string
 	APIVersion       string
 	BasePath         string
 }
 
+// EndpointCatalogueEntry describes an endpoint accepted by the current configuration
+type EndpointCatalogueEntry struct {
+	EndpointName string // Full name of the endpoint (endpoint base + endpoint)
+	APIGroup     string // Group of the API
+	API          string // Name of the API
+	APIVersion   string // Version of the API
+	Throughput   string // Throughput class of the endpoint
+}
+
+// ConfigurationRefreshResult contains the outcome of a configuration update execution
+type ConfigurationRefreshResult struct {
+	Updated       bool      // Indicates if a new configuration version was applied
+	Version       string    // Version of the configuration after the execution
+	ExecutionDate time.Time // Date of the execution
+	Error         string    // Error found during the execution, if any
+}
+
+// configurationRefresh stores the state of an update execution that is in progress
+type configurationRefresh struct {
+	done    chan struct{} // Closed when the execution finishes
+	updated bool          // Indicates if a new configuration version was applied
+	err     error         // Error found during the execution, if any
+}
+
 // ConfigurationManager is the manager in charge of handling configuration parameters of the application
 type ConfigurationManager struct {
 	crosscutting.OFBStruct
@@ -42,6 +79,14 @@ type ConfigurationManager struct {
 	mqdServer                 services.ReportServer         // Report server for MQD
 	configurationUpdateStatus ConfigurationUpdateStatus     // Last status of the configuration update
 	settings                  configuration.Settings
+	conditionalRequest        services.ConditionalRequest // Validators of the last configuration file applied
+	refreshMutex              sync.Mutex                  // Mutex to coalesce concurrent update executions
+	currentRefresh            *configurationRefresh       // Update execution in progress, if any
+	history                   *ConfigurationHistory       // Last configurations applied
+	pinState                  *ConfigurationPinState      // Pinned configuration version, nil if not pinned
+	partialUpdate             bool                        // Indicates that some APIs could not be updated in the last update
+	rules                     *validation.RulesCache      // Parsed validation rules of the endpoints, shared with the workers
+	mutex                     sync.Mutex                  // Mutex for multiprocessing locks
 }
 
 // NewConfigurationManager creates a new configuration manager for the application
@@ -54,24 +99,34 @@ type ConfigurationManager struct {
 // Returns:
 //   - ConfigurationManager: new created configuration manager
 func NewConfigurationManager(logger log.Logger, mqdServer services.ReportServer, settings configuration.Settings) *ConfigurationManager {
-	if configurationManagerSingleton == nil {
-		configurationManagerSingleton = &ConfigurationManager{
-			OFBStruct: crosscutting.OFBStruct{
-				Pack:   "application.ConfigurationManager",
-				Logger: logger,
-			},
-
-			mqdServer: mqdServer,
-			settings:  settings,
-		}
+	cm := &ConfigurationManager{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ConfigurationManager",
+			Logger: logger,
+		},
 
-		configurationManagerSingleton.configurationUpdateStatus.UpdateMessages = make(map[time.Time]string)
+		mqdServer: mqdServer,
+		settings:  settings,
+		history:   NewConfigurationHistory(logger, settings.UpdateSettings.HistoryPath, settings.UpdateSettings.HistorySize),
+		rules:     validation.NewRulesCache(),
 	}
 
-	return configurationManagerSingleton
+	cm.configurationUpdateStatus.UpdateMessages = make([]models.ConfigurationUpdateError, 0)
+	return cm
 }
 
-// getAPIConfigurationFile returns configuration settings for the specified API
+// apiConfigurationJob stores the information to load the endpoint file of an API
+type apiConfigurationJob struct {
+	groupIndex   int                         // Index of the API group in the new settings
+	apiIndex     int                         // Index of the API in the group
+	api          string                      // Name of the API
+	fileName     string                      // Path of the endpoint file
+	previous     *models.APISetting          // Settings of the API currently applied, nil if it is a new API
+	endpointList []models.APIEndpointSetting // Endpoint list loaded
+	err          error                       // Error found while loading the endpoint file
+}
+
+// getAPIConfigurationFileName returns the path of the endpoint file for the specified API
 //
 // Parameters:
 //   - basePath: Base path of the api group
@@ -79,13 +134,23 @@ func NewConfigurationManager(logger log.Logger, mqdServer services.ReportServer,
 //   - apiVersion: api version of the endpoint
 //
 // Returns:
-//   - []models.APIEndpointSetting: Array with endpoint settings for each of the endpoints in the api
-//   - error: error if any
-func (cm *ConfigurationManager) getAPIConfigurationFile(basePath string, apiPath string, apiVersion string) ([]models.APIEndpointSetting, error) {
+//   - string: path of the endpoint file
+func (cm *ConfigurationManager) getAPIConfigurationFileName(basePath string, apiPath string, apiVersion string) string {
 	apiConfigurationPath := basePath + "//" + apiPath + "//" + apiVersion + "//response//"
 	apiConfigurationPath = strings.ReplaceAll(apiConfigurationPath, "ParameterData//", "")
 	apiConfigurationPath = strings.ReplaceAll(apiConfigurationPath, "//", "/")
-	fileName := apiConfigurationPath + "endpoints.json"
+	return apiConfigurationPath + "endpoints.json"
+}
+
+// getAPIConfigurationFile returns configuration settings for the specified API
+//
+// Parameters:
+//   - fileName: Path of the endpoint file
+//
+// Returns:
+//   - []models.APIEndpointSetting: Array with endpoint settings for each of the endpoints in the api
+//   - error: error if any
+func (cm *ConfigurationManager) getAPIConfigurationFile(fileName string) ([]models.APIEndpointSetting, error) {
 	cm.Logger.Debug("loading File Name: "+fileName, cm.Pack, "getAPIConfigurationFile")
 	file, err := cm.mqdServer.LoadAPIConfigurationFile(fileName)
 	if err != nil {
@@ -100,69 +165,139 @@ func (cm *ConfigurationManager) getAPIConfigurationFile(basePath string, apiPath
 		return nil, err
 	}
 
+	// The rules are compiled with the settings, invalid rules are skipped so the rest of the endpoint is still validated
+	for i := range result {
+		endpoint := &result[i]
+		_, err = cm.rules.Parse(endpoint.BodyValidationRules)
+		if err != nil {
+			cm.Logger.Error(err, "Invalid body validation rules skipped for endpoint: "+endpoint.Endpoint, cm.Pack, "getAPIConfigurationFile")
+			endpoint.BodyValidationRules = ""
+		}
+
+		_, err = cm.rules.Parse(endpoint.HeaderValidationRules)
+		if err != nil {
+			cm.Logger.Error(err, "Invalid header validation rules skipped for endpoint: "+endpoint.Endpoint, cm.Pack, "getAPIConfigurationFile")
+			endpoint.HeaderValidationRules = ""
+		}
+	}
+
 	return result, nil
 }
 
-// updateValidationSchemas checks and updates the validation schemas for the endpoints
+// updateValidationSettings checks and updates the endpoint lists of each API, APIs that fail keep the endpoint list
+// currently applied, or are disabled if they were not applied before
 //
 // Parameters:
+//   - currentSettings: configuration settings currently applied, nil on the first load
 //   - newSettings: new configuration settings to update
 //
 // Returns:
-//   - error: error if any
-func (cm *ConfigurationManager) updateValidationSettings(newSettings *models.ConfigurationSettings) error {
-	cm.Logger.Info("Updating Validation Schemas.", cm.Pack, "updateValidationSchemas")
-
-	if cm.ConfigurationSettings == nil {
+//   - []string: list of messages for the APIs that could not be updated
+func (cm *ConfigurationManager) updateValidationSettings(currentSettings *models.ConfigurationSettings, newSettings *models.ConfigurationSettings) []string {
+	cm.Logger.Info("Updating Validation Schemas.", cm.Pack, "updateValidationSettings")
+	if currentSettings == nil {
 		cm.Logger.Info("Executing first load", cm.Pack, "updateValidationSettings")
-		for i, newSet := range newSettings.ValidationSettings.APIGroupSettings {
-			for j, newAPI := range newSet.APIList {
-				cm.Logger.Info("Loading API: "+newAPI.API, cm.Pack, "updateValidationSettings")
-				epList, err := cm.getAPIConfigurationFile(newSet.BasePath, newAPI.BasePath, newAPI.Version)
-				if err != nil {
-					return err
-				}
+	}
 
-				newSettings.ValidationSettings.APIGroupSettings[i].APIList[j].EndpointList = epList
-			}
+	jobs := make([]*apiConfigurationJob, 0)
+	for i, newSet := range newSettings.ValidationSettings.APIGroupSettings {
+		var oldSet *models.APIGroupSetting
+		if currentSettings != nil {
+			oldSet = currentSettings.ValidationSettings.GetGroupSetting(newSet.Group)
 		}
 
-		return nil
+		for j, newAPI := range newSet.APIList {
+			cm.Logger.Debug("Checking API: "+newAPI.API, cm.Pack, "updateValidationSettings")
+			var oldAPI *models.APISetting
+			if oldSet != nil {
+				oldAPI = oldSet.GetAPISetting(newAPI.API)
+			}
+
+			if oldAPI != nil && oldAPI.Version == newAPI.Version {
+				newSettings.ValidationSettings.APIGroupSettings[i].APIList[j].EndpointList = oldAPI.EndpointList
+				continue
+			}
+
+			cm.Logger.Info("Loading API: "+newAPI.API, cm.Pack, "updateValidationSettings")
+			jobs = append(jobs, &apiConfigurationJob{
+				groupIndex: i,
+				apiIndex:   j,
+				api:        newAPI.API,
+				fileName:   cm.getAPIConfigurationFileName(newSet.BasePath, newAPI.BasePath, newAPI.Version),
+				previous:   oldAPI,
+			})
+		}
 	}
 
-	for i, newSet := range newSettings.ValidationSettings.APIGroupSettings {
-		oldSet := cm.ConfigurationSettings.ValidationSettings.GetGroupSetting(newSet.Group)
-		if oldSet == nil {
-			for j, newAPI := range newSet.APIList {
-				epList, err := cm.getAPIConfigurationFile(newSet.BasePath, newAPI.BasePath, newAPI.Version)
-				if err != nil {
-					cm.Logger.Error(err, "error loading api configuration file", cm.Pack, "updateValidationSettings")
-					return err
-				}
+	cm.loadAPIConfigurationFiles(jobs)
 
-				newSettings.ValidationSettings.APIGroupSettings[i].APIList[j].EndpointList = epList
-			}
+	failures := make([]string, 0)
+	disabled := make(map[int]map[int]bool)
+	for _, job := range jobs {
+		if job.err == nil {
+			newSettings.ValidationSettings.APIGroupSettings[job.groupIndex].APIList[job.apiIndex].EndpointList = job.endpointList
+			continue
+		}
+
+		message := "API: " + job.api + ", path: " + job.fileName + ", error: " + job.err.Error()
+		if job.previous != nil {
+			cm.Logger.Warning("Keeping previous version "+job.previous.Version+" for "+message, cm.Pack, "updateValidationSettings")
+			newSettings.ValidationSettings.APIGroupSettings[job.groupIndex].APIList[job.apiIndex] = *job.previous
 		} else {
-			for j, newAPI := range newSet.APIList {
-				cm.Logger.Debug("Cehecking API: "+newAPI.API, cm.Pack, "updateValidationSettings")
-				oldAPI := oldSet.GetAPISetting(newAPI.API)
-				if oldAPI == nil || oldAPI.Version != newAPI.Version {
-					cm.Logger.Info("Updating API: "+newAPI.API, cm.Pack, "updateValidationSettings")
-					epList, err := cm.getAPIConfigurationFile(newSet.BasePath, newAPI.BasePath, newAPI.Version)
-					if err != nil {
-						cm.Logger.Error(err, "error loading api configuration file", cm.Pack, "updateValidationSettings")
-						return err
-					}
+			cm.Logger.Warning("Disabling "+message, cm.Pack, "updateValidationSettings")
+			if disabled[job.groupIndex] == nil {
+				disabled[job.groupIndex] = make(map[int]bool)
+			}
 
-					newSettings.ValidationSettings.APIGroupSettings[i].APIList[j].EndpointList = epList
-				} else {
-					newSettings.ValidationSettings.APIGroupSettings[i].APIList[j].EndpointList = oldAPI.EndpointList
-				}
+			disabled[job.groupIndex][job.apiIndex] = true
+		}
+
+		failures = append(failures, message)
+	}
+
+	for i, apis := range disabled {
+		group := &newSettings.ValidationSettings.APIGroupSettings[i]
+		apiList := make([]models.APISetting, 0, len(group.APIList))
+		for j, api := range group.APIList {
+			if !apis[j] {
+				apiList = append(apiList, api)
 			}
 		}
+
+		group.APIList = apiList
 	}
 
-	return nil
+	return failures
+}
+
+// loadAPIConfigurationFiles loads the endpoint files of the jobs in parallel, with the configured maximum concurrency
+//
+// Parameters:
+//   - jobs: list of endpoint files to load
+//
+// Returns:
+func (cm *ConfigurationManager) loadAPIConfigurationFiles(jobs []*apiConfigurationJob) {
+	concurrency := cm.settings.UpdateSettings.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for _, job := range jobs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(job *apiConfigurationJob) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			job.endpointList, job.err = cm.getAPIConfigurationFile(job.fileName)
+		}(job)
+	}
+
+	wg.Wait()
 }
 
 // updateConfiguration updates all configuration settings of the application
@@ -170,39 +305,257 @@ func (cm *ConfigurationManager) updateValidationSettings(newSettings *models.Con
 // Parameters:
 //
 // Returns:
+//   - bool: true if a new configuration version was applied
 //   - error: error if any
-func (cm *ConfigurationManager) updateConfiguration() error {
+func (cm *ConfigurationManager) updateConfiguration() (bool, error) {
 	cm.Logger.Info("Executing configuration update", cm.Pack, "updateConfiguration")
 
+	if cm.GetPinState() != nil {
+		return false, errConfigurationPinned
+	}
+
+	// The fields are also written by the administration API, a snapshot is used during the update
+	cm.mutex.Lock()
 	cm.configurationUpdateStatus.LastExecutionDate = time.Now()
-	cs, err := cm.mqdServer.LoadConfigurationSettings()
+	currentSettings := cm.ConfigurationSettings
+	partialUpdate := cm.partialUpdate
+	conditional := cm.conditionalRequest
+	cm.mutex.Unlock()
+
+	// After a partial update the file must be processed again, even if it was not modified
+	if currentSettings == nil || partialUpdate {
+		conditional = services.ConditionalRequest{}
+	}
+
+	cs, err := cm.mqdServer.LoadConfigurationSettings(&conditional)
+	if errors.Is(err, services.ErrNotModified) {
+		cm.Logger.Info("Configuration file was not modified.", cm.Pack, "updateConfiguration")
+		return false, nil
+	}
+
 	if err != nil {
-		cm.configurationUpdateStatus.UpdateMessages[time.Now()] = err.Error()
-		return err
+		cm.mutex.Lock()
+		cm.addUpdateMessage(err.Error())
+		cm.mutex.Unlock()
+		return false, err
 	}
 
-	if cm.ConfigurationSettings != nil && cs.Version == cm.ConfigurationSettings.Version {
+	if currentSettings != nil && !partialUpdate && cs.Version == currentSettings.Version {
 		cm.Logger.Info("Same configuration version was found.", cm.Pack, "updateConfiguration")
-		return nil
+		// A configuration pinned or unpinned during the update keeps the validators it has set
+		cm.mutex.Lock()
+		if cm.pinState == nil && cm.ConfigurationSettings == currentSettings {
+			cm.conditionalRequest = conditional
+		}
+
+		cm.mutex.Unlock()
+		return false, nil
 	}
 
-	err = cm.updateValidationSettings(cs)
-	if err != nil {
-		cm.configurationUpdateStatus.UpdateMessages[cm.configurationUpdateStatus.LastExecutionDate] = err.Error()
-		return err
+	failures := cm.updateValidationSettings(currentSettings, cs)
+
+	cm.mutex.Lock()
+	if cm.pinState != nil {
+		cm.mutex.Unlock()
+		return false, errConfigurationPinned
 	}
 
-	configurationManagerMutex.Lock()
+	cs.SecuritySettings.AttributesToMask = append(cs.SecuritySettings.AttributesToMask, "companyCnpj")
+	cm.logConfigurationDiff(cm.ConfigurationSettings, cs)
 	cm.ConfigurationSettings = cs
-	cm.ConfigurationSettings.SecuritySettings.AttributesToMask = append(cm.ConfigurationSettings.SecuritySettings.AttributesToMask, "companyCnpj")
 	cm.configurationUpdateStatus.LastUpdatedDate = cm.configurationUpdateStatus.LastExecutionDate
-	cm.configurationUpdateStatus.UpdateMessages = make(map[time.Time]string)
+	cm.configurationUpdateStatus.UpdateMessages = make([]models.ConfigurationUpdateError, 0, len(failures))
+	for _, failure := range failures {
+		cm.addUpdateMessage(failure)
+	}
+
+	cm.partialUpdate = len(failures) > 0
+	cm.conditionalRequest = conditional
+	updatedDate := cm.configurationUpdateStatus.LastUpdatedDate
 	cm.Logger.Info("Configuration was updated to the latest version: "+cm.ConfigurationSettings.Version, cm.Pack, "updateConfiguration")
-	configurationManagerMutex.Unlock()
+	cm.mutex.Unlock()
+
+	cm.history.Add(cs, updatedDate)
+	return true, nil
+}
+
+// addUpdateMessage includes a message in the update status, the oldest messages are discarded when the maximum is
+// reached, the caller must hold the mutex
+//
+// Parameters:
+//   - message: message to be included
+//
+// Returns:
+func (cm *ConfigurationManager) addUpdateMessage(message string) {
+	messages := append(cm.configurationUpdateStatus.UpdateMessages, models.ConfigurationUpdateError{
+		ErrorDate:    time.Now(),
+		ErrorMessage: message,
+	})
+
+	if len(messages) > maxUpdateMessages {
+		messages = messages[len(messages)-maxUpdateMessages:]
+	}
+
+	cm.configurationUpdateStatus.UpdateMessages = messages
+}
+
+// logConfigurationDiff logs the differences between the previous and the new configuration
+//
+// Parameters:
+//   - previous: configuration previously applied, can be nil
+//   - current: new configuration
+//
+// Returns:
+func (cm *ConfigurationManager) logConfigurationDiff(previous *models.ConfigurationSettings, current *models.ConfigurationSettings) {
+	diff := diffConfigurations(previous, current)
+	if diff.IsEmpty() {
+		return
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		cm.Logger.Error(err, "Error creating configuration diff", cm.Pack, "logConfigurationDiff")
+		return
+	}
+
+	cm.Logger.Info("Configuration changes: "+string(data), cm.Pack, "logConfigurationDiff")
+}
+
+// PinConfiguration applies a previous configuration version and suspends automatic updates until it is unpinned
+//
+// Parameters:
+//   - version: version of the configuration to pin
+//
+// Returns:
+//   - error: error if the version is not found in the history, or the pin state cannot be stored, in both cases the
+//     configuration is not pinned
+func (cm *ConfigurationManager) PinConfiguration(version string) error {
+	entry := cm.history.Get(version)
+	if entry == nil {
+		return errors.Join(errVersionNotInHistory, errors.New("version: "+version))
+	}
+
+	// The pin must survive a restart, otherwise the instance would silently return to the latest version
+	state := &ConfigurationPinState{Version: version, PinnedDate: time.Now()}
+	err := cm.history.SavePinState(state)
+	if err != nil {
+		cm.Logger.Error(err, "Error saving configuration pin state", cm.Pack, "PinConfiguration")
+		return err
+	}
 
+	cm.mutex.Lock()
+	cm.logConfigurationDiff(cm.ConfigurationSettings, entry.Settings)
+	cm.ConfigurationSettings = entry.Settings
+	cm.pinState = state
+	cm.configurationUpdateStatus.LastUpdatedDate = state.PinnedDate
+	cm.mutex.Unlock()
+
+	cm.Logger.Warning("Configuration pinned to version: "+version+", automatic updates are suspended", cm.Pack, "PinConfiguration")
 	return nil
 }
 
+// UnpinConfiguration removes the pinned version and executes the configuration update immediately
+//
+// Parameters:
+//
+// Returns:
+//   - ConfigurationRefreshResult: outcome of the update
+func (cm *ConfigurationManager) UnpinConfiguration() ConfigurationRefreshResult {
+	err := cm.history.SavePinState(nil)
+	if err != nil {
+		cm.Logger.Error(err, "Error removing configuration pin state", cm.Pack, "UnpinConfiguration")
+	}
+
+	cm.mutex.Lock()
+	cm.pinState = nil
+	cm.conditionalRequest = services.ConditionalRequest{}
+	cm.mutex.Unlock()
+
+	cm.Logger.Info("Configuration unpinned, automatic updates are resumed", cm.Pack, "UnpinConfiguration")
+	return cm.RefreshConfiguration()
+}
+
+// GetPinState returns the pinned configuration version
+//
+// Parameters:
+//
+// Returns:
+//   - *ConfigurationPinState: pin state, nil if the configuration is not pinned
+func (cm *ConfigurationManager) GetPinState() *ConfigurationPinState {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.pinState == nil {
+		return nil
+	}
+
+	state := *cm.pinState
+	return &state
+}
+
+// GetConfigurationHistory returns the list of configurations stored in the history
+//
+// Parameters:
+//
+// Returns:
+//   - []ConfigurationHistorySummary: configurations stored, newest first
+func (cm *ConfigurationManager) GetConfigurationHistory() []ConfigurationHistorySummary {
+	return cm.history.List()
+}
+
+// refresh executes the configuration update, concurrent calls are coalesced into a single execution
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if a new configuration version was applied
+//   - error: error if any
+func (cm *ConfigurationManager) refresh() (bool, error) {
+	cm.refreshMutex.Lock()
+	if cm.currentRefresh != nil {
+		current := cm.currentRefresh
+		cm.refreshMutex.Unlock()
+		cm.Logger.Info("Configuration update already running, waiting for the result", cm.Pack, "refresh")
+		<-current.done
+		return current.updated, current.err
+	}
+
+	current := &configurationRefresh{done: make(chan struct{})}
+	cm.currentRefresh = current
+	cm.refreshMutex.Unlock()
+
+	current.updated, current.err = cm.updateConfiguration()
+
+	cm.refreshMutex.Lock()
+	cm.currentRefresh = nil
+	cm.refreshMutex.Unlock()
+	close(current.done)
+
+	return current.updated, current.err
+}
+
+// RefreshConfiguration executes the configuration update immediately and returns its outcome
+//
+// Parameters:
+//
+// Returns:
+//   - ConfigurationRefreshResult: outcome of the update
+func (cm *ConfigurationManager) RefreshConfiguration() ConfigurationRefreshResult {
+	cm.Logger.Info("Configuration update requested", cm.Pack, "RefreshConfiguration")
+	updated, err := cm.refresh()
+	result := ConfigurationRefreshResult{
+		Updated:       updated,
+		Version:       cm.GetConfigurationVersion(),
+		ExecutionDate: cm.GetLastExecutionDate(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
 // getAPIGroupSettings return the settings of API groups
 //
 // Parameters:
@@ -210,16 +563,16 @@ func (cm *ConfigurationManager) updateConfiguration() error {
 // Returns:
 //   - []models.APIGroupSetting: Array of APIGroupSetting found
 func (cm *ConfigurationManager) getAPIGroupSettings() []models.APIGroupSetting {
-	configurationManagerMutex.Lock()
+	cm.mutex.Lock()
 	defer func() {
-		configurationManagerMutex.Unlock()
+		cm.mutex.Unlock()
 	}()
 
 	result := cm.ConfigurationSettings.ValidationSettings.APIGroupSettings
 	return result
 }
 
-// StartUpdateProcess starts the periodic process that prints total results and clears them every 2 minutes
+// StartUpdateProcess starts the periodic process that updates the configuration settings
 //
 // Parameters:
 //
@@ -231,20 +584,63 @@ func (cm *ConfigurationManager) StartUpdateProcess() {
 
 	cm.processRunning = true
 	cm.Logger.Info("Starting configuration update Process", cm.Pack, "StartUpdateProcess")
-	timeWindow := time.Duration(2) * time.Minute
-	if cm.settings.ConfigurationSettings.Environment != "DEBUG" {
-		timeWindow = time.Duration(4) * time.Hour
+	for {
+		time.Sleep(cm.getUpdateWaitTime())
+		_, err := cm.refresh()
+		if errors.Is(err, errConfigurationPinned) {
+			cm.Logger.Info("Configuration is pinned, skipping update", cm.Pack, "StartUpdateProcess")
+		} else if err != nil {
+			cm.Logger.Error(err, "Error updating configuration", cm.Pack, "StartUpdateProcess")
+		}
 	}
+}
 
-	ticker := time.NewTicker(timeWindow)
-	for range ticker.C {
-		err := cm.updateConfiguration()
-		if err != nil {
-			cm.Logger.Error(err, "Error updating configuration", cm.Pack, "StartUpdateProcess")
+// StartRefreshSignalHandler starts listening for SIGHUP signals to execute the configuration update immediately
+//
+// Parameters:
+//
+// Returns:
+func (cm *ConfigurationManager) StartRefreshSignalHandler() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		result := cm.RefreshConfiguration()
+		if result.Error != "" {
+			cm.Logger.Warning("Configuration update requested by signal failed: "+result.Error, cm.Pack, "StartRefreshSignalHandler")
+		} else {
+			cm.Logger.Info("Configuration update requested by signal finished, version: "+result.Version, cm.Pack, "StartRefreshSignalHandler")
 		}
 	}
 }
 
+// getUpdateWaitTime returns the time to wait before the next configuration update, including the configured jitter
+//
+// Parameters:
+//
+// Returns:
+//   - time.Duration: time to wait
+func (cm *ConfigurationManager) getUpdateWaitTime() time.Duration {
+	timeWindow := time.Duration(4) * time.Hour
+	if cm.settings.UpdateSettings.Interval > 0 {
+		timeWindow = time.Duration(cm.settings.UpdateSettings.Interval) * time.Minute
+	} else if cm.settings.ConfigurationSettings.Environment == "DEBUG" {
+		timeWindow = time.Duration(2) * time.Minute
+	}
+
+	if cm.settings.UpdateSettings.Jitter <= 0 {
+		return timeWindow
+	}
+
+	maxJitter := big.NewInt(int64(time.Duration(cm.settings.UpdateSettings.Jitter) * time.Minute))
+	jitter, err := rand.Int(rand.Reader, maxJitter)
+	if err != nil {
+		cm.Logger.Error(err, "Error generating jitter", cm.Pack, "getUpdateWaitTime")
+		return timeWindow
+	}
+
+	return timeWindow + time.Duration(jitter.Int64())
+}
+
 // Initialize executes initial settings configuration
 //
 // Parameters:
@@ -252,7 +648,34 @@ func (cm *ConfigurationManager) StartUpdateProcess() {
 // Returns:
 //   - error: error if any
 func (cm *ConfigurationManager) Initialize() error {
-	return cm.updateConfiguration()
+	err := cm.history.Load()
+	if err != nil {
+		cm.Logger.Error(err, "Error loading configuration history", cm.Pack, "Initialize")
+	}
+
+	state := cm.history.LoadPinState()
+	if state != nil {
+		entry := cm.history.Get(state.Version)
+		if entry != nil {
+			cm.mutex.Lock()
+			cm.ConfigurationSettings = entry.Settings
+			cm.pinState = state
+			cm.configurationUpdateStatus.LastExecutionDate = time.Now()
+			cm.configurationUpdateStatus.LastUpdatedDate = state.PinnedDate
+			cm.mutex.Unlock()
+			cm.Logger.Warning("Configuration pinned to version: "+state.Version+", automatic updates are suspended", cm.Pack, "Initialize")
+			return nil
+		}
+
+		cm.Logger.Warning("Pinned configuration version not found in history: "+state.Version+", removing pin", cm.Pack, "Initialize")
+		err = cm.history.SavePinState(nil)
+		if err != nil {
+			cm.Logger.Error(err, "Error removing configuration pin state", cm.Pack, "Initialize")
+		}
+	}
+
+	_, err = cm.refresh()
+	return err
 }
 
 // GetEndpointSettingFromAPI loads a specific endpoint setting based on the endpoint name
@@ -276,6 +699,7 @@ func (cm *ConfigurationManager) GetEndpointSettingFromAPI(endpointName string, l
 					if apiEndpointName == strings.ToLower(strings.TrimSpace(endpointName)) {
 						return &APIValidationSettings{
 							EndpointSettings: &endpoint,
+							EndpointName:     getEndpointName(api, endpoint),
 							APIVersion:       api.Version,
 							API:              api.API,
 							APIGroup:         setting.Group,
@@ -291,6 +715,85 @@ func (cm *ConfigurationManager) GetEndpointSettingFromAPI(endpointName string, l
 	return nil
 }
 
+// ResolveEndpointName returns the name of the endpoint that matches a request path, the parameters of the endpoint
+// (e.g. {accountId}) match any value. Endpoints with fewer parameters have priority
+//
+// Parameters:
+//   - path: Path of the request, without the API prefix (e.g. /accounts/v2/accounts/123)
+//
+// Returns:
+//   - string: Name of the endpoint, empty if no endpoint matches
+func (cm *ConfigurationManager) ResolveEndpointName(path string) string {
+	pathSegments := strings.Split(strings.Trim(strings.ToLower(path), "/"), "/")
+	result := ""
+	resultParameters := -1
+	for _, entry := range cm.GetEndpointCatalogue() {
+		endpointSegments := strings.Split(strings.Trim(strings.ToLower(entry.EndpointName), "/"), "/")
+		if len(endpointSegments) != len(pathSegments) {
+			continue
+		}
+
+		parameters := 0
+		for i, segment := range endpointSegments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				parameters++
+			} else if segment != pathSegments[i] {
+				parameters = -1
+				break
+			}
+		}
+
+		if parameters >= 0 && (resultParameters < 0 || parameters < resultParameters) {
+			result = entry.EndpointName
+			resultParameters = parameters
+		}
+	}
+
+	return result
+}
+
+// GetEndpointCatalogue returns the list of endpoints supported by the current configuration
+//
+// Parameters:
+//
+// Returns:
+//   - []EndpointCatalogueEntry: List of endpoints with their API information
+func (cm *ConfigurationManager) GetEndpointCatalogue() []EndpointCatalogueEntry {
+	result := make([]EndpointCatalogueEntry, 0)
+	for _, setting := range cm.getAPIGroupSettings() {
+		for _, api := range setting.APIList {
+			for _, endpoint := range api.EndpointList {
+				result = append(result, EndpointCatalogueEntry{
+					EndpointName: getEndpointName(api, endpoint),
+					APIGroup:     setting.Group,
+					API:          api.API,
+					APIVersion:   api.Version,
+					Throughput:   endpoint.Throughput,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// GetConfigurationVersion returns the version of the configuration currently applied
+//
+// Parameters:
+//
+// Returns:
+//   - string: Version of the configuration
+func (cm *ConfigurationManager) GetConfigurationVersion() string {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.ConfigurationSettings == nil {
+		return ""
+	}
+
+	return cm.ConfigurationSettings.Version
+}
+
 // GetLastExecutionDate returns the las execution date
 //
 // Parameters:
@@ -298,6 +801,9 @@ func (cm *ConfigurationManager) GetEndpointSettingFromAPI(endpointName string, l
 // Returns:
 //   - time.Time: Last execution time
 func (cm *ConfigurationManager) GetLastExecutionDate() time.Time {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
 	return cm.configurationUpdateStatus.LastExecutionDate
 }
 
@@ -308,17 +814,25 @@ func (cm *ConfigurationManager) GetLastExecutionDate() time.Time {
 // Returns:
 //   - time.Time: Last updated time
 func (cm *ConfigurationManager) GetLastUpdatedDate() time.Time {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
 	return cm.configurationUpdateStatus.LastUpdatedDate
 }
 
-// GetUpdateMessages returns the list of update messages
+// GetUpdateStatus returns a copy of the status of the configuration update process
 //
 // Parameters:
 //
 // Returns:
-//   - map: map[time.Time]string with the list of messages by date
-func (cm *ConfigurationManager) GetUpdateMessages() map[time.Time]string {
-	return cm.configurationUpdateStatus.UpdateMessages
+//   - ConfigurationUpdateStatus: dates of the last execution and update, with the update messages oldest first
+func (cm *ConfigurationManager) GetUpdateStatus() ConfigurationUpdateStatus {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	status := cm.configurationUpdateStatus
+	status.UpdateMessages = append(make([]models.ConfigurationUpdateError, 0, len(status.UpdateMessages)), status.UpdateMessages...)
+	return status
 }
 
 // GetReportExecutionWindow returns the report execution window configured
@@ -349,6 +863,16 @@ func (cm *ConfigurationManager) GetSendOnReportNumber() int {
 	return cm.ConfigurationSettings.ReportSettings.SendOnReportNumber
 }
 
+// GetReportVersion returns the version of the report accepted by the central server
+//
+// Parameters:
+//
+// Returns:
+//   - int: version of the report, models.ReportVersion1 if the server does not declare it
+func (cm *ConfigurationManager) GetReportVersion() int {
+	return max(cm.ConfigurationSettings.ReportSettings.ReportVersion, models.ReportVersion1)
+}
+
 // IsHTTPS indicates if the application should be configured as HTTP or HTTPS
 //
 // Parameters:
@@ -375,3 +899,147 @@ func (cm *ConfigurationManager) GetCertFilePath() string {
 func (cm *ConfigurationManager) GetKeyFilePath() string {
 	return cm.settings.SecuritySettings.KeyFilePath
 }
+
+// IsAdminEnabled indicates if the administration API should be exposed
+//
+// Parameters:
+// Returns:
+//   - bool: true if the administration API is enabled
+func (cm *ConfigurationManager) IsAdminEnabled() bool {
+	return cm.settings.AdminSettings.Enabled
+}
+
+// GetAdminAPIKey returns the key required to access the administration API
+//
+// Parameters:
+// Returns:
+//   - string: configured API key
+func (cm *ConfigurationManager) GetAdminAPIKey() string {
+	return cm.settings.AdminSettings.APIKey
+}
+
+// GetApplicationRoles returns the roles (TRANSMITTER / RECEIVER) supported by the instance
+//
+// Parameters:
+// Returns:
+//   - []string: list of roles supported
+func (cm *ConfigurationManager) GetApplicationRoles() []string {
+	if cm.settings.ApplicationSettings.Mode == configuration.DualMode {
+		return []string{configuration.TransmitterMode, configuration.ReceiverMode}
+	}
+
+	return []string{cm.settings.ApplicationSettings.Mode}
+}
+
+// IsDualMode indicates if the instance acts as TRANSMITTER and RECEIVER
+//
+// Parameters:
+// Returns:
+//   - bool: true if the instance is configured in dual mode
+func (cm *ConfigurationManager) IsDualMode() bool {
+	return cm.settings.ApplicationSettings.Mode == configuration.DualMode
+}
+
+// GetThroughputValidationRate returns the validation rate configured for a throughput class, unknown classes
+// use the rate of the MEDIUM class
+//
+// Parameters:
+//   - throughput: throughput class of the endpoint
+//
+// Returns:
+//   - int: validation rate in % (0 - 100)
+func (cm *ConfigurationManager) GetThroughputValidationRate(throughput string) int {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	validationSettings := cm.ConfigurationSettings.ValidationSettings
+	switch throughput {
+	case models.ExtremelyHighTroughput:
+		return validationSettings.ExtremelyHighTroughputValidationRate
+	case models.HighTroughput:
+		return validationSettings.HighTroughputValidationRate
+	case models.MediumTroughput:
+		return validationSettings.MediumTroughputValidationRate
+	case models.LowTroughput:
+		return validationSettings.LowTroughputValidationRate
+	case models.VeryLowTroughput:
+		return validationSettings.VeryLowTroughputValidationRate
+	}
+
+	cm.Logger.Debug("Unknown throughput: "+throughput+", using MEDIUM validation rate", cm.Pack, "GetThroughputValidationRate")
+	return validationSettings.MediumTroughputValidationRate
+}
+
+// GetRoleValidationRate returns the validation rate configured for a specific role
+//
+// Parameters:
+//   - role: role of the message (TRANSMITTER / RECEIVER)
+//
+// Returns:
+//   - int: validation rate in % (0 - 100), 100 if the rate is not configured
+func (cm *ConfigurationManager) GetRoleValidationRate(role string) int {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	var rate *int
+	switch role {
+	case configuration.TransmitterMode:
+		rate = cm.ConfigurationSettings.ValidationSettings.TransmitterValidationRate
+	case configuration.ReceiverMode:
+		rate = cm.ConfigurationSettings.ValidationSettings.ReceiverValidationRate
+	}
+
+	// A rate of 0 disables the validation of the role, only a missing rate uses the default
+	if rate == nil {
+		return 100
+	}
+
+	return min(max(*rate, 0), 100)
+}
+
+// GetOrganisationIDs returns the identifiers of the organisations served by the instance
+//
+// Parameters:
+// Returns:
+//   - []string: list of organisation IDs, the main organisation first
+func (cm *ConfigurationManager) GetOrganisationIDs() []string {
+	result := make([]string, 0, len(cm.settings.ApplicationSettings.Organisations))
+	for _, organisation := range cm.settings.ApplicationSettings.Organisations {
+		result = append(result, organisation.OrganisationID)
+	}
+
+	if len(result) == 0 {
+		result = append(result, cm.settings.ApplicationSettings.OrganisationID)
+	}
+
+	return result
+}
+
+// GetDataOwnerID returns the organisation that owns a message, based on the data owner header or the host of the request
+//
+// Parameters:
+//   - dataOwnerHeader: value of the data owner header, can be empty
+//   - host: host of the request
+//
+// Returns:
+//   - string: organisation ID of the data owner, empty if the organisation in the header is not configured
+func (cm *ConfigurationManager) GetDataOwnerID(dataOwnerHeader string, host string) string {
+	if dataOwnerHeader != "" {
+		organisation := cm.settings.GetOrganisation(dataOwnerHeader)
+		if organisation == nil {
+			return ""
+		}
+
+		return organisation.OrganisationID
+	}
+
+	if hostName, _, err := net.SplitHostPort(host); err == nil {
+		host = hostName
+	}
+
+	if organisation := cm.settings.GetOrganisationByHost(host); organisation != nil {
+		return organisation.OrganisationID
+	}
+
+	return cm.settings.ApplicationSettings.OrganisationID
+}
@@ -0,0 +1,320 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+func TestRefreshConfiguration(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		serverErr    error
+		wantUpdated  bool
+		wantVersion  string
+		wantError    bool
+		wantMessages int
+	}{
+		{name: "same version", version: "1.0.0", wantVersion: "1.0.0"},
+		{name: "new version", version: "1.1.0", wantUpdated: true, wantVersion: "1.1.0"},
+		{name: "server error", version: "1.1.0", serverErr: errors.New("connection refused"), wantVersion: "1.0.0", wantError: true, wantMessages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			cm := newTestConfigurationManager(t, newTestSettings(t), server)
+			server.setVersion(tt.version)
+			server.setError(tt.serverErr)
+
+			result := cm.RefreshConfiguration()
+			if result.Updated != tt.wantUpdated || result.Version != tt.wantVersion || (result.Error != "") != tt.wantError {
+				t.Errorf("RefreshConfiguration() = %+v", result)
+			}
+
+			status := cm.GetUpdateStatus()
+			if len(status.UpdateMessages) != tt.wantMessages {
+				t.Errorf("UpdateMessages = %d, want %d", len(status.UpdateMessages), tt.wantMessages)
+			}
+
+			if status.LastExecutionDate.IsZero() || !result.ExecutionDate.Equal(status.LastExecutionDate) {
+				t.Errorf("ExecutionDate = %v, LastExecutionDate = %v", result.ExecutionDate, status.LastExecutionDate)
+			}
+		})
+	}
+}
+
+func TestRefreshConfigurationConcurrentStatus(t *testing.T) {
+	server := newStubReportServer("1.0.0")
+	cm := newTestConfigurationManager(t, newTestSettings(t), server)
+	server.setError(errors.New("connection refused"))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cm.RefreshConfiguration()
+		}()
+
+		go func() {
+			defer wg.Done()
+			status := cm.GetUpdateStatus()
+			// The copy can be modified without affecting the manager
+			status.UpdateMessages = append(status.UpdateMessages, status.UpdateMessages...)
+		}()
+	}
+
+	wg.Wait()
+	status := cm.GetUpdateStatus()
+	if len(status.UpdateMessages) < 1 || len(status.UpdateMessages) > 10 {
+		t.Fatalf("UpdateMessages = %d, want between 1 and 10", len(status.UpdateMessages))
+	}
+
+	for i := 1; i < len(status.UpdateMessages); i++ {
+		if status.UpdateMessages[i].ErrorDate.Before(status.UpdateMessages[i-1].ErrorDate) {
+			t.Errorf("UpdateMessages are not ordered by date")
+		}
+	}
+}
+
+func TestAddUpdateMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages int
+		want     int
+	}{
+		{name: "single message", messages: 1, want: 1},
+		{name: "messages with the same date are kept", messages: 5, want: 5},
+		{name: "oldest messages are discarded", messages: maxUpdateMessages + 10, want: maxUpdateMessages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), newTestSettings(t))
+			for i := 0; i < tt.messages; i++ {
+				cm.addUpdateMessage(time.Duration(i).String())
+			}
+
+			messages := cm.GetUpdateStatus().UpdateMessages
+			if len(messages) != tt.want {
+				t.Fatalf("UpdateMessages = %d, want %d", len(messages), tt.want)
+			}
+
+			if last := messages[len(messages)-1].ErrorMessage; last != time.Duration(tt.messages-1).String() {
+				t.Errorf("last message = %q", last)
+			}
+		})
+	}
+}
+
+func TestGetUpdateWaitTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    int
+		jitter      int
+		environment string
+		min         time.Duration
+		max         time.Duration
+	}{
+		{name: "default", min: 4 * time.Hour, max: 4 * time.Hour},
+		{name: "debug environment", environment: "DEBUG", min: 2 * time.Minute, max: 2 * time.Minute},
+		{name: "configured interval", interval: 30, environment: "DEBUG", min: 30 * time.Minute, max: 30 * time.Minute},
+		{name: "configured jitter", interval: 30, jitter: 5, min: 30 * time.Minute, max: 35 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.UpdateSettings.Interval = tt.interval
+			settings.UpdateSettings.Jitter = tt.jitter
+			settings.ConfigurationSettings.Environment = tt.environment
+			cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), settings)
+
+			for i := 0; i < 20; i++ {
+				wait := cm.getUpdateWaitTime()
+				if wait < tt.min || wait > tt.max {
+					t.Fatalf("getUpdateWaitTime() = %v, want between %v and %v", wait, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestPartialConfigurationUpdate(t *testing.T) {
+	loansFile := "Accounts/loans/1.0.0/response/endpoints.json"
+	addLoans := func(version string) func(settings *models.ConfigurationSettings) {
+		return func(settings *models.ConfigurationSettings) {
+			group := &settings.ValidationSettings.APIGroupSettings[0]
+			group.APIList = append(group.APIList, models.APISetting{API: "loans", BasePath: "loans", Version: version, EndpointBase: "/loans/v1"})
+		}
+	}
+
+	tests := []struct {
+		name          string
+		change        func(settings *models.ConfigurationSettings)
+		wantEndpoints []string
+		wantMessages  int
+	}{
+		{
+			name:          "new API without file is disabled",
+			change:        addLoans("1.0.0"),
+			wantEndpoints: []string{"/accounts/v2/accounts", "/accounts/v2/accounts/{accountId}"},
+			wantMessages:  1,
+		},
+		{
+			name: "new API version without file keeps the previous version",
+			change: func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.APIGroupSettings[0].APIList[0].Version = "2.1.0"
+			},
+			wantEndpoints: []string{"/accounts/v2/accounts", "/accounts/v2/accounts/{accountId}"},
+			wantMessages:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			cm := newTestConfigurationManager(t, newTestSettings(t), server)
+			server.setVersion("1.1.0")
+			server.updateSettings(tt.change)
+
+			result := cm.RefreshConfiguration()
+			if !result.Updated || result.Version != "1.1.0" {
+				t.Fatalf("RefreshConfiguration() = %+v", result)
+			}
+
+			endpoints := make([]string, 0)
+			for _, entry := range cm.GetEndpointCatalogue() {
+				endpoints = append(endpoints, entry.EndpointName)
+				if entry.APIVersion != "2.0.1" {
+					t.Errorf("APIVersion = %q, want the previous version", entry.APIVersion)
+				}
+			}
+
+			if !reflect.DeepEqual(endpoints, tt.wantEndpoints) {
+				t.Errorf("endpoints = %v, want %v", endpoints, tt.wantEndpoints)
+			}
+
+			if messages := cm.GetUpdateStatus().UpdateMessages; len(messages) != tt.wantMessages {
+				t.Errorf("UpdateMessages = %+v, want %d", messages, tt.wantMessages)
+			}
+		})
+	}
+
+	t.Run("failed API is loaded again with the same version", func(t *testing.T) {
+		server := newStubReportServer("1.0.0")
+		cm := newTestConfigurationManager(t, newTestSettings(t), server)
+		server.setVersion("1.1.0")
+		server.updateSettings(addLoans("1.0.0"))
+		cm.RefreshConfiguration()
+
+		file, _ := json.Marshal([]models.APIEndpointSetting{{Endpoint: "/contracts", JSONBodySchema: "{}"}})
+		server.setFile(loansFile, file)
+		result := cm.RefreshConfiguration()
+		if !result.Updated {
+			t.Fatalf("RefreshConfiguration() = %+v, want updated", result)
+		}
+
+		if cm.ResolveEndpointName("/loans/v1/contracts") != "/loans/v1/contracts" {
+			t.Errorf("loans endpoint was not loaded")
+		}
+
+		if messages := cm.GetUpdateStatus().UpdateMessages; len(messages) != 0 {
+			t.Errorf("UpdateMessages = %+v, want none", messages)
+		}
+	})
+}
+
+func TestLoadAPIConfigurationFilesConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		jobs        int
+	}{
+		{name: "sequential", concurrency: 0, jobs: 3},
+		{name: "parallel", concurrency: 4, jobs: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.UpdateSettings.Concurrency = tt.concurrency
+			cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), settings)
+			jobs := make([]*apiConfigurationJob, 0, tt.jobs)
+			for i := 0; i < tt.jobs; i++ {
+				fileName := testEndpointFile
+				if i%2 == 1 {
+					fileName = "missing.json"
+				}
+
+				jobs = append(jobs, &apiConfigurationJob{fileName: fileName})
+			}
+
+			cm.loadAPIConfigurationFiles(jobs)
+			for i, job := range jobs {
+				if (job.err != nil) != (i%2 == 1) || (job.err == nil && len(job.endpointList) != 2) {
+					t.Errorf("job %d: err = %v, endpoints = %d", i, job.err, len(job.endpointList))
+				}
+			}
+		})
+	}
+}
+
+func TestGetAPIConfigurationFileRules(t *testing.T) {
+	validRules := `{"rules":[{"id":"r1","expression":"size(body.data) > 0"}]}`
+	invalidRules := `{"rules":[{"id":"r1","expression":"body.data ==="}]}`
+	tests := []struct {
+		name            string
+		endpoints       []models.APIEndpointSetting
+		wantBodyRules   []string
+		wantHeaderRules []string
+	}{
+		{
+			name:            "valid rules are kept",
+			endpoints:       []models.APIEndpointSetting{{Endpoint: "/a", BodyValidationRules: validRules, HeaderValidationRules: validRules}},
+			wantBodyRules:   []string{validRules},
+			wantHeaderRules: []string{validRules},
+		},
+		{
+			name:            "invalid body rules are skipped",
+			endpoints:       []models.APIEndpointSetting{{Endpoint: "/a", BodyValidationRules: invalidRules, HeaderValidationRules: validRules}, {Endpoint: "/b", BodyValidationRules: validRules}},
+			wantBodyRules:   []string{"", validRules},
+			wantHeaderRules: []string{validRules, ""},
+		},
+		{
+			name:            "invalid header rules are skipped",
+			endpoints:       []models.APIEndpointSetting{{Endpoint: "/a", BodyValidationRules: validRules, HeaderValidationRules: `{`}},
+			wantBodyRules:   []string{validRules},
+			wantHeaderRules: []string{""},
+		},
+	}
+
+	fileName := "Accounts/rules/1.0.0/response/endpoints.json"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			cm := newTestConfigurationManager(t, newTestSettings(t), server)
+			file, _ := json.Marshal(tt.endpoints)
+			server.setFile(fileName, file)
+
+			endpoints, err := cm.getAPIConfigurationFile(fileName)
+			if err != nil {
+				t.Fatalf("getAPIConfigurationFile() error = %v", err)
+			}
+
+			for i, endpoint := range endpoints {
+				if endpoint.BodyValidationRules != tt.wantBodyRules[i] || endpoint.HeaderValidationRules != tt.wantHeaderRules[i] {
+					t.Errorf("endpoint %s rules = %q / %q, want %q / %q", endpoint.Endpoint, endpoint.BodyValidationRules,
+						endpoint.HeaderValidationRules, tt.wantBodyRules[i], tt.wantHeaderRules[i])
+				}
+			}
+		})
+	}
+}
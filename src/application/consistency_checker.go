@@ -0,0 +1,544 @@
+package application
+
+import (
+	"container/list"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+// maxJourneyValues is the maximum number of identifiers and amounts stored for a journey, the values received after
+// the limit are not stored
+const maxJourneyValues = 10000
+
+// transactionIDProperty is the property that identifies the transactions of the paginated lists
+const transactionIDProperty = "transactionId"
+
+// journeyKey identifies the messages of a consent journey
+type journeyKey struct {
+	ConsentID     string // Identifier of the consent
+	TransmitterID string // Organisation ID of the transmitter
+}
+
+// listState contains the values returned by a list endpoint (e.g. /accounts/v2/accounts) during a journey
+type listState struct {
+	ids      map[string]map[string]bool // Identifiers of the items by property name in lowercase (e.g. accountid)
+	pages    map[int]bool               // Pages received
+	lastPage int                        // Number of the last page, 0 if unknown
+}
+
+// amountValue is an amount returned by a list endpoint
+type amountValue struct {
+	amount   string // Amount
+	currency string // Currency of the amount
+}
+
+// journeyState contains the values received during a journey
+type journeyState struct {
+	key          journeyKey                        // Key of the journey
+	lastSeen     time.Time                         // Time of the last message of the journey
+	lists        map[string]*listState             // Values of the list endpoints, by endpoint name in lowercase
+	amounts      map[string]map[string]amountValue // Amounts of the list items by pointer, by list endpoint and identifier
+	transactions map[string]map[string]int         // Page of the transactions, by list (path and filters) and transactionId
+	values       int                               // Number of values stored
+}
+
+// ConsistencyChecker detects inconsistencies between the messages of a consent journey: identifiers queried that
+// were not returned by the list endpoint, amounts and currencies that differ between the list and the detail views,
+// and transactions repeated across pages. The journeys are kept in memory for a limited time
+type ConsistencyChecker struct {
+	crosscutting.OFBStruct
+	ttl         time.Duration                // Time a journey is kept after its last message
+	maxJourneys int                          // Maximum number of journeys kept
+	maxValues   int                          // Maximum number of values stored by all the journeys
+	values      int                          // Number of values stored by all the journeys
+	journeys    map[journeyKey]*list.Element // Elements of the journeys in recent, by consent and transmitter
+	recent      *list.List                   // Journeys (*journeyState) by their last message, the most recent first
+	mutex       sync.Mutex                   // Mutex for thread-safe access to the journeys
+}
+
+// NewConsistencyChecker creates a new consistency checker
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - ttl: Time a journey is kept after its last message
+//   - maxJourneys: Maximum number of journeys kept, the oldest journey is discarded when the limit is reached
+//   - maxValues: Maximum number of values stored by all the journeys, the oldest journeys are discarded when the
+//     limit is reached
+//
+// Returns:
+//   - *ConsistencyChecker: Checker created
+func NewConsistencyChecker(logger log.Logger, ttl time.Duration, maxJourneys int, maxValues int) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "ConsistencyChecker",
+			Logger: logger,
+		},
+		ttl:         ttl,
+		maxJourneys: maxJourneys,
+		maxValues:   maxValues,
+		journeys:    make(map[journeyKey]*list.Element),
+		recent:      list.New(),
+	}
+}
+
+// Check Compares a message with the previous messages of its journey, and stores its values for the next messages.
+// The messages without consent or without links.self are not checked
+//
+// Parameters:
+//   - msg: Message validated
+//   - content: Body of the message
+//
+// Returns:
+//   - []validation.Error: Inconsistencies found
+func (cc *ConsistencyChecker) Check(msg *Message, content validation.DynamicStruct) []validation.Error {
+	links, _ := content["links"].(map[string]interface{})
+	self, _ := links["self"].(string)
+	link, err := url.Parse(self)
+	if msg.ConsentID == "" || self == "" || err != nil {
+		return nil
+	}
+
+	parameters := getPathParameters(msg.Endpoint, link.Path)
+	if parameters == nil {
+		cc.Logger.Debug("links.self does not match the endpoint: "+msg.Endpoint, cc.Pack, "Check")
+		return nil
+	}
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	journey := cc.getJourney(journeyKey{ConsentID: msg.ConsentID, TransmitterID: msg.TransmitterID}, time.Now())
+	findings := make([]validation.Error, 0)
+	for _, parameter := range parameters {
+		findings = cc.checkIdentifier(journey, parameter, findings)
+	}
+
+	switch data := content["data"].(type) {
+	case []interface{}:
+		findings = cc.checkTransactions(journey, getListKey(link), getPage(link), data, findings)
+		cc.storeList(journey, msg.Endpoint, link, content, data)
+	case map[string]interface{}:
+		if len(parameters) > 0 {
+			findings = cc.checkAmounts(journey, parameters[len(parameters)-1], data, findings)
+		}
+	}
+
+	return findings
+}
+
+// getJourney returns the state of a journey, creating it if it is not found or expired. The expired journeys are
+// discarded, and the least recently used journey when the limit of journeys is reached
+//
+// Parameters:
+//   - key: Key of the journey
+//   - now: Current time
+//
+// Returns:
+//   - *journeyState: State of the journey
+func (cc *ConsistencyChecker) getJourney(key journeyKey, now time.Time) *journeyState {
+	if element, found := cc.journeys[key]; found {
+		journey := element.Value.(*journeyState)
+		if now.Sub(journey.lastSeen) <= cc.ttl {
+			journey.lastSeen = now
+			cc.recent.MoveToFront(element)
+			return journey
+		}
+
+		cc.removeJourney(element)
+	}
+
+	// The journeys are sorted by their last message, the expired journeys are at the back of the list
+	for oldest := cc.recent.Back(); oldest != nil && now.Sub(oldest.Value.(*journeyState).lastSeen) > cc.ttl; oldest = cc.recent.Back() {
+		cc.removeJourney(oldest)
+	}
+
+	if len(cc.journeys) >= cc.maxJourneys {
+		cc.removeJourney(cc.recent.Back())
+	}
+
+	journey := &journeyState{
+		key:          key,
+		lastSeen:     now,
+		lists:        make(map[string]*listState),
+		amounts:      make(map[string]map[string]amountValue),
+		transactions: make(map[string]map[string]int),
+	}
+
+	cc.journeys[key] = cc.recent.PushFront(journey)
+	return journey
+}
+
+// removeJourney Discards a journey and its values
+//
+// Parameters:
+//   - element: Element of the journey
+//
+// Returns:
+func (cc *ConsistencyChecker) removeJourney(element *list.Element) {
+	journey := cc.recent.Remove(element).(*journeyState)
+	delete(cc.journeys, journey.key)
+	cc.values -= journey.values
+}
+
+// reserveValue Counts a value stored by a journey. When the values of all the journeys reach the limit, the least
+// recently used journeys are discarded
+//
+// Parameters:
+//   - journey: State of the journey
+//
+// Returns:
+//   - bool: true if the value can be stored, false if the journey reached its limit or it is the only journey
+func (cc *ConsistencyChecker) reserveValue(journey *journeyState) bool {
+	if journey.values >= maxJourneyValues {
+		return false
+	}
+
+	for cc.values >= cc.maxValues {
+		oldest := cc.recent.Back()
+		if oldest == nil || oldest.Value.(*journeyState) == journey {
+			return false
+		}
+
+		cc.removeJourney(oldest)
+	}
+
+	journey.values++
+	cc.values++
+	return true
+}
+
+// checkIdentifier Checks that an identifier queried was returned by the list endpoint, when all the pages of the
+// list were received and its items contain the identifier
+//
+// Parameters:
+//   - journey: State of the journey
+//   - parameter: Parameter of the path queried
+//   - findings: Inconsistencies found
+//
+// Returns:
+//   - []validation.Error: Inconsistencies found, with the identifier if it was not returned
+func (cc *ConsistencyChecker) checkIdentifier(journey *journeyState, parameter pathParameter, findings []validation.Error) []validation.Error {
+	state, found := journey.lists[strings.ToLower(parameter.listEndpoint)]
+	if !found || !state.isComplete() {
+		return findings
+	}
+
+	ids, found := state.ids[strings.ToLower(parameter.name)]
+	if !found || ids[parameter.value] {
+		return findings
+	}
+
+	return append(findings, validation.Error{
+		Code:       validation.CodeUnknownResource,
+		Field:      "links.self",
+		Pointer:    "/links/self",
+		Constraint: parameter.listEndpoint,
+		Message:    parameter.name + " was not returned by " + parameter.listEndpoint,
+	})
+}
+
+// checkTransactions Checks that the transactions of a page were not returned on another page of the same list
+//
+// Parameters:
+//   - journey: State of the journey
+//   - listKey: Key of the list, the path and the filters of the self link
+//   - page: Number of the page
+//   - data: Items of the page
+//   - findings: Inconsistencies found
+//
+// Returns:
+//   - []validation.Error: Inconsistencies found, with the transactions repeated
+func (cc *ConsistencyChecker) checkTransactions(journey *journeyState, listKey string, page int, data []interface{}, findings []validation.Error) []validation.Error {
+	transactions, found := journey.transactions[listKey]
+	if !found {
+		transactions = make(map[string]int)
+		journey.transactions[listKey] = transactions
+	}
+
+	for i, value := range data {
+		item, _ := value.(map[string]interface{})
+		transactionID, _ := item[transactionIDProperty].(string)
+		if transactionID == "" {
+			continue
+		}
+
+		// The same page can be requested again, the transactions are repeated only on a different page
+		previousPage, found := transactions[transactionID]
+		if found && previousPage != page {
+			findings = append(findings, validation.Error{
+				Code:       validation.CodeDuplicateID,
+				Field:      "data." + transactionIDProperty,
+				Pointer:    "/data/" + strconv.Itoa(i) + "/" + transactionIDProperty,
+				Constraint: transactionIDProperty,
+				Message:    "Was returned on another page",
+			})
+
+			continue
+		}
+
+		if !found && cc.reserveValue(journey) {
+			transactions[transactionID] = page
+		}
+	}
+
+	return findings
+}
+
+// checkAmounts Checks that the amounts of a detail view are the same as the amounts of the item in the list
+//
+// Parameters:
+//   - journey: State of the journey
+//   - parameter: Parameter of the path that identifies the item
+//   - data: Content of the detail view
+//   - findings: Inconsistencies found
+//
+// Returns:
+//   - []validation.Error: Inconsistencies found, with the amounts that differ
+func (cc *ConsistencyChecker) checkAmounts(journey *journeyState, parameter pathParameter, data map[string]interface{}, findings []validation.Error) []validation.Error {
+	amounts := journey.amounts[strings.ToLower(parameter.listEndpoint)+"|"+parameter.value]
+	visitAmounts(data, "", func(pointer string, value amountValue) {
+		listValue, found := amounts[pointer]
+		if !found {
+			return
+		}
+
+		field := "data" + strings.ReplaceAll(pointer, "/", ".")
+		if listValue.amount != value.amount {
+			findings = append(findings, validation.Error{
+				Code:       validation.CodeAmountMismatch,
+				Field:      field + ".amount",
+				Pointer:    "/data" + pointer + "/amount",
+				Constraint: parameter.listEndpoint,
+				Message:    "Differs from the amount returned by " + parameter.listEndpoint,
+			})
+		}
+
+		if listValue.currency != value.currency {
+			findings = append(findings, validation.Error{
+				Code:       validation.CodeCurrencyMismatch,
+				Field:      field + ".currency",
+				Pointer:    "/data" + pointer + "/currency",
+				Constraint: parameter.listEndpoint,
+				Message:    "Differs from the currency returned by " + parameter.listEndpoint,
+			})
+		}
+	})
+
+	return findings
+}
+
+// storeList Stores the identifiers and the amounts of the items of a list, and the page received
+//
+// Parameters:
+//   - journey: State of the journey
+//   - endpoint: Name of the endpoint of the list
+//   - link: Self link of the page
+//   - content: Body of the message
+//   - data: Items of the page
+//
+// Returns:
+func (cc *ConsistencyChecker) storeList(journey *journeyState, endpoint string, link *url.URL, content validation.DynamicStruct, data []interface{}) {
+	endpoint = strings.ToLower(endpoint)
+	state, found := journey.lists[endpoint]
+	if !found {
+		state = &listState{ids: make(map[string]map[string]bool), pages: make(map[int]bool)}
+		journey.lists[endpoint] = state
+	}
+
+	page := getPage(link)
+	state.pages[page] = true
+	meta, _ := content["meta"].(map[string]interface{})
+	links, _ := content["links"].(map[string]interface{})
+	if totalPages, ok := meta["totalPages"].(float64); ok && totalPages >= 1 {
+		state.lastPage = int(totalPages)
+	} else if next, _ := links["next"].(string); next == "" {
+		state.lastPage = page
+	}
+
+	for _, value := range data {
+		item, _ := value.(map[string]interface{})
+		ids := make([]string, 0)
+		for name, property := range item {
+			id, ok := property.(string)
+			if !ok || !strings.HasSuffix(strings.ToLower(name), "id") || !cc.reserveValue(journey) {
+				continue
+			}
+
+			name = strings.ToLower(name)
+			if state.ids[name] == nil {
+				state.ids[name] = make(map[string]bool)
+			}
+
+			state.ids[name][id] = true
+			ids = append(ids, id)
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		// The amounts of the item are stored once and shared by its identifiers
+		amounts := make(map[string]amountValue)
+		visitAmounts(item, "", func(pointer string, amount amountValue) {
+			if cc.reserveValue(journey) {
+				amounts[pointer] = amount
+			}
+		})
+
+		for _, id := range ids {
+			journey.amounts[endpoint+"|"+id] = amounts
+		}
+	}
+}
+
+// isComplete indicates if all the pages of the list were received
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if the pages from 1 to the last page were received
+func (ls *listState) isComplete() bool {
+	if ls.lastPage < 1 {
+		return false
+	}
+
+	for page := 1; page <= ls.lastPage; page++ {
+		if !ls.pages[page] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getListKey returns the key of the pages of a list, the path and the query of the self link without the page
+// parameters, so the same list requested with different filters (e.g. the dates of the transactions) is kept apart
+//
+// Parameters:
+//   - link: Self link of the page
+//
+// Returns:
+//   - string: Key of the list
+func getListKey(link *url.URL) string {
+	query := link.Query()
+	query.Del("page")
+	query.Del("page-size")
+	return strings.ToLower(link.Path) + "?" + query.Encode()
+}
+
+// getPage returns the number of the page of a self link
+//
+// Parameters:
+//   - link: Self link of the page
+//
+// Returns:
+//   - int: page parameter of the link, 1 if it is not found or invalid
+func getPage(link *url.URL) int {
+	page, err := strconv.Atoi(link.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+
+	return page
+}
+
+// pathParameter is a parameter of an endpoint (e.g. {accountId}) with the value of the path requested
+type pathParameter struct {
+	name         string // Name of the parameter, without braces
+	value        string // Value on the path
+	listEndpoint string // Name of the endpoint that lists the values of the parameter (e.g. /accounts/v2/accounts)
+}
+
+// getPathParameters returns the parameters of an endpoint with the values of a path, the path can contain a prefix
+// (e.g. /open-banking)
+//
+// Parameters:
+//   - endpoint: Name of the endpoint (e.g. /accounts/v2/accounts/{accountId}/balances)
+//   - path: Path requested (e.g. /open-banking/accounts/v2/accounts/123/balances)
+//
+// Returns:
+//   - []pathParameter: Parameters of the endpoint, nil if the path does not match the endpoint
+func getPathParameters(endpoint string, path string) []pathParameter {
+	endpointSegments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) < len(endpointSegments) {
+		return nil
+	}
+
+	pathSegments = pathSegments[len(pathSegments)-len(endpointSegments):]
+	result := make([]pathParameter, 0)
+	for i, segment := range endpointSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			value, err := url.PathUnescape(pathSegments[i])
+			if err != nil || value == "" {
+				return nil
+			}
+
+			result = append(result, pathParameter{
+				name:         strings.Trim(segment, "{}"),
+				value:        value,
+				listEndpoint: "/" + strings.Join(endpointSegments[:i], "/"),
+			})
+		} else if !strings.EqualFold(segment, pathSegments[i]) {
+			return nil
+		}
+	}
+
+	return result
+}
+
+// visitAmounts Calls a function for each amount of a value, the amounts are the objects with amount and currency
+//
+// Parameters:
+//   - value: Value where the amounts are searched
+//   - pointer: JSON pointer of the value
+//   - visit: Function called for each amount found, with its JSON pointer
+//
+// Returns:
+func visitAmounts(value interface{}, pointer string, visit func(pointer string, amount amountValue)) {
+	switch content := value.(type) {
+	case []interface{}:
+		for i, item := range content {
+			visitAmounts(item, pointer+"/"+strconv.Itoa(i), visit)
+		}
+	case map[string]interface{}:
+		currency, isCurrency := content["currency"].(string)
+		amount, isAmount := content["amount"]
+		if isCurrency && isAmount {
+			visit(pointer, amountValue{amount: formatAmount(amount), currency: currency})
+			return
+		}
+
+		for name, item := range content {
+			visitAmounts(item, pointer+"/"+name, visit)
+		}
+	}
+}
+
+// formatAmount returns the text of an amount, the amounts can be strings or numbers
+//
+// Parameters:
+//   - amount: Amount
+//
+// Returns:
+//   - string: Text of the amount
+func formatAmount(amount interface{}) string {
+	if number, ok := amount.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	// Amounts with different number of decimal places are the same amount (e.g. 100.00 and 100.0000)
+	text, _ := amount.(string)
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return text
+}
@@ -0,0 +1,255 @@
+package application
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+// consistencyTestStep is a message of a journey checked by the consistency checker
+type consistencyTestStep struct {
+	consentID string // Consent of the message, c1 if empty
+	endpoint  string // Name of the endpoint
+	body      string // Body of the message
+}
+
+func TestConsistencyCheckerCheck(t *testing.T) {
+	const (
+		accounts     = "/accounts/v2/accounts"
+		account      = "/accounts/v2/accounts/{accountId}"
+		balances     = "/accounts/v2/accounts/{accountId}/balances"
+		transactions = "/accounts/v2/accounts/{accountId}/transactions"
+		base         = "https://api.bank.com/open-banking/accounts/v2/accounts"
+	)
+
+	accountList := consistencyTestStep{endpoint: accounts, body: `{"data":[{"accountId":"1","balance":{"amount":"10.00","currency":"BRL"}}],"links":{"self":"` + base + `"},"meta":{"totalPages":1}}`}
+	tests := []struct {
+		name      string
+		steps     []consistencyTestStep
+		wantCodes []validation.ErrorCode // Codes of the findings of the last message
+	}{
+		{name: "identifier returned by the list", steps: []consistencyTestStep{accountList, {endpoint: balances, body: `{"data":{},"links":{"self":"` + base + `/1/balances"}}`}}},
+		{
+			name:      "identifier not returned by the list",
+			steps:     []consistencyTestStep{accountList, {endpoint: balances, body: `{"data":{},"links":{"self":"` + base + `/2/balances"}}`}},
+			wantCodes: []validation.ErrorCode{validation.CodeUnknownResource},
+		},
+		{
+			name: "list not complete",
+			steps: []consistencyTestStep{
+				{endpoint: accounts, body: `{"data":[{"accountId":"1"}],"links":{"self":"` + base + `?page=1","next":"` + base + `?page=2"},"meta":{"totalPages":2}}`},
+				{endpoint: balances, body: `{"data":{},"links":{"self":"` + base + `/2/balances"}}`},
+			},
+		},
+		{
+			name:  "identifier of another consent",
+			steps: []consistencyTestStep{accountList, {consentID: "c2", endpoint: balances, body: `{"data":{},"links":{"self":"` + base + `/2/balances"}}`}},
+		},
+		{
+			name:      "amount and currency differ from the list",
+			steps:     []consistencyTestStep{accountList, {endpoint: account, body: `{"data":{"balance":{"amount":"11.00","currency":"USD"}},"links":{"self":"` + base + `/1"}}`}},
+			wantCodes: []validation.ErrorCode{validation.CodeAmountMismatch, validation.CodeCurrencyMismatch},
+		},
+		{name: "amount with other decimal places", steps: []consistencyTestStep{accountList, {endpoint: account, body: `{"data":{"balance":{"amount":"10.0000","currency":"BRL"}},"links":{"self":"` + base + `/1"}}`}}},
+		{
+			name: "transaction on another page",
+			steps: []consistencyTestStep{
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?page=1&page-size=1"}}`},
+				{endpoint: transactions, body: `{"data":[{"transactionId":"b"},{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?page=2&page-size=1"}}`},
+			},
+			wantCodes: []validation.ErrorCode{validation.CodeDuplicateID},
+		},
+		{
+			name: "same page requested again",
+			steps: []consistencyTestStep{
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?page=1&page-size=1"}}`},
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?page-size=1&page=1"}}`},
+			},
+		},
+		{
+			name: "transaction on a list with other filters",
+			steps: []consistencyTestStep{
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?fromBookingDate=2024-01-01&page=1"}}`},
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?fromBookingDate=2024-02-01&page=2"}}`},
+			},
+		},
+		{
+			name: "transaction on a page of the same filters",
+			steps: []consistencyTestStep{
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?fromBookingDate=2024-01-01&page=1"}}`},
+				{endpoint: transactions, body: `{"data":[{"transactionId":"a"}],"links":{"self":"` + base + `/1/transactions?page=2&fromBookingDate=2024-01-01"}}`},
+			},
+			wantCodes: []validation.ErrorCode{validation.CodeDuplicateID},
+		},
+		{name: "message without links.self", steps: []consistencyTestStep{accountList, {endpoint: balances, body: `{"data":{}}`}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := NewConsistencyChecker(newTestLogger(), time.Minute, 10, 10000)
+			var findings []validation.Error
+			for _, step := range tt.steps {
+				var content validation.DynamicStruct
+				if err := json.Unmarshal([]byte(step.body), &content); err != nil {
+					t.Fatal(err)
+				}
+
+				consentID := step.consentID
+				if consentID == "" {
+					consentID = "c1"
+				}
+
+				findings = cc.Check(&Message{Endpoint: step.endpoint, ConsentID: consentID, TransmitterID: testOrganisationID}, content)
+			}
+
+			codes := make([]validation.ErrorCode, 0)
+			for _, finding := range findings {
+				codes = append(codes, finding.Code)
+			}
+
+			if len(codes) != len(tt.wantCodes) || (len(codes) > 0 && !reflect.DeepEqual(codes, tt.wantCodes)) {
+				t.Errorf("findings = %+v, want %v", findings, tt.wantCodes)
+			}
+		})
+	}
+}
+
+func TestConsistencyCheckerStoreList(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantIDs    []string // Identifiers that find the amount of the item
+		wantValues int
+	}{
+		{name: "item with one identifier", data: `[{"accountId":"1","balance":{"amount":"10.00","currency":"BRL"}}]`, wantIDs: []string{"1"}, wantValues: 2},
+		{name: "amounts stored once for several identifiers", data: `[{"accountId":"1","productId":"p1","balance":{"amount":"10.00","currency":"BRL"}}]`, wantIDs: []string{"1", "p1"}, wantValues: 3},
+		{name: "item without identifiers", data: `[{"balance":{"amount":"10.00","currency":"BRL"}}]`, wantValues: 0},
+	}
+
+	link, err := url.Parse("https://api.bank.com/open-banking/accounts/v2/accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data []interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			cc := NewConsistencyChecker(newTestLogger(), time.Minute, 10, 10000)
+			journey := cc.getJourney(journeyKey{ConsentID: "c1"}, time.Now())
+			cc.storeList(journey, "/accounts/v2/accounts", link, validation.DynamicStruct{}, data)
+			if journey.values != tt.wantValues || cc.values != tt.wantValues {
+				t.Errorf("values = %d (%d in the checker), want %d", journey.values, cc.values, tt.wantValues)
+			}
+
+			for _, id := range tt.wantIDs {
+				if amount := journey.amounts["/accounts/v2/accounts|"+id]["/balance"]; amount.amount != formatAmount("10.00") {
+					t.Errorf("amount of %s = %+v, want 10.00 BRL", id, amount)
+				}
+			}
+		})
+	}
+}
+
+func TestGetListKey(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{name: "without query", link: "https://api.bank.com/Accounts/v2/accounts", want: "/accounts/v2/accounts?"},
+		{name: "page parameters removed", link: "https://api.bank.com/accounts/v2/accounts?page=2&page-size=25", want: "/accounts/v2/accounts?"},
+		{name: "filters kept and sorted", link: "https://api.bank.com/a/transactions?toBookingDate=2024-01-31&page=1&fromBookingDate=2024-01-01", want: "/a/transactions?fromBookingDate=2024-01-01&toBookingDate=2024-01-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := url.Parse(tt.link)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := getListKey(link); got != tt.want {
+				t.Errorf("getListKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// getTestJourneys returns the consents of the journeys kept by a checker, sorted
+func getTestJourneys(cc *ConsistencyChecker) []string {
+	consents := make([]string, 0)
+	for key := range cc.journeys {
+		consents = append(consents, key.ConsentID)
+	}
+
+	sort.Strings(consents)
+	return consents
+}
+
+func TestConsistencyCheckerGetJourney(t *testing.T) {
+	tests := []struct {
+		name         string
+		consents     []string        // Consents of the messages, in order
+		offsets      []time.Duration // Time of each message after the first message
+		wantJourneys []string
+	}{
+		{name: "journeys under the limit", consents: []string{"a", "b"}, offsets: []time.Duration{0, time.Second}, wantJourneys: []string{"a", "b"}},
+		{name: "least recently used discarded", consents: []string{"a", "b", "a", "c"}, offsets: []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}, wantJourneys: []string{"a", "c"}},
+		{name: "expired journeys discarded", consents: []string{"a", "b", "c"}, offsets: []time.Duration{0, 50 * time.Second, 61 * time.Second}, wantJourneys: []string{"b", "c"}},
+		{name: "expired journey created again", consents: []string{"a", "a"}, offsets: []time.Duration{0, 2 * time.Minute}, wantJourneys: []string{"a"}},
+	}
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := NewConsistencyChecker(newTestLogger(), time.Minute, 2, 10000)
+			for i, consent := range tt.consents {
+				cc.getJourney(journeyKey{ConsentID: consent}, start.Add(tt.offsets[i]))
+			}
+
+			if got := getTestJourneys(cc); !reflect.DeepEqual(got, tt.wantJourneys) || cc.recent.Len() != len(tt.wantJourneys) {
+				t.Errorf("journeys = %v (%d in the list), want %v", got, cc.recent.Len(), tt.wantJourneys)
+			}
+		})
+	}
+}
+
+func TestConsistencyCheckerReserveValue(t *testing.T) {
+	tests := []struct {
+		name         string
+		consents     []string // Consent of each value stored, the journey is used before the value
+		wantReserved []bool
+		wantJourneys []string
+		wantValues   int
+	}{
+		{name: "values under the limit", consents: []string{"a", "b", "a"}, wantReserved: []bool{true, true, true}, wantJourneys: []string{"a", "b"}, wantValues: 3},
+		{name: "oldest journey discarded", consents: []string{"a", "a", "b", "b"}, wantReserved: []bool{true, true, true, true}, wantJourneys: []string{"b"}, wantValues: 2},
+		{name: "only journey at the limit", consents: []string{"a", "a", "a", "a"}, wantReserved: []bool{true, true, true, false}, wantJourneys: []string{"a"}, wantValues: 3},
+	}
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := NewConsistencyChecker(newTestLogger(), time.Minute, 10, 3)
+			for i, consent := range tt.consents {
+				journey := cc.getJourney(journeyKey{ConsentID: consent}, start.Add(time.Duration(i)*time.Second))
+				if got := cc.reserveValue(journey); got != tt.wantReserved[i] {
+					t.Errorf("reserveValue() %d = %v, want %v", i, got, tt.wantReserved[i])
+				}
+			}
+
+			if got := getTestJourneys(cc); !reflect.DeepEqual(got, tt.wantJourneys) || cc.values != tt.wantValues {
+				t.Errorf("journeys = %v, values = %d, want %v and %d", got, cc.values, tt.wantJourneys, tt.wantValues)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package application
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/fakeserver"
+)
+
+// writeTestFakeSettings writes the settings and the endpoint files of a stub server in the settings directory of a
+// fake server
+func writeTestFakeSettings(t *testing.T, folder string, stub *stubReportServer) {
+	t.Helper()
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+
+	settings, err := json.Marshal(stub.settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{"configurationSettings.json": settings}
+	for name, file := range stub.files {
+		files[name] = file
+	}
+
+	for name, content := range files {
+		path := filepath.Join(folder, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestFakeServerApp starts a fake central server with the settings of the stub server in version 1.0.0, and
+// returns an application instance that uses it as the central server
+func newTestFakeServerApp(t *testing.T) (*App, *fakeserver.Server, string) {
+	t.Helper()
+	folder := t.TempDir()
+	writeTestFakeSettings(t, folder, newStubReportServer("1.0.0"))
+	fake, err := fakeserver.New(fakeserver.Config{SettingsDir: folder, Logger: newTestLogger()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(fake.Handler())
+	t.Cleanup(httpServer.Close)
+
+	settings := newTestSettings(t)
+	settings.SecuritySettings.ProxyURL = httpServer.URL
+	settings.ApplicationSettings.Organisations[0].ProxyURL = httpServer.URL
+	app, err := NewApp(newTestLogger(), settings, nil)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+
+	return app, fake, folder
+}
+
+func TestConfigurationManagerFakeServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		change      func(t *testing.T, fake *fakeserver.Server, folder string)
+		wantUpdated bool
+		wantVersion string
+		wantError   bool
+	}{
+		{name: "settings not modified", change: func(t *testing.T, fake *fakeserver.Server, folder string) {}, wantVersion: "1.0.0"},
+		{name: "new version", change: func(t *testing.T, fake *fakeserver.Server, folder string) {
+			writeTestFakeSettings(t, folder, newStubReportServer("1.1.0"))
+		}, wantUpdated: true, wantVersion: "1.1.0"},
+		{name: "server unavailable", change: func(t *testing.T, fake *fakeserver.Server, folder string) {
+			writeTestFakeSettings(t, folder, newStubReportServer("1.1.0"))
+			fake.SetFault("/settings", fakeserver.Fault{StatusCode: http.StatusForbidden})
+		}, wantVersion: "1.0.0", wantError: true},
+		{name: "settings file not found", change: func(t *testing.T, fake *fakeserver.Server, folder string) {
+			fake.SetFault("/settings/configurationSettings.json", fakeserver.Fault{NoSuchKey: true})
+		}, wantVersion: "1.0.0", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, fake, folder := newTestFakeServerApp(t)
+			if version := app.cm.GetConfigurationVersion(); version != "1.0.0" {
+				t.Fatalf("initial version = %s, want 1.0.0", version)
+			}
+
+			tt.change(t, fake, folder)
+			result := app.cm.RefreshConfiguration()
+			if result.Updated != tt.wantUpdated || result.Version != tt.wantVersion || (result.Error != "") != tt.wantError {
+				t.Errorf("RefreshConfiguration() = %+v", result)
+			}
+
+			// The endpoints of the settings are still available
+			if app.cm.GetEndpointSettingFromAPI("/accounts/v2/accounts", app.Logger) == nil {
+				t.Errorf("endpoint /accounts/v2/accounts not found after the update")
+			}
+		})
+	}
+}
+
+func TestResultProcessorFakeServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		bodies     []string
+		wantErrors int
+	}{
+		{name: "valid messages", bodies: []string{`{"data":[{"accountId":"1"}]}`, `{"data":[]}`}},
+		{name: "invalid messages", bodies: []string{`{"data":[{"accountId":"1"}]}`, `{"data":[{}]}`, `{"accounts":[]}`}, wantErrors: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, fake, _ := newTestFakeServerApp(t)
+			handler := app.Handler()
+			for _, body := range tt.bodies {
+				if recorder := serveTestRequest(handler, newValidateRequest("/accounts/v2/accounts", body)); recorder.Code != http.StatusOK {
+					t.Fatalf("status = %d, body: %s", recorder.Code, recorder.Body.String())
+				}
+			}
+
+			for msg := dequeueTestMessage(app.qm); msg != nil; msg = dequeueTestMessage(app.qm) {
+				app.mp.processMessage(msg)
+			}
+
+			app.rp.processAndSendResults()
+			reports, received := fake.WaitForReports(1, 5*time.Second)
+			if !received {
+				t.Fatalf("no report received by the fake server")
+			}
+
+			report := reports[0]
+			if report.ClientID != testOrganisationID || report.Report.DataOwnerID != testOrganisationID {
+				t.Errorf("report ClientID = %s, DataOwnerID = %s, want %s", report.ClientID, report.Report.DataOwnerID, testOrganisationID)
+			}
+
+			total, validationErrors := 0, 0
+			for _, summary := range report.Report.ServerSummary {
+				if summary.ServerID != testServerOrgID {
+					t.Errorf("ServerID = %s, want %s", summary.ServerID, testServerOrgID)
+				}
+
+				for _, endpoint := range summary.EndpointSummary {
+					total += endpoint.TotalRequests
+					validationErrors += endpoint.ValidationErrors
+				}
+			}
+
+			if total != len(tt.bodies) || validationErrors != tt.wantErrors {
+				t.Errorf("TotalRequests = %d, ValidationErrors = %d, want %d, %d", total, validationErrors, len(tt.bodies), tt.wantErrors)
+			}
+		})
+	}
+}
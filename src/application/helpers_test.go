@@ -0,0 +1,245 @@
+package application
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+)
+
+const (
+	testOrganisationID = "5f1b3c2a-8d4e-4f6a-9b7c-0d1e2f3a4b5c"
+	testServerOrgID    = "a3c1e2f4-5b6d-4e8f-9a0b-1c2d3e4f5a6b"
+	testInteractionID  = "0b8d4c6e-2f1a-4e3b-8c5d-7a9f1e2d3c4b"
+	testAdminKey       = "admin-key"
+	testEndpointFile   = "Accounts/accounts/2.0.1/response/endpoints.json"
+	testAccountsSchema = `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"required": ["data"],
+		"properties": {
+			"data": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["accountId"],
+					"properties": {"accountId": {"type": "string"}}
+				}
+			}
+		}
+	}`
+)
+
+var errStubNotFound = errors.New("file not found")
+
+// stubReportServer is a report server that serves the settings from memory and records the reports
+type stubReportServer struct {
+	mutex    sync.Mutex
+	settings *models.ConfigurationSettings // Settings returned
+	files    map[string][]byte             // Endpoint files by path
+	err      error                         // Error returned when loading the settings
+	reports  []models.Report               // Reports received
+}
+
+// newStubReportServer creates a stub server with the accounts API in the specified settings version
+func newStubReportServer(version string) *stubReportServer {
+	endpoints := []models.APIEndpointSetting{
+		{Endpoint: "/accounts", JSONBodySchema: testAccountsSchema, Throughput: models.HighTroughput},
+		{Endpoint: "/accounts/{accountId}", JSONBodySchema: testAccountsSchema, Throughput: models.MediumTroughput},
+	}
+
+	file, _ := json.Marshal(endpoints)
+	server := &stubReportServer{files: map[string][]byte{testEndpointFile: file}}
+	server.setVersion(version)
+	return server
+}
+
+// setVersion replaces the settings served with a new version of the accounts API settings
+func (s *stubReportServer) setVersion(version string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.settings = &models.ConfigurationSettings{
+		Version: version,
+		ValidationSettings: models.ValidationSettings{
+			APIGroupSettings: []models.APIGroupSetting{{
+				Group:    "Accounts",
+				BasePath: "Accounts",
+				APIList: []models.APISetting{{
+					API:          "accounts",
+					BasePath:     "accounts",
+					Version:      "2.0.1",
+					EndpointBase: "/accounts/v2",
+				}},
+			}},
+			ExtremelyHighTroughputValidationRate: 100,
+			HighTroughputValidationRate:          100,
+			MediumTroughputValidationRate:        100,
+			LowTroughputValidationRate:           100,
+			VeryLowTroughputValidationRate:       100,
+		},
+		ReportSettings: models.ReportSettings{ReportExecutionWindow: 30, SendOnReportNumber: 10000},
+	}
+}
+
+// updateSettings applies a change to the settings served
+func (s *stubReportServer) updateSettings(change func(settings *models.ConfigurationSettings)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	change(s.settings)
+}
+
+// setFile replaces an endpoint file, a nil file removes it
+func (s *stubReportServer) setFile(filePath string, file []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if file == nil {
+		delete(s.files, filePath)
+		return
+	}
+
+	s.files[filePath] = file
+}
+
+// setError makes the server fail when the settings are loaded
+func (s *stubReportServer) setError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+func (s *stubReportServer) SendReport(report models.Report) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func (s *stubReportServer) LoadAPIConfigurationFile(filePath string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file, found := s.files[filePath]
+	if !found {
+		return nil, errStubNotFound
+	}
+
+	return file, nil
+}
+
+func (s *stubReportServer) LoadConfigurationSettings(conditional *services.ConditionalRequest) (*models.ConfigurationSettings, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	// A copy is returned, the configuration manager modifies the settings it receives
+	data, err := json.Marshal(s.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var result models.ConfigurationSettings
+	err = json.Unmarshal(data, &result)
+	return &result, err
+}
+
+// getReports returns the reports received
+func (s *stubReportServer) getReports() []models.Report {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]models.Report(nil), s.reports...)
+}
+
+// newTestLogger returns a logger that only writes errors
+func newTestLogger() log.Logger {
+	return log.NewLogger("ERROR")
+}
+
+// newTestSettings returns valid application settings for a transmitter, with the history in a temporary folder
+func newTestSettings(t *testing.T) configuration.Settings {
+	t.Helper()
+	settings := configuration.Settings{}
+	settings.ApplicationSettings.Mode = configuration.TransmitterMode
+	settings.ApplicationSettings.OrganisationID = testOrganisationID
+	settings.ApplicationSettings.Organisations = []configuration.OrganisationSettings{{OrganisationID: testOrganisationID, ClientID: testOrganisationID}}
+	settings.UpdateSettings.HistoryPath = t.TempDir()
+	settings.UpdateSettings.HistorySize = 5
+	settings.UpdateSettings.Concurrency = 2
+	settings.RequestSettings.MaxBodySize = 10240
+	settings.RequestSettings.MaxDecompressedSize = 51200
+	settings.RequestSettings.MaxCompressionRatio = 100
+	settings.SamplingSettings.Mode = configuration.RandomSampling
+	settings.SamplingSettings.Key = configuration.ConsentIDSamplingKey
+	settings.InboundAuthSettings.Mode = configuration.InboundAuthNone
+	settings.RateLimitSettings.Key = configuration.CallerRateLimitKey
+	settings.RateLimitSettings.RequestsPerSecond = 100
+	settings.RateLimitSettings.Burst = 200
+	settings.ResultSettings.SamplesPerError = 5
+	settings.AdminSettings.Enabled = true
+	settings.AdminSettings.APIKey = testAdminKey
+	settings.ProxySettings.Mode = configuration.DisabledProxy
+	settings.ProxySettings.PathPrefix = "/open-banking"
+	settings.ProxySettings.UpstreamScheme = "https"
+	settings.ProxySettings.ServerOrgID = testOrganisationID
+	return settings
+}
+
+// newTestConfigurationManager returns an initialized configuration manager that loads the settings from the server
+func newTestConfigurationManager(t *testing.T, settings configuration.Settings, server services.ReportServer) *ConfigurationManager {
+	t.Helper()
+	cm := NewConfigurationManager(newTestLogger(), server, settings)
+	err := cm.Initialize()
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	return cm
+}
+
+// newTestApp returns an application instance that loads the settings from a stub server, the workers are not started
+func newTestApp(t *testing.T, settings configuration.Settings) (*App, *stubReportServer) {
+	t.Helper()
+	server := newStubReportServer("1.0.0")
+	app, err := NewApp(newTestLogger(), settings, server)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+
+	return app, server
+}
+
+// dequeueTestMessage returns the next message of the queue, nil if the queue is empty
+func dequeueTestMessage(qm *QueueManager) *Message {
+	select {
+	case msg := <-qm.GetQueue():
+		return msg
+	default:
+		return nil
+	}
+}
+
+// processTestCaptures processes the responses waiting for the capture worker, in the test goroutine
+func processTestCaptures(capture *ResponseCapture) {
+	for {
+		select {
+		case response := <-capture.captured:
+			capture.processResponse(response)
+		default:
+			return
+		}
+	}
+}
+
+// serveTestRequest executes a request on a handler
+func serveTestRequest(handler http.Handler, request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
@@ -0,0 +1,284 @@
+package application
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+const (
+	loadSheddingDecrease = "DECREASE"
+	loadSheddingIncrease = "INCREASE"
+)
+
+// LoadController watches the queue depth and the validation latency, and reduces the validation rates of the
+// HIGH and EXTREMELY_HIGH throughput endpoints while the application is overloaded
+type LoadController struct {
+	crosscutting.OFBStruct
+	cm              *ConfigurationManager           // Manager for application settings
+	qm              *QueueManager                   // Queue manager with the messages to process
+	scale           int                             // Scale in % applied to the validation rates
+	lowestScale     int                             // Lowest scale applied during the report window
+	latencyTotal    time.Duration                   // Sum of the validation times since the last check
+	latencyCount    int                             // Number of validations since the last check
+	droppedMessages int                             // Number of messages discarded during the report window
+	intervalDrops   int                             // Number of messages discarded since the last check, logged once per check
+	adjustments     []models.LoadSheddingAdjustment // Adjustments made during the report window
+	metrics         *monitoring.Metrics             // Metrics of the application instance
+	mutex           sync.Mutex                      // Mutex for thread-safe access
+}
+
+// NewLoadController creates a new load controller
+//
+// Parameters:
+//   - logger: logger to be used
+//   - cm: Configuration manager to be used
+//   - qm: Queue manager with the messages to process
+//   - metrics: Metrics of the application instance
+//
+// Returns:
+//   - *LoadController: new created load controller
+func NewLoadController(logger log.Logger, cm *ConfigurationManager, qm *QueueManager, metrics *monitoring.Metrics) *LoadController {
+	return &LoadController{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.LoadController",
+			Logger: logger,
+		},
+		cm:          cm,
+		qm:          qm,
+		metrics:     metrics,
+		scale:       100,
+		lowestScale: 100,
+	}
+}
+
+// IsEnabled indicates if the load shedding is enabled
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if enabled
+func (lc *LoadController) IsEnabled() bool {
+	return lc.cm.settings.LoadSheddingSettings.Enabled
+}
+
+// StartController starts the periodic evaluation of the load of the application. The messages discarded are logged
+// on each check, also when the load shedding is disabled, as the captured responses are always discarded when the
+// queue is full
+//
+// Parameters:
+//
+// Returns:
+func (lc *LoadController) StartController() {
+	lc.Logger.Info("Starting load controller", lc.Pack, "StartController")
+	ticker := time.NewTicker(time.Duration(lc.cm.settings.LoadSheddingSettings.CheckInterval) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		lc.logDroppedMessages()
+		if lc.IsEnabled() {
+			lc.evaluate()
+		}
+	}
+}
+
+// logDroppedMessages logs the number of messages discarded since the last check, instead of a message for each
+// discarded message
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of messages discarded since the last check
+func (lc *LoadController) logDroppedMessages() int {
+	lc.mutex.Lock()
+	dropped := lc.intervalDrops
+	lc.intervalDrops = 0
+	lc.mutex.Unlock()
+
+	if dropped > 0 {
+		lc.Logger.Warning("Queue full, "+strconv.Itoa(dropped)+" messages discarded since the last check", lc.Pack, "logDroppedMessages")
+	}
+
+	return dropped
+}
+
+// evaluate Checks the queue depth and the average latency, and adjusts the scale of the validation rates.
+// The scale is halved while a threshold is exceeded, and recovers gradually afterwards
+//
+// Parameters:
+//
+// Returns:
+func (lc *LoadController) evaluate() {
+	settings := lc.cm.settings.LoadSheddingSettings
+	queue := lc.qm.GetQueue()
+	queueDepth := len(queue)
+
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	averageLatency := time.Duration(0)
+	if lc.latencyCount > 0 {
+		averageLatency = lc.latencyTotal / time.Duration(lc.latencyCount)
+	}
+
+	lc.latencyTotal = 0
+	lc.latencyCount = 0
+
+	reason := ""
+	if queueDepth*100 >= cap(queue)*settings.QueueThreshold {
+		reason = "Queue depth over threshold: " + strconv.Itoa(queueDepth) + "/" + strconv.Itoa(cap(queue))
+	} else if averageLatency >= time.Duration(settings.LatencyThreshold)*time.Millisecond {
+		reason = "Validation latency over threshold: " + averageLatency.String()
+	}
+
+	newScale := lc.scale
+	direction := loadSheddingDecrease
+	if reason != "" {
+		newScale = max(lc.scale/2, settings.MinimumScale)
+	} else {
+		newScale = min(lc.scale+settings.RecoveryStep, 100)
+		direction = loadSheddingIncrease
+		reason = "Load under thresholds"
+	}
+
+	if newScale == lc.scale {
+		return
+	}
+
+	lc.Logger.Warning("Load shedding scale changed from "+strconv.Itoa(lc.scale)+"% to "+strconv.Itoa(newScale)+"%: "+reason, lc.Pack, "evaluate")
+	lc.adjustments = append(lc.adjustments, models.LoadSheddingAdjustment{
+		Date:           time.Now(),
+		PreviousScale:  lc.scale,
+		NewScale:       newScale,
+		QueueDepth:     queueDepth,
+		AverageLatency: averageLatency.String(),
+		Reason:         reason,
+	})
+
+	lc.scale = newScale
+	lc.lowestScale = min(lc.lowestScale, newScale)
+	lc.metrics.RecordLoadSheddingAdjustment(direction, newScale)
+}
+
+// GetScale returns the scale applied to the validation rates of HIGH and EXTREMELY_HIGH throughput endpoints
+//
+// Parameters:
+//
+// Returns:
+//   - int: scale in % (1 - 100)
+func (lc *LoadController) GetScale() int {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	return lc.scale
+}
+
+// ApplyScale applies the current scale to the validation rate of an endpoint, only HIGH and EXTREMELY_HIGH
+// throughput endpoints are affected
+//
+// Parameters:
+//   - rate: validation rate in % (0 - 100)
+//   - throughput: throughput class of the endpoint
+//
+// Returns:
+//   - int: validation rate after the scale
+func (lc *LoadController) ApplyScale(rate int, throughput string) int {
+	if throughput != models.HighTroughput && throughput != models.ExtremelyHighTroughput {
+		return rate
+	}
+
+	return rate * lc.GetScale() / 100
+}
+
+// RecordLatency records the time used to validate a message
+//
+// Parameters:
+//   - latency: Duration of the validation
+//
+// Returns:
+func (lc *LoadController) RecordLatency(latency time.Duration) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.latencyTotal += latency
+	lc.latencyCount++
+}
+
+// EnqueueMessage queues the message for validation. When the load shedding is enabled the message is discarded
+// if the queue is full, instead of blocking the request
+//
+// Parameters:
+//   - msg: Message to be queued
+//
+// Returns:
+//   - bool: true if the message was queued
+func (lc *LoadController) EnqueueMessage(msg *Message) bool {
+	if !lc.IsEnabled() {
+		lc.qm.EnqueueMessage(msg)
+		return true
+	}
+
+	return lc.TryEnqueueMessage(msg)
+}
+
+// TryEnqueueMessage queues the message for validation without blocking, the message is discarded if the queue is
+// full. It is used for the captured responses, that must never delay the traffic. The messages discarded are counted
+// and logged on the next check of the controller
+//
+// Parameters:
+//   - msg: Message to be queued
+//
+// Returns:
+//   - bool: true if the message was queued
+func (lc *LoadController) TryEnqueueMessage(msg *Message) bool {
+	if lc.qm.TryEnqueueMessage(msg) {
+		return true
+	}
+
+	lc.recordDroppedMessage()
+	return false
+}
+
+// recordDroppedMessage counts a message discarded because the application cannot keep up with the traffic
+//
+// Parameters:
+//
+// Returns:
+func (lc *LoadController) recordDroppedMessage() {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.droppedMessages++
+	lc.intervalDrops++
+}
+
+// GetAndCleanSummary returns the load shedding information of the report window and starts a new window
+//
+// Parameters:
+//
+// Returns:
+//   - *models.LoadSheddingSummary: Load shedding information, nil if disabled
+func (lc *LoadController) GetAndCleanSummary() *models.LoadSheddingSummary {
+	if !lc.IsEnabled() {
+		return nil
+	}
+
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	summary := &models.LoadSheddingSummary{
+		CurrentScale:    lc.scale,
+		LowestScale:     lc.lowestScale,
+		DroppedMessages: lc.droppedMessages,
+		Adjustments:     lc.adjustments,
+	}
+
+	lc.lowestScale = lc.scale
+	lc.droppedMessages = 0
+	lc.adjustments = nil
+	return summary
+}
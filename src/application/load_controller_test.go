@@ -0,0 +1,197 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// newTestLoadController returns a load controller with a queue of 10 messages
+func newTestLoadController(t *testing.T, enabled bool) *LoadController {
+	t.Helper()
+	settings := newTestSettings(t)
+	settings.LoadSheddingSettings.Enabled = enabled
+	settings.LoadSheddingSettings.QueueThreshold = 70
+	settings.LoadSheddingSettings.LatencyThreshold = 500
+	settings.LoadSheddingSettings.MinimumScale = 10
+	settings.LoadSheddingSettings.RecoveryStep = 10
+	settings.LoadSheddingSettings.CheckInterval = 10
+
+	metrics, err := monitoring.NewMetrics()
+	if err != nil {
+		t.Fatalf("NewMetrics() error = %v", err)
+	}
+
+	cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), settings)
+	return NewLoadController(newTestLogger(), cm, NewQueueManager(10), metrics)
+}
+
+func TestLoadControllerEvaluate(t *testing.T) {
+	tests := []struct {
+		name            string
+		scale           int
+		queued          int
+		latencies       []time.Duration
+		wantScale       int
+		wantAdjustments int
+	}{
+		{name: "no load keeps the full scale", scale: 100, wantScale: 100},
+		{name: "queue over threshold halves the scale", scale: 100, queued: 7, wantScale: 50, wantAdjustments: 1},
+		{name: "queue under threshold recovers", scale: 50, queued: 6, wantScale: 60, wantAdjustments: 1},
+		{name: "latency over threshold halves the scale", scale: 100, latencies: []time.Duration{400 * time.Millisecond, 600 * time.Millisecond}, wantScale: 50, wantAdjustments: 1},
+		{name: "latency under threshold recovers", scale: 50, latencies: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, wantScale: 60, wantAdjustments: 1},
+		{name: "scale is not reduced below the minimum", scale: 15, queued: 10, wantScale: 10, wantAdjustments: 1},
+		{name: "scale at the minimum is not changed", scale: 10, queued: 10, wantScale: 10},
+		{name: "recovery does not exceed 100", scale: 95, wantScale: 100, wantAdjustments: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := newTestLoadController(t, true)
+			lc.scale = tt.scale
+			lc.lowestScale = tt.scale
+			for i := 0; i < tt.queued; i++ {
+				lc.qm.EnqueueMessage(&Message{})
+			}
+
+			for _, latency := range tt.latencies {
+				lc.RecordLatency(latency)
+			}
+
+			lc.evaluate()
+			if got := lc.GetScale(); got != tt.wantScale {
+				t.Errorf("GetScale() = %d, want %d", got, tt.wantScale)
+			}
+
+			summary := lc.GetAndCleanSummary()
+			if len(summary.Adjustments) != tt.wantAdjustments {
+				t.Fatalf("Adjustments = %+v, want %d", summary.Adjustments, tt.wantAdjustments)
+			}
+
+			if summary.LowestScale != min(tt.scale, tt.wantScale) {
+				t.Errorf("LowestScale = %d, want %d", summary.LowestScale, min(tt.scale, tt.wantScale))
+			}
+
+			if lc.latencyCount != 0 || lc.latencyTotal != 0 {
+				t.Errorf("latencies not reset after the check")
+			}
+		})
+	}
+}
+
+func TestLoadControllerApplyScale(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       int
+		throughput string
+		want       int
+	}{
+		{name: "extremely high throughput", rate: 80, throughput: models.ExtremelyHighTroughput, want: 40},
+		{name: "high throughput", rate: 100, throughput: models.HighTroughput, want: 50},
+		{name: "medium throughput is not affected", rate: 80, throughput: models.MediumTroughput, want: 80},
+		{name: "low throughput is not affected", rate: 10, throughput: models.LowTroughput, want: 10},
+		{name: "zero rate", rate: 0, throughput: models.HighTroughput, want: 0},
+	}
+
+	lc := newTestLoadController(t, true)
+	lc.scale = 50
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lc.ApplyScale(tt.rate, tt.throughput); got != tt.want {
+				t.Errorf("ApplyScale(%d, %s) = %d, want %d", tt.rate, tt.throughput, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadControllerEnqueueMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		messages    int
+		wantQueued  int
+		wantDropped int
+	}{
+		{name: "disabled queues all messages", enabled: false, messages: 10, wantQueued: 10},
+		{name: "enabled queues while there is space", enabled: true, messages: 10, wantQueued: 10},
+		{name: "enabled discards when the queue is full", enabled: true, messages: 13, wantQueued: 10, wantDropped: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := newTestLoadController(t, tt.enabled)
+			queued := 0
+			for i := 0; i < tt.messages; i++ {
+				if lc.EnqueueMessage(&Message{Endpoint: "/accounts/v2/accounts"}) {
+					queued++
+				}
+			}
+
+			if queued != tt.wantQueued || lc.qm.GetDepth() != tt.wantQueued {
+				t.Errorf("queued = %d, depth = %d, want %d", queued, lc.qm.GetDepth(), tt.wantQueued)
+			}
+
+			summary := lc.GetAndCleanSummary()
+			if !tt.enabled {
+				if summary != nil {
+					t.Errorf("GetAndCleanSummary() = %+v, want nil when disabled", summary)
+				}
+
+				return
+			}
+
+			if summary.DroppedMessages != tt.wantDropped {
+				t.Errorf("DroppedMessages = %d, want %d", summary.DroppedMessages, tt.wantDropped)
+			}
+
+			if next := lc.GetAndCleanSummary(); next.DroppedMessages != 0 {
+				t.Errorf("DroppedMessages = %d after a new window, want 0", next.DroppedMessages)
+			}
+		})
+	}
+}
+
+func TestLoadControllerTryEnqueueMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		messages    int
+		wantQueued  int
+		wantDropped int
+	}{
+		{name: "queues while there is space", enabled: false, messages: 10, wantQueued: 10},
+		{name: "never blocks when disabled", enabled: false, messages: 12, wantQueued: 10},
+		{name: "discards when the queue is full", enabled: true, messages: 12, wantQueued: 10, wantDropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := newTestLoadController(t, tt.enabled)
+			queued := 0
+			for i := 0; i < tt.messages; i++ {
+				if lc.TryEnqueueMessage(&Message{Endpoint: "/accounts/v2/accounts"}) {
+					queued++
+				}
+			}
+
+			if queued != tt.wantQueued || lc.qm.GetDepth() != tt.wantQueued {
+				t.Errorf("queued = %d, depth = %d, want %d", queued, lc.qm.GetDepth(), tt.wantQueued)
+			}
+
+			if summary := lc.GetAndCleanSummary(); summary != nil && summary.DroppedMessages != tt.wantDropped {
+				t.Errorf("DroppedMessages = %d, want %d", summary.DroppedMessages, tt.wantDropped)
+			}
+
+			// The messages discarded are logged once on the next check, also when the load shedding is disabled
+			if logged := lc.logDroppedMessages(); logged != tt.messages-tt.wantQueued {
+				t.Errorf("logDroppedMessages() = %d, want %d", logged, tt.messages-tt.wantQueued)
+			}
+
+			if logged := lc.logDroppedMessages(); logged != 0 {
+				t.Errorf("logDroppedMessages() = %d on the next check, want 0", logged)
+			}
+		})
+	}
+}
@@ -16,11 +16,6 @@ import (
 
 const (
 	resultTimeFormat = "2006-01-02"
-)
-
-var (
-	localResultMutex = sync.Mutex{} // Mutex for thread-safe access to messageResults
-	mu               = sync.Mutex{} // Mutex for thread-safe access to messageResults
 	basePath         = "./data_logs"
 )
 
@@ -28,7 +23,8 @@ type payloadDetail struct {
 	XFapiInteractionID string
 	ConsentID          string
 	Payload            validation.DynamicStruct
-	Errors             map[string][]string
+	Errors             map[string][]string // Messages of the errors by field, kept for compatibility
+	Details            []validation.Error  // Structured errors of the validation
 }
 
 type localEndpointSummary struct {
@@ -45,6 +41,8 @@ type LocalResultManager struct {
 	result         map[string]localEndpointSummary
 	recordedErrors map[string]int
 	lstCleanupDate string
+	resultMutex    sync.Mutex // Mutex for thread-safe access to messageResults
+	fileMutex      sync.Mutex // Mutex for thread-safe access to the last cleanup date
 }
 
 // NewLocalResultManager creates a new Local result manager
@@ -90,7 +88,7 @@ func (mng *LocalResultManager) AppendResult(message Message, result MessageResul
 		return
 	}
 
-	localResultMutex.Lock()
+	mng.resultMutex.Lock()
 
 	key := fmt.Sprintf("%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint)
 	if _, ok := mng.result[key]; !ok {
@@ -108,15 +106,13 @@ func (mng *LocalResultManager) AppendResult(message Message, result MessageResul
 	if !result.Result {
 		summary.RequestsWithErrors++
 		needToSaveSample := false
-		for field, errorField := range result.Errors {
-			for _, validError := range errorField {
-				errorKey := fmt.Sprintf("%s-%s-%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint, field, validError)
-				if mng.recordedErrors[errorKey] >= mng.cm.settings.ResultSettings.SamplesPerError {
-					continue
-				} else {
-					mng.recordedErrors[errorKey]++
-					needToSaveSample = true
-				}
+		for _, validError := range result.Details {
+			errorKey := fmt.Sprintf("%s-%s-%s-%s-%s-%s", settings.APIGroup, strings.ReplaceAll(settings.BasePath, "-", ""), settings.EndpointSettings.Endpoint, validError.Field, validError.Code, validError.Constraint)
+			if mng.recordedErrors[errorKey] >= mng.cm.settings.ResultSettings.SamplesPerError {
+				continue
+			} else {
+				mng.recordedErrors[errorKey]++
+				needToSaveSample = true
 			}
 		}
 
@@ -132,13 +128,14 @@ func (mng *LocalResultManager) AppendResult(message Message, result MessageResul
 				ConsentID:          message.ConsentID,
 				XFapiInteractionID: message.XFapiInteractionID,
 				Errors:             result.Errors,
+				Details:            result.Details,
 			}
 			summary.PayloadDetails = append(summary.PayloadDetails, newDetail)
 		}
 	}
 
 	mng.result[key] = summary
-	localResultMutex.Unlock()
+	mng.resultMutex.Unlock()
 }
 
 func (mng *LocalResultManager) startStoreProcess() {
@@ -165,12 +162,12 @@ func (mng *LocalResultManager) storeFiles() {
 		return
 	}
 
-	localResultMutex.Lock()
+	mng.resultMutex.Lock()
 
 	reports := mng.result
 	mng.result = make(map[string]localEndpointSummary)
 	mng.recordedErrors = make(map[string]int)
-	localResultMutex.Unlock()
+	mng.resultMutex.Unlock()
 	filesToSave := make(map[string][]localEndpointSummary)
 	for key, value := range reports {
 		keyValues := strings.Split(key, "-")
@@ -189,15 +186,15 @@ func (mng *LocalResultManager) storeFiles() {
 
 func (mng *LocalResultManager) startCleanupProcess() {
 	for {
-		mu.Lock()
+		mng.fileMutex.Lock()
 		today := time.Now().Format(resultTimeFormat)
 		if mng.lstCleanupDate != today {
 			// Update last run date and execute the task
 			mng.lstCleanupDate = today
-			mu.Unlock()
+			mng.fileMutex.Unlock()
 			mng.cleanupFiles()
 		} else {
-			mu.Unlock()
+			mng.fileMutex.Unlock()
 		}
 		// Check once per hour to minimize CPU usage
 		time.Sleep(1 * time.Hour)
@@ -2,7 +2,10 @@ package application
 
 import (
 	"encoding/json"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
@@ -11,12 +14,6 @@ import (
 	"github.com/OpenBanking-Brasil/MQD_Client/validation"
 )
 
-var (
-	messageProcessorWorkerMutex = sync.Mutex{}          // Mutex for multiprocessing locks
-	singletonMutex              = sync.Mutex{}          // Mutex for the singleton variable
-	messageProcessorSingleton   *MessageProcessorWorker // Message process singleton
-)
-
 // MessageProcessorWorker is in charge of processing the message requests
 type MessageProcessorWorker struct {
 	crosscutting.OFBStruct
@@ -26,38 +23,46 @@ type MessageProcessorWorker struct {
 	cm              *ConfigurationManager // Configuration manager
 	qm              *QueueManager         // Queue manager to queue the messages
 	lrm             *LocalResultManager
+	lc              *LoadController         // Controller that receives the validation latency
+	cc              *ConsistencyChecker     // Checker of the consistency between the messages of a consent, nil if disabled
+	metrics         *monitoring.Metrics     // Metrics of the application instance
+	schemas         *validation.SchemaCache // Compiled schemas of the draft 2020-12 engine
+	mutex           sync.Mutex              // Mutex for multiprocessing locks
+	processed       atomic.Int64            // Number of messages processed
 }
 
-// GetMessageProcessorWorker returns a new message processor
+// NewMessageProcessorWorker returns a new message processor
 //
 // Parameters:
 //   - logger: Logger to be used by the package
 //   - resultProcessor: Result processor to be used by the package
 //   - qm: Queue manager
 //   - cm: Configuration manager
+//   - lrm: Local result manager
+//   - lc: Load controller
+//   - cc: Consistency checker, nil if disabled
+//   - metrics: Metrics of the application instance
 //
 // Returns:
 //   - MessageProcessorWorker: New message processor
-func GetMessageProcessorWorker(logger log.Logger, resultProcessor *ResultProcessor, qm *QueueManager, cm *ConfigurationManager, lrm *LocalResultManager) *MessageProcessorWorker {
-	if messageProcessorSingleton == nil {
-		singletonMutex.Lock()
-		defer singletonMutex.Unlock()
-		messageProcessorSingleton = &MessageProcessorWorker{
-			OFBStruct: crosscutting.OFBStruct{
-				Pack:   "worker",
-				Logger: logger,
-			},
-
-			receivedValues:  make(map[string]int),
-			validatedValues: make(map[string]int),
-			resultProcessor: resultProcessor,
-			qm:              qm,
-			cm:              cm,
-			lrm:             lrm,
-		}
-	}
+func NewMessageProcessorWorker(logger log.Logger, resultProcessor *ResultProcessor, qm *QueueManager, cm *ConfigurationManager, lrm *LocalResultManager, lc *LoadController, cc *ConsistencyChecker, metrics *monitoring.Metrics) *MessageProcessorWorker {
+	return &MessageProcessorWorker{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "worker",
+			Logger: logger,
+		},
 
-	return messageProcessorSingleton
+		receivedValues:  make(map[string]int),
+		validatedValues: make(map[string]int),
+		resultProcessor: resultProcessor,
+		qm:              qm,
+		cm:              cm,
+		lrm:             lrm,
+		lc:              lc,
+		cc:              cc,
+		metrics:         metrics,
+		schemas:         validation.NewSchemaCache(),
+	}
 }
 
 // processMessage Validates and creates a result of a specific message
@@ -67,9 +72,9 @@ func GetMessageProcessorWorker(logger log.Logger, resultProcessor *ResultProcess
 //
 // Returns:
 func (mpw *MessageProcessorWorker) processMessage(msg *Message) {
-	messageProcessorWorkerMutex.Lock()
+	mpw.mutex.Lock()
 	mpw.receivedValues[msg.Endpoint]++
-	messageProcessorWorkerMutex.Unlock()
+	mpw.mutex.Unlock()
 
 	validationSettings := mpw.cm.GetEndpointSettingFromAPI(msg.Endpoint, mpw.Logger)
 
@@ -82,75 +87,140 @@ func (mpw *MessageProcessorWorker) processMessage(msg *Message) {
 			ServerID:           msg.ServerID,
 			XFapiInteractionID: msg.XFapiInteractionID,
 			TransmitterID:      msg.TransmitterID,
+			Role:               msg.Role,
+			DataOwnerID:        msg.DataOwnerID,
 		}
 		if msg.ConsentID != "" {
 			messageResult.XFapiInteractionID = "[" + msg.ConsentID + "] - [" + msg.XFapiInteractionID + "]"
 		}
 
-		vr, err := mpw.validateMessage(msg, validationSettings.EndpointSettings)
+		startTime := time.Now()
+		vr, content, err := mpw.validateMessage(msg, validationSettings.EndpointSettings)
+		mpw.lc.RecordLatency(time.Since(startTime))
 		if err != nil {
 			mpw.Logger.Error(err, "Error during Validation for endpoint: "+msg.Endpoint, mpw.Pack, "processMessage")
 			messageResult.Result = false
+			invalidError := validation.NewInvalidError(err.Error())
 			messageResult.Errors = map[string][]string{
-				"(error)": {err.Error()},
+				invalidError.Field: {invalidError.Message},
 			}
+			messageResult.Details = []validation.Error{invalidError}
 		} else {
 			// Create a message result entry
 			messageResult.Result = vr.Valid
 			messageResult.Errors = vr.Errors
+			messageResult.Details = vr.Details
+			messageResult.Findings = mpw.checkConsistency(msg, content)
 		}
 
-		monitoring.IncreaseValidationResult(messageResult.ServerID, messageResult.Endpoint, messageResult.Result)
+		mpw.metrics.IncreaseValidationResult(messageResult.ServerID, messageResult.Endpoint, messageResult.Result)
 		mpw.resultProcessor.AppendResult(&messageResult)
 		mpw.lrm.AppendResult(*msg, messageResult, *validationSettings)
-		messageProcessorWorkerMutex.Lock()
+		mpw.mutex.Lock()
 		mpw.validatedValues[msg.Endpoint]++
-		messageProcessorWorkerMutex.Unlock()
+		mpw.mutex.Unlock()
+	}
+}
+
+// checkConsistency Compares the message with the previous messages of its consent, when the checker is enabled.
+// Only the messages selected by the sampling policy are validated and compared
+//
+// Parameters:
+//   - msg: Message validated
+//   - content: Body of the message, read by the validation
+//
+// Returns:
+//   - []validation.Error: Inconsistencies found
+func (mpw *MessageProcessorWorker) checkConsistency(msg *Message, content validation.DynamicStruct) []validation.Error {
+	if mpw.cc == nil || content == nil {
+		return nil
 	}
+
+	findings := mpw.cc.Check(msg, content)
+	if len(findings) > 0 {
+		mpw.Logger.Debug("Consistency findings for endpoint: "+msg.Endpoint+": "+strconv.Itoa(len(findings)), mpw.Pack, "checkConsistency")
+	}
+
+	return findings
 }
 
 // validateContentWithSchema Validates the content against a specific schema
 //
 // Parameters:
-//   - content: Content to be validated
+//   - dynamicStruct: Content to be validated
 //   - schema: JSON schema to validate with
 //   - validationResult: Result to be filled with details from the validation
 //
 // Returns:
 //   - error: Error in case there is a problem reading or validating the schema
-func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, schema string, validationResult *validation.Result) error {
+func (mpw *MessageProcessorWorker) validateContentWithSchema(dynamicStruct validation.DynamicStruct, schema string, validationResult *validation.Result) error {
 	mpw.Logger.Info("Validating content with schema", mpw.Pack, "validateContentWithSchema")
 
-	// Create a dynamic structure from the Message content
-	var dynamicStruct validation.DynamicStruct
-	err := json.Unmarshal([]byte(content), &dynamicStruct)
+	val := validation.NewValidator(mpw.Logger, schema, validation.Options{
+		AssertFormats: mpw.cm.settings.IsAssertFormats(),
+		Cache:         mpw.schemas,
+	})
+	valRes, err := val.Validate(dynamicStruct)
 	if err != nil {
-		mpw.Logger.Error(err, "Error unmarshalling content", mpw.Pack, "validateContentWithSchema")
-		mpw.Logger.Debug("Content message: "+content, mpw.Pack, "validateContentWithSchema")
 		validationResult.Valid = false
+		mpw.Logger.Error(err, "Validation error", mpw.Pack, "validateContentWithSchema")
 		return err
 	}
 
-	val := validation.GetSchemaValidator(mpw.Logger, schema)
-	valRes, err := val.Validate(dynamicStruct)
+	validationResult.Append(valRes)
+	return nil
+}
+
+// validateContentWithRules Validates the content against the validation rules of the endpoint
+//
+// Parameters:
+//   - content: Content where the fields of the rules are searched (the body or the headers)
+//   - rules: Validation rules of the endpoint, can be empty
+//   - msg: Message validated, its body and headers are available to the expressions of the rules
+//   - body: Body of the message
+//   - validationResult: Result to be filled with details from the validation
+//
+// Returns:
+//   - error: Error in case the rules cannot be read
+func (mpw *MessageProcessorWorker) validateContentWithRules(content validation.DynamicStruct, rules string, msg *Message, body validation.DynamicStruct, validationResult *validation.Result) error {
+	parsedRules, err := mpw.cm.rules.Parse(rules)
 	if err != nil {
 		validationResult.Valid = false
-		mpw.Logger.Error(err, "Validation error", mpw.Pack, "validateContentWithSchema")
+		mpw.Logger.Error(err, "Error reading validation rules", mpw.Pack, "validateContentWithRules")
 		return err
 	}
 
-	if !valRes.Valid {
-		for key, value := range valRes.Errors {
-			validationResult.Errors[key] = value
-		}
+	if parsedRules.IsEmpty() {
+		return nil
+	}
 
-		validationResult.Valid = valRes.Valid
+	valRes, err := validation.GetRulesValidator(mpw.Logger, parsedRules, body, msg.GetHeaders()).Validate(content)
+	if err != nil {
+		validationResult.Valid = false
+		mpw.Logger.Error(err, "Validation error", mpw.Pack, "validateContentWithRules")
+		return err
 	}
 
+	validationResult.Append(valRes)
 	return nil
 }
 
-// ValidateMessage gets the Payload on the message and validates its fields
+// ValidateMessage gets the Payload on the message and validates its fields with the schema, then the body and
+// header rules are checked
+//
+// Parameters:
+//   - msg: Message to be validated
+//   - settings: Endpoint configuration settings
+//
+// Returns:
+//   - ValidationResult: Result of the validation for the specified message
+//   - error: error in case there is a problem during the validation
+func (mpw *MessageProcessorWorker) ValidateMessage(msg *Message, settings *models.APIEndpointSetting) (*validation.Result, error) {
+	result, _, err := mpw.validateMessage(msg, settings)
+	return result, err
+}
+
+// validateMessage Validates a message as ValidateMessage, and returns its body
 //
 // Parameters:
 //   - msg: Message to be validated
@@ -158,19 +228,78 @@ func (mpw *MessageProcessorWorker) validateContentWithSchema(content string, sch
 //
 // Returns:
 //   - ValidationResult: Result of the validation for the specified message
+//   - validation.DynamicStruct: Body of the message, nil if it is not valid JSON
 //   - error: error in case there is a problem during the validation
-func (mpw *MessageProcessorWorker) validateMessage(msg *Message, settings *models.APIEndpointSetting) (*validation.Result, error) {
+func (mpw *MessageProcessorWorker) validateMessage(msg *Message, settings *models.APIEndpointSetting) (*validation.Result, validation.DynamicStruct, error) {
 	mpw.Logger.Info("Validating message for endpoint: "+msg.Endpoint, mpw.Pack, "validateMessage")
 	validationResult := validation.Result{Valid: true, Errors: make(map[string][]string)}
 
-	err := mpw.validateContentWithSchema(msg.Message, settings.JSONBodySchema, &validationResult)
+	// Create a dynamic structure from the Message content
+	var dynamicStruct validation.DynamicStruct
+	err := json.Unmarshal([]byte(msg.Message), &dynamicStruct)
+	if err != nil {
+		mpw.Logger.Error(err, "Error unmarshalling content", mpw.Pack, "validateMessage")
+		mpw.Logger.Debug("Content message: "+msg.Message, mpw.Pack, "validateMessage")
+		validationResult.Valid = false
+		return &validationResult, dynamicStruct, err
+	}
+
+	err = mpw.validateContentWithSchema(dynamicStruct, settings.JSONBodySchema, &validationResult)
 	if err != nil {
 		mpw.Logger.Error(err, "Error during body validation", mpw.Pack, "validateMessage")
 		validationResult.Valid = false
-		return &validationResult, err
+		return &validationResult, dynamicStruct, err
+	}
+
+	err = mpw.validateContentWithRules(dynamicStruct, settings.BodyValidationRules, msg, dynamicStruct, &validationResult)
+	if err != nil {
+		mpw.Logger.Error(err, "Error during body rules validation", mpw.Pack, "validateMessage")
+		validationResult.Valid = false
+		return &validationResult, dynamicStruct, err
+	}
+
+	headers := validation.DynamicStruct{}
+	for name, value := range msg.GetHeaders() {
+		headers[name] = value
+	}
+
+	err = mpw.validateContentWithRules(headers, settings.HeaderValidationRules, msg, dynamicStruct, &validationResult)
+	if err != nil {
+		mpw.Logger.Error(err, "Error during header rules validation", mpw.Pack, "validateMessage")
+		validationResult.Valid = false
+		return &validationResult, dynamicStruct, err
 	}
 
-	return &validationResult, nil
+	if mpw.cm.settings.ValidationSettings.PaginationChecks {
+		mpw.validateContentPagination(dynamicStruct, msg, &validationResult)
+	}
+
+	return &validationResult, dynamicStruct, nil
+}
+
+// validateContentPagination Checks the consistency of the links and meta blocks of the content
+//
+// Parameters:
+//   - content: Body of the message
+//   - msg: Message validated
+//   - validationResult: Result to be filled with details from the validation
+//
+// Returns:
+func (mpw *MessageProcessorWorker) validateContentPagination(content validation.DynamicStruct, msg *Message, validationResult *validation.Result) {
+	options := validation.PaginationOptions{
+		EndpointName: msg.Endpoint,
+		APIVersion:   msg.APIVersion,
+		ReceivedAt:   msg.ReceivedAt,
+		MaxSkew:      time.Duration(mpw.cm.settings.ValidationSettings.MaxRequestDateTimeSkew) * time.Second,
+	}
+
+	valRes, err := validation.GetPaginationValidator(mpw.Logger, options).Validate(content)
+	if err != nil {
+		mpw.Logger.Error(err, "Pagination validation error", mpw.Pack, "validateContentPagination")
+		return
+	}
+
+	validationResult.Append(valRes)
 }
 
 // worker is for starting the processing of the queued messages
@@ -181,9 +310,20 @@ func (mpw *MessageProcessorWorker) validateMessage(msg *Message, settings *model
 func (mpw *MessageProcessorWorker) worker() {
 	for msg := range mpw.qm.GetQueue() {
 		mpw.processMessage(msg)
+		mpw.processed.Add(1)
 	}
 }
 
+// GetProcessedCount returns the number of messages taken from the queue and processed
+//
+// Parameters:
+//
+// Returns:
+//   - int64: Number of messages processed
+func (mpw *MessageProcessorWorker) GetProcessedCount() int64 {
+	return mpw.processed.Load()
+}
+
 // StartWorker is for starting the worker process
 //
 // Parameters:
@@ -0,0 +1,149 @@
+package application
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+const testSecondOrganisationID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+// newTestOrganisationSettings returns settings with two organisations, the second one identified by its host
+func newTestOrganisationSettings(t *testing.T) configuration.Settings {
+	settings := newTestSettings(t)
+	settings.ApplicationSettings.Organisations = append(settings.ApplicationSettings.Organisations, configuration.OrganisationSettings{
+		OrganisationID: testSecondOrganisationID,
+		ClientID:       testSecondOrganisationID,
+		Hosts:          []string{"api.second.example.com"},
+	})
+
+	return settings
+}
+
+func TestGetDataOwnerID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		host   string
+		want   string
+	}{
+		{name: "no header uses the main organisation", host: "localhost:8080", want: testOrganisationID},
+		{name: "header of a configured organisation", header: testSecondOrganisationID, want: testSecondOrganisationID},
+		{name: "header is not case sensitive", header: "7C9E6679-7425-40DE-944B-E07FC1F90AE7", want: testSecondOrganisationID},
+		{name: "header of an unknown organisation", header: testServerOrgID, want: ""},
+		{name: "host of an organisation", host: "api.second.example.com", want: testSecondOrganisationID},
+		{name: "host with port", host: "API.second.example.com:443", want: testSecondOrganisationID},
+		{name: "header has priority over host", header: testOrganisationID, host: "api.second.example.com", want: testOrganisationID},
+	}
+
+	cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), newTestOrganisationSettings(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cm.GetDataOwnerID(tt.header, tt.host); got != tt.want {
+				t.Errorf("GetDataOwnerID(%q, %q) = %q, want %q", tt.header, tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOrganisationIDs(t *testing.T) {
+	tests := []struct {
+		name          string
+		organisations []configuration.OrganisationSettings
+		want          []string
+	}{
+		{name: "main organisation only", want: []string{testOrganisationID}},
+		{
+			name:          "several organisations",
+			organisations: []configuration.OrganisationSettings{{OrganisationID: testOrganisationID}, {OrganisationID: testSecondOrganisationID}},
+			want:          []string{testOrganisationID, testSecondOrganisationID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.ApplicationSettings.Organisations = tt.organisations
+			cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), settings)
+			if got := cm.GetOrganisationIDs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOrganisationIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetResultGroupKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		role          string
+		dataOwnerID   string
+		transmitterID string
+		want          resultGroupKey
+	}{
+		{
+			name: "defaults",
+			mode: configuration.ReceiverMode,
+			want: resultGroupKey{Role: configuration.ReceiverMode, DataOwnerID: testOrganisationID, TransmitterID: testOrganisationID},
+		},
+		{
+			name:        "data owner is the default transmitter",
+			mode:        configuration.TransmitterMode,
+			dataOwnerID: testSecondOrganisationID,
+			want:        resultGroupKey{Role: configuration.TransmitterMode, DataOwnerID: testSecondOrganisationID, TransmitterID: testSecondOrganisationID},
+		},
+		{
+			name:          "all values informed",
+			mode:          configuration.DualMode,
+			role:          configuration.ReceiverMode,
+			dataOwnerID:   testSecondOrganisationID,
+			transmitterID: testServerOrgID,
+			want:          resultGroupKey{Role: configuration.ReceiverMode, DataOwnerID: testSecondOrganisationID, TransmitterID: testServerOrgID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestOrganisationSettings(t)
+			settings.ApplicationSettings.Mode = tt.mode
+			cm := NewConfigurationManager(newTestLogger(), newStubReportServer("1.0.0"), settings)
+			if got := getResultGroupKey(cm, tt.role, tt.dataOwnerID, tt.transmitterID); got != tt.want {
+				t.Errorf("getResultGroupKey() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleValidateResponseDataOwner(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		want      int
+		wantOwner string
+	}{
+		{name: "main organisation", want: http.StatusOK, wantOwner: testOrganisationID},
+		{name: "configured organisation", header: testSecondOrganisationID, want: http.StatusOK, wantOwner: testSecondOrganisationID},
+		{name: "unknown organisation", header: testServerOrgID, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _ := newTestApp(t, newTestOrganisationSettings(t))
+			request := newValidateRequest("/accounts/v2/accounts", `{"data":[]}`)
+			if tt.header != "" {
+				request.Header.Set(dataOwnerID, tt.header)
+			}
+
+			recorder := serveTestRequest(app.Handler(), request)
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.want)
+			}
+
+			if msg := dequeueTestMessage(app.qm); tt.wantOwner != "" && (msg == nil || msg.DataOwnerID != tt.wantOwner) {
+				t.Errorf("queued message = %+v, want data owner %q", msg, tt.wantOwner)
+			}
+		})
+	}
+}
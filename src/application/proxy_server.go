@@ -0,0 +1,292 @@
+package application
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/certificates"
+	"github.com/google/uuid"
+)
+
+// inboundRequestContextKey Key of the request received by the proxy in the context of the upstream request
+const inboundRequestContextKey contextKey = "inboundRequest"
+
+// ProxyServer passes the traffic between the receiver and the data holder unchanged, and queues a copy of the
+// responses for validation
+type ProxyServer struct {
+	crosscutting.OFBStruct
+	cm      *ConfigurationManager  // Manager for application settings
+	capture *ResponseCapture       // Capture that queues the responses for validation
+	metrics *monitoring.Metrics    // Metrics of the application instance
+	proxy   *httputil.ReverseProxy // Proxy used to pass the traffic
+}
+
+// NewProxyServer creates a new proxy server
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - cm: Configuration manager to be used
+//   - capture: Capture that queues the responses for validation
+//   - metrics: Metrics of the application instance
+//
+// Returns:
+//   - *ProxyServer: Proxy server created
+func NewProxyServer(logger log.Logger, cm *ConfigurationManager, capture *ResponseCapture, metrics *monitoring.Metrics) *ProxyServer {
+	return &ProxyServer{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ProxyServer",
+			Logger: logger,
+		},
+		cm:      cm,
+		capture: capture,
+		metrics: metrics,
+	}
+}
+
+// Handler returns the handler of the proxy, creating the transport used to call the upstream servers
+//
+// Parameters:
+//
+// Returns:
+//   - http.Handler: Handler of the proxy
+//   - error: error if the target URL is invalid
+func (ps *ProxyServer) Handler() (http.Handler, error) {
+	settings := ps.cm.settings.ProxySettings
+	target, err := url.Parse(settings.TargetURL)
+	if err != nil && settings.Mode == configuration.ReverseProxy {
+		return nil, err
+	}
+
+	ps.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if settings.Mode == configuration.ReverseProxy {
+				pr.SetURL(target)
+				pr.SetXForwarded()
+				return
+			}
+
+			pr.Out.URL.Scheme = settings.UpstreamScheme
+			pr.Out.Host = pr.In.URL.Host
+		},
+		Transport:      ps.getTransport(),
+		ModifyResponse: ps.captureResponse,
+		ErrorHandler:   ps.handleProxyError,
+	}
+
+	return http.HandlerFunc(ps.handleRequest), nil
+}
+
+// getTransport Creates the transport used to call the upstream servers, with the outbound client certificate if
+// configured, required by the data holders for FAPI mTLS
+//
+// Parameters:
+//
+// Returns:
+//   - *http.Transport: Transport of the proxy
+func (ps *ProxyServer) getTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	security := ps.cm.settings.SecuritySettings
+	if security.OutboundCertFile == "" {
+		return transport
+	}
+
+	reloader, err := certificates.NewReloader(ps.Logger, ps.metrics, "proxy-client", security.OutboundCertFile, security.OutboundKeyFile)
+	if err != nil {
+		ps.Logger.Error(err, "Error loading outbound certificate, no client certificate will be used by the proxy", ps.Pack, "getTransport")
+		return transport
+	}
+
+	go reloader.Watch(time.Duration(security.ReloadInterval) * time.Second)
+	transport.TLSClientConfig.GetClientCertificate = reloader.GetClientCertificate
+	return transport
+}
+
+// StartServing Starts the proxy server, if the proxy mode is enabled
+//
+// Parameters:
+//
+// Returns:
+func (ps *ProxyServer) StartServing() {
+	settings := ps.cm.settings.ProxySettings
+	if settings.Mode == configuration.DisabledProxy {
+		return
+	}
+
+	handler, err := ps.Handler()
+	if err != nil {
+		ps.Logger.Fatal(err, "Invalid proxy target URL", ps.Pack, "StartServing")
+	}
+
+	port := strings.Replace(settings.Port, ":", "", -1)
+	server := &http.Server{
+		Addr:         net.JoinHostPort(settings.BindAddress, port),
+		Handler:      handler,
+		ReadTimeout:  60 * time.Second,
+		WriteTimeout: 60 * time.Second,
+	}
+
+	ps.Logger.Log("Starting the proxy ["+settings.Mode+"] on "+server.Addr, ps.Pack, "StartServing")
+	if ps.cm.IsHTTPS() {
+		security := ps.cm.settings.SecuritySettings
+		reloader, err := certificates.NewReloader(ps.Logger, ps.metrics, "proxy", security.CertFilePath, security.KeyFilePath)
+		if err != nil {
+			ps.Logger.Fatal(err, "Error loading proxy certificate", ps.Pack, "StartServing")
+		}
+
+		go reloader.Watch(time.Duration(security.ReloadInterval) * time.Second)
+		minVersion, _ := certificates.ParseTLSVersion(security.MinTLSVersion)
+		server.TLSConfig = &tls.Config{GetCertificate: reloader.GetCertificate, MinVersion: minVersion}
+		ps.Logger.Fatal(server.ListenAndServeTLS("", ""), "", ps.Pack, "StartServing")
+	} else {
+		ps.Logger.Fatal(server.ListenAndServe(), "", ps.Pack, "StartServing")
+	}
+}
+
+// getHostName returns the host name of a host, without the port and in lower case
+//
+// Parameters:
+//   - host: Host with or without port
+//
+// Returns:
+//   - string: Host name
+func getHostName(host string) string {
+	if hostName, _, err := net.SplitHostPort(host); err == nil {
+		host = hostName
+	}
+
+	return strings.ToLower(host)
+}
+
+// handleRequest Passes a request to the proxy. In FORWARD mode the request must use an absolute URL to one of the
+// data holders configured in ServerHosts, the proxy must not be used to reach other hosts
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (ps *ProxyServer) handleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		http.Error(w, "CONNECT not supported, the content of tunnels cannot be validated", http.StatusMethodNotAllowed)
+		return
+	}
+
+	settings := ps.cm.settings.ProxySettings
+	if settings.Mode == configuration.ForwardProxy {
+		if !r.URL.IsAbs() {
+			http.Error(w, "Absolute URL required in FORWARD proxy mode", http.StatusBadRequest)
+			return
+		}
+
+		if _, found := settings.ServerHosts[getHostName(r.URL.Host)]; !found {
+			ps.Logger.Warning("Host not allowed in FORWARD proxy mode: "+r.URL.Host, ps.Pack, "handleRequest")
+			http.Error(w, "Host not allowed in FORWARD proxy mode", http.StatusForbidden)
+			return
+		}
+	}
+
+	// The request received is kept to identify the message, the upstream request is rewritten by the proxy
+	ps.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), inboundRequestContextKey, r)))
+}
+
+// handleProxyError Writes the response when the upstream server cannot be reached
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//   - err: Error of the upstream request
+//
+// Returns:
+func (ps *ProxyServer) handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	ps.Logger.Error(err, "Error proxying request to: "+r.URL.String(), ps.Pack, "handleProxyError")
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// captureResponse Prepares the copy of a successful JSON response of a known endpoint, the response is not changed
+//
+// Parameters:
+//   - resp: Response of the upstream server
+//
+// Returns:
+//   - error: always nil, the response is never blocked
+func (ps *ProxyServer) captureResponse(resp *http.Response) error {
+	if !ps.capture.IsCapturable(resp.StatusCode, resp.Header) {
+		return nil
+	}
+
+	msg := ps.getMessage(resp)
+	if msg == nil {
+		return nil
+	}
+
+	resp.Body = ps.capture.captureBody(resp.Body, msg, resp.Header.Get("Content-Encoding"))
+
+	return nil
+}
+
+// getMessage Creates the message of a response, deriving the header values from the request received by the proxy
+// and the response. The values are checked as in the validation API, and the message is received when the response
+// of the upstream server arrives
+//
+// Parameters:
+//   - resp: Response of the upstream server
+//
+// Returns:
+//   - *Message: Message without content, nil if the endpoint or the server cannot be identified
+func (ps *ProxyServer) getMessage(resp *http.Response) *Message {
+	settings := ps.cm.settings.ProxySettings
+	request, ok := resp.Request.Context().Value(inboundRequestContextKey).(*http.Request)
+	if !ok {
+		request = resp.Request
+	}
+
+	endpointName := ps.capture.GetEndpointName(request.URL.Path, settings.PathPrefix)
+	if endpointName == "" {
+		ps.Logger.Debug("Endpoint not found for path: "+request.URL.Path, ps.Pack, "getMessage")
+		return nil
+	}
+
+	msg := &Message{
+		Endpoint:           endpointName,
+		HTTPMethod:         request.Method,
+		XFapiInteractionID: resp.Header.Get(xFAPIInteractionID),
+		ReceivedAt:         time.Now(),
+	}
+
+	if msg.XFapiInteractionID == "" {
+		msg.XFapiInteractionID = request.Header.Get(xFAPIInteractionID)
+	}
+
+	if _, err := uuid.Parse(msg.XFapiInteractionID); err != nil {
+		ps.Logger.Debug(xFAPIInteractionID+" not found or bad format for path: "+request.URL.Path, ps.Pack, "getMessage")
+		return nil
+	}
+
+	if settings.Mode == configuration.ReverseProxy {
+		msg.Role = configuration.TransmitterMode
+		msg.ServerID = settings.ServerOrgID
+		msg.DataOwnerID = ps.capture.GetDataOwnerID(request.Host)
+	} else {
+		msg.Role = configuration.ReceiverMode
+		msg.DataOwnerID = ps.capture.GetOrganisationID()
+		msg.ServerID = settings.ServerHosts[getHostName(request.URL.Host)]
+	}
+
+	if _, err := uuid.Parse(msg.ServerID); err != nil {
+		ps.Logger.Debug(srvOrgID+" not found or bad format for host: "+request.URL.Host, ps.Pack, "getMessage")
+		return nil
+	}
+
+	return msg
+}
@@ -0,0 +1,186 @@
+package application
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+// newTestUpstream returns a server that answers all the requests with a JSON body, and the path of the last request
+func newTestUpstream(t *testing.T, status int, contentType string) (*httptest.Server, *string) {
+	t.Helper()
+	path := new(string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.Path
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"data":[{"accountId":"1"}]}`)
+	}))
+
+	t.Cleanup(server.Close)
+	return server, path
+}
+
+// newTestProxyHandler returns the handler of a proxy in the specified mode for the upstream server
+func newTestProxyHandler(t *testing.T, mode string, upstream *httptest.Server, serverHosts map[string]string) (http.Handler, *App) {
+	t.Helper()
+	settings := newTestSettings(t)
+	settings.ApplicationSettings.Mode = configuration.DualMode
+	settings.ProxySettings.Mode = mode
+	settings.ProxySettings.TargetURL = upstream.URL + "/base"
+	settings.ProxySettings.UpstreamScheme = "http"
+	settings.ProxySettings.ServerHosts = serverHosts
+	app, _ := newTestApp(t, settings)
+	handler, err := NewProxyServer(app.Logger, app.cm, app.capture, app.metrics).Handler()
+	if err != nil {
+		t.Fatalf("Handler() error = %v", err)
+	}
+
+	return handler, app
+}
+
+func TestProxyServerReverse(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		interactionID string
+		status        int
+		contentType   string
+		wantCaptured  bool
+	}{
+		{name: "response captured", path: "/open-banking/accounts/v2/accounts", interactionID: testInteractionID, status: http.StatusOK, contentType: "application/json", wantCaptured: true},
+		{name: "missing interaction ID", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json"},
+		{name: "invalid interaction ID", path: "/open-banking/accounts/v2/accounts", interactionID: "interaction", status: http.StatusOK, contentType: "application/json"},
+		{name: "unknown endpoint", path: "/open-banking/unknown", interactionID: testInteractionID, status: http.StatusOK, contentType: "application/json"},
+		{name: "error status", path: "/open-banking/accounts/v2/accounts", interactionID: testInteractionID, status: http.StatusNotFound, contentType: "application/json"},
+		{name: "not JSON", path: "/open-banking/accounts/v2/accounts", interactionID: testInteractionID, status: http.StatusOK, contentType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, upstreamPath := newTestUpstream(t, tt.status, tt.contentType)
+			handler, app := newTestProxyHandler(t, configuration.ReverseProxy, upstream, nil)
+
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.interactionID != "" {
+				request.Header.Set(xFAPIInteractionID, tt.interactionID)
+			}
+
+			before := time.Now()
+			recorder := serveTestRequest(handler, request)
+			if recorder.Code != tt.status || recorder.Body.String() != `{"data":[{"accountId":"1"}]}` {
+				t.Fatalf("response = %d %q, want %d and the upstream body", recorder.Code, recorder.Body.String(), tt.status)
+			}
+
+			// The base path of the target URL is only used for the upstream request
+			if *upstreamPath != "/base"+tt.path {
+				t.Errorf("upstream path = %q, want %q", *upstreamPath, "/base"+tt.path)
+			}
+
+			processTestCaptures(app.capture)
+			msg := dequeueTestMessage(app.qm)
+			if (msg != nil) != tt.wantCaptured {
+				t.Fatalf("captured = %v, want %v", msg != nil, tt.wantCaptured)
+			}
+
+			if msg == nil {
+				return
+			}
+
+			if msg.Endpoint != "/accounts/v2/accounts" || msg.Role != configuration.TransmitterMode || msg.ServerID != testOrganisationID || msg.XFapiInteractionID != testInteractionID || msg.HTTPMethod != http.MethodGet {
+				t.Errorf("message = %+v", msg)
+			}
+
+			if msg.ReceivedAt.Before(before) || msg.ReceivedAt.After(time.Now()) {
+				t.Errorf("ReceivedAt = %v, want the time of the response", msg.ReceivedAt)
+			}
+		})
+	}
+}
+
+func TestProxyServerForward(t *testing.T) {
+	upstream, _ := newTestUpstream(t, http.StatusOK, "application/json")
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		serverHosts  map[string]string
+		want         int
+		wantServerID string
+	}{
+		{name: "allowed host", method: http.MethodGet, target: upstream.URL + "/open-banking/accounts/v2/accounts", serverHosts: map[string]string{upstreamURL.Hostname(): testServerOrgID}, want: http.StatusOK, wantServerID: testServerOrgID},
+		{name: "host not allowed", method: http.MethodGet, target: upstream.URL + "/open-banking/accounts/v2/accounts", serverHosts: map[string]string{"api.bank.com": testServerOrgID}, want: http.StatusForbidden},
+		{name: "relative URL", method: http.MethodGet, target: "/open-banking/accounts/v2/accounts", serverHosts: map[string]string{upstreamURL.Hostname(): testServerOrgID}, want: http.StatusBadRequest},
+		{name: "CONNECT", method: http.MethodConnect, target: upstream.URL, serverHosts: map[string]string{upstreamURL.Hostname(): testServerOrgID}, want: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, app := newTestProxyHandler(t, configuration.ForwardProxy, upstream, tt.serverHosts)
+			request := httptest.NewRequest(tt.method, tt.target, nil)
+			request.Header.Set(xFAPIInteractionID, testInteractionID)
+
+			recorder := serveTestRequest(handler, request)
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+
+			processTestCaptures(app.capture)
+			msg := dequeueTestMessage(app.qm)
+			if (msg != nil) != (tt.wantServerID != "") {
+				t.Fatalf("captured = %v, want %v", msg != nil, tt.wantServerID != "")
+			}
+
+			if msg != nil && (msg.ServerID != tt.wantServerID || msg.Role != configuration.ReceiverMode || msg.DataOwnerID != testOrganisationID) {
+				t.Errorf("message = %+v", msg)
+			}
+		})
+	}
+}
+
+func TestProxyServerGetTransport(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, "client")
+
+	tests := []struct {
+		name           string
+		certFile       string
+		keyFile        string
+		wantClientCert bool
+	}{
+		{name: "without client certificate", wantClientCert: false},
+		{name: "with client certificate", certFile: certFile, keyFile: keyFile, wantClientCert: true},
+		{name: "invalid client certificate", certFile: keyFile, keyFile: certFile, wantClientCert: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SecuritySettings.OutboundCertFile = tt.certFile
+			settings.SecuritySettings.OutboundKeyFile = tt.keyFile
+			settings.SecuritySettings.ReloadInterval = 3600
+			app, _ := newTestApp(t, settings)
+			transport := NewProxyServer(app.Logger, app.cm, app.capture, app.metrics).getTransport()
+
+			getClientCertificate := transport.TLSClientConfig.GetClientCertificate
+			if (getClientCertificate != nil) != tt.wantClientCert {
+				t.Fatalf("GetClientCertificate set = %v, want %v", getClientCertificate != nil, tt.wantClientCert)
+			}
+
+			if getClientCertificate == nil {
+				return
+			}
+
+			certificate, err := getClientCertificate(nil)
+			if err != nil || certificate.Leaf.Subject.CommonName != "client" {
+				t.Errorf("GetClientCertificate() = %v, %v, want the client certificate", certificate, err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package application
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/validation"
 )
@@ -16,7 +17,10 @@ type Message struct {
 	ServerID           string `json:"server_id"`   // Identifier of the Client requesting the information
 	XFapiInteractionID string
 	ConsentID          string
-	TransmitterID      string // Organisation ID of the transmitter
+	TransmitterID      string    // Organisation ID of the transmitter
+	Role               string    // Role of the instance for this message (TRANSMITTER / RECEIVER)
+	DataOwnerID        string    // Organisation ID of the institution that owns the message
+	ReceivedAt         time.Time // Time when the message was received, zero if unknown
 }
 
 // GetMappedObject Returns the json message object mapped as a dynamic structure
@@ -37,21 +41,53 @@ func (msg *Message) GetMappedObject() (validation.DynamicStruct, error) {
 	return dynamicStruct, nil
 }
 
-// Buffered channel for message queue
-var messageQueue = make(chan *Message, 1000)
+// GetHeaders Returns the headers received with the message, used by the header validation rules. The names are the
+// names of the headers of the validation API, the headers not received are not included. Only the metadata known by
+// MQD is available, the HTTP headers of the response of the endpoint are not kept in the message
+//
+// Parameters:
+//
+// Returns:
+//   - map[string]string: Headers of the message
+func (msg *Message) GetHeaders() map[string]string {
+	headers := map[string]string{
+		"endpointName":     msg.Endpoint,
+		"version":          msg.APIVersion,
+		"httpMethod":       msg.HTTPMethod,
+		srvOrgID:           msg.ServerID,
+		xFAPIInteractionID: msg.XFapiInteractionID,
+		"consentID":        msg.ConsentID,
+		transmitterID:      msg.TransmitterID,
+		applicationRole:    msg.Role,
+		dataOwnerID:        msg.DataOwnerID,
+	}
+
+	for name, value := range headers {
+		if value == "" {
+			delete(headers, name)
+		}
+	}
+
+	return headers
+}
+
+// defaultQueueSize Size of the message queue of an application instance
+const defaultQueueSize = 1000
 
 // QueueManager is in charge of managing the queue for messages to process
 type QueueManager struct {
+	messageQueue chan *Message // Buffered channel for message queue
 }
 
-// GetQueueManager returns a new queue manager
+// NewQueueManager returns a new queue manager, with its own queue
 //
 // Parameters:
+//   - size: Size of the queue
 //
 // Returns:
 //   - *QueueManager: New queue manager
-func GetQueueManager() *QueueManager {
-	return &QueueManager{}
+func NewQueueManager(size int) *QueueManager {
+	return &QueueManager{messageQueue: make(chan *Message, size)}
 }
 
 // EnqueueMessage is for queueing the message
@@ -61,7 +97,23 @@ func GetQueueManager() *QueueManager {
 //
 // Returns:
 func (qm *QueueManager) EnqueueMessage(msg *Message) {
-	messageQueue <- msg
+	qm.messageQueue <- msg
+}
+
+// TryEnqueueMessage queues the message only if there is space in the queue
+//
+// Parameters:
+//   - msg: Message to be queued
+//
+// Returns:
+//   - bool: true if the message was queued
+func (qm *QueueManager) TryEnqueueMessage(msg *Message) bool {
+	select {
+	case qm.messageQueue <- msg:
+		return true
+	default:
+		return false
+	}
 }
 
 // GetQueue returns the list of messages in the queue
@@ -71,5 +123,15 @@ func (qm *QueueManager) EnqueueMessage(msg *Message) {
 // Returns:
 //   - chan *Message: List of messages in the queue
 func (qm *QueueManager) GetQueue() chan *Message {
-	return messageQueue
+	return qm.messageQueue
+}
+
+// GetDepth returns the number of messages waiting in the queue
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of messages in the queue
+func (qm *QueueManager) GetDepth() int {
+	return len(qm.messageQueue)
 }
@@ -0,0 +1,241 @@
+package application
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+const (
+	// maxBuckets Maximum number of buckets kept, reaching it triggers the removal of the idle buckets
+	maxBuckets = 10000
+	// bucketCleanupInterval Minimum time between removals of idle buckets
+	bucketCleanupInterval = time.Second
+)
+
+// tokenBucket stores the available tokens of a rate limit key
+type tokenBucket struct {
+	tokens     float64   // Tokens available
+	lastUpdate time.Time // Date of the last refill
+	limit      configuration.RateLimit
+}
+
+// take Refills the bucket and takes a token if available
+//
+// Parameters:
+//   - now: Current date
+//
+// Returns:
+//   - bool: true if a token was taken
+//   - time.Duration: time until a token is available, if not taken
+func (tb *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	tb.tokens = math.Min(float64(tb.limit.Burst), tb.tokens+now.Sub(tb.lastUpdate).Seconds()*tb.limit.RequestsPerSecond)
+	tb.lastUpdate = now
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - tb.tokens) / tb.limit.RequestsPerSecond * float64(time.Second))
+}
+
+// RateLimiter limits the requests to the validation API using a token bucket for each key
+type RateLimiter struct {
+	crosscutting.OFBStruct
+	settings    *configuration.Settings // Application settings
+	buckets     map[string]*tokenBucket // Buckets by key
+	overflow    *tokenBucket            // Bucket shared by the new keys while the maximum number of buckets is reached
+	lastCleanup time.Time               // Date of the last removal of idle buckets
+	mutex       sync.Mutex              // Mutex for thread-safe access to the buckets
+}
+
+// NewRateLimiter creates a new rate limiter
+//
+// Parameters:
+//   - logger: logger to be used
+//   - settings: Application settings
+//
+// Returns:
+//   - *RateLimiter: new created rate limiter
+func NewRateLimiter(logger log.Logger, settings *configuration.Settings) *RateLimiter {
+	return &RateLimiter{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.RateLimiter",
+			Logger: logger,
+		},
+		settings: settings,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// getLimit returns the limit of a key, the default limit is used if the key has no specific limit
+//
+// Parameters:
+//   - key: Rate limit key
+//
+// Returns:
+//   - configuration.RateLimit: limit of the key
+func (rl *RateLimiter) getLimit(key string) configuration.RateLimit {
+	if limit, found := rl.settings.RateLimitSettings.Limits[key]; found {
+		return limit
+	}
+
+	return configuration.RateLimit{
+		RequestsPerSecond: rl.settings.RateLimitSettings.RequestsPerSecond,
+		Burst:             rl.settings.RateLimitSettings.Burst,
+	}
+}
+
+// Allow indicates if a request with the key can be processed. When the maximum number of buckets is reached and no
+// bucket is idle, the new keys without a specific limit share a single bucket, to keep the memory bounded
+//
+// Parameters:
+//   - key: Rate limit key of the request
+//
+// Returns:
+//   - bool: true if the request is allowed
+//   - time.Duration: time until the next request is allowed, if not allowed
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := time.Now()
+	bucket, found := rl.buckets[key]
+	if !found {
+		if len(rl.buckets) >= maxBuckets && now.Sub(rl.lastCleanup) >= bucketCleanupInterval {
+			rl.removeIdleBuckets(now)
+			rl.lastCleanup = now
+		}
+
+		_, specificLimit := rl.settings.RateLimitSettings.Limits[key]
+		limit := rl.getLimit(key)
+		if len(rl.buckets) >= maxBuckets && !specificLimit {
+			if rl.overflow == nil {
+				rl.Logger.Warning("Maximum number of rate limit buckets reached, new keys share a single bucket", rl.Pack, "Allow")
+				rl.overflow = &tokenBucket{tokens: float64(limit.Burst), lastUpdate: now, limit: limit}
+			}
+
+			return rl.overflow.take(now)
+		}
+
+		bucket = &tokenBucket{tokens: float64(limit.Burst), lastUpdate: now, limit: limit}
+		rl.buckets[key] = bucket
+	}
+
+	return bucket.take(now)
+}
+
+// removeIdleBuckets Removes the buckets that would be full, as they behave as new buckets
+//
+// Parameters:
+//   - now: Current date
+//
+// Returns:
+func (rl *RateLimiter) removeIdleBuckets(now time.Time) {
+	for key, bucket := range rl.buckets {
+		if bucket.tokens+now.Sub(bucket.lastUpdate).Seconds()*bucket.limit.RequestsPerSecond >= float64(bucket.limit.Burst) {
+			delete(rl.buckets, key)
+		}
+	}
+
+	if len(rl.buckets) < maxBuckets {
+		rl.overflow = nil
+	}
+}
+
+// getRateLimitKey returns the key of the request according to the configured key type
+//
+// Parameters:
+//   - r: Request received
+//
+// Returns:
+//   - string: Rate limit key of the request
+func (rl *RateLimiter) getRateLimitKey(r *http.Request) string {
+	switch rl.settings.RateLimitSettings.Key {
+	case configuration.ServerOrgIDRateLimitKey:
+		return r.Header.Get(srvOrgID)
+	case configuration.EndpointRateLimitKey:
+		return r.Header.Get("endpointName")
+	}
+
+	if credentialID, ok := r.Context().Value(credentialContextKey).(string); ok && credentialID != "" {
+		return credentialID
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// otherRateLimitKey Label used in the metrics for the rate limit keys that are not known by the configuration
+const otherRateLimitKey = "other"
+
+// getRateLimitMetricKey returns the label of a rate limit key for the metrics. Only the values known by the
+// configuration are used, to keep the cardinality bounded: the keys with a specific limit, the authenticated
+// callers and the endpoints of the configuration. Any other key is reported as "other"
+//
+// Parameters:
+//   - r: Request received
+//   - key: Rate limit key of the request
+//
+// Returns:
+//   - string: Label of the key
+func (as *APIServer) getRateLimitMetricKey(r *http.Request, key string) string {
+	if _, found := as.cm.settings.RateLimitSettings.Limits[key]; found {
+		return key
+	}
+
+	switch as.cm.settings.RateLimitSettings.Key {
+	case configuration.ServerOrgIDRateLimitKey:
+		return otherRateLimitKey
+	case configuration.EndpointRateLimitKey:
+		if validationSettings := as.cm.GetEndpointSettingFromAPI(key, as.logger); validationSettings != nil {
+			return validationSettings.EndpointName
+		}
+
+		return otherRateLimitKey
+	}
+
+	if credentialID, ok := r.Context().Value(credentialContextKey).(string); ok && credentialID != "" && credentialID == key {
+		return key
+	}
+
+	return otherRateLimitKey
+}
+
+// rateLimit Middleware that rejects the requests exceeding the limit of its key
+//
+// Parameters:
+//   - next: Handler to be executed if the request is allowed
+//
+// Returns:
+//   - http.Handler: Handler with the rate limit
+func (as *APIServer) rateLimit(next http.Handler) http.Handler {
+	if as.rl == nil || !as.cm.settings.RateLimitSettings.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := as.rl.getRateLimitKey(r)
+		allowed, retryAfter := as.rl.Allow(key)
+		if !allowed {
+			as.metrics.IncreaseRateLimitedRequests(as.cm.settings.RateLimitSettings.Key, as.getRateLimitMetricKey(r, key))
+			as.logger.Debug("Rate limit exceeded for key: "+key, as.pack, "rateLimit")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			as.updateResponseError(w, GenericError{Message: "Rate limit exceeded."}, http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,247 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+// newTestRateLimiter returns a rate limiter with 1 request per second, a burst of 2 and a specific limit for "vip"
+func newTestRateLimiter(t *testing.T, key string) *RateLimiter {
+	t.Helper()
+	settings := newTestSettings(t)
+	settings.RateLimitSettings.Enabled = true
+	settings.RateLimitSettings.Key = key
+	settings.RateLimitSettings.RequestsPerSecond = 1
+	settings.RateLimitSettings.Burst = 2
+	settings.RateLimitSettings.Limits = map[string]configuration.RateLimit{"vip": {RequestsPerSecond: 10, Burst: 5}}
+	return NewRateLimiter(newTestLogger(), &settings)
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		want       bool
+		wantTokens float64
+		wantWait   time.Duration
+	}{
+		{name: "token available", tokens: 2, want: true, wantTokens: 1},
+		{name: "empty bucket", tokens: 0, want: false, wantWait: 500 * time.Millisecond},
+		{name: "refilled bucket", tokens: 0, elapsed: time.Second, want: true, wantTokens: 1},
+		{name: "refill limited by the burst", tokens: 1, elapsed: time.Minute, want: true, wantTokens: 3},
+		{name: "partial token", tokens: 0.5, want: false, wantTokens: 0.5, wantWait: 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := &tokenBucket{tokens: tt.tokens, lastUpdate: start, limit: configuration.RateLimit{RequestsPerSecond: 2, Burst: 4}}
+			got, wait := bucket.take(start.Add(tt.elapsed))
+			if got != tt.want || wait != tt.wantWait {
+				t.Errorf("take() = %v, %v, want %v, %v", got, wait, tt.want, tt.wantWait)
+			}
+
+			if got && bucket.tokens != tt.wantTokens {
+				t.Errorf("tokens = %v, want %v", bucket.tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		requests int
+		want     int
+	}{
+		{name: "default limit", key: "service-a", requests: 5, want: 2},
+		{name: "specific limit", key: "vip", requests: 10, want: 5},
+		{name: "empty key", key: "", requests: 3, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newTestRateLimiter(t, configuration.CallerRateLimitKey)
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				if ok, _ := rl.Allow(tt.key); ok {
+					allowed++
+				}
+			}
+
+			if allowed != tt.want {
+				t.Errorf("allowed = %d, want %d", allowed, tt.want)
+			}
+
+			// Other keys have their own bucket
+			if ok, _ := rl.Allow(tt.key + "-other"); !ok {
+				t.Errorf("Allow() for other key = false, want true")
+			}
+		})
+	}
+}
+
+func TestRateLimiterMaxBuckets(t *testing.T) {
+	tests := []struct {
+		name        string
+		idle        bool
+		key         string
+		wantBuckets int
+		wantShared  bool
+	}{
+		{name: "idle buckets are removed", idle: true, key: "new", wantBuckets: 1},
+		{name: "new keys share a bucket when no bucket is idle", key: "new", wantBuckets: maxBuckets, wantShared: true},
+		{name: "keys with specific limit have their own bucket", key: "vip", wantBuckets: maxBuckets + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newTestRateLimiter(t, configuration.CallerRateLimitKey)
+			lastUpdate := time.Now()
+			if tt.idle {
+				lastUpdate = lastUpdate.Add(-time.Hour)
+			}
+
+			for i := 0; i < maxBuckets; i++ {
+				rl.buckets[strconv.Itoa(i)] = &tokenBucket{lastUpdate: lastUpdate, limit: rl.getLimit("")}
+			}
+
+			rl.Allow(tt.key)
+			if len(rl.buckets) != tt.wantBuckets {
+				t.Errorf("buckets = %d, want %d", len(rl.buckets), tt.wantBuckets)
+			}
+
+			if (rl.overflow != nil) != tt.wantShared {
+				t.Fatalf("overflow = %+v, want shared %v", rl.overflow, tt.wantShared)
+			}
+
+			if !tt.wantShared {
+				return
+			}
+
+			// The shared bucket applies the default limit to all the new keys
+			allowed := 1
+			for i := 0; i < 5; i++ {
+				if ok, _ := rl.Allow("new-" + strconv.Itoa(i)); ok {
+					allowed++
+				}
+			}
+
+			if allowed != 2 || len(rl.buckets) != maxBuckets {
+				t.Errorf("allowed = %d, buckets = %d, want 2 and %d", allowed, len(rl.buckets), maxBuckets)
+			}
+		})
+	}
+}
+
+func TestGetRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyType    string
+		credential string
+		remoteAddr string
+		want       string
+	}{
+		{name: "server organisation", keyType: configuration.ServerOrgIDRateLimitKey, want: testServerOrgID},
+		{name: "endpoint", keyType: configuration.EndpointRateLimitKey, want: "/accounts/v2/accounts"},
+		{name: "caller with credential", keyType: configuration.CallerRateLimitKey, credential: "service-a", want: "service-a"},
+		{name: "caller without credential", keyType: configuration.CallerRateLimitKey, remoteAddr: "10.0.0.1:5000", want: "10.0.0.1"},
+		{name: "caller without port", keyType: configuration.CallerRateLimitKey, remoteAddr: "10.0.0.1", want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newTestRateLimiter(t, tt.keyType)
+			request := newValidateRequest("/accounts/v2/accounts", "")
+			request.RemoteAddr = tt.remoteAddr
+			if tt.credential != "" {
+				request = request.WithContext(context.WithValue(request.Context(), credentialContextKey, tt.credential))
+			}
+
+			if got := rl.getRateLimitKey(request); got != tt.want {
+				t.Errorf("getRateLimitKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		requests int
+		want     int
+	}{
+		{name: "disabled", enabled: false, requests: 5, want: http.StatusOK},
+		{name: "under the limit", enabled: true, requests: 2, want: http.StatusOK},
+		{name: "over the limit", enabled: true, requests: 3, want: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.RateLimitSettings.Enabled = tt.enabled
+			settings.RateLimitSettings.RequestsPerSecond = 0.5
+			settings.RateLimitSettings.Burst = 2
+			app, _ := newTestApp(t, settings)
+			handler := app.Handler()
+
+			var recorder *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				recorder = serveTestRequest(handler, newValidateRequest("/accounts/v2/accounts", `{"data":[]}`))
+			}
+
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.want)
+			}
+
+			if tt.want == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") != "2" {
+				t.Errorf("Retry-After = %q, want 2", recorder.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestGetRateLimitMetricKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyType    string
+		key        string
+		credential string
+		want       string
+	}{
+		{name: "key with a specific limit", keyType: configuration.ServerOrgIDRateLimitKey, key: "vip", want: "vip"},
+		{name: "server organisation without a specific limit", keyType: configuration.ServerOrgIDRateLimitKey, key: testServerOrgID, want: otherRateLimitKey},
+		{name: "endpoint of the configuration", keyType: configuration.EndpointRateLimitKey, key: "/ACCOUNTS/v2/accounts", want: "/accounts/v2/accounts"},
+		{name: "endpoint not in the configuration", keyType: configuration.EndpointRateLimitKey, key: "/accounts/v2/other", want: otherRateLimitKey},
+		{name: "authenticated caller", keyType: configuration.CallerRateLimitKey, key: "service-a", credential: "service-a", want: "service-a"},
+		{name: "caller address", keyType: configuration.CallerRateLimitKey, key: "10.0.0.1", want: otherRateLimitKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.RateLimitSettings.Key = tt.keyType
+			settings.RateLimitSettings.Limits = map[string]configuration.RateLimit{"vip": {RequestsPerSecond: 10, Burst: 5}}
+			app, _ := newTestApp(t, settings)
+			as := NewAPIServer(app.Logger, app.metrics, app.qm, app.cm, app.sp, app.lc)
+
+			request := newValidateRequest("/accounts/v2/accounts", "")
+			if tt.credential != "" {
+				request = request.WithContext(context.WithValue(request.Context(), credentialContextKey, tt.credential))
+			}
+
+			if got := as.getRateLimitMetricKey(request, tt.key); got != tt.want {
+				t.Errorf("getRateLimitMetricKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	identityEncoding = "identity"
+	gzipEncoding     = "gzip"
+	deflateEncoding  = "deflate"
+)
+
+var (
+	errUnsupportedEncoding      = errors.New("unsupported content encoding")
+	errDecompressedSizeExceeded = errors.New("decompressed content exceeds the allowed size or compression ratio")
+)
+
+// limitRequestBody Middleware that limits the size of the bodies received, before any other middleware reads them
+//
+// Parameters:
+//   - next: Handler to be executed
+//
+// Returns:
+//   - http.Handler: Handler with the limit
+func (as *APIServer) limitRequestBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxBodySize := int64(as.cm.settings.RequestSettings.MaxBodySize) * 1024
+		if r.ContentLength > maxBodySize {
+			as.updateResponseError(w, GenericError{Message: "body: Exceeds the maximum size of " + strconv.FormatInt(maxBodySize, 10) + " bytes."}, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getContentEncoding returns the normalized value of a Content-Encoding header
+//
+// Parameters:
+//   - header: Value of the header
+//
+// Returns:
+//   - string: Content encoding, identity if not informed
+func getContentEncoding(header string) string {
+	encoding := strings.ToLower(strings.TrimSpace(header))
+	if encoding == "" {
+		return identityEncoding
+	}
+
+	return encoding
+}
+
+// readRequestBody Reads the body of the request, decompressing it according to the Content-Encoding header
+//
+// Parameters:
+//   - r: Request received
+//
+// Returns:
+//   - []byte: Content of the body
+//   - *GenericError: Error if the body cannot be read
+//   - int: HTTP response code for the error
+func (as *APIServer) readRequestBody(r *http.Request) ([]byte, *GenericError, int) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return nil, &GenericError{Message: "body: Exceeds the maximum size of " + strconv.FormatInt(maxBytesError.Limit, 10) + " bytes."}, http.StatusRequestEntityTooLarge
+		}
+
+		return nil, &GenericError{Message: "Failed to read request body."}, http.StatusInternalServerError
+	}
+
+	encoding := getContentEncoding(r.Header.Get("Content-Encoding"))
+	if encoding != identityEncoding {
+		settings := as.cm.settings.RequestSettings
+		body, err = decompressBody(body, encoding, int64(settings.MaxDecompressedSize)*1024, settings.MaxCompressionRatio)
+		if err != nil {
+			as.logger.Warning("Error decompressing request body: "+err.Error(), as.pack, "readRequestBody")
+			if errors.Is(err, errUnsupportedEncoding) {
+				return nil, &GenericError{Message: "Content-Encoding: Not supported, use gzip or deflate."}, http.StatusUnsupportedMediaType
+			}
+
+			if errors.Is(err, errDecompressedSizeExceeded) {
+				return nil, &GenericError{Message: "body: Decompressed content exceeds the allowed size or compression ratio."}, http.StatusRequestEntityTooLarge
+			}
+
+			return nil, &GenericError{Message: "body: Invalid " + encoding + " content."}, http.StatusBadRequest
+		}
+	}
+
+	return body, nil, http.StatusOK
+}
+
+// decompressBody Decompresses the body, limiting the decompressed size to protect against decompression bombs
+//
+// Parameters:
+//   - body: Compressed content
+//   - encoding: Content encoding (gzip / deflate)
+//   - maxSize: Maximum size in bytes of the decompressed content
+//   - maxRatio: Maximum ratio between the decompressed and the compressed size
+//
+// Returns:
+//   - []byte: Decompressed content
+//   - error: error if the content is invalid or exceeds the limits
+func decompressBody(body []byte, encoding string, maxSize int64, maxRatio int) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case gzipEncoding:
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case deflateEncoding:
+		// deflate is defined as zlib format, but some clients send raw deflate content
+		reader, err = zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return nil, errUnsupportedEncoding
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	limit := min(maxSize, int64(len(body))*int64(maxRatio))
+	content, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > limit {
+		return nil, errDecompressedSizeExceeded
+	}
+
+	return content, nil
+}
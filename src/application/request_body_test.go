@@ -0,0 +1,156 @@
+package application
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// compressTestContent compresses a content with the specified encoding, raw deflate is used for "raw"
+func compressTestContent(t *testing.T, encoding string, content []byte) []byte {
+	t.Helper()
+	buffer := &bytes.Buffer{}
+	var writer io.WriteCloser
+	switch encoding {
+	case gzipEncoding:
+		writer = gzip.NewWriter(buffer)
+	case deflateEncoding:
+		writer = zlib.NewWriter(buffer)
+	default:
+		writer, _ = flate.NewWriter(buffer, flate.DefaultCompression)
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	content := []byte(`{"data":[{"accountId":"1"}]}`)
+	bomb := bytes.Repeat([]byte(" "), 64*1024)
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		maxSize  int64
+		maxRatio int
+		want     []byte
+		wantErr  error
+	}{
+		{name: "gzip", encoding: gzipEncoding, body: compressTestContent(t, gzipEncoding, content), maxSize: 1024, maxRatio: 100, want: content},
+		{name: "zlib deflate", encoding: deflateEncoding, body: compressTestContent(t, deflateEncoding, content), maxSize: 1024, maxRatio: 100, want: content},
+		{name: "raw deflate", encoding: deflateEncoding, body: compressTestContent(t, "raw", content), maxSize: 1024, maxRatio: 100, want: content},
+		{name: "unsupported encoding", encoding: "br", body: content, maxSize: 1024, maxRatio: 100, wantErr: errUnsupportedEncoding},
+		{name: "size exceeded", encoding: gzipEncoding, body: compressTestContent(t, gzipEncoding, bomb), maxSize: 32 * 1024, maxRatio: 100000, wantErr: errDecompressedSizeExceeded},
+		{name: "ratio exceeded", encoding: gzipEncoding, body: compressTestContent(t, gzipEncoding, bomb), maxSize: 1024 * 1024, maxRatio: 10, wantErr: errDecompressedSizeExceeded},
+		{name: "size at the limit", encoding: gzipEncoding, body: compressTestContent(t, gzipEncoding, bomb), maxSize: 64 * 1024, maxRatio: 100000, want: bomb},
+		{name: "invalid content", encoding: gzipEncoding, body: content, maxSize: 1024, maxRatio: 100, wantErr: gzip.ErrHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompressBody(tt.body, tt.encoding, tt.maxSize, tt.maxRatio)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("decompressBody() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || !bytes.Equal(got, tt.want) {
+				t.Errorf("decompressBody() = %d bytes, %v, want %d bytes", len(got), err, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestGetContentEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: identityEncoding},
+		{header: " GZIP ", want: gzipEncoding},
+		{header: "Deflate", want: deflateEncoding},
+		{header: "identity", want: identityEncoding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := getContentEncoding(tt.header); got != tt.want {
+				t.Errorf("getContentEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleValidateResponseBody(t *testing.T) {
+	content := []byte(`{"data":[{"accountId":"1"}]}`)
+	large := []byte(`{"data":[{"accountId":"` + strings.Repeat("1", 2048) + `"}]}`)
+	bomb := []byte(`{"data":[{"accountId":"` + strings.Repeat("1", 8*1024) + `"}]}`)
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		chunked  bool
+		want     int
+	}{
+		{name: "plain body", body: content, want: http.StatusOK},
+		{name: "gzip body", encoding: "gzip", body: compressTestContent(t, gzipEncoding, content), want: http.StatusOK},
+		{name: "deflate body", encoding: "deflate", body: compressTestContent(t, deflateEncoding, content), want: http.StatusOK},
+		{name: "body over the limit", body: large, want: http.StatusRequestEntityTooLarge},
+		{name: "body over the limit without length", body: large, chunked: true, want: http.StatusRequestEntityTooLarge},
+		{name: "decompressed body over the limit", encoding: "gzip", body: compressTestContent(t, gzipEncoding, bomb), want: http.StatusRequestEntityTooLarge},
+		{name: "unsupported encoding", encoding: "br", body: content, want: http.StatusUnsupportedMediaType},
+		{name: "invalid gzip content", encoding: "gzip", body: content, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.RequestSettings.MaxBodySize = 1
+			settings.RequestSettings.MaxDecompressedSize = 4
+			app, _ := newTestApp(t, settings)
+
+			request := newValidateRequest("/accounts/v2/accounts", "")
+			request.Body = io.NopCloser(bytes.NewReader(tt.body))
+			request.ContentLength = int64(len(tt.body))
+			if tt.chunked {
+				request.ContentLength = -1
+			}
+
+			if tt.encoding != "" {
+				request.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			recorder := serveTestRequest(app.Handler(), request)
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+
+			msg := dequeueTestMessage(app.qm)
+			if (msg != nil) != (tt.want == http.StatusOK) {
+				t.Fatalf("queued message = %v, want queued %v", msg != nil, tt.want == http.StatusOK)
+			}
+
+			if msg != nil && msg.Message != string(content) {
+				t.Errorf("queued body = %q, want %q", msg.Message, content)
+			}
+		})
+	}
+}
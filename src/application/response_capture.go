@@ -0,0 +1,264 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+)
+
+// captureQueueSize Maximum number of captured responses waiting to be processed by the capture worker
+const captureQueueSize = 1000
+
+// capturedResponse is the copy of a response waiting to be processed by the capture worker
+type capturedResponse struct {
+	msg      *Message // Message of the response
+	body     []byte   // Content of the response, owned by the capture
+	encoding string   // Content encoding of the response
+}
+
+// ResponseCapture queues copies of the responses of the APIs for validation, it is used by the proxy and by the
+// embedded middleware. The responses are processed by a worker, so the decompression and the checks of the
+// responses do not delay the traffic
+type ResponseCapture struct {
+	crosscutting.OFBStruct
+	cm       *ConfigurationManager // Manager for application settings
+	sp       *SamplingPolicy       // Policy to select the messages to validate
+	lc       *LoadController       // Controller to queue the messages under load
+	metrics  *monitoring.Metrics   // Metrics of the application instance
+	captured chan capturedResponse // Responses waiting to be processed by the worker
+}
+
+// capturingBody keeps a copy of a response body while it is sent to the client, the copy is delivered when the
+// body has been completely read
+type capturingBody struct {
+	io.ReadCloser
+	buffer     bytes.Buffer      // Copy of the content read
+	limit      int               // Maximum size of the copy
+	exceeded   bool              // Indicates that the body is bigger than the limit
+	complete   bool              // Indicates that the body was completely read
+	onComplete func(body []byte) // Function that receives the copy
+}
+
+// Read reads the body, keeping a copy of the content
+//
+// Parameters:
+//   - p: buffer to receive the content
+//
+// Returns:
+//   - int: number of bytes read
+//   - error: error if any, io.EOF at the end of the body
+func (cb *capturingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	if !cb.exceeded {
+		if cb.buffer.Len()+n > cb.limit {
+			cb.exceeded = true
+			cb.buffer = bytes.Buffer{}
+		} else {
+			cb.buffer.Write(p[:n])
+		}
+	}
+
+	if err == io.EOF {
+		cb.complete = true
+	}
+
+	return n, err
+}
+
+// Close closes the body, and delivers the copy if the body was completely read
+//
+// Parameters:
+//
+// Returns:
+//   - error: error if any
+func (cb *capturingBody) Close() error {
+	err := cb.ReadCloser.Close()
+	if cb.complete && !cb.exceeded && cb.onComplete != nil {
+		cb.onComplete(cb.buffer.Bytes())
+		cb.onComplete = nil
+	}
+
+	return err
+}
+
+// NewResponseCapture creates a new response capture
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - cm: Configuration manager to be used
+//   - sp: Sampling policy to select the messages to validate
+//   - lc: Load controller to queue the messages
+//   - metrics: Metrics of the application instance
+//
+// Returns:
+//   - *ResponseCapture: Response capture created
+func NewResponseCapture(logger log.Logger, cm *ConfigurationManager, sp *SamplingPolicy, lc *LoadController, metrics *monitoring.Metrics) *ResponseCapture {
+	return &ResponseCapture{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.ResponseCapture",
+			Logger: logger,
+		},
+		cm:       cm,
+		sp:       sp,
+		lc:       lc,
+		metrics:  metrics,
+		captured: make(chan capturedResponse, captureQueueSize),
+	}
+}
+
+// StartWorker starts the process of the captured responses, this function does not return
+//
+// Parameters:
+//
+// Returns:
+func (rc *ResponseCapture) StartWorker() {
+	rc.Logger.Info("Starting response capture worker", rc.Pack, "StartWorker")
+	for response := range rc.captured {
+		rc.processResponse(response)
+	}
+}
+
+// IsCapturable indicates if a response must be captured, only successful JSON responses are validated
+//
+// Parameters:
+//   - statusCode: HTTP status code of the response
+//   - header: Headers of the response
+//
+// Returns:
+//   - bool: true if the response must be captured
+func (rc *ResponseCapture) IsCapturable(statusCode int, header http.Header) bool {
+	return statusCode >= 200 && statusCode < 300 && strings.Contains(strings.ToLower(header.Get("Content-Type")), "json")
+}
+
+// GetMaxBodySize returns the maximum size in bytes of the bodies captured
+//
+// Parameters:
+//
+// Returns:
+//   - int: Maximum size in bytes
+func (rc *ResponseCapture) GetMaxBodySize() int {
+	return rc.cm.settings.RequestSettings.MaxBodySize * 1024
+}
+
+// GetEndpointName returns the name of the endpoint of a request path
+//
+// Parameters:
+//   - path: Path of the request
+//   - pathPrefix: Prefix removed from the path before the lookup (e.g. /open-banking)
+//
+// Returns:
+//   - string: Name of the endpoint, empty if no endpoint matches
+func (rc *ResponseCapture) GetEndpointName(path string, pathPrefix string) string {
+	if len(path) >= len(pathPrefix) && strings.EqualFold(path[:len(pathPrefix)], pathPrefix) {
+		path = path[len(pathPrefix):]
+	}
+
+	return rc.cm.ResolveEndpointName(path)
+}
+
+// GetDataOwnerID returns the organisation that owns the messages received on a host
+//
+// Parameters:
+//   - host: Host of the request
+//
+// Returns:
+//   - string: Organisation ID of the data owner
+func (rc *ResponseCapture) GetDataOwnerID(host string) string {
+	return rc.cm.GetDataOwnerID("", host)
+}
+
+// GetOrganisationID returns the main organisation of the application
+//
+// Parameters:
+//
+// Returns:
+//   - string: Organisation ID
+func (rc *ResponseCapture) GetOrganisationID() string {
+	return rc.cm.settings.ApplicationSettings.OrganisationID
+}
+
+// captureBody Replaces a response body with a body that queues a copy of the content once it is read
+//
+// Parameters:
+//   - body: Body of the response
+//   - msg: Message of the response
+//   - encoding: Content encoding of the response
+//
+// Returns:
+//   - io.ReadCloser: Body to be sent to the client
+func (rc *ResponseCapture) captureBody(body io.ReadCloser, msg *Message, encoding string) io.ReadCloser {
+	return &capturingBody{
+		ReadCloser: body,
+		limit:      rc.GetMaxBodySize(),
+		onComplete: func(content []byte) {
+			rc.EnqueueResponse(msg, bytes.Clone(content), encoding)
+		},
+	}
+}
+
+// EnqueueResponse Hands the copy of a response to the capture worker without blocking, the copy is discarded if the
+// worker has too many responses waiting. The body must not be changed by the caller afterwards
+//
+// Parameters:
+//   - msg: Message of the response
+//   - body: Content of the response
+//   - encoding: Content encoding of the response
+//
+// Returns:
+//   - bool: true if the copy was handed to the worker
+func (rc *ResponseCapture) EnqueueResponse(msg *Message, body []byte, encoding string) bool {
+	rc.metrics.IncreaseRequestsReceived()
+	select {
+	case rc.captured <- capturedResponse{msg: msg, body: body, encoding: encoding}:
+		return true
+	default:
+		rc.lc.recordDroppedMessage()
+		return false
+	}
+}
+
+// processResponse Queues the copy of a response for validation, following the same checks as the validation API.
+// The message is discarded if the queue is full
+//
+// Parameters:
+//   - response: Copy of the response
+//
+// Returns:
+func (rc *ResponseCapture) processResponse(response capturedResponse) {
+	msg, body, encoding := response.msg, response.body, getContentEncoding(response.encoding)
+
+	if encoding != identityEncoding {
+		settings := rc.cm.settings.RequestSettings
+		content, err := decompressBody(body, encoding, int64(settings.MaxDecompressedSize)*1024, settings.MaxCompressionRatio)
+		if err != nil {
+			rc.Logger.Warning("Error decompressing captured response: "+err.Error(), rc.Pack, "processResponse")
+			rc.metrics.IncreaseBadRequestsReceived()
+			return
+		}
+
+		body = content
+	}
+
+	if !json.Valid(body) {
+		rc.metrics.IncreaseBadRequestsReceived()
+		return
+	}
+
+	validationSettings := rc.cm.GetEndpointSettingFromAPI(msg.Endpoint, rc.Logger)
+	if validationSettings == nil {
+		rc.metrics.IncreaseBadEndpointsReceived(msg.Endpoint, "N.A.", "Endpoint not supported")
+		return
+	}
+
+	rc.metrics.RecordPayloadSize(validationSettings.EndpointName, encoding, len(body))
+	rc.sp.ValidateAndEnqueue(msg, validationSettings, func(msg *Message) bool {
+		msg.Message = string(body)
+		return rc.lc.TryEnqueueMessage(msg)
+	})
+}
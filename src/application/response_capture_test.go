@@ -0,0 +1,35 @@
+package application
+
+import "testing"
+
+func TestResponseCaptureEnqueueResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		responses   int
+		wantHanded  int
+		wantDropped int
+	}{
+		{name: "responses handed to the worker", responses: 2, wantHanded: 2},
+		{name: "responses discarded when the worker is behind", responses: captureQueueSize + 2, wantHanded: captureQueueSize, wantDropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _ := newTestApp(t, newTestSettings(t))
+			handed := 0
+			for i := 0; i < tt.responses; i++ {
+				if app.capture.EnqueueResponse(newTestMessage("/accounts/v2/accounts", ""), []byte(`{"data":[]}`), "") {
+					handed++
+				}
+			}
+
+			if handed != tt.wantHanded || len(app.capture.captured) != tt.wantHanded {
+				t.Errorf("handed = %d, pending = %d, want %d", handed, len(app.capture.captured), tt.wantHanded)
+			}
+
+			if dropped := app.lc.logDroppedMessages(); dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package application
 
 import (
 	"encoding/json"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
 )
 
 // MessageResult contains the information for a validation
@@ -20,8 +22,12 @@ type MessageResult struct {
 	HTTPMethod         string              // Type of HTTP method
 	Result             bool                // Indicates the result of the validation (True= Valid  ok)
 	ServerID           string              // Identifies the server requesting the information
-	Errors             map[string][]string // Details for the errors found during the validation
+	Errors             map[string][]string // Messages of the errors found during the validation by field, kept for compatibility
+	Details            []validation.Error  // Structured errors found during the validation
+	Findings           []validation.Error  // Inconsistencies with previous messages of the same consent, not included in Result
 	XFapiInteractionID string
+	Role               string // Role of the instance for this message (TRANSMITTER / RECEIVER)
+	DataOwnerID        string // Organisation ID of the institution that owns the message
 }
 
 // EndpointSummary contains the summary information for the validations by endpoint
@@ -38,50 +44,61 @@ type ErrorDetail struct {
 	ErrorType string // Description of the error found
 }
 
-// TransmitterResults Stores the result for a specific transmitterID
+// TransmitterResults Stores the result for a specific transmitterID and role
 type TransmitterResults struct {
 	TransmitterID  string
+	Role           string                     // Role of the instance for these results (TRANSMITTER / RECEIVER)
+	DataOwnerID    string                     // Organisation ID of the institution that owns these results
 	GroupedResults map[string][]MessageResult // slice to store grouped results
 }
 
-var (
-	resultProcessorSingleton ResultProcessor // Singleton instance of the ResultProcessor
-	resultProcessorMutex     = sync.Mutex{}  // Mutex for thread-safe access to messageResults
-	txGroupedResults         = make(map[string]TransmitterResults)
-	totalResults             = 0 // total Number of results validated
-)
+// resultGroupKey identifies a group of results that are sent in the same report
+type resultGroupKey struct {
+	Role          string // Role of the instance (TRANSMITTER / RECEIVER)
+	DataOwnerID   string // Organisation ID of the institution that owns the results
+	TransmitterID string // Organisation ID of the transmitter
+}
 
 // ResultProcessor struct in charge of processing results
 type ResultProcessor struct {
 	crosscutting.OFBStruct
-	reportStartTime time.Time             // Datetime of the start of the report
-	mqdServer       services.ReportServer // Report server for MQD
-	cm              *ConfigurationManager // Manager for application settings
+	reportStartTime time.Time                             // Datetime of the start of the report
+	mqdServer       services.ReportServer                 // Report server for MQD
+	cm              *ConfigurationManager                 // Manager for application settings
+	sp              *SamplingPolicy                       // Policy used to select the messages to validate
+	lc              *LoadController                       // Controller with the load shedding information
+	metrics         *monitoring.Metrics                   // Metrics of the application instance
+	mutex           sync.Mutex                            // Mutex for thread-safe access to the results
+	groupedResults  map[resultGroupKey]TransmitterResults // Results grouped by role, data owner and transmitter
+	totalResults    int                                   // total Number of results validated
 }
 
-// GetResultProcessor returns the singleton instance of the ResultProcessor
+// NewResultProcessor returns a new ResultProcessor, with its own results
 //
 // Parameters:
 //   - logger: Logger to be used by the processor
 //   - mqdServer: MQD Server to send the results
 //   - cm: Configuration manager
+//   - sp: Sampling policy with the sampling information of the messages
+//   - lc: Load controller with the load shedding information
+//   - metrics: Metrics of the application instance
 //
 // Returns:
 //   - *ResultProcessor: New result processor created
-func GetResultProcessor(logger log.Logger, mqdServer services.ReportServer, cm *ConfigurationManager) *ResultProcessor {
-	if resultProcessorSingleton.Pack == "" {
-		resultProcessorSingleton = ResultProcessor{
-			OFBStruct: crosscutting.OFBStruct{
-				Pack:   "ResultProcessor",
-				Logger: logger,
-			},
-			cm:              cm,
-			mqdServer:       mqdServer,
-			reportStartTime: time.Time{},
-		}
+func NewResultProcessor(logger log.Logger, mqdServer services.ReportServer, cm *ConfigurationManager, sp *SamplingPolicy, lc *LoadController, metrics *monitoring.Metrics) *ResultProcessor {
+	return &ResultProcessor{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "ResultProcessor",
+			Logger: logger,
+		},
+		cm:              cm,
+		sp:              sp,
+		lc:              lc,
+		metrics:         metrics,
+		mqdServer:       mqdServer,
+		reportStartTime: time.Time{},
+		groupedResults:  make(map[resultGroupKey]TransmitterResults),
 	}
-
-	return &resultProcessorSingleton
 }
 
 // AppendResult is for appending a message result
@@ -91,27 +108,53 @@ func GetResultProcessor(logger log.Logger, mqdServer services.ReportServer, cm *
 //
 // Returns:
 func (rp *ResultProcessor) AppendResult(result *MessageResult) {
-	resultProcessorMutex.Lock()
-	totalResults++
-
-	transmitterID := result.TransmitterID
-	if transmitterID == "" {
-		transmitterID = rp.cm.settings.ApplicationSettings.OrganisationID
-	}
-
-	if _, ok := txGroupedResults[transmitterID]; !ok {
-		txGroupedResults[transmitterID] = TransmitterResults{
+	rp.mutex.Lock()
+	rp.totalResults++
+
+	key := getResultGroupKey(rp.cm, result.Role, result.DataOwnerID, result.TransmitterID)
+	transmitterID := key.TransmitterID
+	txResult, ok := rp.groupedResults[key]
+	if !ok || txResult.GroupedResults == nil {
+		txResult = TransmitterResults{
 			TransmitterID:  transmitterID,
+			Role:           key.Role,
+			DataOwnerID:    key.DataOwnerID,
 			GroupedResults: make(map[string][]MessageResult),
 		}
 	}
 
-	txResult := txGroupedResults[transmitterID]
 	txResult.GroupedResults[result.ServerID] = append(txResult.GroupedResults[result.ServerID], *result)
-	txGroupedResults[transmitterID] = txResult
+	rp.groupedResults[key] = txResult
+
+	rp.Logger.Debug("Total grouped Results for TransmitterID: ["+transmitterID+"] with role ["+key.Role+"] in ServerID ["+result.ServerID+"] :"+strconv.Itoa(len(txResult.GroupedResults[result.ServerID])), rp.Pack, "getAndClearResults")
+	rp.mutex.Unlock()
+}
+
+// getResultGroupKey returns the key of the report group for a message, using the main organisation and the first
+// role of the application when they are not informed
+//
+// Parameters:
+//   - cm: Configuration manager
+//   - role: Role of the message
+//   - dataOwnerID: Organisation ID of the data owner
+//   - transmitterID: Organisation ID of the transmitter
+//
+// Returns:
+//   - resultGroupKey: key of the report group
+func getResultGroupKey(cm *ConfigurationManager, role string, dataOwnerID string, transmitterID string) resultGroupKey {
+	if dataOwnerID == "" {
+		dataOwnerID = cm.settings.ApplicationSettings.OrganisationID
+	}
+
+	if transmitterID == "" {
+		transmitterID = dataOwnerID
+	}
+
+	if role == "" {
+		role = cm.GetApplicationRoles()[0]
+	}
 
-	rp.Logger.Debug("Total grouped Results for TransmitterID: ["+transmitterID+"] in ServerID ["+result.ServerID+"] :"+strconv.Itoa(len(txResult.GroupedResults[result.ServerID])), rp.Pack, "getAndClearResults")
-	resultProcessorMutex.Unlock()
+	return resultGroupKey{Role: role, DataOwnerID: dataOwnerID, TransmitterID: transmitterID}
 }
 
 // GetAndClearResults returns the actual results, and cleans the lists
@@ -119,19 +162,19 @@ func (rp *ResultProcessor) AppendResult(result *MessageResult) {
 // Parameters:
 //
 // Returns:
-//   - map: map[string][]MessageResult List of message results by clientID
-func (rp *ResultProcessor) getAndClearResults() map[string]TransmitterResults {
+//   - map: map[resultGroupKey]TransmitterResults List of message results by role and transmitterID
+func (rp *ResultProcessor) getAndClearResults() map[resultGroupKey]TransmitterResults {
 	rp.Logger.Info("Loading results", rp.Pack, "getAndClearResults")
-	resultProcessorMutex.Lock()
-	rp.Logger.Debug("Total Results Found :"+strconv.Itoa(totalResults), rp.Pack, "getAndClearResults")
+	rp.mutex.Lock()
+	rp.Logger.Debug("Total Results Found :"+strconv.Itoa(rp.totalResults), rp.Pack, "getAndClearResults")
 	defer func() {
 		//groupedResults = make(map[string][]MessageResult)
-		txGroupedResults = make(map[string]TransmitterResults)
-		totalResults = 0
-		resultProcessorMutex.Unlock()
+		rp.groupedResults = make(map[resultGroupKey]TransmitterResults)
+		rp.totalResults = 0
+		rp.mutex.Unlock()
 	}()
 
-	return txGroupedResults
+	return rp.groupedResults
 }
 
 // StartResultsProcessor starts the periodic process that prints total results and clears them every 2 minutes
@@ -143,12 +186,19 @@ func (rp *ResultProcessor) StartResultsProcessor() {
 	rp.Logger.Info("Starting result processor, ReportExecutionWindow: "+strconv.Itoa(rp.cm.ConfigurationSettings.ReportSettings.ReportExecutionWindow), rp.Pack, "StartResultsProcessor")
 	rp.reportStartTime = time.Now()
 	timeWindow := time.Duration(rp.cm.GetReportExecutionWindow()) * time.Minute
-	// create an empty result for the initial run
-	newResult := TransmitterResults{
-		TransmitterID: rp.cm.settings.ApplicationSettings.OrganisationID,
+	// create an empty result for each organisation and role for the initial run
+	rp.mutex.Lock()
+	for _, organisationID := range rp.cm.GetOrganisationIDs() {
+		for _, role := range rp.cm.GetApplicationRoles() {
+			rp.groupedResults[resultGroupKey{Role: role, DataOwnerID: organisationID, TransmitterID: organisationID}] = TransmitterResults{
+				TransmitterID: organisationID,
+				Role:          role,
+				DataOwnerID:   organisationID,
+			}
+		}
 	}
+	rp.mutex.Unlock()
 
-	txGroupedResults[rp.cm.settings.ApplicationSettings.OrganisationID] = newResult
 	// Send an initial report for observability.
 	rp.processAndSendResults()
 	ticker := time.NewTicker(timeWindow)
@@ -157,7 +207,7 @@ func (rp *ResultProcessor) StartResultsProcessor() {
 		case <-ticker.C:
 			rp.processAndSendResults()
 		case <-time.After(5 * time.Second):
-			if totalResults >= rp.cm.GetSendOnReportNumber() {
+			if rp.totalResults >= rp.cm.GetSendOnReportNumber() {
 				rp.processAndSendResults()
 				ticker.Stop()                       // Stop the current ticker
 				ticker = time.NewTicker(timeWindow) // Restart the ticker
@@ -166,7 +216,16 @@ func (rp *ResultProcessor) StartResultsProcessor() {
 	}
 }
 
-// processAndSendResults Processes the current results (creates a summary report) and sends it to the main server
+// processInformation contains the information of the whole application process, that is sent only once in each
+// report window, so the main server does not count it once per data owner
+type processInformation struct {
+	Metrics              []models.MetricObject        // Metrics of the process
+	UnsupportedEndpoints []models.UnsupportedEndpoint // List with the unsupported endpoint requests
+	LoadShedding         *models.LoadSheddingSummary  // Load shedding information, nil if disabled
+}
+
+// processAndSendResults Processes the current results (creates a summary report for each group) and sends them to the
+// main server. The information of the whole process is only included in the first report sent
 //
 // Parameters:
 //
@@ -174,78 +233,116 @@ func (rp *ResultProcessor) StartResultsProcessor() {
 func (rp *ResultProcessor) processAndSendResults() {
 	rp.Logger.Info("Processing and sending results", "result", "processAndSendResults")
 	processStartTime := time.Now()
-	report := models.Report{DataOwnerID: rp.cm.settings.ApplicationSettings.OrganisationID}
-	rp.updateMetrics(&report)
+	windowMetrics := []models.MetricObject{
+		{Key: "runtime.ReportStartDate", Value: rp.reportStartTime.String()},
+		{Key: "runtime.ReportEndDate", Value: time.Now().String()},
+	}
+
+	process := rp.getProcessInformation()
 	rp.reportStartTime = time.Now()
 	results := rp.getAndClearResults()
+	groups := make([]resultGroupKey, 0, len(results))
+	for key := range results {
+		groups = append(groups, key)
+	}
+
+	// Groups with all the messages sampled out have no results, but their sampling information is reported
+	samplingRates := rp.sp.GetAndCleanSamplingRates(groups)
+	for key := range samplingRates {
+		if _, found := results[key]; !found {
+			results[key] = TransmitterResults{TransmitterID: key.TransmitterID, Role: key.Role, DataOwnerID: key.DataOwnerID}
+			groups = append(groups, key)
+		}
+	}
+
+	rp.sortResultGroups(groups)
 	rp.Logger.Debug("Total Results to process :"+strconv.Itoa(len(results)), rp.Pack, "processAndSendResults")
 
-	for _, transmitterResult := range results {
-		report.ClientID = transmitterResult.TransmitterID
+	for _, key := range groups {
+		transmitterResult := results[key]
+		report := models.Report{ClientID: transmitterResult.TransmitterID, DataOwnerID: transmitterResult.DataOwnerID}
+		rp.setApplicationConfiguration(&report)
+		report.ApplicationConfiguration.ApplicationMode = transmitterResult.Role
+		report.Metrics.Values = append(report.Metrics.Values, windowMetrics...)
+		if process != nil {
+			report.Metrics.Values = append(report.Metrics.Values, process.Metrics...)
+			report.UnsupportedEndpoints = process.UnsupportedEndpoints
+			report.LoadShedding = process.LoadShedding
+		}
+
+		report.SamplingRates = samplingRates[key]
 		report.ServerSummary = rp.getSummary(transmitterResult.GroupedResults)
 		rp.Logger.Debug("Total ServerSummary process :"+strconv.Itoa(len(report.ServerSummary)), rp.Pack, "processAndSendResults")
 		report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ReportGenerationTime", Value: time.Since(processStartTime).String()})
+		report.SetVersion(rp.cm.GetReportVersion())
 		err := rp.mqdServer.SendReport(report)
 		if err != nil {
-			rp.Logger.Error(err, "Error sending report", rp.Pack, "processAndSendResults")
-			return
+			rp.Logger.Error(err, "Error sending report for DataOwnerID: "+report.DataOwnerID, rp.Pack, "processAndSendResults")
+			continue
 		}
+
+		// The information of the process is kept for the next report until a report is sent
+		process = nil
 		rp.printReport(report)
 	}
 
 	rp.Logger.Info("processAndSendResults -> Process finished", "server", "postReport")
 }
 
-// updateMetrics Updates the metrics for the report
+// sortResultGroups sorts the groups of results in the order the reports are sent, starting with the organisation of
+// the application
 //
 // Parameters:
-//   - report: Report with the metric information
+//   - groups: Groups of results to sort
 //
 // Returns:
-func (rp *ResultProcessor) updateMetrics(report *models.Report) {
-	rp.Logger.Info("Updating metrics", rp.Pack, "updateMetrics")
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ReportStartDate", Value: rp.reportStartTime.String()})
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ReportEndDate", Value: time.Now().String()})
-	systemMetrics := monitoring.GetAndCleanSystemMetrics()
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.BadRequestErrors", Value: systemMetrics.BadRequestsReceived})
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.TotalRequests", Value: systemMetrics.RequestsReceived})
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.MemoryUsageAvg", Value: systemMetrics.AverageMemory})
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.MemoryUsageMax", Value: systemMetrics.MaxUsedMemory})
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.CPUNumber", Value: systemMetrics.AllowedCPUs})
-	report.Metrics.Values = append(report.Metrics.Values, models.MetricObject{Key: "runtime.ResponseTimeAvg", Value: systemMetrics.AverageResponseTime})
-
-	report.ApplicationConfiguration.ApplicationVersion = monitoring.Version
-	report.ApplicationConfiguration.Environment = rp.cm.settings.ConfigurationSettings.Environment
-	report.ApplicationConfiguration.ApplicationID = rp.cm.settings.ConfigurationSettings.ApplicationID.String()
-	report.ApplicationConfiguration.ReportExecutionWindow = strconv.Itoa(rp.cm.GetReportExecutionWindow())
-	report.ApplicationConfiguration.ReportExecutionNumber = strconv.Itoa(rp.cm.GetSendOnReportNumber())
+func (rp *ResultProcessor) sortResultGroups(groups []resultGroupKey) {
+	organisationID := rp.cm.settings.ApplicationSettings.OrganisationID
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if (a.DataOwnerID == organisationID) != (b.DataOwnerID == organisationID) {
+			return a.DataOwnerID == organisationID
+		}
 
-	report.ApplicationConfiguration.ResultSettingsEnabled = rp.cm.settings.ResultSettings.Enabled
-	report.ApplicationConfiguration.ResultSettingsDaysToStore = rp.cm.settings.ResultSettings.DaysToStore
-	report.ApplicationConfiguration.ResultSettingsFilesPerDay = rp.cm.settings.ResultSettings.FilesPerDay
-	report.ApplicationConfiguration.ResultSettingsSamplesPerError = rp.cm.settings.ResultSettings.SamplesPerError
-	report.ApplicationConfiguration.ResultSettingsMaskPrivateContent = rp.cm.settings.ResultSettings.MaskPrivateContent
+		if a.DataOwnerID != b.DataOwnerID {
+			return a.DataOwnerID < b.DataOwnerID
+		}
 
-	report.ApplicationConfiguration.ConfigurationUpdateStatus.LastExecutionDate = rp.cm.GetLastExecutionDate()
-	report.ApplicationConfiguration.ConfigurationUpdateStatus.LastUpdatedDate = rp.cm.GetLastUpdatedDate()
-	for key, value := range rp.cm.GetUpdateMessages() {
-		report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationUpdateError = append(report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationUpdateError, models.ConfigurationUpdateError{
-			ErrorDate:    key,
-			ErrorMessage: value,
-		})
-	}
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
 
-	report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationVersion = rp.cm.ConfigurationSettings.Version
-	report.ApplicationConfiguration.ApplicationMode = rp.cm.settings.ApplicationSettings.Mode
+		return a.TransmitterID < b.TransmitterID
+	})
+}
 
-	ue := monitoring.GetAndCleanUnsupportedEndpoints()
+// getProcessInformation returns the metrics, unsupported endpoints and load shedding information of the whole
+// process, and cleans them for the next report window
+//
+// Parameters:
+//
+// Returns:
+//   - *processInformation: Information of the process
+func (rp *ResultProcessor) getProcessInformation() *processInformation {
+	rp.Logger.Info("Updating metrics", rp.Pack, "getProcessInformation")
+	process := &processInformation{}
+	systemMetrics := rp.metrics.GetAndCleanSystemMetrics()
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.BadRequestErrors", Value: systemMetrics.BadRequestsReceived})
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.TotalRequests", Value: systemMetrics.RequestsReceived})
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.RateLimitedRequests", Value: systemMetrics.RateLimitedRequests})
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.MemoryUsageAvg", Value: systemMetrics.AverageMemory})
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.MemoryUsageMax", Value: systemMetrics.MaxUsedMemory})
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.CPUNumber", Value: systemMetrics.AllowedCPUs})
+	process.Metrics = append(process.Metrics, models.MetricObject{Key: "runtime.ResponseTimeAvg", Value: systemMetrics.AverageResponseTime})
+
+	ue := rp.metrics.GetAndCleanUnsupportedEndpoints()
 	for key, date := range ue {
 		for versionKey, value := range date {
 			errorMessage := "Endpoint not supported"
 			if versionKey != "N.A." {
 				errorMessage = "Version not supported"
 			}
-			report.UnsupportedEndpoints = append(report.UnsupportedEndpoints, models.UnsupportedEndpoint{
+			process.UnsupportedEndpoints = append(process.UnsupportedEndpoints, models.UnsupportedEndpoint{
 				EndpointName: key,
 				Count:        value,
 				Version:      versionKey,
@@ -253,6 +350,42 @@ func (rp *ResultProcessor) updateMetrics(report *models.Report) {
 			})
 		}
 	}
+
+	process.LoadShedding = rp.lc.GetAndCleanSummary()
+	return process
+}
+
+// setApplicationConfiguration Sets the configuration of the application in the report
+//
+// Parameters:
+//   - report: Report with the configuration information
+//
+// Returns:
+func (rp *ResultProcessor) setApplicationConfiguration(report *models.Report) {
+	report.ApplicationConfiguration.ApplicationVersion = monitoring.Version
+	report.ApplicationConfiguration.Environment = rp.cm.settings.ConfigurationSettings.Environment
+	report.ApplicationConfiguration.ApplicationID = rp.cm.settings.ConfigurationSettings.ApplicationID.String()
+	report.ApplicationConfiguration.ReportExecutionWindow = strconv.Itoa(rp.cm.GetReportExecutionWindow())
+	report.ApplicationConfiguration.ReportExecutionNumber = strconv.Itoa(rp.cm.GetSendOnReportNumber())
+
+	report.ApplicationConfiguration.ResultSettingsEnabled = rp.cm.settings.ResultSettings.Enabled
+	report.ApplicationConfiguration.ResultSettingsDaysToStore = rp.cm.settings.ResultSettings.DaysToStore
+	report.ApplicationConfiguration.ResultSettingsFilesPerDay = rp.cm.settings.ResultSettings.FilesPerDay
+	report.ApplicationConfiguration.ResultSettingsSamplesPerError = rp.cm.settings.ResultSettings.SamplesPerError
+	report.ApplicationConfiguration.ResultSettingsMaskPrivateContent = rp.cm.settings.ResultSettings.MaskPrivateContent
+
+	updateStatus := rp.cm.GetUpdateStatus()
+	report.ApplicationConfiguration.ConfigurationUpdateStatus.LastExecutionDate = updateStatus.LastExecutionDate
+	report.ApplicationConfiguration.ConfigurationUpdateStatus.LastUpdatedDate = updateStatus.LastUpdatedDate
+	report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationUpdateError = updateStatus.UpdateMessages
+
+	report.ApplicationConfiguration.ConfigurationUpdateStatus.ConfigurationVersion = rp.cm.ConfigurationSettings.Version
+	if pinState := rp.cm.GetPinState(); pinState != nil {
+		report.ApplicationConfiguration.ConfigurationUpdateStatus.Pinned = true
+		report.ApplicationConfiguration.ConfigurationUpdateStatus.PinnedDate = pinState.PinnedDate
+	}
+
+	report.ApplicationConfiguration.ApplicationMode = rp.cm.settings.ApplicationSettings.Mode
 }
 
 // getSummary Returns the server summary for a specific set of MessageResults
@@ -286,28 +419,29 @@ func (rp *ResultProcessor) getSummary(results map[string][]MessageResult) []mode
 // Returns:
 //   - ServerSummary: Summary updated with the result
 func (rp *ResultProcessor) updateEndpointSummary(endpointSummary []models.EndPointSummary, messageResult MessageResult) []models.EndPointSummary {
-	newEPSummary := models.EndPointSummary{EndpointName: messageResult.Endpoint, TotalRequests: 1}
-	found := false
+	index := -1
 	for i, ep := range endpointSummary {
-		if ep.EndpointName == newEPSummary.EndpointName {
-			found = true
-			endpointSummary[i].TotalRequests++
-			if !messageResult.Result {
-				endpointSummary[i].ValidationErrors++
-				endpointSummary[i].Detail = rp.updateEndpointSummaryDetail(endpointSummary[i].Detail, messageResult.Errors, messageResult.XFapiInteractionID)
-			}
-
+		if ep.EndpointName == messageResult.Endpoint {
+			index = i
 			break
 		}
 	}
 
-	if !found {
-		if !messageResult.Result {
-			newEPSummary.ValidationErrors = 1
-			newEPSummary.Detail = rp.updateEndpointSummaryDetail(newEPSummary.Detail, messageResult.Errors, messageResult.XFapiInteractionID)
-		}
+	if index < 0 {
+		endpointSummary = append(endpointSummary, models.EndPointSummary{EndpointName: messageResult.Endpoint})
+		index = len(endpointSummary) - 1
+	}
+
+	summary := &endpointSummary[index]
+	summary.TotalRequests++
+	if !messageResult.Result {
+		summary.ValidationErrors++
+		summary.Detail = rp.updateEndpointSummaryDetail(summary.Detail, messageResult.Details, messageResult.XFapiInteractionID)
+	}
 
-		endpointSummary = append(endpointSummary, newEPSummary)
+	if len(messageResult.Findings) > 0 {
+		summary.ConsistencyFindings++
+		summary.ConsistencyDetail = rp.updateEndpointSummaryDetail(summary.ConsistencyDetail, messageResult.Findings, messageResult.XFapiInteractionID)
 	}
 
 	return endpointSummary
@@ -322,53 +456,56 @@ func (rp *ResultProcessor) updateEndpointSummary(endpointSummary []models.EndPoi
 //
 // Returns:
 //   - EndPointSummaryDetail: Updated detail with the errors
-func (rp *ResultProcessor) updateEndpointSummaryDetail(details []models.EndPointSummaryDetail, errors map[string][]string, xfapiID string) []models.EndPointSummaryDetail {
-	for key, val := range errors {
-		newDetail := &models.EndPointSummaryDetail{Field: key}
-		fieldFound := false
+func (rp *ResultProcessor) updateEndpointSummaryDetail(details []models.EndPointSummaryDetail, errors []validation.Error, xfapiID string) []models.EndPointSummaryDetail {
+	for _, validationError := range errors {
+		index := -1
 		for i, field := range details {
-			if key == field.Field {
-				fieldFound = true
-				newDetail = &details[i]
+			if validationError.Field == field.Field {
+				index = i
 				break
 			}
 		}
 
-		newDetail.Details = rp.updateFieldDetails(newDetail.Details, val, xfapiID)
-		if !fieldFound {
-			details = append(details, *newDetail)
+		if index < 0 {
+			details = append(details, models.EndPointSummaryDetail{Field: validationError.Field})
+			index = len(details) - 1
 		}
+
+		details[index].Details = rp.updateFieldDetails(details[index].Details, validationError, xfapiID)
 	}
 
 	return details
 }
 
-// updateFieldDetails Updates the summary detail for a specific field
+// updateFieldDetails Updates the summary detail for a specific field, the errors are grouped by code and constraint,
+// and by message for the messages that cannot be validated
 //
 // Parameters:
 //   - details: Details to be updated
-//   - fieldDetails: Field details to include
+//   - validationError: Error to include
 //   - xfapiID: xFapi ID of the transaction
 //
 // Returns:
 //   - FieldDetail: Updated FieldDetail with the errors
-func (rp *ResultProcessor) updateFieldDetails(details []models.FieldDetail, fieldDetails []string, xfapiID string) []models.FieldDetail {
-	for _, errorDetail := range fieldDetails {
-		detailFound := false
-		for j, fieldDetail := range details {
-			if fieldDetail.ErrorType == errorDetail {
-				detailFound = true
-				details[j].XFapiList = append(details[j].XFapiList, xfapiID)
-				details[j].TotalCount++
-			}
-		}
-
-		if !detailFound {
-			details = append(details, models.FieldDetail{ErrorType: errorDetail, TotalCount: 1, XFapiList: []string{xfapiID}})
+func (rp *ResultProcessor) updateFieldDetails(details []models.FieldDetail, validationError validation.Error, xfapiID string) []models.FieldDetail {
+	for j, fieldDetail := range details {
+		if fieldDetail.Code == string(validationError.Code) && fieldDetail.Constraint == validationError.Constraint &&
+			fieldDetail.RuleID == validationError.RuleID &&
+			(validationError.Code != validation.CodeInvalid || fieldDetail.ErrorType == validationError.Message) {
+			details[j].XFapiList = append(details[j].XFapiList, xfapiID)
+			details[j].TotalCount++
+			return details
 		}
 	}
 
-	return details
+	return append(details, models.FieldDetail{
+		ErrorType:  validationError.Message,
+		Code:       string(validationError.Code),
+		Constraint: validationError.Constraint,
+		RuleID:     validationError.RuleID,
+		TotalCount: 1,
+		XFapiList:  []string{xfapiID},
+	})
 }
 
 // printReport Prits the report to console (Should be used for DEBUG pourpuses only)
@@ -0,0 +1,199 @@
+package application
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+func TestProcessAndSendResultsSampling(t *testing.T) {
+	tests := []struct {
+		name         string
+		rate         int
+		validated    bool
+		wantReceived int
+		wantValid    int
+	}{
+		{name: "messages validated", rate: 100, validated: true, wantReceived: 3, wantValid: 3},
+		{name: "all messages sampled out", rate: 0, wantReceived: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			server.updateSettings(func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.HighTroughputValidationRate = tt.rate
+			})
+
+			app, err := NewApp(newTestLogger(), newTestSettings(t), server)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validationSettings := app.cm.GetEndpointSettingFromAPI("/accounts/v2/accounts", app.Logger)
+			for i := 0; i < 3; i++ {
+				msg := newTestMessage("/accounts/v2/accounts", "")
+				if app.sp.MustValidate(msg, validationSettings) != tt.validated {
+					t.Fatalf("MustValidate() != %v", tt.validated)
+				}
+
+				if tt.validated {
+					app.rp.AppendResult(&MessageResult{Endpoint: msg.Endpoint, ServerID: msg.ServerID, Result: true, Role: msg.Role})
+				}
+			}
+
+			app.rp.processAndSendResults()
+			reports := server.getReports()
+			if len(reports) != 1 {
+				t.Fatalf("reports = %d, want 1", len(reports))
+			}
+
+			report := reports[0]
+			if report.ApplicationConfiguration.ApplicationMode != configuration.TransmitterMode || report.DataOwnerID != testOrganisationID {
+				t.Errorf("unexpected report group: %s %s", report.ApplicationConfiguration.ApplicationMode, report.DataOwnerID)
+			}
+
+			if len(report.SamplingRates) != 2 {
+				t.Fatalf("SamplingRates = %+v, want 2 endpoints", report.SamplingRates)
+			}
+
+			rate := report.SamplingRates[0]
+			if rate.EndpointName != "/accounts/v2/accounts" || rate.ReceivedRequests != tt.wantReceived || rate.ValidatedRequests != tt.wantValid {
+				t.Errorf("SamplingRates[0] = %+v", rate)
+			}
+		})
+	}
+}
+
+func TestProcessAndSendResultsReportVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		serverVersion   int
+		wantVersion     int
+		wantDetails     int
+		wantFindings    int
+		wantCodeInJSON  bool
+		wantConsistency bool
+	}{
+		{name: "version not declared", serverVersion: 0, wantDetails: 1},
+		{name: "version 1", serverVersion: models.ReportVersion1, wantDetails: 1},
+		{name: "version 2", serverVersion: models.ReportVersion2, wantVersion: models.ReportVersion2, wantDetails: 2, wantFindings: 1, wantCodeInJSON: true, wantConsistency: true},
+		{name: "later version", serverVersion: 3, wantVersion: models.ReportVersion2, wantDetails: 2, wantFindings: 1, wantCodeInJSON: true, wantConsistency: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			server.updateSettings(func(settings *models.ConfigurationSettings) {
+				settings.ReportSettings.ReportVersion = tt.serverVersion
+			})
+
+			app, err := NewApp(newTestLogger(), newTestSettings(t), server)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Two errors with the same message and different constraints, grouped as one on version 1
+			app.rp.AppendResult(&MessageResult{
+				Endpoint: "/accounts/v2/accounts", ServerID: testServerOrgID, Role: configuration.TransmitterMode, XFapiInteractionID: testInteractionID,
+				Details: []validation.Error{
+					{Code: validation.CodePattern, Field: "data.id", Constraint: "^[0-9]+$", Message: "Does not match pattern"},
+					{Code: validation.CodePattern, Field: "data.id", Constraint: "^[a-z]+$", Message: "Does not match pattern"},
+				},
+				Findings: []validation.Error{{Code: validation.CodeUnknownResource, Field: "accountId", Message: "Unknown account"}},
+			})
+
+			app.rp.processAndSendResults()
+			reports := server.getReports()
+			if len(reports) != 1 || len(reports[0].ServerSummary) != 1 {
+				t.Fatalf("reports = %+v, want 1 with 1 server", reports)
+			}
+
+			report := reports[0]
+			endpoint := report.ServerSummary[0].EndpointSummary[0]
+			if report.ReportVersion != tt.wantVersion || len(endpoint.Detail) != 1 || len(endpoint.Detail[0].Details) != tt.wantDetails || endpoint.ConsistencyFindings != tt.wantFindings {
+				t.Fatalf("report version = %d, endpoint = %+v", report.ReportVersion, endpoint)
+			}
+
+			total := 0
+			for _, detail := range endpoint.Detail[0].Details {
+				total += detail.TotalCount
+			}
+
+			if total != 2 {
+				t.Errorf("TotalCount = %d, want 2", total)
+			}
+
+			content, err := json.Marshal(report)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(string(content), `"Code"`) != tt.wantCodeInJSON || strings.Contains(string(content), `"ConsistencyDetail"`) != tt.wantConsistency ||
+				strings.Contains(string(content), `"ReportVersion"`) != (tt.wantVersion != 0) {
+				t.Errorf("report JSON = %s", content)
+			}
+		})
+	}
+}
+
+func TestProcessAndSendResultsDataOwners(t *testing.T) {
+	const otherOrganisationID = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	tests := []struct {
+		name        string
+		dataOwners  []string // Data owner of each result
+		wantReports []string // Data owner of each report, in the order sent
+	}{
+		{name: "one data owner", dataOwners: []string{testOrganisationID, testOrganisationID}, wantReports: []string{testOrganisationID}},
+		{name: "two data owners", dataOwners: []string{otherOrganisationID, testOrganisationID, otherOrganisationID}, wantReports: []string{testOrganisationID, otherOrganisationID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubReportServer("1.0.0")
+			app, err := NewApp(newTestLogger(), newTestSettings(t), server)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, dataOwnerID := range tt.dataOwners {
+				app.rp.AppendResult(&MessageResult{Endpoint: "/accounts/v2/accounts", ServerID: testServerOrgID, Result: true, Role: configuration.TransmitterMode, DataOwnerID: dataOwnerID})
+			}
+
+			app.rp.processAndSendResults()
+			reports := server.getReports()
+			if len(reports) != len(tt.wantReports) {
+				t.Fatalf("reports = %d, want %d", len(reports), len(tt.wantReports))
+			}
+
+			processMetrics := 0
+			for i, report := range reports {
+				if report.DataOwnerID != tt.wantReports[i] {
+					t.Errorf("report %d DataOwnerID = %s, want %s", i, report.DataOwnerID, tt.wantReports[i])
+				}
+
+				generationTimes := 0
+				for _, metric := range report.Metrics.Values {
+					switch metric.Key {
+					case "runtime.ReportGenerationTime":
+						generationTimes++
+					case "runtime.TotalRequests":
+						processMetrics++
+					}
+				}
+
+				if generationTimes != 1 {
+					t.Errorf("report %d has %d ReportGenerationTime metrics, want 1", i, generationTimes)
+				}
+			}
+
+			if processMetrics != 1 {
+				t.Errorf("TotalRequests sent %d times, want 1", processMetrics)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package application
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// samplingKey identifies the sampling statistics of an endpoint
+type samplingKey struct {
+	Group        resultGroupKey // Report group of the messages
+	EndpointName string         // Name of the endpoint
+}
+
+// samplingStatistics stores the sampling decisions of an endpoint during the report window
+type samplingStatistics struct {
+	configuredRate    int // Last validation rate applied to the endpoint
+	receivedRequests  int // Number of requests received
+	validatedRequests int // Number of requests selected for validation
+	floorValidations  int // Number of requests selected to reach the minimum validations per window
+}
+
+// SamplingPolicy decides which messages must be validated, based on the validation rates of the role and throughput
+// of the endpoint, the local overrides and the minimum number of validations per report window
+type SamplingPolicy struct {
+	crosscutting.OFBStruct
+	cm         *ConfigurationManager               // Manager for application settings
+	lc         *LoadController                     // Controller that reduces the rates under load
+	statistics map[samplingKey]*samplingStatistics // Sampling statistics of the current report window
+	floorKeys  map[string]bool                     // Sampling keys selected to reach the minimum validations in the current report window
+	mutex      sync.Mutex                          // Mutex for thread-safe access to the statistics
+	salt       []byte                              // Salt used by the deterministic sampling
+	saltPeriod int64                               // Rotation period of the current salt
+	saltMutex  sync.Mutex                          // Mutex for thread-safe access to the salt
+}
+
+// NewSamplingPolicy creates a new sampling policy
+//
+// Parameters:
+//   - logger: logger to be used
+//   - cm: Configuration manager to be used
+//   - lc: Load controller that reduces the rates under load
+//
+// Returns:
+//   - *SamplingPolicy: new created sampling policy
+func NewSamplingPolicy(logger log.Logger, cm *ConfigurationManager, lc *LoadController) *SamplingPolicy {
+	return &SamplingPolicy{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "application.SamplingPolicy",
+			Logger: logger,
+		},
+		cm:         cm,
+		lc:         lc,
+		statistics: make(map[samplingKey]*samplingStatistics),
+		floorKeys:  make(map[string]bool),
+	}
+}
+
+// samplingDecision is the decision of the sampling policy for a message, with the information needed to undo it
+type samplingDecision struct {
+	validate bool   // Indicates that the message must be validated
+	floor    bool   // The message was selected to reach the minimum validations per window
+	floorKey string // Sampling key selected by this message to reach the minimum validations, empty if none
+}
+
+// MustValidate indicates if a message should be validated, and records the decision for the report with the name of
+// the endpoint in the configuration. In deterministic mode the minimum validations per window select whole journeys,
+// once a journey is selected all its messages in the window are validated
+//
+// Parameters:
+//   - msg: Message received
+//   - validationSettings: Settings of the endpoint of the message
+//
+// Returns:
+//   - bool: true if the message should be validated
+func (sp *SamplingPolicy) MustValidate(msg *Message, validationSettings *APIValidationSettings) bool {
+	return sp.selectMessage(msg, validationSettings).validate
+}
+
+// ValidateAndEnqueue indicates if a message should be validated and, when selected, queues it with the function
+// informed. A selected message that is discarded by the queue is recorded as received and not validated, so the
+// effective rate and the minimum validations per window only count the messages queued
+//
+// Parameters:
+//   - msg: Message received
+//   - validationSettings: Settings of the endpoint of the message
+//   - enqueue: Function that queues the message, returns false if the message was discarded
+//
+// Returns:
+//   - bool: true if the message was queued for validation
+func (sp *SamplingPolicy) ValidateAndEnqueue(msg *Message, validationSettings *APIValidationSettings, enqueue func(msg *Message) bool) bool {
+	decision := sp.selectMessage(msg, validationSettings)
+	if !decision.validate {
+		return false
+	}
+
+	if enqueue(msg) {
+		return true
+	}
+
+	sp.cancelValidation(msg, validationSettings, decision)
+	return false
+}
+
+// selectMessage decides if a message should be validated, and records the decision for the report
+//
+// Parameters:
+//   - msg: Message received
+//   - validationSettings: Settings of the endpoint of the message
+//
+// Returns:
+//   - samplingDecision: decision for the message
+func (sp *SamplingPolicy) selectMessage(msg *Message, validationSettings *APIValidationSettings) samplingDecision {
+	rate := sp.GetEffectiveRate(validationSettings.EndpointName, validationSettings.EndpointSettings, msg.Role)
+	decision := samplingDecision{validate: rate >= 100 || sp.getSamplingNumber(msg) < rate}
+	floorKey := ""
+	if sp.cm.settings.SamplingSettings.Mode == configuration.DeterministicSampling {
+		floorKey = sp.getSamplingKeyValue(msg, sp.cm.settings.SamplingSettings.Key)
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	statistics := sp.getStatistics(msg, validationSettings)
+	statistics.configuredRate = rate
+	statistics.receivedRequests++
+	if !decision.validate && (sp.floorKeys[floorKey] || statistics.validatedRequests < sp.cm.settings.SamplingSettings.MinValidationsPerWindow) {
+		decision.validate = true
+		decision.floor = true
+		statistics.floorValidations++
+		if floorKey != "" && !sp.floorKeys[floorKey] {
+			sp.floorKeys[floorKey] = true
+			decision.floorKey = floorKey
+		}
+	}
+
+	if decision.validate {
+		statistics.validatedRequests++
+	}
+
+	return decision
+}
+
+// cancelValidation undoes the validation recorded for a message that could not be queued, the message is kept as
+// received. A journey selected by the message to reach the minimum validations is released
+//
+// Parameters:
+//   - msg: Message received
+//   - validationSettings: Settings of the endpoint of the message
+//   - decision: decision recorded for the message
+//
+// Returns:
+func (sp *SamplingPolicy) cancelValidation(msg *Message, validationSettings *APIValidationSettings, decision samplingDecision) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	// The window may have been cleaned since the decision
+	key := samplingKey{Group: getResultGroupKey(sp.cm, msg.Role, msg.DataOwnerID, msg.TransmitterID), EndpointName: validationSettings.EndpointName}
+	statistics, found := sp.statistics[key]
+	if !found || statistics.validatedRequests == 0 {
+		return
+	}
+
+	statistics.validatedRequests--
+	if decision.floor && statistics.floorValidations > 0 {
+		statistics.floorValidations--
+	}
+
+	if decision.floorKey != "" {
+		delete(sp.floorKeys, decision.floorKey)
+	}
+}
+
+// getStatistics returns the sampling statistics of the endpoint of a message in the current window, the caller must
+// hold the mutex
+//
+// Parameters:
+//   - msg: Message received
+//   - validationSettings: Settings of the endpoint of the message
+//
+// Returns:
+//   - *samplingStatistics: statistics of the endpoint
+func (sp *SamplingPolicy) getStatistics(msg *Message, validationSettings *APIValidationSettings) *samplingStatistics {
+	key := samplingKey{Group: getResultGroupKey(sp.cm, msg.Role, msg.DataOwnerID, msg.TransmitterID), EndpointName: validationSettings.EndpointName}
+	statistics, found := sp.statistics[key]
+	if !found {
+		statistics = &samplingStatistics{}
+		sp.statistics[key] = statistics
+	}
+
+	return statistics
+}
+
+// GetEffectiveRate returns the validation rate for an endpoint, a local override replaces the combination of the
+// throughput and role rates. The scale of the load controller is applied to the resulting rate
+//
+// Parameters:
+//   - endpointName: Name of the endpoint
+//   - endpointSetting: Settings of the endpoint
+//   - role: Role of the message (TRANSMITTER / RECEIVER)
+//
+// Returns:
+//   - int: validation rate in % (0 - 100)
+func (sp *SamplingPolicy) GetEffectiveRate(endpointName string, endpointSetting *models.APIEndpointSetting, role string) int {
+	rate, found := sp.getEndpointOverride(endpointName)
+	if !found {
+		rate = sp.cm.GetThroughputValidationRate(endpointSetting.Throughput) * sp.cm.GetRoleValidationRate(role) / 100
+	}
+
+	return sp.lc.ApplyScale(rate, endpointSetting.Throughput)
+}
+
+// getEndpointOverride returns the local validation rate configured for an endpoint
+//
+// Parameters:
+//   - endpointName: Name of the endpoint
+//
+// Returns:
+//   - int: validation rate configured
+//   - bool: true if the endpoint has a local rate
+func (sp *SamplingPolicy) getEndpointOverride(endpointName string) (int, bool) {
+	for name, rate := range sp.cm.settings.SamplingSettings.EndpointRates {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(endpointName)) {
+			return rate, true
+		}
+	}
+
+	return 0, false
+}
+
+// checkEndpointRates logs a warning for each local validation rate of an endpoint that is not in the endpoint
+// catalogue, these rates are never applied
+//
+// Parameters:
+//
+// Returns:
+//   - []string: Endpoints of the local rates not found in the catalogue, sorted
+func (sp *SamplingPolicy) checkEndpointRates() []string {
+	catalogue := make(map[string]bool)
+	for _, entry := range sp.cm.GetEndpointCatalogue() {
+		catalogue[strings.ToLower(strings.TrimSpace(entry.EndpointName))] = true
+	}
+
+	unknown := make([]string, 0)
+	for name := range sp.cm.settings.SamplingSettings.EndpointRates {
+		if !catalogue[strings.ToLower(strings.TrimSpace(name))] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		sp.Logger.Warning("Endpoint of EndpointRates not found in the endpoint catalogue: "+name+", the rate will not be applied", sp.Pack, "checkEndpointRates")
+	}
+
+	return unknown
+}
+
+// GetAndCleanSamplingRates returns the sampling information of the report window and starts a new window. Every
+// group includes all the endpoints of the configuration, also the endpoints without requests or with all the
+// requests sampled out
+//
+// Parameters:
+//   - groups: Report groups with results, the groups with sampling information are always included
+//
+// Returns:
+//   - map: map[resultGroupKey][]models.EndpointSamplingRate Sampling information by report group
+func (sp *SamplingPolicy) GetAndCleanSamplingRates(groups []resultGroupKey) map[resultGroupKey][]models.EndpointSamplingRate {
+	sp.mutex.Lock()
+	statistics := sp.statistics
+	sp.statistics = make(map[samplingKey]*samplingStatistics)
+	sp.floorKeys = make(map[string]bool)
+	sp.mutex.Unlock()
+
+	result := make(map[resultGroupKey][]models.EndpointSamplingRate)
+	for _, group := range groups {
+		result[group] = nil
+	}
+
+	for key := range statistics {
+		result[key.Group] = nil
+	}
+
+	catalogue := sp.cm.GetEndpointCatalogue()
+	for group := range result {
+		rates := make([]models.EndpointSamplingRate, 0, len(catalogue))
+		for _, entry := range catalogue {
+			key := samplingKey{Group: group, EndpointName: entry.EndpointName}
+			value, found := statistics[key]
+			if !found {
+				value = &samplingStatistics{configuredRate: sp.GetEffectiveRate(entry.EndpointName, &models.APIEndpointSetting{Throughput: entry.Throughput}, group.Role)}
+			}
+
+			delete(statistics, key)
+			rates = append(rates, getEndpointSamplingRate(entry.EndpointName, value))
+		}
+
+		result[group] = rates
+	}
+
+	// Endpoints removed from the configuration during the report window
+	for key, value := range statistics {
+		result[key.Group] = append(result[key.Group], getEndpointSamplingRate(key.EndpointName, value))
+	}
+
+	for _, rates := range result {
+		sort.Slice(rates, func(i, j int) bool {
+			return rates[i].EndpointName < rates[j].EndpointName
+		})
+	}
+
+	return result
+}
+
+// getEndpointSamplingRate returns the sampling information of an endpoint for the report
+//
+// Parameters:
+//   - endpointName: Name of the endpoint
+//   - statistics: Sampling statistics of the endpoint
+//
+// Returns:
+//   - models.EndpointSamplingRate: Sampling information of the endpoint
+func getEndpointSamplingRate(endpointName string, statistics *samplingStatistics) models.EndpointSamplingRate {
+	effectiveRate := 0.0
+	if statistics.receivedRequests > 0 {
+		effectiveRate = float64(statistics.validatedRequests) * 100 / float64(statistics.receivedRequests)
+	}
+
+	return models.EndpointSamplingRate{
+		EndpointName:      endpointName,
+		ConfiguredRate:    statistics.configuredRate,
+		ReceivedRequests:  statistics.receivedRequests,
+		ValidatedRequests: statistics.validatedRequests,
+		FloorValidations:  statistics.floorValidations,
+		EffectiveRate:     fmt.Sprintf("%.2f", effectiveRate),
+	}
+}
+
+// getSamplingNumber returns the number between 0 and 99 compared with the validation rate. In deterministic mode the
+// number is derived from the sampling key of the message, so all messages of the same journey get the same number
+//
+// Parameters:
+//   - msg: Message received
+//
+// Returns:
+//   - int: Sampling number
+func (sp *SamplingPolicy) getSamplingNumber(msg *Message) int {
+	settings := sp.cm.settings.SamplingSettings
+	if settings.Mode != configuration.DeterministicSampling {
+		return sp.getRandomNumber()
+	}
+
+	key := sp.getSamplingKeyValue(msg, settings.Key)
+	if key == "" {
+		sp.Logger.Debug("Sampling key ["+settings.Key+"] not found in message, using random sampling", sp.Pack, "getSamplingNumber")
+		return sp.getRandomNumber()
+	}
+
+	hash := sha256.New()
+	hash.Write(sp.getSalt(settings.SaltRotation))
+	hash.Write([]byte(key))
+	return int(binary.BigEndian.Uint64(hash.Sum(nil)[:8]) % 100)
+}
+
+// getSamplingKeyValue returns the value of the message used to group the sampling decisions
+//
+// Parameters:
+//   - msg: Message received
+//   - samplingKey: Name of the sampling key (CONSENT_ID / INTERACTION_ID / SERVER_ORG_ID)
+//
+// Returns:
+//   - string: Value of the key, empty if not present in the message
+func (sp *SamplingPolicy) getSamplingKeyValue(msg *Message, samplingKey string) string {
+	switch samplingKey {
+	case configuration.InteractionIDSamplingKey:
+		return strings.TrimSpace(msg.XFapiInteractionID)
+	case configuration.ServerOrgIDSamplingKey:
+		return strings.TrimSpace(msg.ServerID)
+	default:
+		return strings.TrimSpace(msg.ConsentID)
+	}
+}
+
+// getSalt returns the salt of the current rotation period, a new random salt is created when the period changes
+//
+// Parameters:
+//   - rotation: Duration of each rotation period in minutes
+//
+// Returns:
+//   - []byte: Salt of the current period
+func (sp *SamplingPolicy) getSalt(rotation int) []byte {
+	sp.saltMutex.Lock()
+	defer sp.saltMutex.Unlock()
+
+	period := time.Now().Unix() / int64(rotation*60)
+	if sp.salt != nil && sp.saltPeriod == period {
+		return sp.salt
+	}
+
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		sp.Logger.Error(err, "Error generating sampling salt", sp.Pack, "getSalt")
+		binary.BigEndian.PutUint64(salt, uint64(period))
+	}
+
+	sp.salt = salt
+	sp.saltPeriod = period
+	sp.Logger.Debug("Sampling salt rotated", sp.Pack, "getSalt")
+	return sp.salt
+}
+
+// getRandomNumber generates a new random number between 0 and 99 using Cryptographic Randomness
+//
+// Returns:
+//   - int: Random number generated
+func (sp *SamplingPolicy) getRandomNumber() int {
+	// Generate a random number between 0 (inclusive) and 100 (exclusive)
+	num, err := rand.Int(rand.Reader, big.NewInt(100))
+	if err != nil {
+		sp.Logger.Error(err, "Error generating random number:", sp.Pack, "getRandomNumber")
+		return 100
+	}
+
+	return int(num.Int64())
+}
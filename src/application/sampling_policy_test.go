@@ -0,0 +1,347 @@
+package application
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// newTestSamplingPolicy returns a sampling policy with the throughput rates set in the settings served
+func newTestSamplingPolicy(t *testing.T, settings configuration.Settings, change func(settings *models.ConfigurationSettings)) *SamplingPolicy {
+	t.Helper()
+	server := newStubReportServer("1.0.0")
+	if change != nil {
+		server.updateSettings(change)
+	}
+
+	app, err := NewApp(newTestLogger(), settings, server)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+
+	return app.sp
+}
+
+// newTestMessage returns a message of the main organisation for an endpoint
+func newTestMessage(endpoint string, consentID string) *Message {
+	return &Message{Endpoint: endpoint, ConsentID: consentID, Role: configuration.TransmitterMode, XFapiInteractionID: testInteractionID, ServerID: testServerOrgID}
+}
+
+func TestGetEffectiveRate(t *testing.T) {
+	rate := func(value int) *int {
+		return &value
+	}
+
+	tests := []struct {
+		name       string
+		throughput string
+		roleRate   *int
+		overrides  map[string]int
+		endpoint   string
+		want       int
+	}{
+		{name: "throughput rate", throughput: models.HighTroughput, endpoint: "/accounts/v2/accounts", want: 20},
+		{name: "unknown throughput uses medium", throughput: "OTHER", endpoint: "/accounts/v2/accounts", want: 50},
+		{name: "role rate is applied", throughput: models.HighTroughput, roleRate: rate(50), endpoint: "/accounts/v2/accounts", want: 10},
+		{name: "local override", throughput: models.HighTroughput, roleRate: rate(50), overrides: map[string]int{" /ACCOUNTS/v2/accounts ": 75}, endpoint: "/accounts/v2/accounts", want: 75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SamplingSettings.EndpointRates = tt.overrides
+			sp := newTestSamplingPolicy(t, settings, func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.HighTroughputValidationRate = 20
+				settings.ValidationSettings.MediumTroughputValidationRate = 50
+				settings.ValidationSettings.TransmitterValidationRate = tt.roleRate
+			})
+
+			got := sp.GetEffectiveRate(tt.endpoint, &models.APIEndpointSetting{Throughput: tt.throughput}, configuration.TransmitterMode)
+			if got != tt.want {
+				t.Errorf("GetEffectiveRate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMustValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rate          int
+		minValidation int
+		messages      int
+		wantValidated int
+		wantFloor     int
+	}{
+		{name: "all messages", rate: 100, messages: 10, wantValidated: 10},
+		{name: "no messages", rate: 0, messages: 10, wantValidated: 0},
+		{name: "minimum validations", rate: 0, minValidation: 3, messages: 10, wantValidated: 3, wantFloor: 3},
+		{name: "minimum already reached", rate: 100, minValidation: 3, messages: 10, wantValidated: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SamplingSettings.MinValidationsPerWindow = tt.minValidation
+			sp := newTestSamplingPolicy(t, settings, func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.HighTroughputValidationRate = tt.rate
+			})
+
+			validationSettings := sp.cm.GetEndpointSettingFromAPI("/accounts/v2/accounts", sp.Logger)
+			validated := 0
+			for i := 0; i < tt.messages; i++ {
+				if sp.MustValidate(newTestMessage("/accounts/v2/accounts", ""), validationSettings) {
+					validated++
+				}
+			}
+
+			if validated != tt.wantValidated {
+				t.Errorf("validated = %d, want %d", validated, tt.wantValidated)
+			}
+
+			rates := sp.GetAndCleanSamplingRates(nil)
+			for _, rate := range rates[getResultGroupKey(sp.cm, configuration.TransmitterMode, "", "")] {
+				if rate.EndpointName == "/accounts/v2/accounts" && (rate.ValidatedRequests != tt.wantValidated || rate.FloorValidations != tt.wantFloor || rate.ReceivedRequests != tt.messages) {
+					t.Errorf("sampling rate = %+v", rate)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAndCleanSamplingRates(t *testing.T) {
+	sp := newTestSamplingPolicy(t, newTestSettings(t), func(settings *models.ConfigurationSettings) {
+		settings.ValidationSettings.HighTroughputValidationRate = 0
+		settings.ValidationSettings.MediumTroughputValidationRate = 40
+	})
+
+	// The endpoint name of the message is resolved to the name in the configuration
+	validationSettings := sp.cm.GetEndpointSettingFromAPI(" /ACCOUNTS/v2/accounts", sp.Logger)
+	for i := 0; i < 4; i++ {
+		sp.MustValidate(newTestMessage(" /ACCOUNTS/v2/accounts", ""), validationSettings)
+	}
+
+	secondGroup := resultGroupKey{Role: configuration.ReceiverMode, DataOwnerID: testOrganisationID, TransmitterID: testServerOrgID}
+	rates := sp.GetAndCleanSamplingRates([]resultGroupKey{secondGroup})
+	mainGroup := getResultGroupKey(sp.cm, configuration.TransmitterMode, "", "")
+	if len(rates) != 2 {
+		t.Fatalf("groups = %d, want 2", len(rates))
+	}
+
+	tests := []struct {
+		name  string
+		group resultGroupKey
+		want  []models.EndpointSamplingRate
+	}{
+		{
+			name:  "endpoints sampled out are included",
+			group: mainGroup,
+			want: []models.EndpointSamplingRate{
+				{EndpointName: "/accounts/v2/accounts", ConfiguredRate: 0, ReceivedRequests: 4, EffectiveRate: "0.00"},
+				{EndpointName: "/accounts/v2/accounts/{accountId}", ConfiguredRate: 40, EffectiveRate: "0.00"},
+			},
+		},
+		{
+			name:  "groups without requests include all the endpoints",
+			group: secondGroup,
+			want: []models.EndpointSamplingRate{
+				{EndpointName: "/accounts/v2/accounts", ConfiguredRate: 0, EffectiveRate: "0.00"},
+				{EndpointName: "/accounts/v2/accounts/{accountId}", ConfiguredRate: 40, EffectiveRate: "0.00"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rates[tt.group]
+			if len(got) != len(tt.want) {
+				t.Fatalf("rates = %+v, want %+v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("rate[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if rates := sp.GetAndCleanSamplingRates(nil); len(rates) != 0 {
+		t.Errorf("window was not cleaned: %+v", rates)
+	}
+}
+
+func TestMustValidateDeterministic(t *testing.T) {
+	const (
+		list   = "/accounts/v2/accounts"
+		single = "/accounts/v2/accounts/{accountId}"
+	)
+
+	type step struct {
+		endpoint string
+		consent  string
+		want     bool
+	}
+
+	tests := []struct {
+		name          string
+		rate          int
+		minValidation int
+		steps         []step
+	}{
+		{
+			name:  "journeys are not selected with rate 0",
+			steps: []step{{list, "consent-1", false}, {single, "consent-2", false}, {single, "consent-1", false}},
+		},
+		{
+			name:  "journeys are selected with rate 100",
+			rate:  100,
+			steps: []step{{list, "consent-1", true}, {single, "consent-2", true}, {single, "consent-1", true}},
+		},
+		{
+			name:          "minimum validations select whole journeys",
+			minValidation: 1,
+			steps: []step{
+				{list, "consent-1", true},
+				{list, "consent-2", false},
+				{single, "consent-1", true},
+				{single, "consent-2", false},
+				{list, "consent-1", true},
+			},
+		},
+		{
+			name:          "messages without key use the minimum per message",
+			minValidation: 1,
+			steps:         []step{{list, "", true}, {list, "", false}, {list, "consent-1", false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SamplingSettings.Mode = configuration.DeterministicSampling
+			settings.SamplingSettings.SaltRotation = 60
+			settings.SamplingSettings.MinValidationsPerWindow = tt.minValidation
+			sp := newTestSamplingPolicy(t, settings, func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.HighTroughputValidationRate = tt.rate
+				settings.ValidationSettings.MediumTroughputValidationRate = tt.rate
+			})
+
+			for i, step := range tt.steps {
+				validationSettings := sp.cm.GetEndpointSettingFromAPI(step.endpoint, sp.Logger)
+				if got := sp.MustValidate(newTestMessage(step.endpoint, step.consent), validationSettings); got != step.want {
+					t.Errorf("message %d (%s, %q): MustValidate() = %v, want %v", i, step.endpoint, step.consent, got, step.want)
+				}
+			}
+
+			// A new window selects the journeys again
+			sp.GetAndCleanSamplingRates(nil)
+			if len(sp.floorKeys) != 0 {
+				t.Errorf("floorKeys = %v, want empty", sp.floorKeys)
+			}
+		})
+	}
+}
+
+func TestGetSamplingNumberDeterministic(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		a    *Message
+		b    *Message
+	}{
+		{name: "consent", key: configuration.ConsentIDSamplingKey, a: &Message{ConsentID: "consent-1", XFapiInteractionID: "a"}, b: &Message{ConsentID: "consent-1", XFapiInteractionID: "b"}},
+		{name: "interaction", key: configuration.InteractionIDSamplingKey, a: &Message{ConsentID: "a", XFapiInteractionID: testInteractionID}, b: &Message{ConsentID: "b", XFapiInteractionID: testInteractionID}},
+		{name: "server organisation", key: configuration.ServerOrgIDSamplingKey, a: &Message{ServerID: testServerOrgID, ConsentID: "a"}, b: &Message{ServerID: testServerOrgID, ConsentID: "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SamplingSettings.Mode = configuration.DeterministicSampling
+			settings.SamplingSettings.Key = tt.key
+			settings.SamplingSettings.SaltRotation = 60
+			sp := newTestSamplingPolicy(t, settings, nil)
+
+			first := sp.getSamplingNumber(tt.a)
+			if first < 0 || first > 99 {
+				t.Fatalf("getSamplingNumber() = %d, want 0 - 99", first)
+			}
+
+			if second := sp.getSamplingNumber(tt.b); second != first {
+				t.Errorf("getSamplingNumber() = %d and %d, want the same number for the same key", first, second)
+			}
+		})
+	}
+}
+
+func TestCheckEndpointRates(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]int
+		want      []string
+	}{
+		{name: "no local rates", want: []string{}},
+		{name: "endpoint of the catalogue", overrides: map[string]int{" /ACCOUNTS/v2/accounts ": 50}, want: []string{}},
+		{name: "endpoint with the open-banking prefix", overrides: map[string]int{"/open-banking/accounts/v2/accounts": 50, "/accounts/v2/accounts": 50}, want: []string{"/open-banking/accounts/v2/accounts"}},
+		{name: "unknown endpoints sorted", overrides: map[string]int{"/b": 10, "/a": 10}, want: []string{"/a", "/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SamplingSettings.EndpointRates = tt.overrides
+			sp := newTestSamplingPolicy(t, settings, nil)
+			if got := sp.checkEndpointRates(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkEndpointRates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAndEnqueue(t *testing.T) {
+	tests := []struct {
+		name          string
+		rate          int
+		minValidation int
+		queued        []bool // Result of the queue for each message
+		wantQueued    int
+		wantValidated int
+		wantFloor     int
+	}{
+		{name: "messages queued", rate: 100, queued: []bool{true, true}, wantQueued: 2, wantValidated: 2},
+		{name: "messages discarded are not validated", rate: 100, queued: []bool{false, true, false}, wantQueued: 1, wantValidated: 1},
+		{name: "messages discarded do not reach the minimum", minValidation: 2, queued: []bool{false, false, true, true, true}, wantQueued: 2, wantValidated: 2, wantFloor: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t)
+			settings.SamplingSettings.MinValidationsPerWindow = tt.minValidation
+			sp := newTestSamplingPolicy(t, settings, func(settings *models.ConfigurationSettings) {
+				settings.ValidationSettings.HighTroughputValidationRate = tt.rate
+			})
+
+			validationSettings := sp.cm.GetEndpointSettingFromAPI("/accounts/v2/accounts", sp.Logger)
+			queued := 0
+			for _, result := range tt.queued {
+				if sp.ValidateAndEnqueue(newTestMessage("/accounts/v2/accounts", ""), validationSettings, func(msg *Message) bool { return result }) {
+					queued++
+				}
+			}
+
+			if queued != tt.wantQueued {
+				t.Errorf("queued = %d, want %d", queued, tt.wantQueued)
+			}
+
+			rates := sp.GetAndCleanSamplingRates(nil)
+			for _, rate := range rates[getResultGroupKey(sp.cm, configuration.TransmitterMode, "", "")] {
+				if rate.EndpointName == "/accounts/v2/accounts" && (rate.ValidatedRequests != tt.wantValidated || rate.FloorValidations != tt.wantFloor || rate.ReceivedRequests != len(tt.queued)) {
+					t.Errorf("sampling rate = %+v", rate)
+				}
+			}
+		})
+	}
+}
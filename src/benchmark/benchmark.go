@@ -0,0 +1,555 @@
+// Package benchmark generates load on the validation API, in-process (handler of the API server, queue, worker and
+// result processor of an application instance) or on a running instance over HTTP, and measures the throughput, the
+// latency, the depth of the queue and the allocations, to size the deployments for a given request rate.
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/google/uuid"
+)
+
+const (
+	validatePath       = "/ValidateResponse"
+	xFAPIInteractionID = "x-fapi-interaction-id"
+
+	inProcessMode = "in-process"
+	httpMode      = "http"
+
+	maxRate = int(time.Second) // Maximum number of requests by second that can be limited
+)
+
+// Config contains the settings of the benchmark
+type Config struct {
+	Handler        http.Handler  // Handler of the API server, used when TargetURL is empty
+	TargetURL      string        // Base URL of a running instance (e.g. http://localhost:8080)
+	Concurrency    int           // Number of concurrent clients, by default 1
+	Requests       int           // Total number of requests, 0 to run for the duration
+	Duration       time.Duration // Duration of the load when Requests is 0, by default 30 seconds
+	Rate           int           // Maximum number of requests by second, 0 for no limit
+	SampleInterval time.Duration // Interval between the samples of the queue, by default 1 second
+	DrainTimeout   time.Duration // Maximum time to wait for the queue to be empty after the load, in-process only
+	Headers        http.Header   // Headers added to all the requests (e.g. serverOrgId, role, authorization)
+	Seed           int64         // Seed used to select the payloads
+	QueueDepth     func() int    // Returns the depth of the queue, nil if not available
+	Processed      func() int64  // Returns the number of messages validated, nil if not available
+}
+
+// LatencySummary contains the percentiles of the latency of the requests
+type LatencySummary struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// QueueSample is the state of the queue at a moment of the benchmark
+type QueueSample struct {
+	Elapsed   time.Duration `json:"elapsed"`   // Time since the start of the benchmark
+	Depth     int           `json:"depth"`     // Messages waiting in the queue
+	Processed int64         `json:"processed"` // Messages validated since the start of the benchmark
+	HeapBytes uint64        `json:"heapBytes"` // Bytes of the heap in use
+}
+
+// AllocationSummary contains the allocations of the process during the benchmark
+type AllocationSummary struct {
+	TotalBytes        uint64        `json:"totalBytes"`        // Bytes allocated
+	Objects           uint64        `json:"objects"`           // Objects allocated
+	BytesPerRequest   float64       `json:"bytesPerRequest"`   // Bytes allocated by request
+	ObjectsPerRequest float64       `json:"objectsPerRequest"` // Objects allocated by request
+	PeakHeapBytes     uint64        `json:"peakHeapBytes"`     // Maximum bytes of the heap in use
+	NumGC             uint32        `json:"numGC"`             // Number of garbage collections
+	GCPause           time.Duration `json:"gcPause"`           // Total pause of the garbage collections
+}
+
+// Result contains the measures of the benchmark
+type Result struct {
+	Mode                string            `json:"mode"`                // in-process or http
+	Concurrency         int               `json:"concurrency"`         // Number of concurrent clients
+	Requests            int64             `json:"requests"`            // Requests sent
+	Failures            int64             `json:"failures"`            // Requests with error or status code different from 2xx
+	StatusCodes         map[int]int64     `json:"statusCodes"`         // Requests by status code, 0 for transport errors
+	Duration            time.Duration     `json:"duration"`            // Duration of the load
+	Throughput          float64           `json:"throughput"`          // Requests by second
+	Processed           int64             `json:"processed"`           // Messages validated, including the drain of the queue
+	ProcessedThroughput float64           `json:"processedThroughput"` // Messages validated by second during the load
+	DrainTime           time.Duration     `json:"drainTime"`           // Time to empty the queue after the load
+	MaxQueueDepth       int               `json:"maxQueueDepth"`       // Maximum depth of the queue sampled
+	Latency             LatencySummary    `json:"latency"`             // Latency of the requests
+	Queue               []QueueSample     `json:"queue"`               // Samples of the queue
+	Allocations         AllocationSummary `json:"allocations"`         // Allocations of the process
+}
+
+// Runner executes a benchmark
+type Runner struct {
+	crosscutting.OFBStruct
+	config Config       // Settings of the benchmark
+	corpus *Corpus      // Payloads sent
+	client *http.Client // Client used on http mode
+}
+
+// workerResult contains the measures of a client
+type workerResult struct {
+	latencies   []time.Duration // Latency of the requests
+	statusCodes map[int]int64   // Requests by status code
+	failures    int64           // Requests failed
+}
+
+// discardWriter is a response writer that keeps only the status code, used on in-process mode
+type discardWriter struct {
+	header     http.Header // Headers of the response
+	statusCode int         // Status code of the response
+}
+
+// Header returns the headers of the response
+//
+// Parameters:
+//
+// Returns:
+//   - http.Header: Headers of the response
+func (dw *discardWriter) Header() http.Header {
+	return dw.header
+}
+
+// Write discards the content of the response
+//
+// Parameters:
+//   - p: Content of the response
+//
+// Returns:
+//   - int: number of bytes written
+//   - error: always nil
+func (dw *discardWriter) Write(p []byte) (int, error) {
+	if dw.statusCode == 0 {
+		dw.statusCode = http.StatusOK
+	}
+
+	return len(p), nil
+}
+
+// WriteHeader records the status code
+//
+// Parameters:
+//   - statusCode: HTTP status code
+//
+// Returns:
+func (dw *discardWriter) WriteHeader(statusCode int) {
+	if dw.statusCode == 0 {
+		dw.statusCode = statusCode
+	}
+}
+
+// NewRunner creates a new benchmark runner
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - config: Settings of the benchmark
+//   - corpus: Payloads to be sent
+//
+// Returns:
+//   - *Runner: Runner created
+func NewRunner(logger log.Logger, config Config, corpus *Corpus) *Runner {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	if config.Requests <= 0 && config.Duration <= 0 {
+		config.Duration = 30 * time.Second
+	}
+
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = time.Second
+	}
+
+	if config.Headers == nil {
+		config.Headers = http.Header{}
+	}
+
+	// The interval between the tokens cannot be shorter than a nanosecond
+	if config.Rate < 0 || config.Rate > maxRate {
+		logger.Warning("Value out of range for Rate (0 - "+strconv.Itoa(maxRate)+"), the rate is not limited", "benchmark.Runner", "NewRunner")
+		config.Rate = 0
+	}
+
+	return &Runner{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "benchmark.Runner",
+			Logger: logger,
+		},
+		config: config,
+		corpus: corpus,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{MaxIdleConnsPerHost: config.Concurrency},
+		},
+	}
+}
+
+// Run Executes the benchmark until the number of requests is sent, the duration expires or the context is cancelled
+//
+// Parameters:
+//   - ctx: Context of the benchmark
+//
+// Returns:
+//   - *Result: Measures of the benchmark
+func (r *Runner) Run(ctx context.Context) *Result {
+	result := &Result{Mode: inProcessMode, Concurrency: r.config.Concurrency, StatusCodes: make(map[int]int64)}
+	if r.config.TargetURL != "" {
+		result.Mode = httpMode
+	}
+
+	if r.config.Requests <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Duration)
+		defer cancel()
+	}
+
+	r.Logger.Log("Starting benchmark ["+result.Mode+"]", r.Pack, "Run")
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	processedBefore := r.getProcessed()
+
+	startTime := time.Now()
+	samplerDone := make(chan struct{})
+	samplesReady := make(chan []QueueSample)
+	go r.sample(startTime, processedBefore, samplerDone, samplesReady)
+
+	tokens, stopRateLimiter := r.startRateLimiter(ctx)
+	var remaining atomic.Int64
+	remaining.Store(int64(r.config.Requests))
+
+	results := make([]*workerResult, r.config.Concurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i] = &workerResult{statusCodes: make(map[int]int64)}
+		wg.Add(1)
+		go func(worker *workerResult, seed int64) {
+			defer wg.Done()
+			r.work(ctx, worker, rand.New(rand.NewSource(seed)), tokens, &remaining)
+		}(results[i], r.config.Seed+int64(i))
+	}
+
+	wg.Wait()
+	stopRateLimiter()
+	result.Duration = time.Since(startTime)
+	processedLoad := r.getProcessed() - processedBefore
+	result.DrainTime = r.drain()
+
+	close(samplerDone)
+	result.Queue = <-samplesReady
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	var latencies []time.Duration
+	for _, worker := range results {
+		latencies = append(latencies, worker.latencies...)
+		result.Failures += worker.failures
+		for code, count := range worker.statusCodes {
+			result.StatusCodes[code] += count
+		}
+	}
+
+	result.Requests = int64(len(latencies))
+	result.Throughput = float64(result.Requests) / result.Duration.Seconds()
+	result.Processed = r.getProcessed() - processedBefore
+	result.ProcessedThroughput = float64(processedLoad) / result.Duration.Seconds()
+	result.Latency = summarizeLatencies(latencies)
+	result.Allocations = summarizeAllocations(before, after, result.Requests, result.Queue)
+	for _, sample := range result.Queue {
+		result.MaxQueueDepth = max(result.MaxQueueDepth, sample.Depth)
+	}
+
+	return result
+}
+
+// work Sends requests until the benchmark ends
+//
+// Parameters:
+//   - ctx: Context of the benchmark
+//   - worker: Measures of the client
+//   - random: Source of the random values of the client
+//   - tokens: Channel that limits the rate of requests, nil for no limit
+//   - remaining: Number of requests remaining, when the benchmark is limited by requests
+//
+// Returns:
+func (r *Runner) work(ctx context.Context, worker *workerResult, random *rand.Rand, tokens <-chan struct{}, remaining *atomic.Int64) {
+	for ctx.Err() == nil {
+		if r.config.Requests > 0 && remaining.Add(-1) < 0 {
+			return
+		}
+
+		if tokens != nil {
+			select {
+			case <-tokens:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		payload := r.corpus.next(random)
+		startTime := time.Now()
+		statusCode := r.send(ctx, payload)
+		if statusCode == 0 && ctx.Err() != nil {
+			return
+		}
+
+		worker.latencies = append(worker.latencies, time.Since(startTime))
+		worker.statusCodes[statusCode]++
+		if statusCode < 200 || statusCode >= 300 {
+			worker.failures++
+		}
+	}
+}
+
+// send Sends a payload to the validation API
+//
+// Parameters:
+//   - ctx: Context of the benchmark
+//   - payload: Payload to be sent
+//
+// Returns:
+//   - int: Status code of the response, 0 if the request failed
+func (r *Runner) send(ctx context.Context, payload Payload) int {
+	url := strings.TrimSuffix(r.config.TargetURL, "/") + validatePath
+	if r.config.TargetURL == "" {
+		url = "http://localhost" + validatePath
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload.Document))
+	if err != nil {
+		r.Logger.Error(err, "Error creating request", r.Pack, "send")
+		return 0
+	}
+
+	for key, values := range r.config.Headers {
+		request.Header[key] = values
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("endpointName", payload.Endpoint)
+	request.Header.Set(xFAPIInteractionID, uuid.NewString())
+	if payload.Version != "" {
+		request.Header.Set("version", payload.Version)
+	}
+
+	if r.config.TargetURL == "" {
+		request.RemoteAddr = "127.0.0.1:0"
+		writer := &discardWriter{header: http.Header{}}
+		r.config.Handler.ServeHTTP(writer, request)
+		if writer.statusCode == 0 {
+			writer.statusCode = http.StatusOK
+		}
+
+		return writer.statusCode
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.Logger.Warning("Error sending request: "+err.Error(), r.Pack, "send")
+		}
+
+		return 0
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+	return response.StatusCode
+}
+
+// startRateLimiter Starts the generation of the tokens that limit the rate of requests
+//
+// Parameters:
+//   - ctx: Context of the benchmark
+//
+// Returns:
+//   - <-chan struct{}: Channel of tokens, nil if the rate is not limited
+//   - func(): Stops the generation of the tokens and waits for it to end, must be called when the load ends
+func (r *Runner) startRateLimiter(ctx context.Context) (<-chan struct{}, func()) {
+	if r.config.Rate <= 0 {
+		return nil, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	tokens := make(chan struct{}, r.config.Concurrency)
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Second / time.Duration(r.config.Rate))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case tokens <- struct{}{}:
+				default:
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return tokens, func() {
+		cancel()
+		<-done
+	}
+}
+
+// sample Records the state of the queue until the benchmark ends
+//
+// Parameters:
+//   - startTime: Start of the benchmark
+//   - processedBefore: Messages processed before the benchmark
+//   - done: Closed when the benchmark ends
+//   - samples: Receives the samples recorded
+//
+// Returns:
+func (r *Runner) sample(startTime time.Time, processedBefore int64, done <-chan struct{}, samples chan<- []QueueSample) {
+	ticker := time.NewTicker(r.config.SampleInterval)
+	defer ticker.Stop()
+
+	var result []QueueSample
+	record := func() {
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+		sample := QueueSample{
+			Elapsed:   time.Since(startTime).Round(time.Millisecond),
+			Processed: r.getProcessed() - processedBefore,
+			HeapBytes: memStats.HeapInuse,
+			Depth:     -1,
+		}
+
+		if r.config.QueueDepth != nil {
+			sample.Depth = r.config.QueueDepth()
+		}
+
+		result = append(result, sample)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			record()
+		case <-done:
+			record()
+			samples <- result
+			return
+		}
+	}
+}
+
+// drain Waits for the queue to be empty, on in-process mode
+//
+// Parameters:
+//
+// Returns:
+//   - time.Duration: Time waited
+func (r *Runner) drain() time.Duration {
+	if r.config.QueueDepth == nil || r.config.DrainTimeout <= 0 {
+		return 0
+	}
+
+	startTime := time.Now()
+	for r.config.QueueDepth() > 0 && time.Since(startTime) < r.config.DrainTimeout {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return time.Since(startTime)
+}
+
+// getProcessed returns the number of messages validated, 0 if not available
+//
+// Parameters:
+//
+// Returns:
+//   - int64: Number of messages validated
+func (r *Runner) getProcessed() int64 {
+	if r.config.Processed == nil {
+		return 0
+	}
+
+	return r.config.Processed()
+}
+
+// summarizeLatencies Calculates the percentiles of the latencies
+//
+// Parameters:
+//   - latencies: Latency of the requests
+//
+// Returns:
+//   - LatencySummary: Percentiles of the latency
+func summarizeLatencies(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	percentile := func(p float64) time.Duration {
+		index := int(p*float64(len(latencies))+0.5) - 1
+		return latencies[min(max(index, 0), len(latencies)-1)]
+	}
+
+	return LatencySummary{
+		Min:  latencies[0],
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// summarizeAllocations Calculates the allocations of the process during the benchmark
+//
+// Parameters:
+//   - before: Memory statistics at the start of the benchmark
+//   - after: Memory statistics at the end of the benchmark
+//   - requests: Number of requests sent
+//   - samples: Samples of the queue, with the heap in use
+//
+// Returns:
+//   - AllocationSummary: Allocations of the process
+func summarizeAllocations(before runtime.MemStats, after runtime.MemStats, requests int64, samples []QueueSample) AllocationSummary {
+	result := AllocationSummary{
+		TotalBytes: after.TotalAlloc - before.TotalAlloc,
+		Objects:    after.Mallocs - before.Mallocs,
+		NumGC:      after.NumGC - before.NumGC,
+		GCPause:    time.Duration(after.PauseTotalNs - before.PauseTotalNs),
+	}
+
+	if requests > 0 {
+		result.BytesPerRequest = float64(result.TotalBytes) / float64(requests)
+		result.ObjectsPerRequest = float64(result.Objects) / float64(requests)
+	}
+
+	for _, sample := range samples {
+		result.PeakHeapBytes = max(result.PeakHeapBytes, sample.HeapBytes)
+	}
+
+	return result
+}
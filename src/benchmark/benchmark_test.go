@@ -0,0 +1,189 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+)
+
+// newTestCorpus returns a corpus with a payload of an endpoint
+func newTestCorpus(t *testing.T) *Corpus {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "corpus.jsonl")
+	if err := os.WriteFile(path, []byte(`{"endpoint":"/accounts/v2/accounts","document":{"data":[]}}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	corpus, err := LoadCorpus(path, "")
+	if err != nil {
+		t.Fatalf("LoadCorpus() error = %v", err)
+	}
+
+	return corpus
+}
+
+func TestNewRunnerRate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate int
+		want int
+	}{
+		{name: "no limit", rate: 0, want: 0},
+		{name: "rate limited", rate: 100, want: 100},
+		{name: "maximum rate", rate: maxRate, want: maxRate},
+		{name: "negative rate", rate: -1, want: 0},
+		{name: "rate above the maximum", rate: maxRate + 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewRunner(log.NewLogger("PANIC"), Config{Rate: tt.rate}, nil)
+			if runner.config.Rate != tt.want {
+				t.Errorf("Rate = %d, want %d", runner.config.Rate, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerStartRateLimiter(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       int
+		wantTokens bool
+	}{
+		{name: "no limit", rate: 0},
+		{name: "rate limited", rate: 1000, wantTokens: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewRunner(log.NewLogger("PANIC"), Config{Rate: tt.rate, Concurrency: 1}, nil)
+			tokens, stop := runner.startRateLimiter(context.Background())
+			if (tokens != nil) != tt.wantTokens {
+				t.Fatalf("tokens = %v, want tokens %v", tokens, tt.wantTokens)
+			}
+
+			if tokens != nil {
+				select {
+				case <-tokens:
+				case <-time.After(time.Second):
+					t.Fatalf("no token generated")
+				}
+			}
+
+			// stop returns after the generation of the tokens ends
+			stop()
+			for tokens != nil && len(tokens) > 0 {
+				<-tokens
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			if tokens != nil && len(tokens) != 0 {
+				t.Errorf("tokens generated after stop")
+			}
+		})
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests int
+		rate     int
+	}{
+		{name: "limited by requests", requests: 20},
+		{name: "limited by requests and rate", requests: 10, rate: 1000},
+		{name: "rate above the maximum", requests: 10, rate: maxRate + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received atomic.Int64
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received.Add(1)
+				if r.Header.Get("endpointName") != "/accounts/v2/accounts" || r.Header.Get(xFAPIInteractionID) == "" {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			})
+
+			config := Config{Handler: handler, Concurrency: 3, Requests: tt.requests, Rate: tt.rate, SampleInterval: 10 * time.Millisecond}
+			result := NewRunner(log.NewLogger("PANIC"), config, newTestCorpus(t)).Run(context.Background())
+			if result.Requests != int64(tt.requests) || received.Load() != int64(tt.requests) {
+				t.Errorf("Requests = %d, received = %d, want %d", result.Requests, received.Load(), tt.requests)
+			}
+
+			if result.Failures != 0 || result.StatusCodes[http.StatusOK] != int64(tt.requests) || result.Mode != inProcessMode {
+				t.Errorf("result = %+v", result)
+			}
+		})
+	}
+}
+
+// stubSettingsServer is a report server that records the calls received
+type stubSettingsServer struct {
+	reports int // Reports received
+}
+
+func (s *stubSettingsServer) SendReport(report models.Report) error {
+	s.reports++
+	return nil
+}
+
+func (s *stubSettingsServer) LoadAPIConfigurationFile(filePath string) ([]byte, error) {
+	if filePath == "missing" {
+		return nil, errors.New("file not found")
+	}
+
+	return []byte(filePath), nil
+}
+
+func (s *stubSettingsServer) LoadConfigurationSettings(conditional *services.ConditionalRequest) (*models.ConfigurationSettings, error) {
+	return &models.ConfigurationSettings{Version: "1.0.0"}, nil
+}
+
+func TestReportServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		reports  int
+		filePath string
+		wantErr  bool
+	}{
+		{name: "reports discarded", reports: 3, filePath: "endpoints.json"},
+		{name: "settings error", filePath: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubSettingsServer{}
+			rs := NewReportServer(log.NewLogger("PANIC"), stub)
+			for i := 0; i < tt.reports; i++ {
+				if err := rs.SendReport(models.Report{}); err != nil {
+					t.Fatalf("SendReport() error = %v", err)
+				}
+			}
+
+			if stub.reports != 0 || rs.GetDiscarded() != int64(tt.reports) {
+				t.Errorf("reports sent = %d, discarded = %d, want 0, %d", stub.reports, rs.GetDiscarded(), tt.reports)
+			}
+
+			file, err := rs.LoadAPIConfigurationFile(tt.filePath)
+			if (err != nil) != tt.wantErr || (!tt.wantErr && string(file) != tt.filePath) {
+				t.Errorf("LoadAPIConfigurationFile() = %q, %v", file, err)
+			}
+
+			settings, err := rs.LoadConfigurationSettings(nil)
+			if err != nil || settings.Version != "1.0.0" {
+				t.Errorf("LoadConfigurationSettings() = %+v, %v", settings, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package benchmark
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxCorpusLine is the maximum size of a line of a corpus file
+const maxCorpusLine = 16 * 1024 * 1024
+
+// Payload is a message of the corpus
+type Payload struct {
+	Endpoint string          `json:"endpoint"`          // Name of the endpoint of the message
+	Version  string          `json:"version,omitempty"` // Version of the API, can be empty
+	Document json.RawMessage `json:"document"`          // Body of the message
+}
+
+// Corpus contains the payloads used by the benchmark, grouped by endpoint
+type Corpus struct {
+	payloads  map[string][]Payload // Payloads by endpoint
+	endpoints []string             // Endpoints selected, in order
+	weights   []int                // Cumulative weights of the endpoints
+}
+
+// LoadCorpus Loads the payloads of the .json and .jsonl files of a directory (or a single file), one payload by line,
+// with the format of the output of the generate subcommand
+//
+// Parameters:
+//   - path: Directory or file with the payloads
+//   - mix: Weights by endpoint (e.g. /accounts/v2/accounts=3,/resources/v2/resources=1), empty to use the
+//     frequency of the endpoints in the corpus
+//
+// Returns:
+//   - *Corpus: Corpus loaded
+//   - error: error if the files or the mix are invalid, or an endpoint of the mix has no payloads
+func LoadCorpus(path string, mix string) (*Corpus, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		files = nil
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			extension := filepath.Ext(entry.Name())
+			if !entry.IsDir() && (extension == ".json" || extension == ".jsonl") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	corpus := &Corpus{payloads: make(map[string][]Payload)}
+	for _, file := range files {
+		if err := corpus.loadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(corpus.payloads) == 0 {
+		return nil, errors.New("corpus has no payloads: " + path)
+	}
+
+	return corpus, corpus.setMix(mix)
+}
+
+// loadFile Loads the payloads of a file
+//
+// Parameters:
+//   - file: Path of the file
+//
+// Returns:
+//   - error: error if the file cannot be read or a line is invalid
+func (c *Corpus) loadFile(file string) error {
+	content, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+
+	defer content.Close()
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 64*1024), maxCorpusLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var payload Payload
+		if err := json.Unmarshal(scanner.Bytes(), &payload); err != nil {
+			return errors.New(file + ":" + strconv.Itoa(line) + ": " + err.Error())
+		}
+
+		if payload.Endpoint == "" || len(payload.Document) == 0 {
+			return errors.New(file + ":" + strconv.Itoa(line) + ": endpoint and document are required")
+		}
+
+		c.payloads[payload.Endpoint] = append(c.payloads[payload.Endpoint], payload)
+	}
+
+	return scanner.Err()
+}
+
+// setMix Sets the weights of the endpoints
+//
+// Parameters:
+//   - mix: Weights by endpoint, empty to use the frequency of the endpoints in the corpus
+//
+// Returns:
+//   - error: error if the mix is invalid or an endpoint of the mix has no payloads
+func (c *Corpus) setMix(mix string) error {
+	weights := make(map[string]int)
+	if strings.TrimSpace(mix) == "" {
+		for endpoint, payloads := range c.payloads {
+			weights[endpoint] = len(payloads)
+		}
+	}
+
+	for _, item := range strings.Split(mix, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		endpoint, weightText, found := strings.Cut(strings.TrimSpace(item), "=")
+		weight, err := strconv.Atoi(weightText)
+		if !found || err != nil || weight <= 0 {
+			return errors.New("invalid mix item, expected endpoint=weight: " + item)
+		}
+
+		if len(c.payloads[endpoint]) == 0 {
+			return errors.New("no payloads found in the corpus for endpoint: " + endpoint)
+		}
+
+		weights[endpoint] = weight
+	}
+
+	total := 0
+	for endpoint := range weights {
+		c.endpoints = append(c.endpoints, endpoint)
+	}
+
+	sort.Strings(c.endpoints)
+	for _, endpoint := range c.endpoints {
+		total += weights[endpoint]
+		c.weights = append(c.weights, total)
+	}
+
+	return nil
+}
+
+// next returns a payload, selecting the endpoint by its weight
+//
+// Parameters:
+//   - random: Source of the random values
+//
+// Returns:
+//   - Payload: Payload selected
+func (c *Corpus) next(random *rand.Rand) Payload {
+	value := random.Intn(c.weights[len(c.weights)-1])
+	index := sort.SearchInts(c.weights, value+1)
+	payloads := c.payloads[c.endpoints[index]]
+	return payloads[random.Intn(len(payloads))]
+}
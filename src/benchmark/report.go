@@ -0,0 +1,67 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// WriteText Writes the measures of the benchmark as a text report
+//
+// Parameters:
+//   - w: Writer that receives the report
+//
+// Returns:
+//   - error: error if the report cannot be written
+func (result *Result) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Mode:\t%s\n", result.Mode)
+	fmt.Fprintf(tw, "Concurrency:\t%d\n", result.Concurrency)
+	fmt.Fprintf(tw, "Duration:\t%s\n", result.Duration.Round(1e6))
+	fmt.Fprintf(tw, "Requests:\t%d (%d failed)\n", result.Requests, result.Failures)
+	fmt.Fprintf(tw, "Throughput:\t%.1f req/s\n", result.Throughput)
+
+	codes := make([]int, 0, len(result.StatusCodes))
+	for code := range result.StatusCodes {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+	for _, code := range codes {
+		label := fmt.Sprint(code)
+		if code == 0 {
+			label = "error"
+		}
+
+		fmt.Fprintf(tw, "  Status %s:\t%d\n", label, result.StatusCodes[code])
+	}
+
+	if result.Mode == inProcessMode {
+		fmt.Fprintf(tw, "Validated:\t%d (%.1f msg/s during the load)\n", result.Processed, result.ProcessedThroughput)
+		fmt.Fprintf(tw, "Queue drain time:\t%s\n", result.DrainTime.Round(1e6))
+		fmt.Fprintf(tw, "Max queue depth:\t%d\n", result.MaxQueueDepth)
+	}
+
+	latency := result.Latency
+	fmt.Fprintf(tw, "Latency:\tmin %s, mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		latency.Min, latency.Mean, latency.P50, latency.P90, latency.P95, latency.P99, latency.Max)
+
+	allocations := result.Allocations
+	fmt.Fprintf(tw, "Allocations:\t%d bytes, %d objects (%.0f bytes/req, %.1f objects/req)\n",
+		allocations.TotalBytes, allocations.Objects, allocations.BytesPerRequest, allocations.ObjectsPerRequest)
+	fmt.Fprintf(tw, "Peak heap in use:\t%d bytes\n", allocations.PeakHeapBytes)
+	fmt.Fprintf(tw, "GC:\t%d cycles, %s paused\n", allocations.NumGC, allocations.GCPause)
+
+	fmt.Fprintf(tw, "\nElapsed\tQueue depth\tValidated\tHeap in use\n")
+	for _, sample := range result.Queue {
+		depth := "n/a"
+		if sample.Depth >= 0 {
+			depth = fmt.Sprint(sample.Depth)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", sample.Elapsed, depth, sample.Processed, sample.HeapBytes)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,83 @@
+package benchmark
+
+import (
+	"sync/atomic"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+)
+
+// ReportServer is the report server of the in-process benchmark: the settings are loaded from the central server, and
+// the reports of the synthetic traffic are discarded
+type ReportServer struct {
+	crosscutting.OFBStruct
+	settings  services.ReportServer // Server used to load the settings
+	discarded atomic.Int64          // Number of reports discarded
+}
+
+// NewReportServer creates a new report server for the benchmark
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - settings: Server used to load the settings
+//
+// Returns:
+//   - *ReportServer: Report server created
+func NewReportServer(logger log.Logger, settings services.ReportServer) *ReportServer {
+	return &ReportServer{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "benchmark.ReportServer",
+			Logger: logger,
+		},
+		settings: settings,
+	}
+}
+
+// SendReport Discards the report, the synthetic traffic must not be reported to the central server
+//
+// Parameters:
+//   - report: Report to be discarded
+//
+// Returns:
+//   - error: always nil
+func (rs *ReportServer) SendReport(report models.Report) error {
+	rs.discarded.Add(1)
+	rs.Logger.Debug("Report of the benchmark discarded", rs.Pack, "SendReport")
+	return nil
+}
+
+// LoadAPIConfigurationFile Loads a configuration file from the central server
+//
+// Parameters:
+//   - filePath: Path of the file
+//
+// Returns:
+//   - []byte: Content of the file
+//   - error: Error if any
+func (rs *ReportServer) LoadAPIConfigurationFile(filePath string) ([]byte, error) {
+	return rs.settings.LoadAPIConfigurationFile(filePath)
+}
+
+// LoadConfigurationSettings Loads the configuration settings from the central server
+//
+// Parameters:
+//   - conditional: Validators of the last response, nil to load the settings unconditionally
+//
+// Returns:
+//   - *models.ConfigurationSettings: Settings loaded
+//   - error: Error if any
+func (rs *ReportServer) LoadConfigurationSettings(conditional *services.ConditionalRequest) (*models.ConfigurationSettings, error) {
+	return rs.settings.LoadConfigurationSettings(conditional)
+}
+
+// GetDiscarded returns the number of reports discarded
+//
+// Parameters:
+//
+// Returns:
+//   - int64: Number of reports discarded
+func (rs *ReportServer) GetDiscarded() int64 {
+	return rs.discarded.Load()
+}
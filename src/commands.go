@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/application"
+	"github.com/OpenBanking-Brasil/MQD_Client/benchmark"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/OpenBanking-Brasil/MQD_Client/fakeserver"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation/generator"
+	"github.com/google/uuid"
+)
+
+// generatedPayload is a line of the output of the generate subcommand
+type generatedPayload struct {
+	Endpoint string `json:"endpoint,omitempty"` // Endpoint of the schema, with -endpoint
+	generator.Mutation
+	ActualErrors []validation.Error `json:"actualErrors,omitempty"` // Errors reported by the validation, with -verify
+	Verified     *bool              `json:"verified,omitempty"`     // Indicates that the validation reported the expected error, with -verify
+}
+
+// runFakeServer Starts the fake central server used for local end-to-end tests, this function does not return
+//
+// Parameters:
+//   - args: Arguments of the subcommand
+//
+// Returns:
+func runFakeServer(args []string) {
+	flags := flag.NewFlagSet("fake-server", flag.ExitOnError)
+	addr := flags.String("addr", ":8081", "Address to listen on")
+	settingsDir := flags.String("settings", "./fake-settings", "Directory with configurationSettings.json and the endpoint files")
+	tokenExpiry := flags.Duration("token-expiry", 5*time.Minute, "Expiration of the tokens issued")
+	signingKey := flags.String("signing-key", "", "Key used to sign the tokens, random if empty")
+	loggingLevel := flags.String("log-level", "INFO", "Logging level")
+	_ = flags.Parse(args)
+
+	logger := log.NewLogger(*loggingLevel)
+	server, err := fakeserver.New(fakeserver.Config{
+		SettingsDir: *settingsDir,
+		TokenExpiry: *tokenExpiry,
+		SigningKey:  []byte(*signingKey),
+		Logger:      logger,
+	})
+	if err != nil {
+		logger.Fatal(err, "Error creating fake server", "Main", "runFakeServer")
+	}
+
+	logger.Fatal(server.ListenAndServe(*addr), "", "Main", "runFakeServer")
+}
+
+// runGenerate Writes synthetic payloads of an endpoint to the standard output, one JSON document by line
+//
+// Parameters:
+//   - args: Arguments of the subcommand
+//
+// Returns:
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "File with the JSON schema of the body")
+	endpoint := flags.String("endpoint", "", "Endpoint whose schema is loaded from the validation settings, if -schema is not set")
+	mode := flags.String("mode", "valid", "valid, all, or one of the mutation modes")
+	count := flags.Int("count", 1, "Number of payloads by mode")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "Seed of the random values")
+	optional := flags.Bool("optional", true, "Includes the properties that are not required")
+	verify := flags.Bool("verify", false, "Validates the payloads and reports the errors found")
+	loggingLevel := flags.String("log-level", "WARNING", "Logging level")
+	_ = flags.Parse(args)
+
+	logger := log.NewLogger(*loggingLevel)
+	schema, err := loadSchema(logger, *schemaFile, *endpoint)
+	if err != nil {
+		logger.Fatal(err, "Error loading schema", "Main", "runGenerate")
+	}
+
+	gen, err := generator.NewGenerator(logger, schema, generator.Options{Seed: *seed, IncludeOptional: *optional})
+	if err != nil {
+		logger.Fatal(err, "Invalid schema", "Main", "runGenerate")
+	}
+
+	var modes []generator.MutationMode
+	switch *mode {
+	case "valid":
+	case "all":
+		modes = generator.GetMutationModes()
+	default:
+		if !slices.Contains(generator.GetMutationModes(), generator.MutationMode(*mode)) {
+			logger.Fatal(errors.New("unknown mode: "+*mode), "Invalid mode", "Main", "runGenerate")
+		}
+
+		modes = []generator.MutationMode{generator.MutationMode(*mode)}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	validator := validation.NewValidator(logger, schema, validation.DefaultOptions())
+	for i := 0; i < *count; i++ {
+		if len(modes) == 0 {
+			payload := generatedPayload{Endpoint: *endpoint, Mutation: generator.Mutation{Mode: "valid", Document: gen.Generate()}}
+			if *verify {
+				verifyPayload(logger, validator, &payload)
+			}
+
+			_ = encoder.Encode(payload)
+		}
+
+		for _, m := range modes {
+			mutation, err := gen.Mutate(m)
+			if errors.Is(err, generator.ErrNoMutationTarget) {
+				logger.Warning("Mutation not applicable to the schema: "+string(m), "Main", "runGenerate")
+				continue
+			}
+
+			payload := generatedPayload{Endpoint: *endpoint, Mutation: *mutation}
+			if *verify {
+				verifyPayload(logger, validator, &payload)
+			}
+
+			_ = encoder.Encode(payload)
+		}
+	}
+}
+
+// loadSchema Loads the JSON schema from a file, or from the validation settings of an endpoint
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - schemaFile: File with the JSON schema, can be empty
+//   - endpoint: Name of the endpoint, used if the file is empty
+//
+// Returns:
+//   - string: JSON schema
+//   - error: error if the schema cannot be loaded
+func loadSchema(logger log.Logger, schemaFile string, endpoint string) (string, error) {
+	if schemaFile != "" {
+		content, err := os.ReadFile(schemaFile)
+		return string(content), err
+	}
+
+	if endpoint == "" {
+		return "", errors.New("-schema or -endpoint is required")
+	}
+
+	cnf := configuration.Configuration{}
+	app, err := application.NewApp(logger, cnf.GetApplicationSettings(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return app.GetEndpointSchema(endpoint)
+}
+
+// verifyPayload Validates a payload with the schema, and indicates if the expected error was reported
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - validator: Validator of the schema
+//   - payload: Payload to be verified
+//
+// Returns:
+func verifyPayload(logger log.Logger, validator validation.Validator, payload *generatedPayload) {
+	// The document is converted as done for the messages received
+	content, _ := json.Marshal(payload.Document)
+	var data validation.DynamicStruct
+	_ = json.Unmarshal(content, &data)
+
+	result, err := validator.Validate(data)
+	if err != nil {
+		logger.Error(err, "Error validating payload", "Main", "verifyPayload")
+		return
+	}
+
+	verified := result.Valid
+	if payload.Mode != "valid" {
+		verified = slices.ContainsFunc(result.Details, func(detail validation.Error) bool {
+			return detail.Code == payload.ExpectedError.Code && detail.Pointer == payload.ExpectedError.Pointer &&
+				detail.Field == payload.ExpectedError.Field
+		})
+	}
+
+	payload.ActualErrors = result.Details
+	payload.Verified = &verified
+}
+
+// runBenchmark Sends load to the validation API, in-process or to a running instance, and writes the measures
+//
+// Parameters:
+//   - args: Arguments of the subcommand
+//
+// Returns:
+func runBenchmark(args []string) {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	corpusPath := flags.String("corpus", "", "Directory or file with the payloads, one JSON by line with endpoint, version and document")
+	mix := flags.String("mix", "", "Weights by endpoint (e.g. /accounts/v2/accounts=3,/resources/v2/resources=1), by default the frequency in the corpus")
+	target := flags.String("target", "", "URL of a running instance (e.g. http://localhost:8080), empty to run the pipeline in-process")
+	concurrency := flags.Int("concurrency", 10, "Number of concurrent clients")
+	requests := flags.Int("requests", 0, "Total number of requests, 0 to run for the duration")
+	duration := flags.Duration("duration", 30*time.Second, "Duration of the load when -requests is 0")
+	rate := flags.Int("rate", 0, "Maximum number of requests by second, 0 for no limit")
+	interval := flags.Duration("interval", time.Second, "Interval between the samples of the queue")
+	drain := flags.Duration("drain", 30*time.Second, "Maximum time to wait for the queue to be empty after the load (in-process)")
+	serverOrgID := flags.String("server-org-id", uuid.NewString(), "serverOrgId header of the requests")
+	role := flags.String("role", configuration.TransmitterMode, "role header of the requests")
+	dataOwner := flags.String("data-owner", "", "dataOwnerID header of the requests")
+	authorization := flags.String("authorization", "", "Authorization header of the requests, with -target")
+	seed := flags.Int64("seed", 1, "Seed used to select the payloads")
+	jsonOutput := flags.Bool("json", false, "Writes the result as JSON")
+	loggingLevel := flags.String("log-level", "WARNING", "Logging level")
+	_ = flags.Parse(args)
+
+	logger := log.NewLogger(*loggingLevel)
+	corpus, err := benchmark.LoadCorpus(*corpusPath, *mix)
+	if err != nil {
+		logger.Fatal(err, "Error loading corpus", "Main", "runBenchmark")
+	}
+
+	config := benchmark.Config{
+		TargetURL:      *target,
+		Concurrency:    *concurrency,
+		Requests:       *requests,
+		Duration:       *duration,
+		Rate:           *rate,
+		SampleInterval: *interval,
+		DrainTimeout:   *drain,
+		Seed:           *seed,
+		Headers:        http.Header{},
+	}
+
+	config.Headers.Set("serverOrgId", *serverOrgID)
+	config.Headers.Set("role", *role)
+	if *dataOwner != "" {
+		config.Headers.Set("dataOwnerID", *dataOwner)
+	}
+
+	if *authorization != "" {
+		config.Headers.Set("Authorization", *authorization)
+	}
+
+	if *target == "" {
+		// The synthetic traffic is not authenticated, nor limited, nor reported to the central server
+		cnf := configuration.Configuration{}
+		settings := cnf.GetApplicationSettings()
+		settings.InboundAuthSettings.Mode = configuration.InboundAuthNone
+		settings.RateLimitSettings.Enabled = false
+		reportServer := benchmark.NewReportServer(logger, services.NewReportServer(logger, nil, settings.SecuritySettings.ProxyURL, settings))
+		app, err := application.NewApp(logger, settings, reportServer)
+		if err != nil {
+			logger.Fatal(err, "There was a fatal error loading initial settings.", "Main", "runBenchmark")
+		}
+
+		app.Start()
+		config.Handler = app.Handler()
+		config.QueueDepth = app.GetQueueDepth
+		config.Processed = app.GetProcessedCount
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result := benchmark.NewRunner(logger, config, corpus).Run(ctx)
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+	} else {
+		err = result.WriteText(os.Stdout)
+	}
+
+	if err != nil {
+		logger.Error(err, "Error writing result", "Main", "runBenchmark")
+	}
+}
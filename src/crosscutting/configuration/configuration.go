@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/certificates"
 	"github.com/google/uuid"
 	"github.com/sethvargo/go-envconfig"
 	"gopkg.in/yaml.v3"
@@ -15,14 +19,56 @@ import (
 const (
 	serverOrgIDEnv     = "SERVER_ORG_ID"    // constant  to store name of the server id environment variable
 	applicationModeEnv = "APPLICATION_MODE" // constant  to store name of the application mode environment variable"
-	transmitterMode    = "TRANSMITTER"      // TRANSMITTER Application mode Constant
-	receiverMode       = "RECEIVER"         // RECEIVER Application mode Constant
 	certPath           = "/certificates/"
-)
-
-var (
-	// ServerID has the OrganisationID for the server
-	ServerID = ""
+	historyPath        = "/configuration_history" // default folder of the configuration history, mounted as a volume in docker
+
+	// TransmitterMode TRANSMITTER Application mode Constant
+	TransmitterMode = "TRANSMITTER"
+	// ReceiverMode RECEIVER Application mode Constant
+	ReceiverMode = "RECEIVER"
+	// DualMode Application mode Constant for instances that act as TRANSMITTER and RECEIVER
+	DualMode = "DUAL"
+
+	// RandomSampling Sampling mode Constant, each message is selected independently
+	RandomSampling = "RANDOM"
+	// DeterministicSampling Sampling mode Constant, messages with the same sampling key are selected together
+	DeterministicSampling = "DETERMINISTIC"
+	// ConsentIDSamplingKey Sampling key Constant to group messages by consent ID
+	ConsentIDSamplingKey = "CONSENT_ID"
+	// InteractionIDSamplingKey Sampling key Constant to group messages by x-fapi-interaction-id
+	InteractionIDSamplingKey = "INTERACTION_ID"
+	// ServerOrgIDSamplingKey Sampling key Constant to group messages by server organisation ID
+	ServerOrgIDSamplingKey = "SERVER_ORG_ID"
+
+	// InboundAuthNone Inbound authentication Constant, requests are not authenticated
+	InboundAuthNone = "NONE"
+	// InboundAuthAPIKey Inbound authentication Constant, requests must include a static API key
+	InboundAuthAPIKey = "API_KEY"
+	// InboundAuthHMAC Inbound authentication Constant, requests must be signed with a shared secret
+	InboundAuthHMAC = "HMAC"
+	// InboundAuthMTLS Inbound authentication Constant, requests must present a client certificate
+	InboundAuthMTLS = "MTLS"
+
+	// NoClientAuth TLS client authentication Constant, client certificates are not requested
+	NoClientAuth = "NONE"
+	// OptionalClientAuth TLS client authentication Constant, client certificates are verified if presented
+	OptionalClientAuth = "OPTIONAL"
+	// RequiredClientAuth TLS client authentication Constant, client certificates are required and verified
+	RequiredClientAuth = "REQUIRED"
+
+	// DisabledProxy Proxy mode Constant, the proxy is not started
+	DisabledProxy = "DISABLED"
+	// ReverseProxy Proxy mode Constant, the proxy runs in front of the API of the data holder (TRANSMITTER)
+	ReverseProxy = "REVERSE"
+	// ForwardProxy Proxy mode Constant, the proxy receives the calls of the receiver to the data holders (RECEIVER)
+	ForwardProxy = "FORWARD"
+
+	// CallerRateLimitKey Rate limit key Constant to limit the requests by authenticated caller
+	CallerRateLimitKey = "CALLER"
+	// ServerOrgIDRateLimitKey Rate limit key Constant to limit the requests by serverOrgId
+	ServerOrgIDRateLimitKey = "SERVER_ORG_ID"
+	// EndpointRateLimitKey Rate limit key Constant to limit the requests by endpoint name
+	EndpointRateLimitKey = "ENDPOINT"
 )
 
 // Configuration exposes the settings of the application
@@ -36,7 +82,7 @@ type Configuration struct {
 // Parameters:
 // Returns:
 func (cnf *Configuration) GetApplicationSettings() Settings {
-	cnf.logger = log.GetLogger("DEBUG")
+	cnf.logger = log.NewLogger("DEBUG")
 	cnf.logger.Info("Initializing application configuration", "configuration", "GetApplicationSettings")
 	err := cnf.loadApplicationSettings()
 	if err != nil {
@@ -94,8 +140,8 @@ func (cnf *Configuration) loadApplicationSettings() error {
 // Returns: true if validation was ok
 func (cnf *Configuration) validateSettings() bool {
 	isValid := true
-	if !(cnf.Settings.ApplicationSettings.Mode == transmitterMode || cnf.Settings.ApplicationSettings.Mode == receiverMode) {
-		cnf.logger.Warning("APPLICATION_MODE not found, please set Environment Variable: ["+applicationModeEnv+"], as ["+transmitterMode+"] or ["+receiverMode+"] ", "Configuration", "validateSettings")
+	if !(cnf.Settings.ApplicationSettings.Mode == TransmitterMode || cnf.Settings.ApplicationSettings.Mode == ReceiverMode || cnf.Settings.ApplicationSettings.Mode == DualMode) {
+		cnf.logger.Warning("APPLICATION_MODE not found, please set Environment Variable: ["+applicationModeEnv+"], as ["+TransmitterMode+"], ["+ReceiverMode+"] or ["+DualMode+"] ", "Configuration", "validateSettings")
 		isValid = false
 	}
 
@@ -105,6 +151,10 @@ func (cnf *Configuration) validateSettings() bool {
 		isValid = false
 	}
 
+	if !cnf.validateOrganisations() {
+		isValid = false
+	}
+
 	if cnf.Settings.ReportSettings.ExecutionWindow != 0 && (cnf.Settings.ReportSettings.ExecutionWindow > 60 || cnf.Settings.ReportSettings.ExecutionWindow < 0) {
 		cnf.logger.Warning("Value out of range for  REPORT_EXECUTION_WINDOW(1 - 60), using default value from system", "Configuration", "validateSettings")
 		cnf.Settings.ReportSettings.ExecutionWindow = 0
@@ -115,10 +165,12 @@ func (cnf *Configuration) validateSettings() bool {
 		cnf.Settings.ReportSettings.ExecutionNumber = 0
 	}
 
-	if cnf.Settings.SecuritySettings.EnableHTTPS {
-		cnf.validateHTTPSCertificates()
+	if cnf.Settings.SecuritySettings.EnableHTTPS && !cnf.validateHTTPSCertificates() {
+		isValid = false
 	}
 
+	cnf.validateOutboundCertificate()
+
 	if cnf.Settings.ResultSettings.FilesPerDay < 1 || cnf.Settings.ResultSettings.FilesPerDay > 24 {
 		cnf.logger.Warning("Value out of range for RESULT_FILES_PER_DAY (1 - 24), using default value from system", "Configuration", "validateSettings")
 		cnf.Settings.ResultSettings.FilesPerDay = 8
@@ -134,26 +186,465 @@ func (cnf *Configuration) validateSettings() bool {
 		cnf.Settings.ResultSettings.SamplesPerError = 7
 	}
 
+	if cnf.Settings.RequestSettings.MaxBodySize < 1 || cnf.Settings.RequestSettings.MaxBodySize > 102400 {
+		cnf.logger.Warning("Value out of range for REQUEST_MAX_BODY_SIZE (1 - 102400), using default value 10240", "Configuration", "validateSettings")
+		cnf.Settings.RequestSettings.MaxBodySize = 10240
+	}
+
+	if cnf.Settings.RequestSettings.MaxDecompressedSize < cnf.Settings.RequestSettings.MaxBodySize || cnf.Settings.RequestSettings.MaxDecompressedSize > 512000 {
+		cnf.logger.Warning("Value out of range for REQUEST_MAX_DECOMPRESSED_SIZE (REQUEST_MAX_BODY_SIZE - 512000), using REQUEST_MAX_BODY_SIZE * 5", "Configuration", "validateSettings")
+		cnf.Settings.RequestSettings.MaxDecompressedSize = min(cnf.Settings.RequestSettings.MaxBodySize*5, 512000)
+	}
+
+	if cnf.Settings.RequestSettings.MaxCompressionRatio < 1 || cnf.Settings.RequestSettings.MaxCompressionRatio > 1000 {
+		cnf.logger.Warning("Value out of range for REQUEST_MAX_COMPRESSION_RATIO (1 - 1000), using default value 100", "Configuration", "validateSettings")
+		cnf.Settings.RequestSettings.MaxCompressionRatio = 100
+	}
+
+	if cnf.Settings.SamplingSettings.MinValidationsPerWindow < 0 || cnf.Settings.SamplingSettings.MinValidationsPerWindow > 1000 {
+		cnf.logger.Warning("Value out of range for SAMPLING_MIN_VALIDATIONS (0 - 1000), no minimum will be used", "Configuration", "validateSettings")
+		cnf.Settings.SamplingSettings.MinValidationsPerWindow = 0
+	}
+
+	for endpoint, rate := range cnf.Settings.SamplingSettings.EndpointRates {
+		if rate < 0 || rate > 100 {
+			cnf.logger.Warning("Value out of range for EndpointRates (0 - 100) on endpoint: "+endpoint+", the rate will be ignored", "Configuration", "validateSettings")
+			delete(cnf.Settings.SamplingSettings.EndpointRates, endpoint)
+		}
+	}
+
+	cnf.validateSamplingMode()
+	cnf.validateLoadShedding()
+	cnf.validateRateLimit()
+
+	if cnf.Settings.UpdateSettings.Interval < 0 || cnf.Settings.UpdateSettings.Interval > 1440 {
+		cnf.logger.Warning("Value out of range for CONFIGURATION_UPDATE_INTERVAL (1 - 1440), using default value from system", "Configuration", "validateSettings")
+		cnf.Settings.UpdateSettings.Interval = 0
+	}
+
+	if cnf.Settings.UpdateSettings.Jitter < 0 || cnf.Settings.UpdateSettings.Jitter > 60 {
+		cnf.logger.Warning("Value out of range for CONFIGURATION_UPDATE_JITTER (0 - 60), no jitter will be used", "Configuration", "validateSettings")
+		cnf.Settings.UpdateSettings.Jitter = 0
+	}
+
+	if cnf.Settings.UpdateSettings.Concurrency < 1 || cnf.Settings.UpdateSettings.Concurrency > 16 {
+		cnf.logger.Warning("Value out of range for CONFIGURATION_UPDATE_CONCURRENCY (1 - 16), using default value from system", "Configuration", "validateSettings")
+		cnf.Settings.UpdateSettings.Concurrency = 4
+	}
+
+	if cnf.Settings.UpdateSettings.HistorySize < 1 || cnf.Settings.UpdateSettings.HistorySize > 20 {
+		cnf.logger.Warning("Value out of range for CONFIGURATION_HISTORY_SIZE (1 - 20), using default value from system", "Configuration", "validateSettings")
+		cnf.Settings.UpdateSettings.HistorySize = 5
+	}
+
+	if cnf.Settings.UpdateSettings.HistoryPath == "" {
+		cnf.Settings.UpdateSettings.HistoryPath = historyPath
+	}
+
+	if cnf.Settings.ValidationSettings.AssertFormats == nil {
+		assertFormats := true
+		cnf.Settings.ValidationSettings.AssertFormats = &assertFormats
+	}
+
+	if cnf.Settings.ValidationSettings.MaxRequestDateTimeSkew < 1 || cnf.Settings.ValidationSettings.MaxRequestDateTimeSkew > 86400 {
+		cnf.logger.Warning("Value out of range for VALIDATION_MAX_REQUEST_DATETIME_SKEW (1 - 86400), using default value 300", "Configuration", "validateSettings")
+		cnf.Settings.ValidationSettings.MaxRequestDateTimeSkew = 300
+	}
+
+	if cnf.Settings.ConsistencySettings.TTL < 1 || cnf.Settings.ConsistencySettings.TTL > 1440 {
+		cnf.logger.Warning("Value out of range for CONSISTENCY_TTL (1 - 1440), using default value 30", "Configuration", "validateSettings")
+		cnf.Settings.ConsistencySettings.TTL = 30
+	}
+
+	if cnf.Settings.ConsistencySettings.MaxJourneys < 1 || cnf.Settings.ConsistencySettings.MaxJourneys > 100000 {
+		cnf.logger.Warning("Value out of range for CONSISTENCY_MAX_JOURNEYS (1 - 100000), using default value 10000", "Configuration", "validateSettings")
+		cnf.Settings.ConsistencySettings.MaxJourneys = 10000
+	}
+
+	if cnf.Settings.ConsistencySettings.MaxValues < 10000 || cnf.Settings.ConsistencySettings.MaxValues > 100000000 {
+		cnf.logger.Warning("Value out of range for CONSISTENCY_MAX_VALUES (10000 - 100000000), using default value 1000000", "Configuration", "validateSettings")
+		cnf.Settings.ConsistencySettings.MaxValues = 1000000
+	}
+
+	if cnf.Settings.AdminSettings.Enabled && cnf.Settings.AdminSettings.APIKey == "" {
+		cnf.logger.Warning("ADMIN_API_KEY not found, the administration API will be disabled", "Configuration", "validateSettings")
+		cnf.Settings.AdminSettings.Enabled = false
+	}
+
+	if !cnf.validateInboundAuth() {
+		isValid = false
+	}
+
+	if !cnf.validateProxy() {
+		isValid = false
+	}
+
+	return isValid
+}
+
+// validateSamplingMode Validates the sampling mode and key, using random sampling when the values are not valid
+//
+// Parameters:
+// Returns:
+func (cnf *Configuration) validateSamplingMode() {
+	sampling := &cnf.Settings.SamplingSettings
+	sampling.Mode = strings.ToUpper(strings.TrimSpace(sampling.Mode))
+	sampling.Key = strings.ToUpper(strings.TrimSpace(sampling.Key))
+	if sampling.Mode == "" {
+		sampling.Mode = RandomSampling
+	}
+
+	if sampling.Mode != RandomSampling && sampling.Mode != DeterministicSampling {
+		cnf.logger.Warning("Invalid value for SAMPLING_MODE (["+RandomSampling+"], ["+DeterministicSampling+"]), using ["+RandomSampling+"]", "Configuration", "validateSamplingMode")
+		sampling.Mode = RandomSampling
+	}
+
+	if sampling.Mode != DeterministicSampling {
+		return
+	}
+
+	if sampling.Key == "" {
+		sampling.Key = ConsentIDSamplingKey
+	}
+
+	if sampling.Key != ConsentIDSamplingKey && sampling.Key != InteractionIDSamplingKey && sampling.Key != ServerOrgIDSamplingKey {
+		cnf.logger.Warning("Invalid value for SAMPLING_KEY (["+ConsentIDSamplingKey+"], ["+InteractionIDSamplingKey+"], ["+ServerOrgIDSamplingKey+"]), using ["+ConsentIDSamplingKey+"]", "Configuration", "validateSamplingMode")
+		sampling.Key = ConsentIDSamplingKey
+	}
+
+	if sampling.SaltRotation == 0 {
+		sampling.SaltRotation = 60
+	}
+
+	if sampling.SaltRotation < 1 || sampling.SaltRotation > 1440 {
+		cnf.logger.Warning("Value out of range for SAMPLING_SALT_ROTATION (1 - 1440), using default value 60", "Configuration", "validateSamplingMode")
+		sampling.SaltRotation = 60
+	}
+}
+
+// validateLoadShedding Validates the load shedding settings, using the default values when out of range
+//
+// Parameters:
+// Returns:
+func (cnf *Configuration) validateLoadShedding() {
+	loadShedding := &cnf.Settings.LoadSheddingSettings
+	if loadShedding.QueueThreshold < 1 || loadShedding.QueueThreshold > 100 {
+		cnf.logger.Warning("Value out of range for LOAD_SHEDDING_QUEUE_THRESHOLD (1 - 100), using default value 70", "Configuration", "validateLoadShedding")
+		loadShedding.QueueThreshold = 70
+	}
+
+	if loadShedding.LatencyThreshold < 1 || loadShedding.LatencyThreshold > 60000 {
+		cnf.logger.Warning("Value out of range for LOAD_SHEDDING_LATENCY_THRESHOLD (1 - 60000), using default value 500", "Configuration", "validateLoadShedding")
+		loadShedding.LatencyThreshold = 500
+	}
+
+	if loadShedding.MinimumScale < 1 || loadShedding.MinimumScale > 100 {
+		cnf.logger.Warning("Value out of range for LOAD_SHEDDING_MINIMUM_SCALE (1 - 100), using default value 10", "Configuration", "validateLoadShedding")
+		loadShedding.MinimumScale = 10
+	}
+
+	if loadShedding.RecoveryStep < 1 || loadShedding.RecoveryStep > 100 {
+		cnf.logger.Warning("Value out of range for LOAD_SHEDDING_RECOVERY_STEP (1 - 100), using default value 10", "Configuration", "validateLoadShedding")
+		loadShedding.RecoveryStep = 10
+	}
+
+	if loadShedding.CheckInterval < 1 || loadShedding.CheckInterval > 300 {
+		cnf.logger.Warning("Value out of range for LOAD_SHEDDING_CHECK_INTERVAL (1 - 300), using default value 10", "Configuration", "validateLoadShedding")
+		loadShedding.CheckInterval = 10
+	}
+}
+
+// validateInboundAuth Validates the inbound authentication mode and the files required by it
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateInboundAuth() bool {
+	inboundAuth := &cnf.Settings.InboundAuthSettings
+	inboundAuth.Mode = strings.ToUpper(strings.TrimSpace(inboundAuth.Mode))
+	if inboundAuth.MaxClockSkew < 1 || inboundAuth.MaxClockSkew > 3600 {
+		inboundAuth.MaxClockSkew = 300
+	}
+
+	switch inboundAuth.Mode {
+	case "", InboundAuthNone:
+		inboundAuth.Mode = InboundAuthNone
+		return true
+	case InboundAuthAPIKey, InboundAuthHMAC:
+		_, err := os.Stat(inboundAuth.KeysFile)
+		if err != nil {
+			cnf.logger.Warning("Keys file not found, please set Environment Variable: [INBOUND_AUTH_KEYS_FILE] for INBOUND_AUTH_MODE ["+inboundAuth.Mode+"]", "Configuration", "validateInboundAuth")
+			return false
+		}
+	case InboundAuthMTLS:
+		if !cnf.Settings.SecuritySettings.EnableHTTPS {
+			cnf.logger.Warning("INBOUND_AUTH_MODE ["+InboundAuthMTLS+"] requires ENABLE_HTTPS", "Configuration", "validateInboundAuth")
+			return false
+		}
+
+		_, err := os.Stat(inboundAuth.ClientCAFile)
+		if err != nil {
+			cnf.logger.Warning("Client CA file not found, please set Environment Variable: [INBOUND_AUTH_CLIENT_CA_FILE]", "Configuration", "validateInboundAuth")
+			return false
+		}
+	default:
+		cnf.logger.Warning("Invalid value for INBOUND_AUTH_MODE, please use ["+InboundAuthNone+"], ["+InboundAuthAPIKey+"], ["+InboundAuthHMAC+"] or ["+InboundAuthMTLS+"]", "Configuration", "validateInboundAuth")
+		return false
+	}
+
+	return true
+}
+
+// validateRateLimit Validates the rate limit settings, using the default values when out of range
+//
+// Parameters:
+// Returns:
+func (cnf *Configuration) validateRateLimit() {
+	rateLimit := &cnf.Settings.RateLimitSettings
+	rateLimit.Key = strings.ToUpper(strings.TrimSpace(rateLimit.Key))
+	if rateLimit.Key == "" {
+		rateLimit.Key = CallerRateLimitKey
+	}
+
+	if rateLimit.Key != CallerRateLimitKey && rateLimit.Key != ServerOrgIDRateLimitKey && rateLimit.Key != EndpointRateLimitKey {
+		cnf.logger.Warning("Invalid value for RATE_LIMIT_KEY (["+CallerRateLimitKey+"], ["+ServerOrgIDRateLimitKey+"], ["+EndpointRateLimitKey+"]), using ["+CallerRateLimitKey+"]", "Configuration", "validateRateLimit")
+		rateLimit.Key = CallerRateLimitKey
+	}
+
+	if rateLimit.RequestsPerSecond <= 0 || rateLimit.RequestsPerSecond > 100000 {
+		cnf.logger.Warning("Value out of range for RATE_LIMIT_REQUESTS_PER_SECOND (0 - 100000), using default value 100", "Configuration", "validateRateLimit")
+		rateLimit.RequestsPerSecond = 100
+	}
+
+	if rateLimit.Burst < 1 || rateLimit.Burst > 100000 {
+		cnf.logger.Warning("Value out of range for RATE_LIMIT_BURST (1 - 100000), using default value 200", "Configuration", "validateRateLimit")
+		rateLimit.Burst = 200
+	}
+
+	for key, limit := range rateLimit.Limits {
+		if limit.RequestsPerSecond <= 0 || limit.Burst < 1 {
+			cnf.logger.Warning("Invalid rate limit for key: "+key+", the default limit will be used", "Configuration", "validateRateLimit")
+			delete(rateLimit.Limits, key)
+		}
+	}
+}
+
+// validateProxy Validates the proxy mode, the mode must be compatible with the application mode
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateProxy() bool {
+	proxy := &cnf.Settings.ProxySettings
+	proxy.Mode = strings.ToUpper(strings.TrimSpace(proxy.Mode))
+	applicationMode := cnf.Settings.ApplicationSettings.Mode
+	if proxy.Port == "" {
+		proxy.Port = ":8090"
+	}
+
+	if net.ParseIP(proxy.BindAddress) == nil {
+		if proxy.BindAddress != "" {
+			cnf.logger.Warning("Invalid value for PROXY_BIND_ADDRESS, using default value 127.0.0.1", "Configuration", "validateProxy")
+		}
+
+		proxy.BindAddress = "127.0.0.1"
+	}
+
+	if proxy.PathPrefix == "" {
+		proxy.PathPrefix = "/open-banking"
+	}
+
+	if proxy.UpstreamScheme == "" {
+		proxy.UpstreamScheme = "https"
+	}
+
+	if proxy.ServerOrgID == "" {
+		proxy.ServerOrgID = cnf.Settings.ApplicationSettings.OrganisationID
+	}
+
+	switch proxy.Mode {
+	case "", DisabledProxy:
+		proxy.Mode = DisabledProxy
+	case ReverseProxy:
+		if applicationMode == ReceiverMode {
+			cnf.logger.Warning("PROXY_MODE ["+ReverseProxy+"] requires APPLICATION_MODE ["+TransmitterMode+"] or ["+DualMode+"]", "Configuration", "validateProxy")
+			return false
+		}
+
+		if _, err := url.ParseRequestURI(proxy.TargetURL); err != nil {
+			cnf.logger.Warning("PROXY_TARGET_URL not found or invalid, required for PROXY_MODE ["+ReverseProxy+"]", "Configuration", "validateProxy")
+			return false
+		}
+	case ForwardProxy:
+		if applicationMode == TransmitterMode {
+			cnf.logger.Warning("PROXY_MODE ["+ForwardProxy+"] requires APPLICATION_MODE ["+ReceiverMode+"] or ["+DualMode+"]", "Configuration", "validateProxy")
+			return false
+		}
+
+		return cnf.validateServerHosts()
+	default:
+		cnf.logger.Warning("Invalid value for PROXY_MODE, please use ["+DisabledProxy+"], ["+ReverseProxy+"] or ["+ForwardProxy+"]", "Configuration", "validateProxy")
+		return false
+	}
+
+	return true
+}
+
+// validateServerHosts Validates the hosts of the data holders allowed in FORWARD mode, the host names are stored in
+// lower case
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateServerHosts() bool {
+	proxy := &cnf.Settings.ProxySettings
+	if len(proxy.ServerHosts) == 0 {
+		cnf.logger.Warning("ServerHosts not found, at least one host is required for PROXY_MODE ["+ForwardProxy+"]", "Configuration", "validateServerHosts")
+		return false
+	}
+
+	serverHosts := make(map[string]string, len(proxy.ServerHosts))
+	for host, serverOrgID := range proxy.ServerHosts {
+		if _, err := uuid.Parse(serverOrgID); err != nil {
+			cnf.logger.Warning("Invalid serverOrgId in ServerHosts for host: "+host, "Configuration", "validateServerHosts")
+			return false
+		}
+
+		serverHosts[strings.ToLower(strings.TrimSpace(host))] = serverOrgID
+	}
+
+	proxy.ServerHosts = serverHosts
+	return true
+}
+
+// validateOrganisations Validates the list of organisations and includes the main organisation as the first one
+//
+// Parameters:
+// Returns: true if validation was ok
+func (cnf *Configuration) validateOrganisations() bool {
+	isValid := true
+	organisations := make([]OrganisationSettings, 0, len(cnf.Settings.ApplicationSettings.Organisations)+1)
+	mainOrganisation := OrganisationSettings{OrganisationID: cnf.Settings.ApplicationSettings.OrganisationID}
+	if found := cnf.Settings.GetOrganisation(mainOrganisation.OrganisationID); found != nil {
+		mainOrganisation = *found
+	}
+
+	organisations = append(organisations, mainOrganisation)
+	for _, organisation := range cnf.Settings.ApplicationSettings.Organisations {
+		if strings.EqualFold(organisation.OrganisationID, mainOrganisation.OrganisationID) {
+			continue
+		}
+
+		_, err := uuid.Parse(organisation.OrganisationID)
+		if err != nil {
+			cnf.logger.Warning("OrganisationID not found or wrong format in Organisations list: ["+organisation.OrganisationID+"]", "Configuration", "validateOrganisations")
+			isValid = false
+			continue
+		}
+
+		organisations = append(organisations, organisation)
+	}
+
+	for i := range organisations {
+		if organisations[i].ClientID == "" {
+			organisations[i].ClientID = organisations[i].OrganisationID
+		}
+
+		if organisations[i].ProxyURL == "" {
+			organisations[i].ProxyURL = cnf.Settings.SecuritySettings.ProxyURL
+		}
+	}
+
+	cnf.Settings.ApplicationSettings.Organisations = organisations
 	return isValid
 }
 
+// validateHTTPSCertificates Validates the certificate files and the TLS settings of the server
+//
+// Parameters:
+// Returns: true if validation was ok
 func (cnf *Configuration) validateHTTPSCertificates() bool {
-	certFile := "server.crt"
-	keyFile := "server.key"
+	security := &cnf.Settings.SecuritySettings
+	if security.KeyFilePath == "" {
+		security.KeyFilePath = fmt.Sprintf("%s%s", certPath, "server.key")
+	}
 
-	cnf.Settings.SecuritySettings.KeyFilePath = fmt.Sprintf("%s%s", certPath, keyFile)
-	cnf.Settings.SecuritySettings.CertFilePath = fmt.Sprintf("%s%s", certPath, certFile)
-	_, err := os.Stat(cnf.Settings.SecuritySettings.KeyFilePath)
+	if security.CertFilePath == "" {
+		security.CertFilePath = fmt.Sprintf("%s%s", certPath, "server.crt")
+	}
+
+	_, err := os.Stat(security.KeyFilePath)
 	if os.IsNotExist(err) {
-		cnf.logger.Panic("Key certificate not found: "+cnf.Settings.SecuritySettings.KeyFilePath, "Configuration", "validateHTTPSCertificates")
+		cnf.logger.Panic("Key certificate not found: "+security.KeyFilePath, "Configuration", "validateHTTPSCertificates")
 	}
 
-	_, err = os.Stat(cnf.Settings.SecuritySettings.CertFilePath)
+	_, err = os.Stat(security.CertFilePath)
 	if os.IsNotExist(err) {
-		cnf.logger.Panic("Certificate file not found: "+cnf.Settings.SecuritySettings.CertFilePath, "Configuration", "validateHTTPSCertificates")
+		cnf.logger.Panic("Certificate file not found: "+security.CertFilePath, "Configuration", "validateHTTPSCertificates")
 	}
 
-	return true
+	isValid := true
+	if security.MinTLSVersion == "" {
+		security.MinTLSVersion = "1.2"
+	}
+
+	_, err = certificates.ParseTLSVersion(security.MinTLSVersion)
+	if err != nil {
+		cnf.logger.Warning("Invalid value for TLS_MIN_VERSION: "+err.Error(), "Configuration", "validateHTTPSCertificates")
+		isValid = false
+	}
+
+	_, err = certificates.ParseCipherSuites(security.CipherSuites)
+	if err != nil {
+		cnf.logger.Warning("Invalid value for TLS_CIPHER_SUITES: "+err.Error(), "Configuration", "validateHTTPSCertificates")
+		isValid = false
+	}
+
+	security.ClientAuth = strings.ToUpper(strings.TrimSpace(security.ClientAuth))
+	switch security.ClientAuth {
+	case "", NoClientAuth:
+		security.ClientAuth = NoClientAuth
+	case OptionalClientAuth, RequiredClientAuth:
+		if security.ClientCAFile == "" {
+			security.ClientCAFile = cnf.Settings.InboundAuthSettings.ClientCAFile
+		}
+
+		_, err = os.Stat(security.ClientCAFile)
+		if err != nil {
+			cnf.logger.Warning("Client CA file not found, please set Environment Variable: [TLS_CLIENT_CA_FILE] for TLS_CLIENT_AUTH ["+security.ClientAuth+"]", "Configuration", "validateHTTPSCertificates")
+			isValid = false
+		}
+	default:
+		cnf.logger.Warning("Invalid value for TLS_CLIENT_AUTH, please use ["+NoClientAuth+"], ["+OptionalClientAuth+"] or ["+RequiredClientAuth+"]", "Configuration", "validateHTTPSCertificates")
+		isValid = false
+	}
+
+	if security.ReloadInterval < 1 || security.ReloadInterval > 3600 {
+		security.ReloadInterval = 60
+	}
+
+	return isValid
+}
+
+// validateOutboundCertificate Validates the client certificate used for outbound calls, the certificate is not used
+// if the files are not found
+//
+// Parameters:
+// Returns:
+func (cnf *Configuration) validateOutboundCertificate() {
+	security := &cnf.Settings.SecuritySettings
+	if security.OutboundCertFile == "" && security.OutboundKeyFile == "" {
+		return
+	}
+
+	_, certErr := os.Stat(security.OutboundCertFile)
+	_, keyErr := os.Stat(security.OutboundKeyFile)
+	if certErr != nil || keyErr != nil {
+		cnf.logger.Warning("Outbound certificate files not found (OUTBOUND_CERT_FILE / OUTBOUND_KEY_FILE), no client certificate will be used", "Configuration", "validateOutboundCertificate")
+		security.OutboundCertFile = ""
+		security.OutboundKeyFile = ""
+	}
+
+	if security.ReloadInterval < 1 || security.ReloadInterval > 3600 {
+		security.ReloadInterval = 60
+	}
 }
 
 // loadConfigurationFile Loads the settings from the configuration file
@@ -1,6 +1,24 @@
 package configuration
 
-import "github.com/google/uuid"
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// OrganisationSettings stores the settings of an organisation served by the instance
+type OrganisationSettings struct {
+	OrganisationID string   `yaml:"OrganisationID"` // Unique identifier of the organisation
+	ClientID       string   `yaml:"ClientID"`       // Client ID used to request tokens, by default the OrganisationID
+	ProxyURL       string   `yaml:"ProxyURL"`       // URL of the proxy with the certificates of the organisation, by default SecuritySettings.ProxyURL
+	Hosts          []string `yaml:"Hosts"`          // Hosts that identify the messages of the organisation
+}
+
+// RateLimit stores the limits of a token bucket
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"RequestsPerSecond"` // Number of tokens added to the bucket each second
+	Burst             int     `yaml:"Burst"`             // Maximum number of tokens in the bucket
+}
 
 // Settings Manages the configuration values for the application
 type Settings struct {
@@ -14,8 +32,9 @@ type Settings struct {
 
 	// ApplicationSettings stores the settings for the application
 	ApplicationSettings struct {
-		Mode           string `yaml:"Mode" env:"APPLICATION_MODE, overwrite"`
-		OrganisationID string `yaml:"OrganisationID" env:"SERVER_ORG_ID, overwrite"`
+		Mode           string                 `yaml:"Mode" env:"APPLICATION_MODE, overwrite"`
+		OrganisationID string                 `yaml:"OrganisationID" env:"SERVER_ORG_ID, overwrite"`
+		Organisations  []OrganisationSettings `yaml:"Organisations"`
 	} `yaml:"ApplicationSettings"`
 
 	// ReportSettings stores the settings for reporting
@@ -26,10 +45,17 @@ type Settings struct {
 
 	// ReportSettings stores the security settings of the application
 	SecuritySettings struct {
-		EnableHTTPS  bool   `yaml:"EnableHTTPS" env:"ENABLE_HTTPS, overwrite"`
-		ProxyURL     string `yaml:"ProxyURL" env:"PROXY_URL, overwrite"`
-		CertFilePath string
-		KeyFilePath  string
+		EnableHTTPS      bool     `yaml:"EnableHTTPS" env:"ENABLE_HTTPS, overwrite"`
+		ProxyURL         string   `yaml:"ProxyURL" env:"PROXY_URL, overwrite"`
+		CertFilePath     string   `yaml:"CertFilePath" env:"TLS_CERT_FILE, overwrite"`
+		KeyFilePath      string   `yaml:"KeyFilePath" env:"TLS_KEY_FILE, overwrite"`
+		MinTLSVersion    string   `yaml:"MinTLSVersion" env:"TLS_MIN_VERSION, overwrite"`
+		CipherSuites     []string `yaml:"CipherSuites" env:"TLS_CIPHER_SUITES, overwrite"`
+		ClientAuth       string   `yaml:"ClientAuth" env:"TLS_CLIENT_AUTH, overwrite"`
+		ClientCAFile     string   `yaml:"ClientCAFile" env:"TLS_CLIENT_CA_FILE, overwrite"`
+		ReloadInterval   int      `yaml:"ReloadInterval" env:"TLS_RELOAD_INTERVAL, overwrite"`
+		OutboundCertFile string   `yaml:"OutboundCertFile" env:"OUTBOUND_CERT_FILE, overwrite"`
+		OutboundKeyFile  string   `yaml:"OutboundKeyFile" env:"OUTBOUND_KEY_FILE, overwrite"`
 	} `yaml:"SecuritySettings"`
 
 	// ResultSettings stores the settings for result management
@@ -40,4 +66,138 @@ type Settings struct {
 		SamplesPerError    int  `yaml:"SamplesPerError" env:"RESULT_SAMPLES_PER_ERROR, overwrite"`
 		MaskPrivateContent bool `yaml:"MaskPrivateContent" env:"RESULT_MASK_PRIVATE_CONTENT, overwrite"`
 	} `yaml:"ResultSettings"`
+
+	// ProxySettings stores the settings for the proxy mode, that captures the responses for validation
+	ProxySettings struct {
+		Mode           string            `yaml:"Mode" env:"PROXY_MODE, overwrite"`
+		Port           string            `yaml:"Port" env:"PROXY_PORT, overwrite"`
+		BindAddress    string            `yaml:"BindAddress" env:"PROXY_BIND_ADDRESS, overwrite"`
+		TargetURL      string            `yaml:"TargetURL" env:"PROXY_TARGET_URL, overwrite"`
+		ServerOrgID    string            `yaml:"ServerOrgID" env:"PROXY_SERVER_ORG_ID, overwrite"`
+		ServerHosts    map[string]string `yaml:"ServerHosts"`
+		PathPrefix     string            `yaml:"PathPrefix" env:"PROXY_PATH_PREFIX, overwrite"`
+		UpstreamScheme string            `yaml:"UpstreamScheme" env:"PROXY_UPSTREAM_SCHEME, overwrite"`
+	} `yaml:"ProxySettings"`
+
+	// RequestSettings stores the limits for the bodies received by the validation API
+	RequestSettings struct {
+		MaxBodySize         int `yaml:"MaxBodySize" env:"REQUEST_MAX_BODY_SIZE, overwrite"`
+		MaxDecompressedSize int `yaml:"MaxDecompressedSize" env:"REQUEST_MAX_DECOMPRESSED_SIZE, overwrite"`
+		MaxCompressionRatio int `yaml:"MaxCompressionRatio" env:"REQUEST_MAX_COMPRESSION_RATIO, overwrite"`
+	} `yaml:"RequestSettings"`
+
+	// SamplingSettings stores the local settings for the selection of messages to validate
+	SamplingSettings struct {
+		MinValidationsPerWindow int            `yaml:"MinValidationsPerWindow" env:"SAMPLING_MIN_VALIDATIONS, overwrite"`
+		EndpointRates           map[string]int `yaml:"EndpointRates"`
+		Mode                    string         `yaml:"Mode" env:"SAMPLING_MODE, overwrite"`
+		Key                     string         `yaml:"Key" env:"SAMPLING_KEY, overwrite"`
+		SaltRotation            int            `yaml:"SaltRotation" env:"SAMPLING_SALT_ROTATION, overwrite"`
+	} `yaml:"SamplingSettings"`
+
+	// LoadSheddingSettings stores the settings for the reduction of validation rates under load
+	LoadSheddingSettings struct {
+		Enabled          bool `yaml:"Enabled" env:"LOAD_SHEDDING_ENABLED, overwrite"`
+		QueueThreshold   int  `yaml:"QueueThreshold" env:"LOAD_SHEDDING_QUEUE_THRESHOLD, overwrite"`
+		LatencyThreshold int  `yaml:"LatencyThreshold" env:"LOAD_SHEDDING_LATENCY_THRESHOLD, overwrite"`
+		MinimumScale     int  `yaml:"MinimumScale" env:"LOAD_SHEDDING_MINIMUM_SCALE, overwrite"`
+		RecoveryStep     int  `yaml:"RecoveryStep" env:"LOAD_SHEDDING_RECOVERY_STEP, overwrite"`
+		CheckInterval    int  `yaml:"CheckInterval" env:"LOAD_SHEDDING_CHECK_INTERVAL, overwrite"`
+	} `yaml:"LoadSheddingSettings"`
+
+	// RateLimitSettings stores the settings for the rate limiting of the validation API
+	RateLimitSettings struct {
+		Enabled           bool                 `yaml:"Enabled" env:"RATE_LIMIT_ENABLED, overwrite"`
+		Key               string               `yaml:"Key" env:"RATE_LIMIT_KEY, overwrite"`
+		RequestsPerSecond float64              `yaml:"RequestsPerSecond" env:"RATE_LIMIT_REQUESTS_PER_SECOND, overwrite"`
+		Burst             int                  `yaml:"Burst" env:"RATE_LIMIT_BURST, overwrite"`
+		Limits            map[string]RateLimit `yaml:"Limits"`
+	} `yaml:"RateLimitSettings"`
+
+	// UpdateSettings stores the settings for the configuration update process
+	UpdateSettings struct {
+		Interval    int    `yaml:"Interval" env:"CONFIGURATION_UPDATE_INTERVAL, overwrite"`
+		Jitter      int    `yaml:"Jitter" env:"CONFIGURATION_UPDATE_JITTER, overwrite"`
+		Concurrency int    `yaml:"Concurrency" env:"CONFIGURATION_UPDATE_CONCURRENCY, overwrite"`
+		HistorySize int    `yaml:"HistorySize" env:"CONFIGURATION_HISTORY_SIZE, overwrite"`
+		HistoryPath string `yaml:"HistoryPath" env:"CONFIGURATION_HISTORY_PATH, overwrite"`
+	} `yaml:"UpdateSettings"`
+
+	// ValidationSettings stores the settings of the validation engines
+	ValidationSettings struct {
+		AssertFormats          *bool `yaml:"AssertFormats" env:"VALIDATION_ASSERT_FORMATS, overwrite, noinit"`
+		PaginationChecks       bool  `yaml:"PaginationChecks" env:"VALIDATION_PAGINATION_CHECKS, overwrite"`
+		MaxRequestDateTimeSkew int   `yaml:"MaxRequestDateTimeSkew" env:"VALIDATION_MAX_REQUEST_DATETIME_SKEW, overwrite"`
+	} `yaml:"ValidationSettings"`
+
+	// ConsistencySettings stores the settings of the checks between the messages of a consent journey
+	ConsistencySettings struct {
+		Enabled     bool `yaml:"Enabled" env:"CONSISTENCY_ENABLED, overwrite"`
+		TTL         int  `yaml:"TTL" env:"CONSISTENCY_TTL, overwrite"`
+		MaxJourneys int  `yaml:"MaxJourneys" env:"CONSISTENCY_MAX_JOURNEYS, overwrite"`
+		MaxValues   int  `yaml:"MaxValues" env:"CONSISTENCY_MAX_VALUES, overwrite"`
+	} `yaml:"ConsistencySettings"`
+
+	// AdminSettings stores the settings for the administration API
+	AdminSettings struct {
+		Enabled bool   `yaml:"Enabled" env:"ADMIN_ENABLED, overwrite"`
+		APIKey  string `yaml:"APIKey" env:"ADMIN_API_KEY, overwrite" json:"-"`
+	} `yaml:"AdminSettings"`
+
+	// InboundAuthSettings stores the settings for the authentication of the requests to the validation API
+	InboundAuthSettings struct {
+		Mode            string   `yaml:"Mode" env:"INBOUND_AUTH_MODE, overwrite"`
+		KeysFile        string   `yaml:"KeysFile" env:"INBOUND_AUTH_KEYS_FILE, overwrite"`
+		ClientCAFile    string   `yaml:"ClientCAFile" env:"INBOUND_AUTH_CLIENT_CA_FILE, overwrite"`
+		AllowedSubjects []string `yaml:"AllowedSubjects" env:"INBOUND_AUTH_ALLOWED_SUBJECTS, overwrite"`
+		MaxClockSkew    int      `yaml:"MaxClockSkew" env:"INBOUND_AUTH_MAX_CLOCK_SKEW, overwrite"`
+		ProtectMetrics  bool     `yaml:"ProtectMetrics" env:"INBOUND_AUTH_PROTECT_METRICS, overwrite"`
+		ProtectHealth   bool     `yaml:"ProtectHealth" env:"INBOUND_AUTH_PROTECT_HEALTH, overwrite"`
+	} `yaml:"InboundAuthSettings"`
+}
+
+// GetOrganisation returns the settings of a specific organisation
+//
+// Parameters:
+//   - organisationID: identifier of the organisation
+//
+// Returns:
+//   - *OrganisationSettings: settings found, nil if the organisation is not configured
+func (s *Settings) GetOrganisation(organisationID string) *OrganisationSettings {
+	for i, organisation := range s.ApplicationSettings.Organisations {
+		if strings.EqualFold(organisation.OrganisationID, organisationID) {
+			return &s.ApplicationSettings.Organisations[i]
+		}
+	}
+
+	return nil
+}
+
+// GetOrganisationByHost returns the settings of the organisation mapped to a specific host
+//
+// Parameters:
+//   - host: host of the request, without port
+//
+// Returns:
+//   - *OrganisationSettings: settings found, nil if no organisation is mapped to the host
+func (s *Settings) GetOrganisationByHost(host string) *OrganisationSettings {
+	for i, organisation := range s.ApplicationSettings.Organisations {
+		for _, organisationHost := range organisation.Hosts {
+			if strings.EqualFold(organisationHost, host) {
+				return &s.ApplicationSettings.Organisations[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsAssertFormats indicates if the formats are validated by the schemas, true if the setting is not set
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if the formats are validated
+func (s *Settings) IsAssertFormats() bool {
+	return s.ValidationSettings.AssertFormats == nil || *s.ValidationSettings.AssertFormats
 }
@@ -0,0 +1,339 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+const (
+	testOrganisationID       = "5f1b3c2a-8d4e-4f6a-9b7c-0d1e2f3a4b5c"
+	testSecondOrganisationID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+)
+
+// newTestConfiguration returns a configuration with the main organisation and a logger that only writes errors
+func newTestConfiguration() *Configuration {
+	cnf := &Configuration{logger: log.NewLogger("ERROR")}
+	cnf.Settings.ApplicationSettings.OrganisationID = testOrganisationID
+	cnf.Settings.SecuritySettings.ProxyURL = "http://proxy"
+	return cnf
+}
+
+func TestValidateOrganisations(t *testing.T) {
+	tests := []struct {
+		name          string
+		organisations []OrganisationSettings
+		wantValid     bool
+		want          []OrganisationSettings
+	}{
+		{
+			name:      "main organisation is included",
+			wantValid: true,
+			want:      []OrganisationSettings{{OrganisationID: testOrganisationID, ClientID: testOrganisationID, ProxyURL: "http://proxy"}},
+		},
+		{
+			name: "main organisation is the first one and keeps its settings",
+			organisations: []OrganisationSettings{
+				{OrganisationID: testSecondOrganisationID, ProxyURL: "http://second"},
+				{OrganisationID: testOrganisationID, ClientID: "client"},
+			},
+			wantValid: true,
+			want: []OrganisationSettings{
+				{OrganisationID: testOrganisationID, ClientID: "client", ProxyURL: "http://proxy"},
+				{OrganisationID: testSecondOrganisationID, ClientID: testSecondOrganisationID, ProxyURL: "http://second"},
+			},
+		},
+		{
+			name:          "invalid organisation ID",
+			organisations: []OrganisationSettings{{OrganisationID: "not-a-uuid"}},
+			wantValid:     false,
+			want:          []OrganisationSettings{{OrganisationID: testOrganisationID, ClientID: testOrganisationID, ProxyURL: "http://proxy"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := newTestConfiguration()
+			cnf.Settings.ApplicationSettings.Organisations = tt.organisations
+			if got := cnf.validateOrganisations(); got != tt.wantValid {
+				t.Errorf("validateOrganisations() = %v, want %v", got, tt.wantValid)
+			}
+
+			if got := cnf.Settings.ApplicationSettings.Organisations; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Organisations = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOrganisationByHost(t *testing.T) {
+	settings := Settings{}
+	settings.ApplicationSettings.Organisations = []OrganisationSettings{
+		{OrganisationID: testOrganisationID},
+		{OrganisationID: testSecondOrganisationID, Hosts: []string{"api.second.example.com"}},
+	}
+
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "known host", host: "api.second.example.com", want: testSecondOrganisationID},
+		{name: "host is not case sensitive", host: "API.Second.example.com", want: testSecondOrganisationID},
+		{name: "unknown host", host: "api.other.example.com", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if organisation := settings.GetOrganisationByHost(tt.host); organisation != nil {
+				got = organisation.OrganisationID
+			}
+
+			if got != tt.want {
+				t.Errorf("GetOrganisationByHost(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLoadShedding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value int
+		want  [5]int
+	}{
+		{name: "zero values use the defaults", value: 0, want: [5]int{70, 500, 10, 10, 10}},
+		{name: "negative values use the defaults", value: -1, want: [5]int{70, 500, 10, 10, 10}},
+		{name: "values in range are kept", value: 50, want: [5]int{50, 50, 50, 50, 50}},
+		{name: "values over the limits use the defaults", value: 60001, want: [5]int{70, 500, 10, 10, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := newTestConfiguration()
+			loadShedding := &cnf.Settings.LoadSheddingSettings
+			loadShedding.QueueThreshold = tt.value
+			loadShedding.LatencyThreshold = tt.value
+			loadShedding.MinimumScale = tt.value
+			loadShedding.RecoveryStep = tt.value
+			loadShedding.CheckInterval = tt.value
+			cnf.validateLoadShedding()
+
+			got := [5]int{loadShedding.QueueThreshold, loadShedding.LatencyThreshold, loadShedding.MinimumScale, loadShedding.RecoveryStep, loadShedding.CheckInterval}
+			if got != tt.want {
+				t.Errorf("validateLoadShedding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateHTTPSCertificates(t *testing.T) {
+	folder := t.TempDir()
+	certFile := filepath.Join(folder, "server.crt")
+	if err := os.WriteFile(certFile, []byte("certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		minVersion     string
+		cipherSuites   []string
+		clientAuth     string
+		caFile         string
+		reloadInterval int
+		wantValid      bool
+		wantMinVersion string
+		wantClientAuth string
+		wantReload     int
+	}{
+		{name: "defaults", wantValid: true, wantMinVersion: "1.2", wantClientAuth: NoClientAuth, wantReload: 60},
+		{name: "TLS 1.3 and required client certificate", minVersion: "1.3", clientAuth: " required ", caFile: certFile, reloadInterval: 10, wantValid: true, wantMinVersion: "1.3", wantClientAuth: RequiredClientAuth, wantReload: 10},
+		{name: "invalid TLS version", minVersion: "1.1", wantValid: false, wantMinVersion: "1.1", wantClientAuth: NoClientAuth, wantReload: 60},
+		{name: "insecure cipher suite", cipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}, wantValid: false, wantMinVersion: "1.2", wantClientAuth: NoClientAuth, wantReload: 60},
+		{name: "client certificate without CA file", clientAuth: OptionalClientAuth, wantValid: false, wantMinVersion: "1.2", wantClientAuth: OptionalClientAuth, wantReload: 60},
+		{name: "invalid client authentication", clientAuth: "ALWAYS", wantValid: false, wantMinVersion: "1.2", wantClientAuth: "ALWAYS", wantReload: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := newTestConfiguration()
+			security := &cnf.Settings.SecuritySettings
+			security.CertFilePath = certFile
+			security.KeyFilePath = certFile
+			security.MinTLSVersion = tt.minVersion
+			security.CipherSuites = tt.cipherSuites
+			security.ClientAuth = tt.clientAuth
+			security.ClientCAFile = tt.caFile
+			security.ReloadInterval = tt.reloadInterval
+
+			if got := cnf.validateHTTPSCertificates(); got != tt.wantValid {
+				t.Errorf("validateHTTPSCertificates() = %v, want %v", got, tt.wantValid)
+			}
+
+			if security.MinTLSVersion != tt.wantMinVersion || security.ClientAuth != tt.wantClientAuth || security.ReloadInterval != tt.wantReload {
+				t.Errorf("settings = %q, %q, %d, want %q, %q, %d", security.MinTLSVersion, security.ClientAuth, security.ReloadInterval, tt.wantMinVersion, tt.wantClientAuth, tt.wantReload)
+			}
+		})
+	}
+}
+
+func TestValidateOutboundCertificate(t *testing.T) {
+	folder := t.TempDir()
+	certFile := filepath.Join(folder, "client.crt")
+	if err := os.WriteFile(certFile, []byte("certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		want     string
+	}{
+		{name: "not configured", want: ""},
+		{name: "files found", certFile: certFile, keyFile: certFile, want: certFile},
+		{name: "key file not found", certFile: certFile, keyFile: filepath.Join(folder, "missing.key"), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := newTestConfiguration()
+			cnf.Settings.SecuritySettings.OutboundCertFile = tt.certFile
+			cnf.Settings.SecuritySettings.OutboundKeyFile = tt.keyFile
+			cnf.validateOutboundCertificate()
+
+			if got := cnf.Settings.SecuritySettings.OutboundCertFile; got != tt.want || cnf.Settings.SecuritySettings.OutboundKeyFile != tt.want {
+				t.Errorf("OutboundCertFile = %q, OutboundKeyFile = %q, want %q", got, cnf.Settings.SecuritySettings.OutboundKeyFile, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateProxy(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            string
+		applicationMode string
+		bindAddress     string
+		targetURL       string
+		serverHosts     map[string]string
+		wantValid       bool
+		wantBindAddress string
+		wantServerHosts map[string]string
+	}{
+		{name: "disabled", applicationMode: TransmitterMode, wantValid: true, wantBindAddress: "127.0.0.1"},
+		{name: "reverse", mode: "reverse", applicationMode: TransmitterMode, targetURL: "https://api.bank", bindAddress: "0.0.0.0", wantValid: true, wantBindAddress: "0.0.0.0"},
+		{name: "reverse without target URL", mode: ReverseProxy, applicationMode: TransmitterMode, wantValid: false},
+		{name: "reverse for a receiver", mode: ReverseProxy, applicationMode: ReceiverMode, targetURL: "https://api.bank", wantValid: false},
+		{name: "invalid bind address", applicationMode: TransmitterMode, bindAddress: "localhost", wantValid: true, wantBindAddress: "127.0.0.1"},
+		{
+			name:            "forward with hosts",
+			mode:            ForwardProxy,
+			applicationMode: ReceiverMode,
+			serverHosts:     map[string]string{" API.Bank.com ": testSecondOrganisationID},
+			wantValid:       true,
+			wantBindAddress: "127.0.0.1",
+			wantServerHosts: map[string]string{"api.bank.com": testSecondOrganisationID},
+		},
+		{name: "forward without hosts", mode: ForwardProxy, applicationMode: ReceiverMode, wantValid: false},
+		{name: "forward with invalid serverOrgId", mode: ForwardProxy, applicationMode: ReceiverMode, serverHosts: map[string]string{"api.bank.com": "bank"}, wantValid: false},
+		{name: "forward for a transmitter", mode: ForwardProxy, applicationMode: TransmitterMode, serverHosts: map[string]string{"api.bank.com": testSecondOrganisationID}, wantValid: false},
+		{name: "invalid mode", mode: "TUNNEL", applicationMode: TransmitterMode, wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := newTestConfiguration()
+			cnf.Settings.ApplicationSettings.Mode = tt.applicationMode
+			cnf.Settings.ProxySettings.Mode = tt.mode
+			cnf.Settings.ProxySettings.BindAddress = tt.bindAddress
+			cnf.Settings.ProxySettings.TargetURL = tt.targetURL
+			cnf.Settings.ProxySettings.ServerHosts = tt.serverHosts
+
+			if got := cnf.validateProxy(); got != tt.wantValid {
+				t.Fatalf("validateProxy() = %v, want %v", got, tt.wantValid)
+			}
+
+			if !tt.wantValid {
+				return
+			}
+
+			proxy := cnf.Settings.ProxySettings
+			if proxy.BindAddress != tt.wantBindAddress || proxy.Port != ":8090" || proxy.ServerOrgID != testOrganisationID {
+				t.Errorf("BindAddress = %q, Port = %q, ServerOrgID = %q", proxy.BindAddress, proxy.Port, proxy.ServerOrgID)
+			}
+
+			if tt.wantServerHosts != nil && !reflect.DeepEqual(proxy.ServerHosts, tt.wantServerHosts) {
+				t.Errorf("ServerHosts = %v, want %v", proxy.ServerHosts, tt.wantServerHosts)
+			}
+		})
+	}
+}
+
+func TestValidateAssertFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string // Value of VALIDATION_ASSERT_FORMATS, empty if not set
+		file *bool  // Value of the configuration file, nil if not set
+		want bool
+	}{
+		{name: "not set", want: true},
+		{name: "disabled on the file", file: new(bool), want: false},
+		{name: "disabled on the environment", env: "false", want: false},
+		{name: "enabled on the environment", env: "true", file: new(bool), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("VALIDATION_ASSERT_FORMATS", tt.env)
+			}
+
+			cnf := newTestConfiguration()
+			cnf.Settings.ValidationSettings.AssertFormats = tt.file
+			if err := cnf.loadSettingsFromEnvironment(); err != nil {
+				t.Fatal(err)
+			}
+
+			cnf.validateSettings()
+			if cnf.Settings.ValidationSettings.AssertFormats == nil || cnf.Settings.IsAssertFormats() != tt.want {
+				t.Errorf("AssertFormats = %v, want %v", cnf.Settings.ValidationSettings.AssertFormats, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConsistency(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       int
+		journeys  int
+		maxValues int
+		want      [3]int
+	}{
+		{name: "zero values use the defaults", want: [3]int{30, 10000, 1000000}},
+		{name: "values in range are kept", ttl: 10, journeys: 100, maxValues: 50000, want: [3]int{10, 100, 50000}},
+		{name: "values below the limits use the defaults", ttl: -1, journeys: -1, maxValues: 9999, want: [3]int{30, 10000, 1000000}},
+		{name: "values over the limits use the defaults", ttl: 1441, journeys: 100001, maxValues: 100000001, want: [3]int{30, 10000, 1000000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := newTestConfiguration()
+			consistency := &cnf.Settings.ConsistencySettings
+			consistency.TTL = tt.ttl
+			consistency.MaxJourneys = tt.journeys
+			consistency.MaxValues = tt.maxValues
+			cnf.validateSettings()
+
+			got := [3]int{consistency.TTL, consistency.MaxJourneys, consistency.MaxValues}
+			if got != tt.want {
+				t.Errorf("ConsistencySettings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,44 +2,62 @@ package log
 
 import (
 	"context"
+	"io"
+	"os"
 
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
 // JSONLogger struct in charge of logging tasks
 type JSONLogger struct {
 	context context.Context // Context to be used during logging
+	logger  zerolog.Logger  // Logger of the instance, with its own level
 }
 
-// GetNewJSONLogger Creates a new JSONLogger
+// GetNewJSONLogger Creates a new JSONLogger that writes to the standard error
 //
 // Parameters:
 //
 // Returns:
 //   - JSONLogger: New JSONLogger
 func GetNewJSONLogger() *JSONLogger {
-	return &JSONLogger{}
+	return NewJSONLogger(os.Stderr)
 }
 
-// SetLoggingGlobalLevel Sets the global level for the globbing feature
+// NewJSONLogger Creates a new JSONLogger that writes to a specific writer
+//
+// Parameters:
+//   - writer: Writer of the log entries
+//
+// Returns:
+//   - JSONLogger: New JSONLogger
+func NewJSONLogger(writer io.Writer) *JSONLogger {
+	return &JSONLogger{logger: zerolog.New(writer).With().Timestamp().Logger()}
+}
+
+// SetLoggingGlobalLevel Sets the level of the logger, other loggers are not affected
 //
 // Parameters:
 //   - level: logging Level to be configured
 //
 // Returns:
 func (l *JSONLogger) SetLoggingGlobalLevel(level Level) {
-	zerolog.SetGlobalLevel(zerolog.Level(level))
+	// zerolog also filters by its global level, it is only lowered so the level of each logger applies
+	if zerolog.Level(level) < zerolog.GlobalLevel() {
+		zerolog.SetGlobalLevel(zerolog.Level(level))
+	}
+
+	l.logger = l.logger.Level(zerolog.Level(level))
 }
 
-// GetLoggingGlobalLevel Gets the global level for the globbing feature
+// GetLoggingGlobalLevel Gets the level of the logger
 //
 // Parameters:
 //
 // Returns:
-//   - level: global logging level
+//   - level: logging level
 func (l *JSONLogger) GetLoggingGlobalLevel() Level {
-	return Level(zerolog.GlobalLevel())
+	return Level(l.logger.GetLevel())
 }
 
 // WithContext Sets the context for the logger
@@ -93,7 +111,7 @@ func (l *JSONLogger) SetLoggingGlobalLevelFromString(level string) {
 //
 // Returns:
 func (l *JSONLogger) Trace(message string, pack string, component string) {
-	log.Trace().Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Trace().Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Log Trace writes a message to the LOG level
@@ -105,7 +123,7 @@ func (l *JSONLogger) Trace(message string, pack string, component string) {
 //
 // Returns:
 func (l *JSONLogger) Log(message string, pack string, component string) {
-	log.Log().Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Log().Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Debug Trace writes a message to the DEBUG level
@@ -117,7 +135,7 @@ func (l *JSONLogger) Log(message string, pack string, component string) {
 //
 // Returns:
 func (l *JSONLogger) Debug(message string, pack string, component string) {
-	log.Debug().Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Debug().Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Info Trace writes a message to the INFO level
@@ -129,7 +147,7 @@ func (l *JSONLogger) Debug(message string, pack string, component string) {
 //
 // Returns:
 func (l *JSONLogger) Info(message string, pack string, component string) {
-	log.Info().Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Info().Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Warning Trace writes a message to the WARNING level
@@ -141,7 +159,7 @@ func (l *JSONLogger) Info(message string, pack string, component string) {
 //
 // Returns:
 func (l *JSONLogger) Warning(message string, pack string, component string) {
-	log.Warn().Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Warn().Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Error Trace writes a message to the ERROR level
@@ -153,7 +171,7 @@ func (l *JSONLogger) Warning(message string, pack string, component string) {
 //
 // Returns:
 func (l *JSONLogger) Error(err error, message string, pack string, component string) {
-	log.Error().Err(err).Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Error().Err(err).Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Fatal Trace writes a message to the FATAL level
@@ -165,7 +183,7 @@ func (l *JSONLogger) Error(err error, message string, pack string, component str
 //
 // Returns:
 func (l *JSONLogger) Fatal(err error, message string, pack string, component string) {
-	log.Fatal().Err(err).Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Fatal().Err(err).Str("package", pack).Str("component", component).Msg(message)
 }
 
 // Panic Trace writes a message to the PANIC level
@@ -177,5 +195,5 @@ func (l *JSONLogger) Fatal(err error, message string, pack string, component str
 //
 // Returns:
 func (l *JSONLogger) Panic(message string, pack string, component string) {
-	log.Panic().Str("package", pack).Str("component", component).Msg(message)
+	l.logger.Panic().Str("package", pack).Str("component", component).Msg(message)
 }
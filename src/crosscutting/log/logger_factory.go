@@ -1,25 +1,14 @@
 package log
 
-import "sync"
-
-var (
-	singleton Logger          // Logger variable to be used as a singleton
-	lock      = &sync.Mutex{} // mutex for multithreading
-)
-
-// GetLogger returns the logger
+// NewLogger returns a new logger with its own level, loggers are not shared between application instances
 //
 // Parameters:
+//   - loggingLevel: Logging level of the logger
 //
 // Returns:
 //   - logger: Logger created
-func GetLogger(loggingLevel string) Logger {
-	if singleton == nil {
-		lock.Lock()
-		defer lock.Unlock()
-		singleton = GetNewJSONLogger()
-		singleton.SetLoggingGlobalLevelFromString(loggingLevel)
-	}
-
-	return singleton
+func NewLogger(loggingLevel string) Logger {
+	logger := GetNewJSONLogger()
+	logger.SetLoggingGlobalLevelFromString(loggingLevel)
+	return logger
 }
@@ -3,13 +3,14 @@ package monitoring
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"runtime"
 	"strconv"
 	"sync"
 	"time"
 
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -40,27 +41,36 @@ type SystemMetrics struct {
 	AllowedCPUs         string
 	RequestsReceived    string
 	BadRequestsReceived string
+	RateLimitedRequests string
 	AverageResponseTime string
 }
 
-var (
+// Metrics stores the metrics of an application instance, each instance has its own registry
+type Metrics struct {
 	requests                 metric.Float64Counter // Stores the number of requests the application has received
 	endpointRequests         metric.Float64Counter // Stores the number of requests by endpoint / server
 	endpointValidationErrors metric.Float64Counter // Stores the number of validation errors by endpoint / server
-	mutex                    = sync.Mutex{}        // Mutex for thread-safe access
-	requestsReceived         = 0                   // Stores the number of requests received
-	badRequestsReceived      = 0                   // Stores the number of bad requests errors
+	loadSheddingAdjustments  metric.Float64Counter // Stores the number of adjustments of the load shedding scale
+	loadSheddingScale        metric.Float64Gauge   // Stores the scale applied to the validation rates
+	rateLimitHits            metric.Float64Counter // Stores the number of rate limited requests by key type and key
+	payloadSize              metric.Int64Histogram // Stores the size of the payloads by endpoint
+	certificateExpiry        metric.Float64Gauge   // Stores the time until the expiration of the certificates
+	handler                  http.Handler          // Handler that exports the metrics of the registry
+	rateLimitedRequests      int                   // Stores the number of rate limited requests
+	mutex                    sync.Mutex            // Mutex for thread-safe access
+	requestsReceived         int                   // Stores the number of requests received
+	badRequestsReceived      int                   // Stores the number of bad requests errors
 	measurements             []Measurement
 	responseTime             []time.Duration
-	unsupportedEndpoints     = make(map[string]map[string]int) // Stores the number of unsupported endpoints
-)
+	unsupportedEndpoints     map[string]map[string]int // Stores the number of unsupported endpoints
+}
 
-// startMemoryCalculator Starts the memory calculation for observability
+// StartMemoryCalculator Starts the memory calculation for observability
 //
 // Parameters:
 //
 // Returns:
-func startMemoryCalculator() {
+func (m *Metrics) StartMemoryCalculator() {
 	// Specify the duration for which you want to collect memory statistics in each interval
 	collectionDuration := 1 * time.Minute // Change this as needed
 
@@ -69,21 +79,21 @@ func startMemoryCalculator() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		mutex.Lock()
+		m.mutex.Lock()
 		// Collect memory and CPU statistics for the specified duration
 		var memStats runtime.MemStats
 		runtime.ReadMemStats(&memStats)
 		cpuUsage := collectCPUUsage()
 
 		// Append the measurements to the slice
-		measurements = append(measurements, Measurement{
+		m.measurements = append(m.measurements, Measurement{
 			Timestamp:     time.Now(),
 			Memory:        memStats.Alloc,
 			MaxUSedMemory: memStats.TotalAlloc,
 			CPU:           cpuUsage,
 			NumCPU:        runtime.NumCPU(),
 		})
-		mutex.Unlock()
+		m.mutex.Unlock()
 	}
 }
 
@@ -129,13 +139,19 @@ func collectCPUUsage() float64 {
 	return 0.0 // Placeholder value, replace with actual implementation
 }
 
-// StartOpenTelemetry Initializes the counters and OpenTelemetry exporter for the service
-// @author AB
-// @params
-// @return
-func StartOpenTelemetry() {
+// NewMetrics Initializes the counters and OpenTelemetry exporter for an application instance, the metrics are
+// registered in a registry owned by the instance
+//
+// Parameters:
+//
+// Returns:
+//   - *Metrics: Metrics created
+//   - error: error if the metrics cannot be created
+func NewMetrics() (*Metrics, error) {
 	ctx := context.Background()
-	go startMemoryCalculator()
+	m := &Metrics{unsupportedEndpoints: make(map[string]map[string]int)}
+	registry := prom.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
 	resources := resource.NewWithAttributes(
 		semconv.SchemaURL,
@@ -146,9 +162,9 @@ func StartOpenTelemetry() {
 	// The exporter embeds a default OpenTelemetry Reader and
 	// implements prometheus.Collector, allowing it to be used as
 	// both a Reader and Collector.
-	exporter, err := prometheus.New()
+	exporter, err := prometheus.New(prometheus.WithRegisterer(registry))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	meterProvider := sdk.NewMeterProvider(
@@ -162,36 +178,85 @@ func StartOpenTelemetry() {
 	)
 
 	// This is the equivalent of prometheus.NewCounterVec
-	requests, err = meter.Float64Counter(
+	m.requests, err = meter.Float64Counter(
 		"request_count",
 		metric.WithDescription("Incoming request count"),
 		metric.WithUnit("request"),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// This is the equivalent of prometheus.NewCounterVec
-	endpointRequests, err = meter.Float64Counter(
+	m.endpointRequests, err = meter.Float64Counter(
 		"endpoint_requests",
 		metric.WithDescription("Endpoint Requests by Server"),
 		metric.WithUnit("requests"),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// This is the equivalent of prometheus.NewCounterVec
-	endpointValidationErrors, err = meter.Float64Counter(
+	m.endpointValidationErrors, err = meter.Float64Counter(
 		"endpoint_validation_errors",
 		metric.WithDescription("Endpoint validation errors by Server"),
 		metric.WithUnit("errors"),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	m.loadSheddingAdjustments, err = meter.Float64Counter(
+		"load_shedding_adjustments",
+		metric.WithDescription("Adjustments of the validation rates by load shedding"),
+		metric.WithUnit("adjustments"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.loadSheddingScale, err = meter.Float64Gauge(
+		"load_shedding_scale",
+		metric.WithDescription("Scale applied to the validation rates of HIGH and EXTREMELY_HIGH endpoints"),
+		metric.WithUnit("%"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.rateLimitHits, err = meter.Float64Counter(
+		"rate_limit_hits",
+		metric.WithDescription("Requests rejected by the rate limit by key type and key"),
+		metric.WithUnit("requests"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.payloadSize, err = meter.Int64Histogram(
+		"payload_size",
+		metric.WithDescription("Size of the payloads received by endpoint"),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.certificateExpiry, err = meter.Float64Gauge(
+		"certificate_expiry_seconds",
+		metric.WithDescription("Time until the expiration of the certificates used by the application"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	requests.Add(ctx, 0)
+	m.requests.Add(ctx, 0)
+	m.loadSheddingScale.Record(ctx, 100)
+	m.handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return m, nil
 }
 
 // GetOpentelemetryHandler Returns the specified handler to export metrics
@@ -199,8 +264,8 @@ func StartOpenTelemetry() {
 // @params
 // @return
 // http.Handler handler that supports metric export
-func GetOpentelemetryHandler() http.Handler {
-	return promhttp.Handler()
+func (m *Metrics) GetOpentelemetryHandler() http.Handler {
+	return m.handler
 }
 
 // RecordResponseDuration records thee response duration for a specific request.
@@ -208,31 +273,70 @@ func GetOpentelemetryHandler() http.Handler {
 // @params
 // startTime: Initial start time for the request
 // @return
-func RecordResponseDuration(startTime time.Time) {
-	mutex.Lock()
-	responseTime = append(responseTime, time.Since(startTime))
-	mutex.Unlock()
+func (m *Metrics) RecordResponseDuration(startTime time.Time) {
+	m.mutex.Lock()
+	m.responseTime = append(m.responseTime, time.Since(startTime))
+	m.mutex.Unlock()
 }
 
 // IncreaseRequestsReceived increases the number of requests received metric
 // @author AB
 // @params
 // @return
-func IncreaseRequestsReceived() {
-	mutex.Lock()
-	requestsReceived++
-	requests.Add(context.Background(), 1)
-	mutex.Unlock()
+func (m *Metrics) IncreaseRequestsReceived() {
+	m.mutex.Lock()
+	m.requestsReceived++
+	m.requests.Add(context.Background(), 1)
+	m.mutex.Unlock()
 }
 
 // IncreaseBadRequestsReceived increases the number of bad requests received metric
 // @author AB
 // @params
 // @return
-func IncreaseBadRequestsReceived() {
-	mutex.Lock()
-	badRequestsReceived++
-	mutex.Unlock()
+func (m *Metrics) IncreaseBadRequestsReceived() {
+	m.mutex.Lock()
+	m.badRequestsReceived++
+	m.mutex.Unlock()
+}
+
+// IncreaseRateLimitedRequests increases the number of requests rejected by the rate limit. The caller must bound the
+// cardinality of the key, using only values known by the configuration
+//
+// Parameters:
+//   - keyType: Type of the rate limit key (CALLER / SERVER_ORG_ID / ENDPOINT)
+//   - key: Rate limit key, "other" for the keys not known by the configuration
+//
+// Returns:
+func (m *Metrics) IncreaseRateLimitedRequests(keyType string, key string) {
+	m.mutex.Lock()
+	m.rateLimitedRequests++
+	m.rateLimitHits.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("key.type").String(keyType), attribute.Key("key").String(key)))
+	m.mutex.Unlock()
+}
+
+// RecordPayloadSize records the size of a payload received
+//
+// Parameters:
+//   - endpointName: Name of the endpoint
+//   - encoding: Content encoding of the request
+//   - size: Size of the payload in bytes, after decompression
+//
+// Returns:
+func (m *Metrics) RecordPayloadSize(endpointName string, encoding string, size int) {
+	m.payloadSize.Record(context.Background(), int64(size), metric.WithAttributes(attribute.Key("endpoint").String(endpointName), attribute.Key("encoding").String(encoding)))
+}
+
+// RecordCertificateExpiry records the time until the expiration of a certificate
+//
+// Parameters:
+//   - certificate: Name of the certificate (server / client)
+//   - subject: Subject of the certificate
+//   - seconds: Seconds until the expiration
+//
+// Returns:
+func (m *Metrics) RecordCertificateExpiry(certificate string, subject string, seconds float64) {
+	m.certificateExpiry.Record(context.Background(), seconds, metric.WithAttributes(attribute.Key("certificate").String(certificate), attribute.Key("subject").String(subject)))
 }
 
 // IncreaseBadEndpointsReceived increases the number of bad requests received metric
@@ -243,15 +347,15 @@ func IncreaseBadRequestsReceived() {
 //   - float64: Error message to record
 //
 // Returns:
-func IncreaseBadEndpointsReceived(endpoint string, version string, errorMessage string) {
-	mutex.Lock()
-	badRequestsReceived++
-	if unsupportedEndpoints[endpoint] == nil {
-		unsupportedEndpoints[endpoint] = make(map[string]int)
+func (m *Metrics) IncreaseBadEndpointsReceived(endpoint string, version string, errorMessage string) {
+	m.mutex.Lock()
+	m.badRequestsReceived++
+	if m.unsupportedEndpoints[endpoint] == nil {
+		m.unsupportedEndpoints[endpoint] = make(map[string]int)
 	}
 
-	unsupportedEndpoints[endpoint][version]++
-	mutex.Unlock()
+	m.unsupportedEndpoints[endpoint][version]++
+	m.mutex.Unlock()
 }
 
 // IncreaseValidationResult increases the number validation result for a specific server / endpoint, if the validation is false
@@ -263,15 +367,27 @@ func IncreaseBadEndpointsReceived(endpoint string, version string, errorMessage
 //   - valid: Validation result
 //
 // Returns:
-func IncreaseValidationResult(serverID string, endpointName string, valid bool) {
-	mutex.Lock()
+func (m *Metrics) IncreaseValidationResult(serverID string, endpointName string, valid bool) {
+	m.mutex.Lock()
 
-	endpointRequests.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("server.name").String(serverID), attribute.Key("endpoint").String(endpointName)))
+	m.endpointRequests.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("server.name").String(serverID), attribute.Key("endpoint").String(endpointName)))
 	if !valid {
-		endpointValidationErrors.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("server.name").String(serverID), attribute.Key("endpoint").String(endpointName)))
+		m.endpointValidationErrors.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("server.name").String(serverID), attribute.Key("endpoint").String(endpointName)))
 	}
 
-	mutex.Unlock()
+	m.mutex.Unlock()
+}
+
+// RecordLoadSheddingAdjustment records a change in the scale applied to the validation rates
+//
+// Parameters:
+//   - direction: Direction of the adjustment (DECREASE / INCREASE)
+//   - scale: New scale in %
+//
+// Returns:
+func (m *Metrics) RecordLoadSheddingAdjustment(direction string, scale int) {
+	m.loadSheddingAdjustments.Add(context.Background(), 1, metric.WithAttributes(attribute.Key("direction").String(direction)))
+	m.loadSheddingScale.Record(context.Background(), float64(scale))
 }
 
 // GetAndCleanRequestsReceived returns and cleans the lists of requests
@@ -279,12 +395,12 @@ func IncreaseValidationResult(serverID string, endpointName string, valid bool)
 // @params
 // @return
 // int: Number of requests received in the period of time
-func getAndCleanRequestsReceived() int {
+func (m *Metrics) getAndCleanRequestsReceived() int {
 	defer func() {
-		requestsReceived = 0
+		m.requestsReceived = 0
 	}()
 
-	return requestsReceived
+	return m.requestsReceived
 }
 
 // GetAndCleanBadRequestsReceived returns and cleans the lists of bad requests
@@ -292,12 +408,26 @@ func getAndCleanRequestsReceived() int {
 // @params
 // @return
 // int: Number of bad requests received in the period of time
-func getAndCleanBadRequestsReceived() int {
+func (m *Metrics) getAndCleanBadRequestsReceived() int {
+	defer func() {
+		m.badRequestsReceived = 0
+	}()
+
+	return m.badRequestsReceived
+}
+
+// getAndCleanRateLimitedRequests returns and cleans the number of rate limited requests
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of rate limited requests received in the period of time
+func (m *Metrics) getAndCleanRateLimitedRequests() int {
 	defer func() {
-		badRequestsReceived = 0
+		m.rateLimitedRequests = 0
 	}()
 
-	return badRequestsReceived
+	return m.rateLimitedRequests
 }
 
 // GetAndCleanUnsupportedEndpoints returns and cleans the lists of bad requests
@@ -307,14 +437,14 @@ func getAndCleanBadRequestsReceived() int {
 //
 // Returns:
 //   - map: map[string]map[string]int Number of bad requests received in the period of time by endpoint and version
-func GetAndCleanUnsupportedEndpoints() map[string]map[string]int {
-	mutex.Lock()
+func (m *Metrics) GetAndCleanUnsupportedEndpoints() map[string]map[string]int {
+	m.mutex.Lock()
 	defer func() {
-		unsupportedEndpoints = make(map[string]map[string]int)
-		mutex.Unlock()
+		m.unsupportedEndpoints = make(map[string]map[string]int)
+		m.mutex.Unlock()
 	}()
 
-	return unsupportedEndpoints
+	return m.unsupportedEndpoints
 }
 
 // GetAndCleanResponseTime Returns and cleans the metric fot average response time
@@ -322,9 +452,9 @@ func GetAndCleanUnsupportedEndpoints() map[string]map[string]int {
 // @params
 // @return
 // string: Avg memory used
-func getAndCleanResponseTime() string {
-	avgTime := calculateAverageDuration(responseTime)
-	responseTime = []time.Duration{}
+func (m *Metrics) getAndCleanResponseTime() string {
+	avgTime := calculateAverageDuration(m.responseTime)
+	m.responseTime = []time.Duration{}
 	return fmt.Sprint(avgTime)
 }
 
@@ -349,24 +479,25 @@ func calculateAverageDuration(durations []time.Duration) int64 {
 // @params
 // @return
 // SystemMetrics: instance of the system metrics object
-func GetAndCleanSystemMetrics() SystemMetrics {
-	mutex.Lock()
+func (m *Metrics) GetAndCleanSystemMetrics() SystemMetrics {
+	m.mutex.Lock()
 	// Calculate the average memory usage and CPU consumption and print them
-	avgMemory, maxMemory, numCPU := calculateAverageMemory(measurements)
+	avgMemory, maxMemory, numCPU := calculateAverageMemory(m.measurements)
 
 	result := SystemMetrics{
 		AverageMemory:       fmt.Sprintf("%.2f MB", float64(avgMemory)/1024/1024),
 		MaxUsedMemory:       fmt.Sprintf("%.2f MB", float64(maxMemory)/1024/1024),
 		CPUUsage:            "",
 		AllowedCPUs:         strconv.Itoa(numCPU),
-		RequestsReceived:    strconv.Itoa(getAndCleanRequestsReceived()),
-		BadRequestsReceived: strconv.Itoa(getAndCleanBadRequestsReceived()),
-		AverageResponseTime: getAndCleanResponseTime(),
+		RequestsReceived:    strconv.Itoa(m.getAndCleanRequestsReceived()),
+		BadRequestsReceived: strconv.Itoa(m.getAndCleanBadRequestsReceived()),
+		RateLimitedRequests: strconv.Itoa(m.getAndCleanRateLimitedRequests()),
+		AverageResponseTime: m.getAndCleanResponseTime(),
 	}
 
 	// Reset measurements for the next interval
-	measurements = []Measurement{}
-	mutex.Unlock()
+	m.measurements = []Measurement{}
+	m.mutex.Unlock()
 
 	return result
 }
@@ -0,0 +1,275 @@
+package certificates
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+)
+
+const pack = "certificates"
+
+// Reloader keeps a certificate loaded from its files, and reloads it when the files change
+type Reloader struct {
+	logger      log.Logger          // Logger to be used
+	metrics     *monitoring.Metrics // Metrics of the application instance, nil to skip the expiry metric
+	name        string              // Name of the certificate, used in logs and metrics (server / client)
+	certFile    string              // Path of the certificate file
+	keyFile     string              // Path of the key file
+	certificate *tls.Certificate    // Certificate currently loaded
+	modTime     time.Time           // Last modification date of the files when loaded
+	mutex       sync.RWMutex        // Mutex for thread-safe access to the certificate
+}
+
+// NewReloader creates a new reloader, loading the certificate from the files
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - metrics: Metrics of the application instance, nil to skip the expiry metric
+//   - name: Name of the certificate, used in logs and metrics
+//   - certFile: Path of the certificate file
+//   - keyFile: Path of the key file
+//
+// Returns:
+//   - *Reloader: Reloader created
+//   - error: error if the certificate cannot be loaded
+func NewReloader(logger log.Logger, metrics *monitoring.Metrics, name string, certFile string, keyFile string) (*Reloader, error) {
+	reloader := &Reloader{
+		logger:   logger,
+		metrics:  metrics,
+		name:     name,
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	err := reloader.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// getModTime returns the most recent modification date of the certificate and key files
+//
+// Parameters:
+//
+// Returns:
+//   - time.Time: Modification date
+//   - error: error if the files cannot be accessed
+func (r *Reloader) getModTime() (time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+
+	return certInfo.ModTime(), nil
+}
+
+// load Loads the certificate from the files and records its expiry
+//
+// Parameters:
+//
+// Returns:
+//   - error: error if the certificate cannot be loaded
+func (r *Reloader) load() error {
+	modTime, err := r.getModTime()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if certificate.Leaf == nil {
+		certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	r.certificate = &certificate
+	r.modTime = modTime
+	r.mutex.Unlock()
+
+	r.logger.Info("Certificate ["+r.name+"] loaded, subject: "+certificate.Leaf.Subject.String()+", expires: "+certificate.Leaf.NotAfter.String(), pack, "load")
+	r.recordExpiry()
+	return nil
+}
+
+// recordExpiry Records the time until the expiration of the certificate
+//
+// Parameters:
+//
+// Returns:
+func (r *Reloader) recordExpiry() {
+	if r.metrics == nil {
+		return
+	}
+
+	r.mutex.RLock()
+	leaf := r.certificate.Leaf
+	r.mutex.RUnlock()
+
+	r.metrics.RecordCertificateExpiry(r.name, leaf.Subject.String(), time.Until(leaf.NotAfter).Seconds())
+}
+
+// Watch Checks the files periodically, reloading the certificate when they change. If the new files are not valid
+// the previous certificate is kept
+//
+// Parameters:
+//   - interval: Time between checks
+//
+// Returns:
+func (r *Reloader) Watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.checkFiles()
+	}
+}
+
+// checkFiles Reloads the certificate if its files changed since the last load
+//
+// Parameters:
+//
+// Returns:
+func (r *Reloader) checkFiles() {
+	modTime, err := r.getModTime()
+	if err != nil {
+		r.logger.Warning("Error checking certificate ["+r.name+"] files: "+err.Error(), pack, "checkFiles")
+		return
+	}
+
+	r.mutex.RLock()
+	changed := !modTime.Equal(r.modTime)
+	r.mutex.RUnlock()
+	if changed {
+		err = r.load()
+		if err != nil {
+			r.logger.Error(err, "Error reloading certificate ["+r.name+"], the previous certificate will be used", pack, "checkFiles")
+		}
+	}
+
+	r.recordExpiry()
+}
+
+// GetCertificate returns the current certificate, to be used as tls.Config.GetCertificate
+//
+// Parameters:
+//   - hello: Information of the client hello
+//
+// Returns:
+//   - *tls.Certificate: Current certificate
+//   - error: error if any
+func (r *Reloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.certificate, nil
+}
+
+// GetClientCertificate returns the current certificate, to be used as tls.Config.GetClientCertificate
+//
+// Parameters:
+//   - request: Information of the certificate request
+//
+// Returns:
+//   - *tls.Certificate: Current certificate
+//   - error: error if any
+func (r *Reloader) GetClientCertificate(request *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.certificate, nil
+}
+
+// ParseTLSVersion returns the TLS version for a configured value
+//
+// Parameters:
+//   - version: Configured version (1.2 / 1.3)
+//
+// Returns:
+//   - uint16: TLS version
+//   - error: error if the version is not supported
+func ParseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimSpace(version) {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, errors.New("TLS version not supported: " + version + ", use 1.2 or 1.3")
+}
+
+// ParseCipherSuites returns the IDs of the configured cipher suites, only secure cipher suites are accepted
+//
+// Parameters:
+//   - names: Names of the cipher suites (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256)
+//
+// Returns:
+//   - []uint16: IDs of the cipher suites, nil to use the default cipher suites
+//   - error: error if a cipher suite is unknown or insecure
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	available := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	result := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, found := available[strings.TrimSpace(name)]
+		if !found {
+			return nil, errors.New("cipher suite not supported: " + name)
+		}
+
+		result = append(result, id)
+	}
+
+	return result, nil
+}
+
+// LoadCertPool Loads the CA certificates from a file
+//
+// Parameters:
+//   - path: Path of the CA file
+//
+// Returns:
+//   - *x509.CertPool: Pool with the CA certificates
+//   - error: error if the file cannot be read or has no certificates
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errors.New("no certificates found in CA file: " + path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,208 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key in a folder and returns the paths of the files
+func writeTestCertificate(t *testing.T, folder string, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(folder, "server.crt")
+	keyFile := filepath.Join(folder, "server.key")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+// touchTestFile changes the modification date of a file, the files written in the same test may have the same date
+func touchTestFile(t *testing.T, path string) {
+	t.Helper()
+	date := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, date, date); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{version: "1.2", want: tls.VersionTLS12},
+		{version: " 1.3 ", want: tls.VersionTLS13},
+		{version: "1.1", wantErr: true},
+		{version: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseTLSVersion(tt.version)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseTLSVersion(%q) = %v, %v, want %v, wantErr %v", tt.version, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    []uint16
+		wantErr bool
+	}{
+		{name: "default cipher suites", names: nil, want: nil},
+		{name: "secure cipher suites", names: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", " TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 "}, want: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}},
+		{name: "insecure cipher suite", names: []string{"TLS_RSA_WITH_RC4_128_SHA"}, wantErr: true},
+		{name: "unknown cipher suite", names: []string{"TLS_UNKNOWN"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCipherSuites(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCipherSuites() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseCipherSuites() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseCipherSuites()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	folder := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, folder, "ca")
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "CA file", path: certFile},
+		{name: "file without certificates", path: keyFile, wantErr: true},
+		{name: "missing file", path: filepath.Join(folder, "missing.crt"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := LoadCertPool(tt.path)
+			if (err != nil) != tt.wantErr || (pool != nil) == tt.wantErr {
+				t.Errorf("LoadCertPool() = %v, %v, wantErr %v", pool, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReloader(t *testing.T) {
+	tests := []struct {
+		name        string
+		change      func(t *testing.T, folder string, certFile string)
+		wantSubject string
+	}{
+		{name: "files not changed", change: func(t *testing.T, folder string, certFile string) {}, wantSubject: "first"},
+		{name: "new certificate is loaded", change: func(t *testing.T, folder string, certFile string) {
+			writeTestCertificate(t, folder, "second")
+			touchTestFile(t, certFile)
+		}, wantSubject: "second"},
+		{name: "invalid certificate keeps the previous one", change: func(t *testing.T, folder string, certFile string) {
+			if err := os.WriteFile(certFile, []byte("invalid"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			touchTestFile(t, certFile)
+		}, wantSubject: "first"},
+		{name: "removed files keep the previous certificate", change: func(t *testing.T, folder string, certFile string) {
+			if err := os.Remove(certFile); err != nil {
+				t.Fatal(err)
+			}
+		}, wantSubject: "first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := monitoring.NewMetrics()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			folder := t.TempDir()
+			certFile, keyFile := writeTestCertificate(t, folder, "first")
+			reloader, err := NewReloader(log.NewLogger("PANIC"), metrics, "server", certFile, keyFile)
+			if err != nil {
+				t.Fatalf("NewReloader() error = %v", err)
+			}
+
+			tt.change(t, folder, certFile)
+			reloader.checkFiles()
+
+			certificate, _ := reloader.GetCertificate(nil)
+			if certificate.Leaf.Subject.CommonName != tt.wantSubject {
+				t.Errorf("GetCertificate() subject = %q, want %q", certificate.Leaf.Subject.CommonName, tt.wantSubject)
+			}
+
+			client, _ := reloader.GetClientCertificate(nil)
+			if client != certificate {
+				t.Errorf("GetClientCertificate() returned a different certificate")
+			}
+		})
+	}
+
+	if _, err := NewReloader(log.NewLogger("PANIC"), nil, "server", "missing.crt", "missing.key"); err == nil {
+		t.Errorf("NewReloader() with missing files, want error")
+	}
+}
@@ -42,8 +42,8 @@ type APIGroupSetting struct {
 // ValidationSettings stores the configuration for validations of the application
 type ValidationSettings struct {
 	APIGroupSettings                     []APIGroupSetting `json:"APIGroupSettings"`                     // API group validation settings
-	TransmitterValidationRate            int               `json:"TransmitterValidationRate"`            // Validation rate in % for transmitter mode 1 - 100
-	ReceiverValidationRate               int               `json:"ReceiverValidationRate"`               // Validation rate in % for receiver mode 1 - 100
+	TransmitterValidationRate            *int              `json:"TransmitterValidationRate"`            // Validation rate in % for transmitter mode 0 - 100, nil if not configured
+	ReceiverValidationRate               *int              `json:"ReceiverValidationRate"`               // Validation rate in % for receiver mode 0 - 100, nil if not configured
 	ExtremelyHighTroughputValidationRate int               `json:"ExtremelyHighTroughputValidationRate"` // Validation rate in % for extremely high throughput mode 1 - 100
 	HighTroughputValidationRate          int               `json:"HighTroughputValidationRate"`          // Validation rate in % for high throughput mode 1 - 100
 	MediumTroughputValidationRate        int               `json:"MediumTroughputValidationRate"`        // Validation rate in % for medium throughput mode 1 - 100
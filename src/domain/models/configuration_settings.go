@@ -14,6 +14,7 @@ type ConfigurationSettings struct {
 type ReportSettings struct {
 	ReportExecutionWindow int `json:"ReportExecutionWindow"` // Report execution window in minutes
 	SendOnReportNumber    int `json:"SendOnReportNumber"`    // Indicates the number of reports to send on (ex. 10000000)
+	ReportVersion         int `json:"ReportVersion"`         // Latest version of the report accepted by the server, 0 for ReportVersion1
 }
 
 // SecuritySettings Stores security settings information
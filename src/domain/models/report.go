@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+const (
+	// ReportVersion1 is the original format of the report, with the errors grouped by message (ErrorType)
+	ReportVersion1 = 1
+	// ReportVersion2 adds the error codes, constraints and rule IDs of the errors, and the consistency findings
+	ReportVersion2 = 2
+)
+
 // MetricObject Contains the name and value for different types of metrics for the report
 type MetricObject struct {
 	Key   string // Name of the metric
@@ -26,6 +33,8 @@ type ConfigurationUpdateStatus struct {
 	LastExecutionDate        time.Time                  // Indicates the data execution of the configuration update
 	LastUpdatedDate          time.Time                  // Indicates the data of the las successful configuration update
 	ConfigurationUpdateError []ConfigurationUpdateError // List of error messages if any durin the update process
+	Pinned                   bool                       // Indicates if the configuration is pinned to a previous version
+	PinnedDate               time.Time                  // Indicates the date when the configuration was pinned
 }
 
 // ApplicationConfiguration Contains the information of the actual configuration of the application
@@ -61,7 +70,10 @@ type ServerSummary struct {
 
 // FieldDetail contains the details for a filed with an error type
 type FieldDetail struct {
-	ErrorType  string   // Name of the error type found
+	ErrorType  string   // Message of the error found, kept for compatibility
+	Code       string   `json:",omitempty"` // Stable code of the error (e.g. required, pattern, enum), ReportVersion2
+	Constraint string   `json:",omitempty"` // Constraint expected by the validation (e.g. the pattern or the allowed values), ReportVersion2
+	RuleID     string   `json:",omitempty"` // Identifier of the business rule violated, empty for the schema errors, ReportVersion2
 	XFapiList  []string // List of xFapiInteractionIds that showed this specific error
 	TotalCount int      // Number of times the error was found
 }
@@ -74,10 +86,40 @@ type EndPointSummaryDetail struct {
 
 // EndPointSummary Contains a summary for a specific endpoint
 type EndPointSummary struct {
-	EndpointName     string                  // Name of the endpoint
-	TotalRequests    int                     // Total number of requests
-	ValidationErrors int                     // Total number of validation errors
-	Detail           []EndPointSummaryDetail // Detail of the errors
+	EndpointName        string                  // Name of the endpoint
+	TotalRequests       int                     // Total number of requests
+	ValidationErrors    int                     // Total number of validation errors
+	Detail              []EndPointSummaryDetail // Detail of the errors
+	ConsistencyFindings int                     `json:",omitempty"` // Total number of requests inconsistent with previous requests of the same consent, ReportVersion2
+	ConsistencyDetail   []EndPointSummaryDetail `json:",omitempty"` // Detail of the inconsistencies with previous requests of the same consent, ReportVersion2
+}
+
+// EndpointSamplingRate contains the sampling information of an endpoint during the report window
+type EndpointSamplingRate struct {
+	EndpointName      string // Name of the endpoint
+	ConfiguredRate    int    // Validation rate in % applied to the endpoint
+	ReceivedRequests  int    // Number of requests received
+	ValidatedRequests int    // Number of requests selected for validation
+	FloorValidations  int    // Number of requests selected to reach the minimum validations per window
+	EffectiveRate     string // Percentage of received requests that were validated
+}
+
+// LoadSheddingAdjustment contains the information of a change in the scale applied to the validation rates
+type LoadSheddingAdjustment struct {
+	Date           time.Time // Date of the adjustment
+	PreviousScale  int       // Scale in % applied before the adjustment
+	NewScale       int       // Scale in % applied after the adjustment
+	QueueDepth     int       // Number of messages waiting in the queue
+	AverageLatency string    // Average validation time of the messages
+	Reason         string    // Reason of the adjustment
+}
+
+// LoadSheddingSummary contains the load shedding information during the report window
+type LoadSheddingSummary struct {
+	CurrentScale    int                      // Scale in % applied to HIGH and EXTREMELY_HIGH endpoints
+	LowestScale     int                      // Lowest scale in % applied during the window
+	DroppedMessages int                      // Number of messages discarded because the queue was full
+	Adjustments     []LoadSheddingAdjustment // List of adjustments during the window
 }
 
 // Report is the object to be sent to the server
@@ -86,6 +128,65 @@ type Report struct {
 	ApplicationConfiguration ApplicationConfiguration // Configuration of the application on the Client Side
 	ClientID                 string                   // Client identifier (UUID)
 	DataOwnerID              string                   // OrganisationID of the institution reporting the information
-	UnsupportedEndpoints     []UnsupportedEndpoint    // List with the unsupported endpoint requests
+	UnsupportedEndpoints     []UnsupportedEndpoint    // List with the unsupported endpoint requests, only in the first report of the window
 	ServerSummary            []ServerSummary          // List of Servers requested
+	SamplingRates            []EndpointSamplingRate   // Sampling information of the endpoints requested
+	LoadShedding             *LoadSheddingSummary     // Load shedding information, nil if disabled or not the first report of the window
+	ReportVersion            int                      `json:",omitempty"` // Version of the report, omitted for ReportVersion1
+}
+
+// SetVersion Sets the version of the report, removing the information that the version does not support. On
+// ReportVersion1, the errors of a field are grouped again by message, and the consistency findings are removed
+//
+// Parameters:
+//   - version: Version of the report, ReportVersion1 if lower
+//
+// Returns:
+func (r *Report) SetVersion(version int) {
+	if version >= ReportVersion2 {
+		r.ReportVersion = ReportVersion2
+		return
+	}
+
+	r.ReportVersion = 0
+	for i := range r.ServerSummary {
+		for j := range r.ServerSummary[i].EndpointSummary {
+			endpoint := &r.ServerSummary[i].EndpointSummary[j]
+			endpoint.ConsistencyFindings = 0
+			endpoint.ConsistencyDetail = nil
+			for k := range endpoint.Detail {
+				endpoint.Detail[k].Details = groupByErrorType(endpoint.Detail[k].Details)
+			}
+		}
+	}
+}
+
+// groupByErrorType groups the details of a field by message, without the information of ReportVersion2
+//
+// Parameters:
+//   - details: Details of the field
+//
+// Returns:
+//   - []FieldDetail: Details grouped by message
+func groupByErrorType(details []FieldDetail) []FieldDetail {
+	result := make([]FieldDetail, 0, len(details))
+	for _, detail := range details {
+		index := -1
+		for i := range result {
+			if result[i].ErrorType == detail.ErrorType {
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			result = append(result, FieldDetail{ErrorType: detail.ErrorType})
+			index = len(result) - 1
+		}
+
+		result[index].XFapiList = append(result[index].XFapiList, detail.XFapiList...)
+		result[index].TotalCount += detail.TotalCount
+	}
+
+	return result
 }
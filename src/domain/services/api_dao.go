@@ -1,24 +1,29 @@
 package services
 
 import (
+	"crypto/tls"
 	"errors"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/certificates"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/jwt"
 )
 
 // RestAPI is the struct to handle connections to APIs
 type RestAPI struct {
-	crosscutting.OFBStruct               // Base structure
-	token                  *jwt.JWKToken // Token used by the server
+	crosscutting.OFBStruct                          // Base structure
+	tokens                 map[string]*jwt.JWKToken // Tokens used by the server, by client ID
+	tokenMutex             sync.Mutex               // Mutex for thread-safe access to the tokens
 	serverURL              string
+	clientCertificate      *certificates.Reloader // Client certificate for outbound calls, nil if not configured
 }
 
 // loadCertificates Loads certificates from environment variables
@@ -27,11 +32,11 @@ type RestAPI struct {
 // @return
 // error: Error if any
 // Response from server in case of success
-func (ad *RestAPI) requestNewJWTToken(clientID string) (*jwt.JWKToken, error) {
+func (ad *RestAPI) requestNewJWTToken(serverURL string, clientID string) (*jwt.JWKToken, error) {
 	ad.Logger.Info("Requesting new token", ad.Pack, "requestNewJWTToken")
 
 	// Create an HTTP client
-	client := &http.Client{}
+	client := ad.getHTTPClient()
 
 	// Define the parameters for the token request
 	params := url.Values{}
@@ -39,11 +44,11 @@ func (ad *RestAPI) requestNewJWTToken(clientID string) (*jwt.JWKToken, error) {
 	params.Set("client_id", clientID)
 	requestBody := params.Encode()
 
-	ad.Logger.Debug("ServerURL:"+ad.serverURL+tokenPath, ad.Pack, "requestNewJWTToken")
+	ad.Logger.Debug("ServerURL:"+serverURL+tokenPath, ad.Pack, "requestNewJWTToken")
 	ad.Logger.Debug("Body:"+requestBody, ad.Pack, "requestNewJWTToken")
 
 	// Create a new HTTP request
-	req, err := http.NewRequest("POST", ad.serverURL+tokenPath, strings.NewReader(requestBody))
+	req, err := http.NewRequest("POST", serverURL+tokenPath, strings.NewReader(requestBody))
 	if err != nil {
 		ad.Logger.Error(err, "Error creating request", ad.Pack, "requestNewJWTToken")
 		return nil, err
@@ -86,28 +91,39 @@ func (ad *RestAPI) requestNewJWTToken(clientID string) (*jwt.JWKToken, error) {
 }
 
 // getJWKToken returns a valid Token to be used in a secure communication
-// @author AB
-// @params
-// @return
-// Error if any
-func (ad *RestAPI) getJWKToken(clientID string) error {
+//
+// Parameters:
+//   - serverURL: URL of the server that issues the token
+//   - clientID: Client ID of the organisation
+//
+// Returns:
+//   - *jwt.JWKToken: valid token for the client
+//   - error: Error if any
+func (ad *RestAPI) getJWKToken(serverURL string, clientID string) (*jwt.JWKToken, error) {
 	ad.Logger.Info("Loading JWT token", ad.Pack, "getJWKToken")
+	ad.tokenMutex.Lock()
+	defer ad.tokenMutex.Unlock()
 
-	if ad.token != nil && jwt.ValidateExpiration(ad.Logger, ad.token) {
+	token := ad.tokens[clientID]
+	if token != nil && jwt.ValidateExpiration(ad.Logger, token) {
 		ad.Logger.Info("Token is valid, using previous token", ad.Pack, "getJWKToken")
-		return nil
+		return token, nil
 	}
 
 	ad.Logger.Info("Token is invalid, Requesting new token", ad.Pack, "getJWKToken")
 
-	token, err := ad.requestNewJWTToken(clientID)
+	token, err := ad.requestNewJWTToken(serverURL, clientID)
 	if err != nil {
 		ad.Logger.Error(err, "Error sending request", ad.Pack, "getJWKToken")
-		return err
+		return nil, err
 	}
 
-	ad.token = token
-	return nil
+	if ad.tokens == nil {
+		ad.tokens = make(map[string]*jwt.JWKToken)
+	}
+
+	ad.tokens[clientID] = token
+	return token, nil
 }
 
 // getHTTPClient Returns a client configured to use certificates for mTLS communication
@@ -115,37 +131,77 @@ func (ad *RestAPI) getJWKToken(clientID string) error {
 // @return
 // http client: Client created with certificate info
 func (ad *RestAPI) getHTTPClient() *http.Client {
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			// TLSClientConfig: &tls.Config{
-			// 	Certificates:       []tls.Certificate{ad.certificates},
-			// 	InsecureSkipVerify: true,
-			// },
-		},
+	transport := &http.Transport{}
+	if ad.clientCertificate != nil {
+		transport.TLSClientConfig = &tls.Config{
+			GetClientCertificate: ad.clientCertificate.GetClientCertificate,
+			MinVersion:           tls.VersionTLS12,
+		}
 	}
 
-	return httpClient
+	return &http.Client{Transport: transport}
 }
 
 // executeGet returns the response body of a GET request
 func (ad *RestAPI) executeGet(url string, retryTimes int) ([]byte, error) {
+	return ad.executeConditionalGet(url, retryTimes, nil)
+}
+
+// executeConditionalGet returns the response body of a GET request, using the validators of a previous response if any
+//
+// Parameters:
+//   - url: URL to request
+//   - retryTimes: Number of retries in case of failure
+//   - conditional: Validators of the previous response, updated with the validators of the new response. Can be nil
+//
+// Returns:
+//   - []byte: Body of the response
+//   - error: ErrNotModified if the server indicates that the content has not changed, or error if any
+func (ad *RestAPI) executeConditionalGet(url string, retryTimes int, conditional *ConditionalRequest) ([]byte, error) {
 	ad.Logger.Info("Executing Get Request", ad.Pack, "executeGet")
 	ad.Logger.Debug("URL: "+url, ad.Pack, "executeGet")
 	httpClient := ad.getHTTPClient()
 
 	// Create a new request
-	response, err := httpClient.Get(url)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		ad.Logger.Error(err, "Error creating request", ad.Pack, "executeGet")
+		return nil, err
+	}
+
+	if conditional != nil {
+		if conditional.ETag != "" {
+			request.Header.Set("If-None-Match", conditional.ETag)
+		}
+
+		if conditional.LastModified != "" {
+			request.Header.Set("If-Modified-Since", conditional.LastModified)
+		}
+	}
+
+	response, err := httpClient.Do(request)
 	if err != nil {
 		ad.Logger.Error(err, "Error executing request", ad.Pack, "executeGet")
 		if retryTimes > 0 {
 			ad.Logger.Info("Retrying request", ad.Pack, "executeGet")
 			time.Sleep(1 * time.Second)
-			return ad.executeGet(url, retryTimes-1)
+			return ad.executeConditionalGet(url, retryTimes-1, conditional)
 		}
 
 		return nil, err
 	}
 
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			ad.Logger.Error(err, "Error closing response body", ad.Pack, "executeGet")
+		}
+	}()
+
+	if response.StatusCode == http.StatusNotModified && conditional != nil {
+		ad.Logger.Info("Content not modified", ad.Pack, "executeGet")
+		return nil, ErrNotModified
+	}
+
 	if response.StatusCode == http.StatusForbidden {
 		ad.Logger.Warning("Forbidden status code", ad.Pack, "executeGet")
 		return nil, errors.New("forbidden status code")
@@ -157,19 +213,11 @@ func (ad *RestAPI) executeGet(url string, retryTimes int) ([]byte, error) {
 		if retryTimes > 0 {
 			ad.Logger.Info("Retrying request", ad.Pack, "executeGet")
 			time.Sleep(1 * time.Second)
-			return ad.executeGet(url, retryTimes-1)
+			return ad.executeConditionalGet(url, retryTimes-1, conditional)
 		}
 		return nil, errors.New("invalid status code: " + strconv.Itoa(response.StatusCode))
 	}
 
-	defer func() {
-		if err := response.Body.Close(); err != nil {
-			ad.Logger.Error(err, "Error closing response body", ad.Pack, "executeGet")
-		}
-	}()
-
-	// defer response.Body.Close()
-
 	// Read the response body
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -183,5 +231,10 @@ func (ad *RestAPI) executeGet(url string, retryTimes int) ([]byte, error) {
 		return nil, errors.New("configuration file not found: " + url)
 	}
 
+	if conditional != nil {
+		conditional.ETag = response.Header.Get("ETag")
+		conditional.LastModified = response.Header.Get("Last-Modified")
+	}
+
 	return body, nil
 }
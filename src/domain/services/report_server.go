@@ -1,10 +1,23 @@
 package services
 
-import "github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+import (
+	"errors"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+)
+
+// ErrNotModified indicates that the requested file was not modified since the last request
+var ErrNotModified = errors.New("file not modified")
+
+// ConditionalRequest stores the validators used to execute conditional requests to the server
+type ConditionalRequest struct {
+	ETag         string // Value of the ETag header of the last response
+	LastModified string // Value of the Last-Modified header of the last response
+}
 
 // ReportServer is the Interface trhat exposes the methods to interact with report server
 type ReportServer interface {
-	SendReport(report models.Report) error                             // Send the report
-	LoadAPIConfigurationFile(filePath string) ([]byte, error)          // Loads the configuration file specified in the path
-	LoadConfigurationSettings() (*models.ConfigurationSettings, error) // Loads the configuration settings from the configuration file
+	SendReport(report models.Report) error                                                            // Send the report
+	LoadAPIConfigurationFile(filePath string) ([]byte, error)                                         // Loads the configuration file specified in the path
+	LoadConfigurationSettings(conditional *ConditionalRequest) (*models.ConfigurationSettings, error) // Loads the configuration settings from the configuration file
 }
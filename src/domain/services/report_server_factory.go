@@ -1,30 +1,21 @@
 package services
 
 import (
-	"sync"
-
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
 )
 
-var (
-	lock      = &sync.Mutex{} // mutex for multithreading
-	singleton ReportServer    // Singleton for the Report Server
-)
-
-// GetReportServer Returns the report server to be used
+// NewReportServer Returns a new report server for an application instance
 //
 // Parameters:
 //   - logger: Logger to be used
+//   - metrics: Metrics of the application instance
+//   - serverURL: URL of the report server
+//   - settings: Application settings
 //
 // Returns:
 //   - ReportServer: ReportServer instance
-func GetReportServer(logger log.Logger, serverURL string, settings configuration.Settings) *ReportServer {
-	if singleton == nil {
-		lock.Lock()
-		defer lock.Unlock()
-		singleton = NewReportServerMQD(logger, serverURL, settings)
-	}
-
-	return &singleton
+func NewReportServer(logger log.Logger, metrics *monitoring.Metrics, serverURL string, settings configuration.Settings) ReportServer {
+	return NewReportServerMQD(logger, metrics, serverURL, settings)
 }
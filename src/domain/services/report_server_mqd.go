@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/certificates"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/jwt"
 	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
 )
 
@@ -31,10 +35,13 @@ type ReportServerMQD struct {
 //
 // Parameters:
 //   - logger: Logger to be used
+//   - metrics: Metrics of the application instance
+//   - serverURL: URL of the report server
+//   - settings: Application settings
 //
 // Returns:
 //   - ReportServerMQD: Server created
-func NewReportServerMQD(logger log.Logger, serverURL string, settings configuration.Settings) *ReportServerMQD {
+func NewReportServerMQD(logger log.Logger, metrics *monitoring.Metrics, serverURL string, settings configuration.Settings) *ReportServerMQD {
 	result := &ReportServerMQD{
 		RestAPI: RestAPI{
 			OFBStruct: crosscutting.OFBStruct{
@@ -46,11 +53,21 @@ func NewReportServerMQD(logger log.Logger, serverURL string, settings configurat
 		settings: settings,
 	}
 
-	// result.loadCertificates()
+	security := settings.SecuritySettings
+	if security.OutboundCertFile != "" {
+		reloader, err := certificates.NewReloader(logger, metrics, "client", security.OutboundCertFile, security.OutboundKeyFile)
+		if err != nil {
+			logger.Error(err, "Error loading outbound certificate, no client certificate will be used", result.Pack, "NewReportServerMQD")
+		} else {
+			result.clientCertificate = reloader
+			go reloader.Watch(time.Duration(security.ReloadInterval) * time.Second)
+		}
+	}
+
 	return result
 }
 
-// SendReport Sends a report to the central server
+// SendReport Sends a report to the central server, using the credentials of the data owner of the report
 //
 // Parameters:
 //   - report: Report to be sent
@@ -60,12 +77,20 @@ func NewReportServerMQD(logger log.Logger, serverURL string, settings configurat
 func (rs *ReportServerMQD) SendReport(report models.Report) error {
 	rs.Logger.Info("Sending report to central Server", rs.Pack, "sendReportToAPI")
 
-	err := rs.getJWKToken(rs.settings.ApplicationSettings.OrganisationID)
+	serverURL := rs.serverURL
+	clientID := rs.settings.ApplicationSettings.OrganisationID
+	organisation := rs.settings.GetOrganisation(report.DataOwnerID)
+	if organisation != nil {
+		serverURL = organisation.ProxyURL
+		clientID = organisation.ClientID
+	}
+
+	token, err := rs.getJWKToken(serverURL, clientID)
 	if err != nil {
 		return err
 	}
 
-	err = rs.postReport(report)
+	err = rs.postReport(serverURL, token, report)
 	if err != nil {
 		return err
 	}
@@ -76,11 +101,13 @@ func (rs *ReportServerMQD) SendReport(report models.Report) error {
 // postReport sends the report to the server using required authorization
 //
 // Parameters:
+//   - serverURL: URL of the server
+//   - token: Token used for the authorization
 //   - report: Report to be sent
 //
 // Returns:
 //   - error: Error if any
-func (rs *ReportServerMQD) postReport(report models.Report) error {
+func (rs *ReportServerMQD) postReport(serverURL string, token *jwt.JWKToken, report models.Report) error {
 	rs.Logger.Info("Posting report", rs.Pack, "postReport")
 
 	httpClient := rs.getHTTPClient()
@@ -91,14 +118,14 @@ func (rs *ReportServerMQD) postReport(report models.Report) error {
 	}
 
 	// Create a new request
-	req, err := http.NewRequest("POST", rs.serverURL+reportPath, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequest("POST", serverURL+reportPath, bytes.NewBuffer(requestBody))
 	if err != nil {
 		fmt.Println("Error creating request:", err)
 		return err
 	}
 
 	// Set the Authorization header with your token
-	req.Header.Set("Authorization", "Bearer "+rs.token.AccessToken)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 
 	// Send the request
 	resp, err := httpClient.Do(req)
@@ -146,15 +173,16 @@ func (rs *ReportServerMQD) LoadAPIConfigurationFile(filePath string) ([]byte, er
 // LoadConfigurationSettings Loads the main configuration file for the application
 //
 // Parameters:
+//   - conditional: Validators of the last configuration loaded, updated with the validators of the new file. Can be nil
 //
 // Returns:
 //   - ConfigurationSettings: configuration file found on the server
-//   - error: Error if any
-func (rs *ReportServerMQD) LoadConfigurationSettings() (*models.ConfigurationSettings, error) {
+//   - error: ErrNotModified if the file has not changed, or error if any
+func (rs *ReportServerMQD) LoadConfigurationSettings(conditional *ConditionalRequest) (*models.ConfigurationSettings, error) {
 	rs.Logger.Info("Loading ConfigurationSettings", rs.Pack, "LoadConfigurationSettings")
 	serverPath := rs.serverURL + settingsPath + "/" + configurationSettingsFile
 
-	body, err := rs.executeGet(serverPath, 3)
+	body, err := rs.executeConditionalGet(serverPath, 3, conditional)
 	if err != nil {
 		return nil, err
 	}
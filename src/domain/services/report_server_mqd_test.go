@@ -0,0 +1,173 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/fakeserver"
+)
+
+const (
+	testOrganisationID = "5f1b3c2a-8d4e-4f6a-9b7c-0d1e2f3a4b5c"
+	testSecondClientID = "second-client"
+	testEndpointFile   = "Accounts/accounts/2.0.1/response/endpoints.json"
+	testEndpoints      = `[{"Endpoint":"/accounts"}]`
+)
+
+// newTestFakeServer starts a fake central server with the settings and an endpoint file, and returns a report
+// server that uses it
+func newTestFakeServer(t *testing.T) (*fakeserver.Server, *ReportServerMQD) {
+	t.Helper()
+	folder := t.TempDir()
+	files := map[string]string{
+		configurationSettingsFile: `{"Version":"1.0.0","ReportSettings":{"ReportExecutionWindow":30}}`,
+		testEndpointFile:          testEndpoints,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(folder, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fake, err := fakeserver.New(fakeserver.Config{SettingsDir: folder, Logger: log.NewLogger("PANIC")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(fake.Handler())
+	t.Cleanup(httpServer.Close)
+
+	settings := configuration.Settings{}
+	settings.ApplicationSettings.OrganisationID = testOrganisationID
+	settings.ApplicationSettings.Organisations = []configuration.OrganisationSettings{
+		{OrganisationID: testOrganisationID, ClientID: testOrganisationID, ProxyURL: httpServer.URL},
+		{OrganisationID: testSecondClientID, ClientID: testSecondClientID, ProxyURL: httpServer.URL},
+	}
+
+	return fake, NewReportServerMQD(log.NewLogger("PANIC"), nil, httpServer.URL, settings)
+}
+
+func TestReportServerMQDLoadConfigurationSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		fault       *fakeserver.Fault
+		conditional bool
+		wantErr     string
+		wantVersion string
+	}{
+		{name: "settings loaded", wantVersion: "1.0.0"},
+		{name: "settings not modified", conditional: true, wantErr: ErrNotModified.Error()},
+		{name: "temporary failure is retried", fault: &fakeserver.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1}, wantVersion: "1.0.0"},
+		{name: "file not found", fault: &fakeserver.Fault{NoSuchKey: true}, wantErr: "configuration file not found"},
+		{name: "forbidden", fault: &fakeserver.Fault{StatusCode: http.StatusForbidden}, wantErr: "forbidden status code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, rs := newTestFakeServer(t)
+			var conditional *ConditionalRequest
+			if tt.conditional {
+				conditional = &ConditionalRequest{}
+				if _, err := rs.LoadConfigurationSettings(conditional); err != nil || conditional.ETag == "" {
+					t.Fatalf("first LoadConfigurationSettings() error = %v, ETag = %q", err, conditional.ETag)
+				}
+			}
+
+			if tt.fault != nil {
+				fake.SetFault(settingsPath, *tt.fault)
+			}
+
+			settings, err := rs.LoadConfigurationSettings(conditional)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadConfigurationSettings() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || settings.Version != tt.wantVersion {
+				t.Errorf("LoadConfigurationSettings() = %+v, %v, want version %s", settings, err, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestReportServerMQDLoadAPIConfigurationFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+		wantErr  bool
+	}{
+		{name: "endpoint file", filePath: testEndpointFile, want: testEndpoints},
+		{name: "missing file", filePath: "Loans/loans/2.0.0/response/endpoints.json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rs := newTestFakeServer(t)
+			file, err := rs.LoadAPIConfigurationFile(tt.filePath)
+			if (err != nil) != tt.wantErr || string(file) != tt.want {
+				t.Errorf("LoadAPIConfigurationFile() = %q, %v, want %q, wantErr %v", file, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportServerMQDSendReport(t *testing.T) {
+	tests := []struct {
+		name          string
+		dataOwners    []string
+		fault         *fakeserver.Fault
+		wantErr       bool
+		wantClientIDs []string
+		wantTokens    int
+	}{
+		{name: "report sent", dataOwners: []string{testOrganisationID}, wantClientIDs: []string{testOrganisationID}, wantTokens: 1},
+		{name: "token reused", dataOwners: []string{testOrganisationID, testOrganisationID}, wantClientIDs: []string{testOrganisationID, testOrganisationID}, wantTokens: 1},
+		{name: "token by client", dataOwners: []string{testOrganisationID, testSecondClientID}, wantClientIDs: []string{testOrganisationID, testSecondClientID}, wantTokens: 2},
+		{name: "token not issued", dataOwners: []string{testOrganisationID}, fault: &fakeserver.Fault{StatusCode: http.StatusInternalServerError}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, rs := newTestFakeServer(t)
+			if tt.fault != nil {
+				fake.SetFault(tokenPath, *tt.fault)
+			}
+
+			for _, dataOwnerID := range tt.dataOwners {
+				err := rs.SendReport(models.Report{DataOwnerID: dataOwnerID})
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("SendReport() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+
+			reports, _ := fake.WaitForReports(len(tt.wantClientIDs), time.Second)
+			if len(reports) != len(tt.wantClientIDs) || fake.GetTokensIssued() != tt.wantTokens {
+				t.Fatalf("reports = %d, tokens = %d, want %d, %d", len(reports), fake.GetTokensIssued(), len(tt.wantClientIDs), tt.wantTokens)
+			}
+
+			for i, report := range reports {
+				if report.ClientID != tt.wantClientIDs[i] || report.Report.DataOwnerID != tt.dataOwners[i] {
+					t.Errorf("report %d = %s %s, want %s %s", i, report.ClientID, report.Report.DataOwnerID, tt.wantClientIDs[i], tt.dataOwners[i])
+				}
+			}
+		})
+	}
+}
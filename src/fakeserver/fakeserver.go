@@ -0,0 +1,503 @@
+// Package fakeserver implements a fake central MQD server to test the client end to end without network access. It
+// serves the settings files from a directory, issues signed tokens, records the reports received and can inject
+// failures on any of its endpoints.
+//
+// The server can be used from Go tests with httptest.NewServer(server.Handler()), or started by the "fake-server"
+// subcommand of the application, in which case the faults and the reports are managed by the admin endpoints.
+package fakeserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/jwt"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	tokenPath    = "/token"
+	reportPath   = "/report"
+	settingsPath = "/settings"
+	adminPath    = "/_fake"
+
+	defaultTokenExpiry = 5 * time.Minute
+
+	noSuchKeyBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`
+)
+
+// Config contains the settings of the fake server
+type Config struct {
+	SettingsDir string        // Directory with configurationSettings.json and the endpoint files
+	TokenExpiry time.Duration // Expiration of the tokens issued, by default 5 minutes
+	SigningKey  []byte        // Key used to sign the tokens, by default a random key
+	Logger      log.Logger    // Logger to be used, by default a new logger with INFO level
+}
+
+// Fault describes a failure injected on the requests of a path
+type Fault struct {
+	StatusCode int  `json:"statusCode"` // Status code returned instead of the normal response, 0 to keep it
+	DelayMs    int  `json:"delayMs"`    // Delay in milliseconds before the response
+	NoSuchKey  bool `json:"noSuchKey"`  // Returns the NoSuchKey error of the storage service with status 200
+	Times      int  `json:"times"`      // Number of requests affected, 0 to affect all the requests
+}
+
+// ReceivedReport is a report received by the server
+type ReceivedReport struct {
+	ClientID   string        // Client ID of the token used to send the report
+	ReceivedAt time.Time     // Time the report was received
+	Report     models.Report // Report received
+}
+
+// Server is a fake central MQD server
+type Server struct {
+	crosscutting.OFBStruct
+	config  Config
+	mutex   sync.Mutex       // Mutex for thread-safe access to the faults and the reports
+	faults  map[string]Fault // Faults by path prefix
+	reports []ReceivedReport // Reports received
+	tokens  int              // Number of tokens issued
+	changed chan struct{}    // Closed and replaced every time a report is received
+}
+
+// New creates a new fake server
+//
+// Parameters:
+//   - config: Settings of the server
+//
+// Returns:
+//   - *Server: Server created
+//   - error: error if the signing key cannot be generated
+func New(config Config) (*Server, error) {
+	if config.Logger == nil {
+		config.Logger = log.NewLogger("INFO")
+	}
+
+	if config.TokenExpiry <= 0 {
+		config.TokenExpiry = defaultTokenExpiry
+	}
+
+	if len(config.SigningKey) == 0 {
+		config.SigningKey = make([]byte, 32)
+		if _, err := rand.Read(config.SigningKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Server{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "fakeserver.Server",
+			Logger: config.Logger,
+		},
+		config:  config,
+		faults:  make(map[string]Fault),
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// Handler returns the handler of the server
+//
+// Parameters:
+//
+// Returns:
+//   - http.Handler: Handler with the endpoints of the central server and the admin endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+tokenPath, s.withFaults(s.handleToken))
+	mux.HandleFunc("POST "+reportPath, s.withFaults(s.handleReport))
+	mux.HandleFunc("GET "+settingsPath+"/", s.withFaults(s.handleSettings))
+	mux.HandleFunc("GET "+adminPath+"/reports", s.handleGetReports)
+	mux.HandleFunc("DELETE "+adminPath+"/reports", s.handleClearReports)
+	mux.HandleFunc("PUT "+adminPath+"/faults", s.handleSetFault)
+	mux.HandleFunc("DELETE "+adminPath+"/faults", s.handleClearFaults)
+	return mux
+}
+
+// ListenAndServe Starts the server on the port, this function does not return unless the server fails
+//
+// Parameters:
+//   - addr: Address to listen on (e.g. :8081)
+//
+// Returns:
+//   - error: error of the server
+func (s *Server) ListenAndServe(addr string) error {
+	s.Logger.Log("Starting fake central server on "+addr+", settings from: "+s.config.SettingsDir, s.Pack, "ListenAndServe")
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server.ListenAndServe()
+}
+
+// SetFault Injects a failure on the requests whose path starts with the prefix, replacing the previous fault of
+// the prefix
+//
+// Parameters:
+//   - pathPrefix: Prefix of the paths affected (e.g. /report, /settings/configurationSettings.json)
+//   - fault: Failure to be injected
+//
+// Returns:
+func (s *Server) SetFault(pathPrefix string, fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults[pathPrefix] = fault
+}
+
+// ClearFaults Removes all the injected failures
+//
+// Parameters:
+//
+// Returns:
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = make(map[string]Fault)
+}
+
+// GetReports returns a copy of the reports received
+//
+// Parameters:
+//
+// Returns:
+//   - []ReceivedReport: Reports received, in order of arrival
+func (s *Server) GetReports() []ReceivedReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]ReceivedReport(nil), s.reports...)
+}
+
+// ClearReports Removes the reports received
+//
+// Parameters:
+//
+// Returns:
+func (s *Server) ClearReports() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reports = nil
+}
+
+// GetTokensIssued returns the number of tokens issued
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of tokens issued
+func (s *Server) GetTokensIssued() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.tokens
+}
+
+// WaitForReports Waits until the server has received a number of reports
+//
+// Parameters:
+//   - count: Number of reports expected
+//   - timeout: Maximum time to wait
+//
+// Returns:
+//   - []ReceivedReport: Reports received
+//   - bool: false if the timeout expired before the reports were received
+func (s *Server) WaitForReports(count int, timeout time.Duration) ([]ReceivedReport, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mutex.Lock()
+		reports := append([]ReceivedReport(nil), s.reports...)
+		changed := s.changed
+		s.mutex.Unlock()
+
+		if len(reports) >= count {
+			return reports, true
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return reports, false
+		}
+	}
+}
+
+// withFaults Applies the fault of the request path, if any, before the handler
+//
+// Parameters:
+//   - handler: Handler of the endpoint
+//
+// Returns:
+//   - http.HandlerFunc: Handler that applies the faults
+func (s *Server) withFaults(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fault, found := s.takeFault(r.URL.Path)
+		if !found {
+			handler(w, r)
+			return
+		}
+
+		if fault.DelayMs > 0 {
+			select {
+			case <-time.After(time.Duration(fault.DelayMs) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case fault.NoSuchKey:
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(noSuchKeyBody))
+		case fault.StatusCode != 0:
+			http.Error(w, http.StatusText(fault.StatusCode), fault.StatusCode)
+		default:
+			handler(w, r)
+		}
+	}
+}
+
+// takeFault returns the fault with the longest prefix matching the path, and consumes one of its uses
+//
+// Parameters:
+//   - requestPath: Path of the request
+//
+// Returns:
+//   - Fault: Fault found
+//   - bool: true if a fault applies to the path
+func (s *Server) takeFault(requestPath string) (Fault, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prefix := ""
+	found := false
+	for key := range s.faults {
+		if strings.HasPrefix(requestPath, key) && (!found || len(key) > len(prefix)) {
+			prefix = key
+			found = true
+		}
+	}
+
+	if !found {
+		return Fault{}, false
+	}
+
+	fault := s.faults[prefix]
+	if fault.Times > 0 {
+		if fault.Times == 1 {
+			delete(s.faults, prefix)
+		} else {
+			remaining := fault
+			remaining.Times--
+			s.faults[prefix] = remaining
+		}
+	}
+
+	return fault, true
+}
+
+// handleToken Issues a signed token for the client of the request
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_id") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	now := time.Now()
+	claims := jwtlib.MapClaims{
+		"sub": clientID,
+		"iat": now.Unix(),
+		"exp": now.Add(s.config.TokenExpiry).Unix(),
+	}
+
+	accessToken, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims).SignedString(s.config.SigningKey)
+	if err != nil {
+		s.Logger.Error(err, "Error signing token", s.Pack, "handleToken")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s.mutex.Lock()
+	s.tokens++
+	s.mutex.Unlock()
+
+	s.Logger.Info("Token issued for client: "+clientID, s.Pack, "handleToken")
+	writeJSON(w, jwt.JWKToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.config.TokenExpiry.Seconds()),
+		Scope:       "mqd",
+	})
+}
+
+// handleReport Records a report sent with a valid token
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	clientID, err := s.validateToken(r.Header.Get("Authorization"))
+	if err != nil {
+		s.Logger.Warning("Report rejected: "+err.Error(), s.Pack, "handleReport")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var report models.Report
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, "invalid report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	s.reports = append(s.reports, ReceivedReport{ClientID: clientID, ReceivedAt: time.Now(), Report: report})
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mutex.Unlock()
+
+	s.Logger.Info("Report received from client: "+clientID, s.Pack, "handleReport")
+	writeJSON(w, map[string]string{"status": "received"})
+}
+
+// validateToken Validates the signature and the expiration of a bearer token
+//
+// Parameters:
+//   - authorization: Value of the Authorization header
+//
+// Returns:
+//   - string: Client ID of the token
+//   - error: error if the token is missing or invalid
+func (s *Server) validateToken(authorization string) (string, error) {
+	tokenString, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found || tokenString == "" {
+		return "", errors.New("missing bearer token")
+	}
+
+	token, err := jwtlib.Parse(tokenString, func(*jwtlib.Token) (interface{}, error) {
+		return s.config.SigningKey, nil
+	}, jwtlib.WithValidMethods([]string{jwtlib.SigningMethodHS256.Alg()}), jwtlib.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+
+	return token.Claims.GetSubject()
+}
+
+// handleSettings Serves a file of the settings directory, supporting conditional requests
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, settingsPath))
+	content, err := os.ReadFile(filepath.Join(s.config.SettingsDir, filepath.FromSlash(name)))
+	if err != nil {
+		// The storage service of the central server answers NoSuchKey for missing files
+		s.Logger.Warning("Settings file not found: "+name, s.Pack, "handleSettings")
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(noSuchKeyBody))
+		return
+	}
+
+	hash := sha256.Sum256(content)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(hash[:8])+`"`)
+	w.Header().Set("Content-Type", "application/json")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
+
+// handleGetReports Returns the reports received
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleGetReports(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.GetReports())
+}
+
+// handleClearReports Removes the reports received
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleClearReports(w http.ResponseWriter, _ *http.Request) {
+	s.ClearReports()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetFault Injects the fault of the body on the path of the query parameter "path"
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleSetFault(w http.ResponseWriter, r *http.Request) {
+	pathPrefix := r.URL.Query().Get("path")
+	if !strings.HasPrefix(pathPrefix, "/") {
+		http.Error(w, "query parameter path is required", http.StatusBadRequest)
+		return
+	}
+
+	var fault Fault
+	if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+		http.Error(w, "invalid fault: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.SetFault(pathPrefix, fault)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleClearFaults Removes all the injected failures
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - r: Request received
+//
+// Returns:
+func (s *Server) handleClearFaults(w http.ResponseWriter, _ *http.Request) {
+	s.ClearFaults()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON Writes a value as a JSON response
+//
+// Parameters:
+//   - w: Writer to create the response
+//   - value: Value to be written
+//
+// Returns:
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
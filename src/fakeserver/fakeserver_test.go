@@ -0,0 +1,239 @@
+package fakeserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/security/jwt"
+)
+
+const testSettingsFile = `{"Version":"1.0.0"}`
+
+// newTestServer returns a fake server with a settings directory that contains configurationSettings.json
+func newTestServer(t *testing.T, tokenExpiry time.Duration) *Server {
+	t.Helper()
+	folder := t.TempDir()
+	if err := os.WriteFile(filepath.Join(folder, "configurationSettings.json"), []byte(testSettingsFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := New(Config{SettingsDir: folder, TokenExpiry: tokenExpiry, SigningKey: []byte("test-key"), Logger: log.NewLogger("PANIC")})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return server
+}
+
+// requestTestToken requests a token for a client and returns the access token, empty if the request failed
+func requestTestToken(t *testing.T, handler http.Handler, clientID string) string {
+	t.Helper()
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {clientID}}
+	request := httptest.NewRequest(http.MethodPost, tokenPath, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		return ""
+	}
+
+	var token jwt.JWKToken
+	if err := json.Unmarshal(recorder.Body.Bytes(), &token); err != nil {
+		t.Fatal(err)
+	}
+
+	return token.AccessToken
+}
+
+func TestServerToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     url.Values
+		want     int
+		wantSent int
+	}{
+		{name: "client credentials", form: url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}}, want: http.StatusOK, wantSent: 1},
+		{name: "missing client ID", form: url.Values{"grant_type": {"client_credentials"}}, want: http.StatusBadRequest},
+		{name: "invalid grant type", form: url.Values{"grant_type": {"password"}, "client_id": {"client"}}, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, 0)
+			request := httptest.NewRequest(http.MethodPost, tokenPath, strings.NewReader(tt.form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want || server.GetTokensIssued() != tt.wantSent {
+				t.Errorf("status = %d, tokens = %d, want %d, %d", recorder.Code, server.GetTokensIssued(), tt.want, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestServerReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenExpiry time.Duration
+		token       func(t *testing.T, handler http.Handler) string
+		body        string
+		want        int
+	}{
+		{name: "report received", token: func(t *testing.T, handler http.Handler) string { return requestTestToken(t, handler, "client") }, body: `{"DataOwnerID":"owner"}`, want: http.StatusOK},
+		{name: "missing token", token: func(t *testing.T, handler http.Handler) string { return "" }, body: `{}`, want: http.StatusUnauthorized},
+		{name: "token with another key", token: func(t *testing.T, handler http.Handler) string {
+			return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiJjbGllbnQifQ.invalid"
+		}, body: `{}`, want: http.StatusUnauthorized},
+		{name: "expired token", tokenExpiry: time.Nanosecond, token: func(t *testing.T, handler http.Handler) string {
+			token := requestTestToken(t, handler, "client")
+			time.Sleep(time.Second)
+			return token
+		}, body: `{}`, want: http.StatusUnauthorized},
+		{name: "invalid report", token: func(t *testing.T, handler http.Handler) string { return requestTestToken(t, handler, "client") }, body: `{`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.tokenExpiry)
+			handler := server.Handler()
+			request := httptest.NewRequest(http.MethodPost, reportPath, strings.NewReader(tt.body))
+			request.Header.Set("Authorization", "Bearer "+tt.token(t, handler))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+
+			reports, received := server.WaitForReports(1, 10*time.Millisecond)
+			if received != (tt.want == http.StatusOK) {
+				t.Fatalf("reports = %d, want received %v", len(reports), tt.want == http.StatusOK)
+			}
+
+			if received && (reports[0].ClientID != "client" || reports[0].Report.DataOwnerID != "owner") {
+				t.Errorf("report = %+v", reports[0])
+			}
+		})
+	}
+}
+
+func TestServerSettings(t *testing.T) {
+	server := newTestServer(t, 0)
+	first := httptest.NewRecorder()
+	server.Handler().ServeHTTP(first, httptest.NewRequest(http.MethodGet, settingsPath+"/configurationSettings.json", nil))
+	etag := first.Header().Get("ETag")
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		want        int
+		wantBody    string
+	}{
+		{name: "settings file", path: "/configurationSettings.json", want: http.StatusOK, wantBody: testSettingsFile},
+		{name: "file not modified", path: "/configurationSettings.json", ifNoneMatch: etag, want: http.StatusNotModified},
+		{name: "file changed", path: "/configurationSettings.json", ifNoneMatch: `"other"`, want: http.StatusOK, wantBody: testSettingsFile},
+		{name: "missing file", path: "/Accounts/endpoints.json", want: http.StatusNotFound, wantBody: noSuchKeyBody},
+		{name: "path outside the directory", path: "/../configurationSettings.json", want: http.StatusOK, wantBody: testSettingsFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, settingsPath+"/placeholder", nil)
+			request.URL.Path = settingsPath + tt.path
+			if tt.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			// The handler is called directly, the mux redirects the paths that are not clean
+			recorder := httptest.NewRecorder()
+			server.handleSettings(recorder, request)
+			if recorder.Code != tt.want || (tt.wantBody != "" && recorder.Body.String() != tt.wantBody) {
+				t.Errorf("response = %d %q, want %d %q", recorder.Code, recorder.Body.String(), tt.want, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults map[string]Fault
+		path   string
+		want   []int // Status codes of consecutive requests
+	}{
+		{name: "status code", faults: map[string]Fault{settingsPath: {StatusCode: http.StatusInternalServerError}}, path: settingsPath + "/configurationSettings.json", want: []int{500, 500}},
+		{name: "limited times", faults: map[string]Fault{settingsPath: {StatusCode: http.StatusServiceUnavailable, Times: 2}}, path: settingsPath + "/configurationSettings.json", want: []int{503, 503, 200}},
+		{name: "longest prefix", faults: map[string]Fault{settingsPath: {StatusCode: 500}, settingsPath + "/configurationSettings.json": {StatusCode: 502}}, path: settingsPath + "/configurationSettings.json", want: []int{502}},
+		{name: "other path", faults: map[string]Fault{reportPath: {StatusCode: 500}}, path: settingsPath + "/configurationSettings.json", want: []int{200}},
+		{name: "NoSuchKey", faults: map[string]Fault{settingsPath: {NoSuchKey: true, Times: 1}}, path: settingsPath + "/configurationSettings.json", want: []int{200, 200}},
+		{name: "delay", faults: map[string]Fault{settingsPath: {DelayMs: 20}}, path: settingsPath + "/configurationSettings.json", want: []int{200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, 0)
+			for prefix, fault := range tt.faults {
+				server.SetFault(prefix, fault)
+			}
+
+			for i, want := range tt.want {
+				recorder := httptest.NewRecorder()
+				server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				if recorder.Code != want {
+					t.Errorf("request %d status = %d, want %d", i, recorder.Code, want)
+				}
+
+				// The NoSuchKey error is only returned while the fault applies
+				noSuchKey := strings.Contains(recorder.Body.String(), "NoSuchKey")
+				if tt.name == "NoSuchKey" && noSuchKey != (i == 0) {
+					t.Errorf("request %d NoSuchKey = %v, want %v", i, noSuchKey, i == 0)
+				}
+			}
+
+			server.ClearFaults()
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != http.StatusOK {
+				t.Errorf("status after ClearFaults() = %d, want 200", recorder.Code)
+			}
+		})
+	}
+}
+
+func TestServerAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{name: "set fault", method: http.MethodPut, path: adminPath + "/faults?path=" + reportPath, body: `{"statusCode":500}`, want: http.StatusNoContent},
+		{name: "set fault without path", method: http.MethodPut, path: adminPath + "/faults", body: `{"statusCode":500}`, want: http.StatusBadRequest},
+		{name: "set invalid fault", method: http.MethodPut, path: adminPath + "/faults?path=" + reportPath, body: `{`, want: http.StatusBadRequest},
+		{name: "clear faults", method: http.MethodDelete, path: adminPath + "/faults", want: http.StatusNoContent},
+		{name: "get reports", method: http.MethodGet, path: adminPath + "/reports", want: http.StatusOK},
+		{name: "clear reports", method: http.MethodDelete, path: adminPath + "/reports", want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, 0)
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d, body: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -1,47 +1,42 @@
 package main
 
 import (
+	"os"
+
 	"github.com/OpenBanking-Brasil/MQD_Client/application"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
 	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
-	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/monitoring"
-	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
-)
-
-var (
-	logger   log.Logger
-	settings configuration.Settings
 )
 
-func init() {
-	monitoring.StartOpenTelemetry()
-	cnf := configuration.Configuration{}
-	settings = cnf.GetApplicationSettings()
-	logger = log.GetLogger(cnf.Settings.ConfigurationSettings.LoggingLevel)
-}
-
 // Main is the main function of the api, that is executed on "run"
 // @author AB
 // @params
 // @return
 func main() {
-	reportServer := services.GetReportServer(logger, settings.SecuritySettings.ProxyURL, settings)
-	cm := application.NewConfigurationManager(logger, *reportServer, settings)
-	err := cm.Initialize()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fake-server":
+			runFakeServer(os.Args[2:])
+			return
+		case "generate":
+			runGenerate(os.Args[2:])
+			return
+		case "benchmark":
+			runBenchmark(os.Args[2:])
+			return
+		}
+	}
+
+	cnf := configuration.Configuration{}
+	settings := cnf.GetApplicationSettings()
+	logger := log.NewLogger(settings.ConfigurationSettings.LoggingLevel)
+
+	app, err := application.NewApp(logger, settings, nil)
 	if err != nil {
 		logger.Fatal(err, "There was a fatal error loading initial settings.", "Main", "Main")
 	}
 
-	qm := application.GetQueueManager()
-	rp := application.GetResultProcessor(logger, *reportServer, cm)
-	lrm := application.NewLocalResultManager(logger, cm)
-	mp := application.GetMessageProcessorWorker(logger, rp, qm, cm, lrm)
-
 	// Start workers
-	go cm.StartUpdateProcess()
-	go mp.StartWorker()
-	go rp.StartResultsProcessor()
-	go lrm.StartResultProcess()
-
-	application.GetAPIServer(logger, monitoring.GetOpentelemetryHandler(), qm, cm).StartServing()
+	app.Start()
+	app.Serve()
 }
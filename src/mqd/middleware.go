@@ -0,0 +1,194 @@
+package mqd
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/application"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/google/uuid"
+)
+
+const xFAPIInteractionID = "x-fapi-interaction-id"
+
+// MiddlewareOptions contains the values used to identify the captured responses
+type MiddlewareOptions struct {
+	Role        string                       // Role of the service (TRANSMITTER / RECEIVER), by default TRANSMITTER
+	ServerOrgID string                       // serverOrgId of the responses, by default the organisation of the application
+	PathPrefix  string                       // Prefix removed from the path to identify the endpoint, by default /open-banking
+	DataOwnerID func(r *http.Request) string // Returns the data owner of a request, by default resolved by the host
+	EndpointFor func(r *http.Request) string // Returns the endpoint of a request, by default resolved by the path
+	Skip        func(r *http.Request) bool   // Indicates that a request must not be captured
+}
+
+// captureWriter keeps a copy of the response written by the handler
+type captureWriter struct {
+	http.ResponseWriter
+	statusCode int          // HTTP status code written
+	buffer     bytes.Buffer // Copy of the content written
+	limit      int          // Maximum size of the copy
+	exceeded   bool         // Indicates that the body is bigger than the limit
+}
+
+// WriteHeader records the status code and writes it
+//
+// Parameters:
+//   - statusCode: HTTP status code
+//
+// Returns:
+func (cw *captureWriter) WriteHeader(statusCode int) {
+	if cw.statusCode == 0 {
+		cw.statusCode = statusCode
+	}
+
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write keeps a copy of the content and writes it
+//
+// Parameters:
+//   - p: Content to be written
+//
+// Returns:
+//   - int: number of bytes written
+//   - error: error if any
+func (cw *captureWriter) Write(p []byte) (int, error) {
+	if cw.statusCode == 0 {
+		cw.statusCode = http.StatusOK
+	}
+
+	if !cw.exceeded {
+		if cw.buffer.Len()+len(p) > cw.limit {
+			cw.exceeded = true
+			cw.buffer = bytes.Buffer{}
+		} else {
+			cw.buffer.Write(p)
+		}
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the original writer, to be used by http.ResponseController
+//
+// Parameters:
+//
+// Returns:
+//   - http.ResponseWriter: Original writer
+func (cw *captureWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Middleware returns a middleware that passes the responses of the handler unchanged, and queues a copy of the
+// successful JSON responses for validation and reporting. The handler only keeps the copy of the body and hands it
+// to the capture worker started by Start, the decompression, the checks and the validation are done by the workers.
+// The copy is discarded if the workers cannot keep up with the traffic
+//
+// Parameters:
+//   - options: Values used to identify the captured responses
+//
+// Returns:
+//   - func(http.Handler) http.Handler: Middleware
+func (c *Client) Middleware(options MiddlewareOptions) func(http.Handler) http.Handler {
+	options = c.getOptions(options)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if options.Skip != nil && options.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &captureWriter{ResponseWriter: w, limit: c.capture.GetMaxBodySize()}
+			next.ServeHTTP(cw, r)
+			if cw.exceeded || cw.buffer.Len() == 0 || !c.capture.IsCapturable(cw.statusCode, w.Header()) {
+				return
+			}
+
+			msg := c.getMessage(r, w.Header(), options)
+			if msg == nil {
+				return
+			}
+
+			// The buffer of the writer is not used after the handler returns, the worker takes its content
+			c.capture.EnqueueResponse(msg, cw.buffer.Bytes(), w.Header().Get("Content-Encoding"))
+		})
+	}
+}
+
+// getOptions Returns the options of the middleware with the default values
+//
+// Parameters:
+//   - options: Values used to identify the captured responses
+//
+// Returns:
+//   - MiddlewareOptions: Options with the default values
+func (c *Client) getOptions(options MiddlewareOptions) MiddlewareOptions {
+	if options.Role == "" {
+		options.Role = configuration.TransmitterMode
+	}
+
+	if options.ServerOrgID == "" {
+		options.ServerOrgID = c.capture.GetOrganisationID()
+	}
+
+	if options.PathPrefix == "" {
+		options.PathPrefix = "/open-banking"
+	}
+
+	return options
+}
+
+// getMessage Creates the message of a response captured by the middleware
+//
+// Parameters:
+//   - r: Request received by the service
+//   - header: Headers of the response
+//   - options: Values used to identify the captured responses
+//
+// Returns:
+//   - *application.Message: Message without content, nil if the endpoint or the identifiers are not valid
+func (c *Client) getMessage(r *http.Request, header http.Header, options MiddlewareOptions) *application.Message {
+	endpointName := ""
+	if options.EndpointFor != nil {
+		endpointName = options.EndpointFor(r)
+	} else {
+		endpointName = c.capture.GetEndpointName(r.URL.Path, options.PathPrefix)
+	}
+
+	if endpointName == "" {
+		c.Logger.Debug("Endpoint not found for path: "+r.URL.Path, c.Pack, "getMessage")
+		return nil
+	}
+
+	msg := &application.Message{
+		Endpoint:           endpointName,
+		HTTPMethod:         r.Method,
+		ServerID:           options.ServerOrgID,
+		XFapiInteractionID: header.Get(xFAPIInteractionID),
+		Role:               options.Role,
+		ReceivedAt:         time.Now(),
+	}
+
+	if msg.XFapiInteractionID == "" {
+		msg.XFapiInteractionID = r.Header.Get(xFAPIInteractionID)
+	}
+
+	if _, err := uuid.Parse(msg.XFapiInteractionID); err != nil {
+		c.Logger.Debug(xFAPIInteractionID+" not found or bad format for path: "+r.URL.Path, c.Pack, "getMessage")
+		return nil
+	}
+
+	if _, err := uuid.Parse(msg.ServerID); err != nil {
+		c.Logger.Debug("Invalid serverOrgId for path: "+r.URL.Path, c.Pack, "getMessage")
+		return nil
+	}
+
+	if options.DataOwnerID != nil {
+		msg.DataOwnerID = options.DataOwnerID(r)
+	} else {
+		msg.DataOwnerID = c.capture.GetDataOwnerID(r.Host)
+	}
+
+	return msg
+}
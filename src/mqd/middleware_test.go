@@ -0,0 +1,163 @@
+package mqd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+)
+
+const testServerOrgID = "a3c1e2f4-5b6d-4e8f-9a0b-1c2d3e4f5a6b"
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		status        int
+		contentType   string
+		body          string
+		interactionID string
+		skip          bool
+		wantQueued    int
+	}{
+		{name: "response captured", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json", body: `{"data":[]}`, interactionID: testInteractionID, wantQueued: 1},
+		{name: "error status", path: "/open-banking/accounts/v2/accounts", status: http.StatusBadRequest, contentType: "application/json", body: `{"errors":[]}`, interactionID: testInteractionID},
+		{name: "not JSON", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "text/plain", body: "data", interactionID: testInteractionID},
+		{name: "unknown endpoint", path: "/open-banking/unknown", status: http.StatusOK, contentType: "application/json", body: `{"data":[]}`, interactionID: testInteractionID},
+		{name: "missing interaction ID", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json", body: `{"data":[]}`},
+		{name: "invalid interaction ID", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json", body: `{"data":[]}`, interactionID: "interaction"},
+		{name: "body over the limit", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json", body: `{"data":["` + strings.Repeat("1", 2048) + `"]}`, interactionID: testInteractionID},
+		{name: "empty body", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json", interactionID: testInteractionID},
+		{name: "skipped request", path: "/open-banking/accounts/v2/accounts", status: http.StatusOK, contentType: "application/json", body: `{"data":[]}`, interactionID: testInteractionID, skip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t)
+			go client.capture.StartWorker()
+			handler := client.Middleware(MiddlewareOptions{Skip: func(r *http.Request) bool { return tt.skip }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.interactionID != "" {
+				request.Header.Set(xFAPIInteractionID, tt.interactionID)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			// The response is passed unchanged
+			if recorder.Code != tt.status || recorder.Body.String() != tt.body {
+				t.Fatalf("response = %d %q, want %d %q", recorder.Code, recorder.Body.String(), tt.status, tt.body)
+			}
+
+			// The responses captured are processed by the capture worker after the response
+			deadline := time.Now().Add(time.Second)
+			for client.app.GetQueueDepth() < tt.wantQueued && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			if depth := client.app.GetQueueDepth(); depth != tt.wantQueued {
+				t.Errorf("queue depth = %d, want %d", depth, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestMiddlewareGetMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         MiddlewareOptions
+		path            string
+		headerID        string
+		requestID       string
+		wantNil         bool
+		wantEndpoint    string
+		wantRole        string
+		wantServerID    string
+		wantDataOwnerID string
+	}{
+		{
+			name:            "default options",
+			path:            "/open-banking/accounts/v2/accounts",
+			headerID:        testInteractionID,
+			wantEndpoint:    "/accounts/v2/accounts",
+			wantRole:        configuration.TransmitterMode,
+			wantServerID:    testOrganisationID,
+			wantDataOwnerID: testOrganisationID,
+		},
+		{
+			name:            "interaction ID of the request",
+			path:            "/open-banking/accounts/v2/accounts",
+			requestID:       testInteractionID,
+			wantEndpoint:    "/accounts/v2/accounts",
+			wantRole:        configuration.TransmitterMode,
+			wantServerID:    testOrganisationID,
+			wantDataOwnerID: testOrganisationID,
+		},
+		{
+			name: "custom options",
+			options: MiddlewareOptions{
+				Role:        configuration.ReceiverMode,
+				ServerOrgID: testServerOrgID,
+				PathPrefix:  "/api",
+				DataOwnerID: func(r *http.Request) string { return "owner" },
+			},
+			path:            "/api/accounts/v2/accounts",
+			headerID:        testInteractionID,
+			wantEndpoint:    "/accounts/v2/accounts",
+			wantRole:        configuration.ReceiverMode,
+			wantServerID:    testServerOrgID,
+			wantDataOwnerID: "owner",
+		},
+		{
+			name:            "endpoint of the service",
+			options:         MiddlewareOptions{EndpointFor: func(r *http.Request) string { return "/accounts/v2/accounts/{accountId}" }},
+			path:            "/internal/account",
+			headerID:        testInteractionID,
+			wantEndpoint:    "/accounts/v2/accounts/{accountId}",
+			wantRole:        configuration.TransmitterMode,
+			wantServerID:    testOrganisationID,
+			wantDataOwnerID: testOrganisationID,
+		},
+		{name: "invalid serverOrgId", options: MiddlewareOptions{ServerOrgID: "server"}, path: "/open-banking/accounts/v2/accounts", headerID: testInteractionID, wantNil: true},
+		{name: "missing interaction ID", path: "/open-banking/accounts/v2/accounts", wantNil: true},
+		{name: "unknown endpoint", path: "/open-banking/unknown", headerID: testInteractionID, wantNil: true},
+	}
+
+	client := newTestClient(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := client.getOptions(tt.options)
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			request.Header.Set(xFAPIInteractionID, tt.requestID)
+			header := http.Header{}
+			header.Set(xFAPIInteractionID, tt.headerID)
+
+			before := time.Now()
+			msg := client.getMessage(request, header, options)
+			if (msg == nil) != tt.wantNil {
+				t.Fatalf("getMessage() = %+v, want nil %v", msg, tt.wantNil)
+			}
+
+			if msg == nil {
+				return
+			}
+
+			if msg.Endpoint != tt.wantEndpoint || msg.Role != tt.wantRole || msg.ServerID != tt.wantServerID || msg.DataOwnerID != tt.wantDataOwnerID || msg.XFapiInteractionID != testInteractionID {
+				t.Errorf("getMessage() = %+v", msg)
+			}
+
+			if msg.ReceivedAt.Before(before) || msg.ReceivedAt.After(time.Now()) {
+				t.Errorf("ReceivedAt = %v, want the time of the capture", msg.ReceivedAt)
+			}
+		})
+	}
+}
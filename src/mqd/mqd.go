@@ -0,0 +1,125 @@
+// Package mqd exposes the data quality engine to be embedded in Go services. The client validates messages
+// in-process and provides an http.Handler middleware that queues the responses of the service for validation,
+// reporting and local results, the same pipeline used by the standalone application.
+//
+// Each client owns its queue, results, metrics and logger, several clients (e.g. one by tenant) can run in the
+// same process.
+package mqd
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/application"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+var (
+	// ErrEndpointNotSupported is returned when the endpoint is not found in the validation settings
+	ErrEndpointNotSupported = application.ErrEndpointNotSupported
+
+	// ErrVersionNotSupported is returned when the version is different from the version of the validation settings
+	ErrVersionNotSupported = application.ErrVersionNotSupported
+)
+
+// Config contains the dependencies of the client
+type Config struct {
+	Settings     configuration.Settings // Application settings, see LoadSettings
+	Logger       log.Logger             // Logger to be used, by default a new logger with the configured level
+	ReportServer services.ReportServer  // Server that receives settings and reports, by default the central MQD server
+}
+
+// Client validates messages in-process and queues captured responses for reporting
+type Client struct {
+	crosscutting.OFBStruct
+	app       *application.App             // Application instance of the client
+	capture   *application.ResponseCapture // Capture used by the middleware
+	startOnce sync.Once                    // Starts the workers only once
+}
+
+// LoadSettings Loads the application settings from the settings file and the environment variables, as done by the
+// standalone application
+//
+// Parameters:
+//
+// Returns:
+//   - configuration.Settings: Settings loaded
+func LoadSettings() configuration.Settings {
+	cnf := configuration.Configuration{}
+	return cnf.GetApplicationSettings()
+}
+
+// New creates a new client and loads the validation settings, the workers are started by Start
+//
+// Parameters:
+//   - config: Dependencies of the client
+//
+// Returns:
+//   - *Client: Client created
+//   - error: error if the validation settings cannot be loaded
+func New(config Config) (*Client, error) {
+	logger := config.Logger
+	if logger == nil {
+		logger = log.NewLogger(config.Settings.ConfigurationSettings.LoggingLevel)
+	}
+
+	app, err := application.NewApp(logger, config.Settings, config.ReportServer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "mqd.Client",
+			Logger: logger,
+		},
+		app:     app,
+		capture: app.GetResponseCapture(),
+	}, nil
+}
+
+// Start Starts the workers that update the settings, validate the queued messages and send the reports
+//
+// Parameters:
+//
+// Returns:
+func (c *Client) Start() {
+	c.startOnce.Do(func() {
+		c.app.Start()
+		c.Logger.Log("Embedded client started.", c.Pack, "Start")
+	})
+}
+
+// Validate Validates a message synchronously, the result is returned to the caller and is not reported
+//
+// Parameters:
+//   - ctx: Context of the validation
+//   - endpoint: Name of the endpoint (e.g. /accounts/v2/accounts)
+//   - version: Version of the API, empty to accept the configured version
+//   - body: JSON content of the message
+//
+// Returns:
+//   - *validation.Result: Result of the validation
+//   - error: error if the endpoint or version are not supported, or the message cannot be validated
+func (c *Client) Validate(ctx context.Context, endpoint string, version string, body []byte) (*validation.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.app.Validate(endpoint, version, body)
+}
+
+// MetricsHandler returns the handler that exposes the metrics of the engine, to be registered by the service
+//
+// Parameters:
+//
+// Returns:
+//   - http.Handler: Handler of the metrics
+func (c *Client) MetricsHandler() http.Handler {
+	return c.app.GetMetrics().GetOpentelemetryHandler()
+}
@@ -0,0 +1,151 @@
+package mqd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/configuration"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/models"
+	"github.com/OpenBanking-Brasil/MQD_Client/domain/services"
+)
+
+const (
+	testOrganisationID = "5f1b3c2a-8d4e-4f6a-9b7c-0d1e2f3a4b5c"
+	testInteractionID  = "0b8d4c6e-2f1a-4e3b-8c5d-7a9f1e2d3c4b"
+	testEndpointFile   = "Accounts/accounts/2.0.1/response/endpoints.json"
+	testAccountsSchema = `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"required": ["data"],
+		"properties": {"data": {"type": "array"}}
+	}`
+)
+
+var errStubNotFound = errors.New("file not found")
+
+// stubReportServer is a report server that serves the accounts API settings from memory
+type stubReportServer struct {
+	settings models.ConfigurationSettings // Settings returned
+	file     []byte                       // Endpoint file of the accounts API
+}
+
+// newStubReportServer creates a stub server with the accounts API
+func newStubReportServer() *stubReportServer {
+	file, _ := json.Marshal([]models.APIEndpointSetting{{Endpoint: "/accounts", JSONBodySchema: testAccountsSchema, Throughput: models.HighTroughput}})
+	return &stubReportServer{
+		file: file,
+		settings: models.ConfigurationSettings{
+			Version: "1.0.0",
+			ValidationSettings: models.ValidationSettings{
+				APIGroupSettings: []models.APIGroupSetting{{
+					Group:    "Accounts",
+					BasePath: "Accounts",
+					APIList:  []models.APISetting{{API: "accounts", BasePath: "accounts", Version: "2.0.1", EndpointBase: "/accounts/v2"}},
+				}},
+				ExtremelyHighTroughputValidationRate: 100,
+				HighTroughputValidationRate:          100,
+				MediumTroughputValidationRate:        100,
+				LowTroughputValidationRate:           100,
+				VeryLowTroughputValidationRate:       100,
+			},
+			ReportSettings: models.ReportSettings{ReportExecutionWindow: 30, SendOnReportNumber: 10000},
+		},
+	}
+}
+
+func (s *stubReportServer) SendReport(report models.Report) error {
+	return nil
+}
+
+func (s *stubReportServer) LoadAPIConfigurationFile(filePath string) ([]byte, error) {
+	if filePath != testEndpointFile {
+		return nil, errStubNotFound
+	}
+
+	return s.file, nil
+}
+
+func (s *stubReportServer) LoadConfigurationSettings(conditional *services.ConditionalRequest) (*models.ConfigurationSettings, error) {
+	// A copy is returned, the configuration manager modifies the settings it receives
+	data, err := json.Marshal(s.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var result models.ConfigurationSettings
+	err = json.Unmarshal(data, &result)
+	return &result, err
+}
+
+// newTestClient returns a client for a transmitter that loads the settings from a stub server, the workers are not
+// started
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	settings := configuration.Settings{}
+	settings.ApplicationSettings.Mode = configuration.TransmitterMode
+	settings.ApplicationSettings.OrganisationID = testOrganisationID
+	settings.ApplicationSettings.Organisations = []configuration.OrganisationSettings{{OrganisationID: testOrganisationID, ClientID: testOrganisationID}}
+	settings.UpdateSettings.HistoryPath = t.TempDir()
+	settings.UpdateSettings.HistorySize = 5
+	settings.UpdateSettings.Concurrency = 2
+	settings.RequestSettings.MaxBodySize = 1
+	settings.RequestSettings.MaxDecompressedSize = 5
+	settings.RequestSettings.MaxCompressionRatio = 100
+	settings.SamplingSettings.Mode = configuration.RandomSampling
+	settings.ProxySettings.Mode = configuration.DisabledProxy
+
+	client, err := New(Config{Settings: settings, Logger: log.NewLogger("ERROR"), ReportServer: newStubReportServer()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return client
+}
+
+func TestClientValidate(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		endpoint  string
+		version   string
+		body      string
+		wantValid bool
+		wantErr   error
+	}{
+		{name: "valid message", ctx: context.Background(), endpoint: "/accounts/v2/accounts", body: `{"data":[]}`, wantValid: true},
+		{name: "valid message with version", ctx: context.Background(), endpoint: "/accounts/v2/accounts", version: "2.0.1", body: `{"data":[]}`, wantValid: true},
+		{name: "invalid message", ctx: context.Background(), endpoint: "/accounts/v2/accounts", body: `{"accounts":[]}`, wantValid: false},
+		{name: "endpoint not supported", ctx: context.Background(), endpoint: "/loans/v2/contracts", body: `{"data":[]}`, wantErr: ErrEndpointNotSupported},
+		{name: "version not supported", ctx: context.Background(), endpoint: "/accounts/v2/accounts", version: "1.0.0", body: `{"data":[]}`, wantErr: ErrVersionNotSupported},
+		{name: "canceled context", ctx: canceled, endpoint: "/accounts/v2/accounts", body: `{"data":[]}`, wantErr: context.Canceled},
+	}
+
+	client := newTestClient(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := client.Validate(tt.ctx, tt.endpoint, tt.version, []byte(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil || result.Valid != tt.wantValid {
+				t.Errorf("Validate() = %+v, %v, want valid %v", result, err, tt.wantValid)
+			}
+
+			// Synchronous validations are not queued for reporting
+			if depth := client.app.GetQueueDepth(); depth != 0 {
+				t.Errorf("queue depth = %d, want 0", depth)
+			}
+		})
+	}
+}
@@ -0,0 +1,719 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// draft2020SchemaURL is the location used to compile the schema, references to other documents are not loaded
+const draft2020SchemaURL = "mqd://schema.json"
+
+// Draft2020Validator Validator that uses JSON Schemas of the drafts 2019-09 and 2020-12
+type Draft2020Validator struct {
+	pack     string             // Package name
+	schema   string             // JSON Schema
+	logger   log.Logger         // Logger
+	options  Options            // Options of the validation
+	printer  *message.Printer   // Printer of the messages of the errors
+	compiled *jsonschema.Schema // Compiled schema, when the options have no cache
+}
+
+// GetDraft2020Validator is for creating a Draft2020Validator
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - schema: JSON Schema to be used for validation
+//   - options: Options of the validation
+//
+// Returns:
+//   - *Draft2020Validator: Validator created
+func GetDraft2020Validator(logger log.Logger, schema string, options Options) *Draft2020Validator {
+	return &Draft2020Validator{
+		pack:    "Draft2020Validator",
+		schema:  schema,
+		logger:  logger,
+		options: options,
+		printer: message.NewPrinter(language.English),
+	}
+}
+
+// Validate is for Validating a dynamic structure using a JSON Schema
+//
+// Parameters:
+//   - data: DynamicStruct to be validated
+//
+// Returns:
+//   - *Result: Result of the validation
+//   - error: error if the schema cannot be compiled or the data cannot be validated
+func (dv *Draft2020Validator) Validate(data DynamicStruct) (*Result, error) {
+	dv.logger.Info("Starting Validation With Schema", dv.pack, "Validate")
+
+	validationResult := Result{Valid: true}
+	if dv.schema == "" {
+		return &validationResult, nil
+	}
+
+	schema, err := dv.compile()
+	if err != nil {
+		dv.logger.Error(err, "error compiling schema", dv.pack, "Validate")
+		return nil, err
+	}
+
+	// A nil structure is validated as null, like the documents that are not JSON objects
+	var document interface{}
+	if data != nil {
+		document = map[string]interface{}(data)
+	}
+
+	err = schema.Validate(document)
+	if err == nil {
+		return &validationResult, nil
+	}
+
+	var validationError *jsonschema.ValidationError
+	if !errors.As(err, &validationError) {
+		dv.logger.Error(err, "error validating message", dv.pack, "Validate")
+		return nil, err
+	}
+
+	validationResult.Errors = make(map[string][]string)
+	validationResult.Details = dv.cleanErrors(document, validationError, nil)
+	sort.SliceStable(validationResult.Details, func(i, j int) bool {
+		return validationResult.Details[i].Pointer < validationResult.Details[j].Pointer
+	})
+
+	for _, detail := range validationResult.Details {
+		dv.logger.Debug(detail.Field+": "+detail.Message, dv.pack, "cleanErrors")
+		if detail.Code == CodeConditional {
+			continue
+		}
+
+		validationResult.Errors[detail.Field] = append(validationResult.Errors[detail.Field], detail.Message)
+	}
+
+	validationResult.Valid = len(validationResult.Details) == 0
+	return &validationResult, nil
+}
+
+// compile Compiles the schema, the default dialect is 2020-12 and the OpenAPI 3.1 dialects are compiled as 2020-12.
+// The compiled schema is kept by the validator, or in the cache of the options, because the validator can be created
+// for each message
+//
+// Parameters:
+//
+// Returns:
+//   - *jsonschema.Schema: Schema compiled
+//   - error: error if the schema is invalid
+func (dv *Draft2020Validator) compile() (*jsonschema.Schema, error) {
+	if dv.compiled != nil {
+		return dv.compiled, nil
+	}
+
+	key := compiledSchemaKey{schema: dv.schema, assertFormats: dv.options.AssertFormats}
+
+	if dv.options.Cache != nil {
+		if schema := dv.options.Cache.get(key); schema != nil {
+			return schema, nil
+		}
+	}
+
+	document, err := jsonschema.UnmarshalJSON(strings.NewReader(dv.schema))
+	if err != nil {
+		return nil, err
+	}
+
+	// The vocabulary of OpenAPI only adds annotations, the meta-schemas of the dialect are not loaded
+	if root, ok := document.(map[string]interface{}); ok {
+		if dialect, _ := root["$schema"].(string); isOpenAPIDialect(dialect) {
+			root["$schema"] = draft2020Dialect
+		}
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	registerFormats(compiler)
+	if dv.options.AssertFormats {
+		compiler.AssertFormat()
+	}
+
+	err = compiler.AddResource(draft2020SchemaURL, document)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := compiler.Compile(draft2020SchemaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if dv.options.Cache != nil {
+		dv.options.Cache.add(key, schema)
+	} else {
+		dv.compiled = schema
+	}
+
+	return schema, nil
+}
+
+// cleanErrors Creates the structured errors from the tree of errors of the validation. Groups and references are
+// replaced by their causes, and for oneOf / anyOf only the errors of the closest subschema are included
+//
+// Parameters:
+//   - document: Document validated
+//   - validationError: Error of the validation
+//   - details: Errors found so far
+//
+// Returns:
+//   - []Error: Structured errors
+func (dv *Draft2020Validator) cleanErrors(document interface{}, validationError *jsonschema.ValidationError, details []Error) []Error {
+	location := validationError.InstanceLocation
+	details = dv.addConditionalErrors(validationError, details)
+	switch errorKind := validationError.ErrorKind.(type) {
+	case *kind.Schema, *kind.Group, *kind.Reference, *kind.AllOf:
+		for _, cause := range validationError.Causes {
+			details = dv.cleanErrors(document, cause, details)
+		}
+
+	case *kind.AnyOf, *kind.OneOf:
+		details = append(details, dv.newError(document, errorKind, location, nil, ""))
+		var closest []Error
+		for _, cause := range validationError.Causes {
+			causeDetails := dv.cleanErrors(document, cause, nil)
+			if closest == nil || len(causeDetails) < len(closest) {
+				closest = causeDetails
+			}
+		}
+
+		details = append(details, closest...)
+
+	case *kind.Required:
+		for _, property := range errorKind.Missing {
+			details = append(details, dv.newError(document, &kind.Required{Missing: []string{property}}, location, nil, property))
+		}
+
+	case *kind.AdditionalProperties:
+		for _, property := range errorKind.Properties {
+			details = append(details, dv.newError(document, &kind.AdditionalProperties{Properties: []string{property}}, location, nil, property))
+		}
+
+	case *kind.Dependency:
+		for _, property := range errorKind.Missing {
+			details = append(details, dv.newError(document, &kind.Dependency{Prop: errorKind.Prop, Missing: []string{property}}, location, nil, property))
+		}
+
+	case *kind.DependentRequired:
+		for _, property := range errorKind.Missing {
+			details = append(details, dv.newError(document, &kind.DependentRequired{Prop: errorKind.Prop, Missing: []string{property}}, location, nil, property))
+		}
+
+	case *kind.FalseSchema:
+		// The properties and items not allowed by unevaluatedProperties, unevaluatedItems or items are validated
+		// against a false schema, the error is reported on the parent like additionalProperties
+		code := getFalseSchemaCode(validationError.SchemaURL)
+		if code != CodeFalseSchema && len(location) > 0 {
+			property := location[len(location)-1]
+			detail := dv.newError(document, errorKind, location[:len(location)-1], &code, property)
+			switch code {
+			case CodeAdditionalProperties:
+				detail.Message = "Additional property " + property + " is not allowed"
+			case CodeAdditionalItems:
+				detail.Message = "No additional items allowed on array"
+			default:
+				detail.Message = dv.printer.Sprintf("%s: '%s' not allowed", code, property)
+			}
+
+			details = append(details, detail)
+		} else {
+			details = append(details, dv.newError(document, errorKind, location, &code, ""))
+		}
+
+	default:
+		details = append(details, dv.newError(document, errorKind, location, nil, ""))
+	}
+
+	return details
+}
+
+// conditionalKeyword is a then / else keyword of the location of an error
+type conditionalKeyword struct {
+	keyword string // then or else
+	depth   int    // Number of tokens of the location of the value validated by the condition
+}
+
+// addConditionalErrors adds a conditional error for each then / else keyword that contains the error, like the
+// draft-07 engine. The library reports the errors of then and else without an error of their own, so the keywords are
+// found in the location of the schema. A condition is reported once, for its first error
+//
+// Parameters:
+//   - validationError: Error of the validation
+//   - details: Errors found so far
+//
+// Returns:
+//   - []Error: Errors with the conditional errors added
+func (dv *Draft2020Validator) addConditionalErrors(validationError *jsonschema.ValidationError, details []Error) []Error {
+	code := CodeConditional
+	for _, conditional := range getConditionalKeywords(validationError.SchemaURL, len(validationError.InstanceLocation)) {
+		detail := dv.newError(nil, &kind.Group{}, validationError.InstanceLocation[:conditional.depth], &code, "")
+		if conditional.keyword == "then" {
+			detail.Message = `Must validate "then" as "if" was valid`
+		} else {
+			detail.Message = `Must validate "else" as "if" was not valid`
+		}
+
+		found := false
+		for _, previous := range details {
+			if previous.Code == CodeConditional && previous.Pointer == detail.Pointer && previous.Message == detail.Message {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			details = append(details, detail)
+		}
+	}
+
+	return details
+}
+
+// getConditionalKeywords returns the then / else keywords of the location of a schema, with the location of the value
+// validated by each condition. The keywords are walked to know how many tokens of the location of the value are
+// consumed after each condition, the location of the schema may start on a definition reached by a reference
+//
+// Parameters:
+//   - schemaURL: Location of the schema of the error
+//   - depth: Number of tokens of the location of the value of the error
+//
+// Returns:
+//   - []conditionalKeyword: Conditions that contain the error
+func getConditionalKeywords(schemaURL string, depth int) []conditionalKeyword {
+	fragment := schemaURL[strings.Index(schemaURL, "#")+1:]
+	if !strings.Contains(fragment, "/then") && !strings.Contains(fragment, "/else") {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(fragment, "/"), "/")
+	conditionals := make([]conditionalKeyword, 0)
+	consumed := 0
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "properties", "patternProperties":
+			i++
+			consumed++
+		case "$defs", "definitions", "dependentSchemas", "dependencies", "allOf", "anyOf", "oneOf":
+			i++
+		case "prefixItems":
+			i++
+			consumed++
+		case "items":
+			// Before 2020-12 items can be an array of schemas
+			if i+1 < len(tokens) && isInteger(tokens[i+1]) {
+				i++
+			}
+
+			consumed++
+		case "additionalProperties", "unevaluatedProperties", "additionalItems", "unevaluatedItems", "contains":
+			consumed++
+		case "then", "else":
+			conditionals = append(conditionals, conditionalKeyword{keyword: tokens[i], depth: consumed})
+		}
+	}
+
+	// The location of the schema starts on the value at depth - consumed
+	base := depth - consumed
+	if base < 0 {
+		return nil
+	}
+
+	for i := range conditionals {
+		conditionals[i].depth += base
+	}
+
+	return conditionals
+}
+
+// newError creates a structured error from an error of the validation
+//
+// Parameters:
+//   - document: Document validated
+//   - errorKind: Kind of the error
+//   - location: Location of the value of the error, for properties the location of the object
+//   - code: Code of the error, nil to use the code of the kind
+//   - property: Name of the missing or additional property, empty if the error is on the value
+//
+// Returns:
+//   - Error: Error created
+func (dv *Draft2020Validator) newError(document interface{}, errorKind jsonschema.ErrorKind, location []string, code *ErrorCode, property string) Error {
+	field := "(root)"
+	if len(location) > 0 {
+		field = strings.Join(location, ".")
+	}
+
+	result := Error{
+		Field:      cleanString(field),
+		Message:    cleanString(dv.getMessage(errorKind, field, property)),
+		Constraint: getConstraint(errorKind),
+	}
+
+	// The integers are reported as integer, like in the draft-07 engine
+	if typeKind, ok := errorKind.(*kind.Type); ok {
+		result.Constraint, result.Message = getTypeError(typeKind.Want, getJSONType(getValue(document, location)))
+	}
+
+	if code != nil {
+		result.Code = *code
+	} else {
+		result.Code = getKindCode(errorKind)
+	}
+
+	for _, token := range location {
+		result.Pointer += "/" + escapePointer(token)
+	}
+
+	if property != "" {
+		result.Pointer += "/" + escapePointer(property)
+		if result.Constraint == "" {
+			result.Constraint = property
+		}
+	}
+
+	return result
+}
+
+// getMessage returns the message of an error, with the same text used by the draft-07 engine so that the errors of
+// the reports do not depend on the engine. The kinds not supported by draft-07 use the message of the library
+//
+// Parameters:
+//   - errorKind: Kind of the error
+//   - field: Field of the error
+//   - property: Name of the missing or additional property, empty if the error is on the value
+//
+// Returns:
+//   - string: Message of the error
+func (dv *Draft2020Validator) getMessage(errorKind jsonschema.ErrorKind, field string, property string) string {
+	constraint := getConstraint(errorKind)
+	switch errorKind := errorKind.(type) {
+	case *kind.Required:
+		return property + " is required"
+	case *kind.Enum:
+		return field + " must be one of the following: " + constraint
+	case *kind.Const:
+		return field + " does not match: " + constraint
+	case *kind.Pattern:
+		return "Does not match pattern '" + constraint + "'"
+	case *kind.Format:
+		return "Does not match format '" + constraint + "'"
+	case *kind.MinLength:
+		return "String length must be greater than or equal to " + constraint
+	case *kind.MaxLength:
+		return "String length must be less than or equal to " + constraint
+	case *kind.Minimum:
+		return "Must be greater than or equal to " + constraint
+	case *kind.Maximum:
+		return "Must be less than or equal to " + constraint
+	case *kind.ExclusiveMinimum:
+		return "Must be greater than " + constraint
+	case *kind.ExclusiveMaximum:
+		return "Must be less than " + constraint
+	case *kind.MultipleOf:
+		return "Must be a multiple of " + constraint
+	case *kind.MinItems:
+		return "Array must have at least " + constraint + " items"
+	case *kind.MaxItems:
+		return "Array must have at most " + constraint + " items"
+	case *kind.UniqueItems:
+		return fmt.Sprintf("array items[%d,%d] must be unique", errorKind.Duplicates[0], errorKind.Duplicates[1])
+	case *kind.Contains:
+		return "At least one of the items must match"
+	case *kind.AdditionalItems:
+		return "No additional items allowed on array"
+	case *kind.MinProperties:
+		return "Must have at least " + constraint + " properties"
+	case *kind.MaxProperties:
+		return "Must have at most " + constraint + " properties"
+	case *kind.AdditionalProperties:
+		return "Additional property " + property + " is not allowed"
+	case *kind.PropertyNames:
+		return "Property name of " + strconv.Quote(constraint) + " does not match"
+	case *kind.Dependency, *kind.DependentRequired:
+		return "Has a dependency on " + property
+	case *kind.OneOf:
+		return "Must validate one and only one schema (oneOf)"
+	case *kind.AnyOf:
+		return "Must validate at least one schema (anyOf)"
+	case *kind.Not:
+		return "Must not validate the schema (not)"
+	case *kind.FalseSchema:
+		return "False always fails validation"
+	}
+
+	return errorKind.LocalizedString(dv.printer)
+}
+
+// getValue returns the value of a location of a document
+//
+// Parameters:
+//   - document: Document validated
+//   - location: Tokens of the location
+//
+// Returns:
+//   - interface{}: Value of the location, nil if it is not found
+func getValue(document interface{}, location []string) interface{} {
+	value := document
+	for _, token := range location {
+		switch container := value.(type) {
+		case map[string]interface{}:
+			value = container[token]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(container) {
+				return nil
+			}
+
+			value = container[index]
+		default:
+			return nil
+		}
+	}
+
+	return value
+}
+
+// getJSONType returns the JSON type of a value, the numbers without a fractional part are integers like in draft-07
+//
+// Parameters:
+//   - value: Value of the document
+//
+// Returns:
+//   - string: JSON type of the value
+func getJSONType(value interface{}) string {
+	switch number := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case json.Number:
+		if isInteger(number.String()) {
+			return "integer"
+		}
+	case float64:
+		if number == math.Trunc(number) {
+			return "integer"
+		}
+	case int, int64:
+		return "integer"
+	}
+
+	return "number"
+}
+
+// isInteger returns whether the text of a number has no fractional part
+//
+// Parameters:
+//   - text: Text of the number
+//
+// Returns:
+//   - bool: true if the number is an integer
+func isInteger(text string) bool {
+	number, ok := new(big.Rat).SetString(text)
+	return ok && number.IsInt()
+}
+
+// getKindCode returns the stable code of a kind of error
+//
+// Parameters:
+//   - errorKind: Kind of the error
+//
+// Returns:
+//   - ErrorCode: Code of the error, CodeInvalid if the kind is not known
+func getKindCode(errorKind jsonschema.ErrorKind) ErrorCode {
+	switch errorKind.(type) {
+	case *kind.Required:
+		return CodeRequired
+	case *kind.Type:
+		return CodeType
+	case *kind.Enum:
+		return CodeEnum
+	case *kind.Const:
+		return CodeConst
+	case *kind.Pattern:
+		return CodePattern
+	case *kind.Format:
+		return CodeFormat
+	case *kind.MinLength:
+		return CodeMinLength
+	case *kind.MaxLength:
+		return CodeMaxLength
+	case *kind.Minimum:
+		return CodeMinimum
+	case *kind.Maximum:
+		return CodeMaximum
+	case *kind.ExclusiveMinimum:
+		return CodeExclusiveMinimum
+	case *kind.ExclusiveMaximum:
+		return CodeExclusiveMaximum
+	case *kind.MultipleOf:
+		return CodeMultipleOf
+	case *kind.MinItems:
+		return CodeMinItems
+	case *kind.MaxItems:
+		return CodeMaxItems
+	case *kind.UniqueItems:
+		return CodeUniqueItems
+	case *kind.Contains, *kind.MinContains, *kind.MaxContains:
+		return CodeContains
+	case *kind.AdditionalItems:
+		return CodeAdditionalItems
+	case *kind.MinProperties:
+		return CodeMinProperties
+	case *kind.MaxProperties:
+		return CodeMaxProperties
+	case *kind.AdditionalProperties:
+		return CodeAdditionalProperties
+	case *kind.PropertyNames:
+		return CodePropertyNames
+	case *kind.Dependency:
+		return CodeDependencies
+	case *kind.DependentRequired:
+		return CodeDependentRequired
+	case *kind.OneOf:
+		return CodeOneOf
+	case *kind.AnyOf:
+		return CodeAnyOf
+	case *kind.Not:
+		return CodeNot
+	case *kind.FalseSchema:
+		return CodeFalseSchema
+	}
+
+	return CodeInvalid
+}
+
+// getFalseSchemaCode returns the code of an error of a false schema, based on the keyword of the schema
+//
+// Parameters:
+//   - schemaURL: Location of the false schema
+//
+// Returns:
+//   - ErrorCode: Code of the keyword, CodeFalseSchema if the keyword is not known
+func getFalseSchemaCode(schemaURL string) ErrorCode {
+	switch schemaURL[strings.LastIndex(schemaURL, "/")+1:] {
+	case "unevaluatedProperties":
+		return CodeUnevaluatedProperties
+	case "unevaluatedItems":
+		return CodeUnevaluatedItems
+	case "additionalProperties":
+		return CodeAdditionalProperties
+	case "items", "additionalItems":
+		return CodeAdditionalItems
+	}
+
+	return CodeFalseSchema
+}
+
+// getConstraint returns the text of the constraint expected by an error
+//
+// Parameters:
+//   - errorKind: Kind of the error
+//
+// Returns:
+//   - string: Text of the constraint, empty if the kind has no constraint
+func getConstraint(errorKind jsonschema.ErrorKind) string {
+	switch constraint := errorKind.(type) {
+	case *kind.Type:
+		return strings.Join(constraint.Want, ", ")
+	case *kind.Enum:
+		values := make([]string, 0, len(constraint.Want))
+		for _, value := range constraint.Want {
+			values = append(values, formatJSONValue(value))
+		}
+
+		return strings.Join(values, ", ")
+	case *kind.Const:
+		return formatJSONValue(constraint.Want)
+	case *kind.Pattern:
+		return constraint.Want
+	case *kind.Format:
+		return constraint.Want
+	case *kind.MinLength:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MaxLength:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MinItems:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MaxItems:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MinProperties:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MaxProperties:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MinContains:
+		return strconv.Itoa(constraint.Want)
+	case *kind.MaxContains:
+		return strconv.Itoa(constraint.Want)
+	case *kind.Minimum:
+		return formatRat(constraint.Want)
+	case *kind.Maximum:
+		return formatRat(constraint.Want)
+	case *kind.ExclusiveMinimum:
+		return formatRat(constraint.Want)
+	case *kind.ExclusiveMaximum:
+		return formatRat(constraint.Want)
+	case *kind.MultipleOf:
+		return formatRat(constraint.Want)
+	case *kind.PropertyNames:
+		return constraint.Property
+	}
+
+	return ""
+}
+
+// formatJSONValue returns the JSON text of a value
+//
+// Parameters:
+//   - value: Value to format
+//
+// Returns:
+//   - string: JSON text of the value
+func formatJSONValue(value interface{}) string {
+	text, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(text)
+}
+
+// formatRat returns the decimal text of a number
+//
+// Parameters:
+//   - value: Number to format
+//
+// Returns:
+//   - string: Text of the number
+func formatRat(value *big.Rat) string {
+	if value == nil {
+		return ""
+	}
+
+	return formatConstraint(new(big.Float).SetRat(value))
+}
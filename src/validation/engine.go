@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// Engine identifies the implementation that validates the documents of a schema
+type Engine string
+
+const (
+	EngineDraft7    Engine = "draft-07" // gojsonschema, for drafts 4, 6 and 7, used when the schema does not declare $schema
+	EngineDraft2020 Engine = "2020-12"  // santhosh-tekuri/jsonschema, for drafts 2019-09 and 2020-12 and the OpenAPI 3.1 dialects
+)
+
+const (
+	draft2020Dialect     = "https://json-schema.org/draft/2020-12/schema" // Dialect of the draft 2020-12
+	openAPI31DialectBase = "https://spec.openapis.org/oas/3.1/dialect/"   // Prefix of the OpenAPI 3.1 dialects (e.g. base), based on the draft 2020-12
+	draft2020DialectPath = "/draft/2020-12/"                              // Path of the draft 2020-12 dialect
+	draft2019DialectPath = "/draft/2019-09/"                              // Path of the draft 2019-09 dialect
+)
+
+// Options stores the options of the validation engines
+type Options struct {
+	AssertFormats bool         // Indicates if the formats (date, date-time, uuid, uri, etc.) are validated, not only annotated
+	Cache         *SchemaCache // Cache of the compiled schemas shared by the validators, nil to compile the schema by validator
+}
+
+// DefaultOptions returns the options used when no options are specified
+//
+// Parameters:
+//
+// Returns:
+//   - Options: Options with the format assertions enabled
+func DefaultOptions() Options {
+	return Options{AssertFormats: true}
+}
+
+// GetEngine returns the engine that supports the dialect declared on the $schema keyword of a schema
+//
+// Parameters:
+//   - schema: JSON Schema
+//
+// Returns:
+//   - Engine: Engine for the schema, EngineDraft7 if $schema is not set or the schema cannot be read, EngineDraft2020
+//     for the drafts 2019-09 and 2020-12 and the OpenAPI 3.1 dialects
+func GetEngine(schema string) Engine {
+	var dialect struct {
+		Schema string `json:"$schema"`
+	}
+
+	if err := json.Unmarshal([]byte(schema), &dialect); err != nil {
+		return EngineDraft7
+	}
+
+	if strings.Contains(dialect.Schema, draft2020DialectPath) || strings.Contains(dialect.Schema, draft2019DialectPath) ||
+		isOpenAPIDialect(dialect.Schema) {
+		return EngineDraft2020
+	}
+
+	return EngineDraft7
+}
+
+// isOpenAPIDialect indicates if a dialect is one of the OpenAPI 3.1 dialects, the draft 2020-12 with the annotations
+// of OpenAPI (discriminator, xml, externalDocs and example)
+//
+// Parameters:
+//   - dialect: Value of the $schema keyword
+//
+// Returns:
+//   - bool: true if the dialect is an OpenAPI 3.1 dialect
+func isOpenAPIDialect(dialect string) bool {
+	return strings.HasPrefix(dialect, openAPI31DialectBase)
+}
+
+// NewValidator creates the validator of a schema, using the engine selected by its $schema keyword
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - schema: JSON Schema to be used for validation
+//   - options: Options of the validation
+//
+// Returns:
+//   - Validator: Validator of the schema
+func NewValidator(logger log.Logger, schema string, options Options) Validator {
+	if GetEngine(schema) == EngineDraft2020 {
+		return GetDraft2020Validator(logger, schema, options)
+	}
+
+	validator := GetSchemaValidator(logger, schema)
+	validator.options = options
+	return validator
+}
@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+func TestGetEngine(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   Engine
+	}{
+		{name: "no dialect", schema: `{"type":"object"}`, want: EngineDraft7},
+		{name: "invalid schema", schema: `{`, want: EngineDraft7},
+		{name: "draft-07", schema: `{"$schema":"http://json-schema.org/draft-07/schema#"}`, want: EngineDraft7},
+		{name: "draft 2019-09", schema: `{"$schema":"https://json-schema.org/draft/2019-09/schema"}`, want: EngineDraft2020},
+		{name: "draft 2020-12", schema: `{"$schema":"https://json-schema.org/draft/2020-12/schema"}`, want: EngineDraft2020},
+		{name: "OpenAPI 3.1 base dialect", schema: `{"$schema":"https://spec.openapis.org/oas/3.1/dialect/base"}`, want: EngineDraft2020},
+		{name: "OpenAPI 3.1 dated dialect", schema: `{"$schema":"https://spec.openapis.org/oas/3.1/dialect/2024-10-25"}`, want: EngineDraft2020},
+		{name: "OpenAPI 3.0 is not a dialect", schema: `{"$schema":"https://spec.openapis.org/oas/3.0/schema/2021-09-28"}`, want: EngineDraft7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetEngine(tt.schema); got != tt.want {
+				t.Errorf("GetEngine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOpenAPIDialect(t *testing.T) {
+	tests := []struct {
+		name      string
+		document  string
+		wantValid bool
+		wantCode  ErrorCode
+	}{
+		{name: "valid", document: `{"amount":"10.00","type":"PIX"}`, wantValid: true},
+		{name: "missing property", document: `{"type":"PIX"}`, wantCode: CodeRequired},
+		{name: "invalid format", document: `{"amount":"10.00","type":"PIX","date":"2024-13-45"}`, wantCode: CodeFormat},
+	}
+
+	// The annotations of OpenAPI (discriminator, example) are not validated
+	schema := `{"$schema":"https://spec.openapis.org/oas/3.1/dialect/base","type":"object","required":["amount"],
+		"discriminator":{"propertyName":"type"},"properties":{"amount":{"type":"string","example":"10.00"},
+		"type":{"type":"string"},"date":{"type":"string","format":"date"}}}`
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateTestDocument(t, schema, tt.document)
+			if result.Valid != tt.wantValid {
+				t.Fatalf("Validate() = %+v, want valid %v", result, tt.wantValid)
+			}
+
+			if !tt.wantValid && (len(result.Details) != 1 || result.Details[0].Code != tt.wantCode) {
+				t.Errorf("errors = %+v, want code %s", result.Details, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	schema := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"v":{"format":"date"}}}`
+	other := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object"}`
+	tests := []struct {
+		name    string
+		schemas []string
+		options []Options
+		wantLen int
+	}{
+		{name: "same schema", schemas: []string{schema, schema}, options: []Options{{AssertFormats: true}, {AssertFormats: true}}, wantLen: 1},
+		{name: "different schemas", schemas: []string{schema, other}, options: []Options{{AssertFormats: true}, {AssertFormats: true}}, wantLen: 2},
+		{name: "different options", schemas: []string{schema, schema}, options: []Options{{AssertFormats: true}, {AssertFormats: false}}, wantLen: 2},
+	}
+
+	logger := log.NewLogger("PANIC")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewSchemaCache()
+			for i, schema := range tt.schemas {
+				options := tt.options[i]
+				options.Cache = cache
+				if _, err := NewValidator(logger, schema, options).Validate(DynamicStruct{"v": "2024-13-45"}); err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+			}
+
+			if got := cache.Len(); got != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", got, tt.wantLen)
+			}
+		})
+	}
+}
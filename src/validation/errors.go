@@ -0,0 +1,237 @@
+package validation
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ErrorCode is the stable code of a validation error, it does not depend on the messages of the validation library
+type ErrorCode string
+
+const (
+	CodeRequired              ErrorCode = "required"              // A required property is missing
+	CodeType                  ErrorCode = "type"                  // The value has a type different from the expected type
+	CodeEnum                  ErrorCode = "enum"                  // The value is not one of the allowed values
+	CodeConst                 ErrorCode = "const"                 // The value is different from the constant value
+	CodePattern               ErrorCode = "pattern"               // The string does not match the pattern
+	CodeFormat                ErrorCode = "format"                // The string does not match the format
+	CodeMinLength             ErrorCode = "minLength"             // The string is shorter than the minimum length
+	CodeMaxLength             ErrorCode = "maxLength"             // The string is longer than the maximum length
+	CodeMinimum               ErrorCode = "minimum"               // The number is lower than the minimum
+	CodeMaximum               ErrorCode = "maximum"               // The number is greater than the maximum
+	CodeExclusiveMinimum      ErrorCode = "exclusiveMinimum"      // The number is not greater than the exclusive minimum
+	CodeExclusiveMaximum      ErrorCode = "exclusiveMaximum"      // The number is not lower than the exclusive maximum
+	CodeMultipleOf            ErrorCode = "multipleOf"            // The number is not a multiple of the value
+	CodeMinItems              ErrorCode = "minItems"              // The array has fewer items than the minimum
+	CodeMaxItems              ErrorCode = "maxItems"              // The array has more items than the maximum
+	CodeUniqueItems           ErrorCode = "uniqueItems"           // The array has repeated items
+	CodeContains              ErrorCode = "contains"              // The array has no item that matches the schema
+	CodeAdditionalItems       ErrorCode = "additionalItems"       // The array has more items than the item schemas
+	CodeMinProperties         ErrorCode = "minProperties"         // The object has fewer properties than the minimum
+	CodeMaxProperties         ErrorCode = "maxProperties"         // The object has more properties than the maximum
+	CodeAdditionalProperties  ErrorCode = "additionalProperties"  // The object has a property that is not allowed
+	CodePropertyNames         ErrorCode = "propertyNames"         // The name of a property is not valid
+	CodeDependencies          ErrorCode = "dependencies"          // A property required by another property is missing
+	CodeDependentRequired     ErrorCode = "dependentRequired"     // A property required by another property is missing (2019-09)
+	CodeUnevaluatedItems      ErrorCode = "unevaluatedItems"      // The array has items not evaluated by any schema (2019-09)
+	CodeUnevaluatedProperties ErrorCode = "unevaluatedProperties" // The object has properties not evaluated by any schema (2019-09)
+	CodeOneOf                 ErrorCode = "oneOf"                 // The value does not match exactly one schema
+	CodeAnyOf                 ErrorCode = "anyOf"                 // The value does not match any schema
+	CodeAllOf                 ErrorCode = "allOf"                 // The value does not match all the schemas
+	CodeNot                   ErrorCode = "not"                   // The value matches a schema that is not allowed
+	CodeFalseSchema           ErrorCode = "false"                 // The value is not allowed by a false schema
+	CodeConditional           ErrorCode = "conditional"           // The value does not match the then / else schema of a condition
+	CodeRule                  ErrorCode = "rule"                  // The value does not meet a business rule of the endpoint
+	CodeLinks                 ErrorCode = "links"                 // The self link does not match the endpoint or the API version
+	CodePagination            ErrorCode = "pagination"            // The page links do not agree with the position of the page
+	CodeTotals                ErrorCode = "totals"                // The totals of meta are not coherent with the page
+	CodeRequestDateTime       ErrorCode = "requestDateTime"       // meta.requestDateTime differs from the receipt time
+	CodeUnknownResource       ErrorCode = "unknownResource"       // An identifier queried was not returned by the list of the journey
+	CodeAmountMismatch        ErrorCode = "amountMismatch"        // An amount differs between the list and the detail views
+	CodeCurrencyMismatch      ErrorCode = "currencyMismatch"      // A currency differs between the list and the detail views
+	CodeDuplicateID           ErrorCode = "duplicateId"           // A transaction was returned on more than one page of a list
+	CodeInvalid               ErrorCode = "invalid"               // The message cannot be validated (e.g. invalid JSON)
+)
+
+// codesByType maps the error types of gojsonschema to the stable codes
+var codesByType = map[string]ErrorCode{
+	"required":                        CodeRequired,
+	"invalid_type":                    CodeType,
+	"enum":                            CodeEnum,
+	"const":                           CodeConst,
+	"pattern":                         CodePattern,
+	"format":                          CodeFormat,
+	"string_gte":                      CodeMinLength,
+	"string_lte":                      CodeMaxLength,
+	"number_gte":                      CodeMinimum,
+	"number_lte":                      CodeMaximum,
+	"number_gt":                       CodeExclusiveMinimum,
+	"number_lt":                       CodeExclusiveMaximum,
+	"multiple_of":                     CodeMultipleOf,
+	"array_min_items":                 CodeMinItems,
+	"array_max_items":                 CodeMaxItems,
+	"unique":                          CodeUniqueItems,
+	"contains":                        CodeContains,
+	"array_no_additional_items":       CodeAdditionalItems,
+	"array_min_properties":            CodeMinProperties,
+	"array_max_properties":            CodeMaxProperties,
+	"additional_property_not_allowed": CodeAdditionalProperties,
+	"invalid_property_pattern":        CodeAdditionalProperties,
+	"invalid_property_name":           CodePropertyNames,
+	"missing_dependency":              CodeDependencies,
+	"number_one_of":                   CodeOneOf,
+	"number_any_of":                   CodeAnyOf,
+	"number_all_of":                   CodeAllOf,
+	"number_not":                      CodeNot,
+	"condition_then":                  CodeConditional,
+	"condition_else":                  CodeConditional,
+	"false":                           CodeFalseSchema,
+}
+
+// constraintKeys are the keys of the details of gojsonschema that contain the expected constraint
+var constraintKeys = []string{"expected", "allowed", "pattern", "format", "min", "max", "multiple", "property", "dependency"}
+
+// Error is a validation error with a stable code
+type Error struct {
+	Code       ErrorCode `json:"code"`                 // Stable code of the error
+	Field      string    `json:"field"`                // Field of the error, the key of Result.Errors
+	Pointer    string    `json:"pointer"`              // JSON pointer of the value, or of the missing / additional property
+	Constraint string    `json:"constraint,omitempty"` // Constraint expected (e.g. the pattern, the minimum, the allowed values)
+	Message    string    `json:"message"`              // Human-readable message, the value of Result.Errors
+	RuleID     string    `json:"ruleId,omitempty"`     // Identifier of the business rule, for the errors with code rule
+}
+
+// NewInvalidError creates the error of a message that cannot be validated
+//
+// Parameters:
+//   - message: Description of the problem
+//
+// Returns:
+//   - Error: Error created
+func NewInvalidError(message string) Error {
+	return Error{Code: CodeInvalid, Field: "(error)", Message: message}
+}
+
+// newError creates a structured error from an error of gojsonschema
+//
+// Parameters:
+//   - err: Error of gojsonschema
+//   - field: Cleaned field of the error
+//   - message: Cleaned message of the error
+//
+// Returns:
+//   - Error: Error created
+func newError(err gojsonschema.ResultError, field string, message string) Error {
+	code, found := codesByType[err.Type()]
+	if !found {
+		code = CodeInvalid
+	}
+
+	result := Error{
+		Code:    code,
+		Field:   field,
+		Pointer: getJSONPointer(err.Context()),
+		Message: message,
+	}
+
+	details := err.Details()
+	for _, key := range constraintKeys {
+		if value, found := details[key]; found {
+			result.Constraint = formatConstraint(value)
+			break
+		}
+	}
+
+	// The types are reported in the same order by both engines
+	if code == CodeType {
+		expected := strings.Split(strings.Trim(fmt.Sprint(details["expected"]), "[]"), ",")
+		result.Constraint, result.Message = getTypeError(expected, fmt.Sprint(details["given"]))
+	}
+
+	// The required and additional properties are reported on the object, the pointer identifies the property
+	if code == CodeRequired || code == CodeAdditionalProperties || code == CodeDependencies {
+		if property, ok := details["property"].(string); ok {
+			result.Pointer += "/" + escapePointer(property)
+		}
+	}
+
+	return result
+}
+
+// getJSONPointer returns the JSON pointer (RFC 6901) of the context of an error
+//
+// Parameters:
+//   - context: Context of the error
+//
+// Returns:
+//   - string: JSON pointer, empty for the root of the document
+func getJSONPointer(context *gojsonschema.JsonContext) string {
+	if context == nil {
+		return ""
+	}
+
+	// The separator cannot be found in the property names, unlike the default separator "."
+	parts := strings.Split(context.String("\x00"), "\x00")
+	var builder strings.Builder
+	for _, part := range parts[1:] {
+		builder.WriteString("/" + escapePointer(part))
+	}
+
+	return builder.String()
+}
+
+// escapePointer escapes a token of a JSON pointer
+//
+// Parameters:
+//   - token: Property name or array index
+//
+// Returns:
+//   - string: Escaped token
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// formatConstraint returns the text of a constraint
+//
+// Parameters:
+//   - value: Value of the constraint
+//
+// Returns:
+//   - string: Text of the constraint
+func formatConstraint(value interface{}) string {
+	switch constraint := value.(type) {
+	case *big.Float:
+		return constraint.Text('g', -1)
+	case *big.Rat:
+		return constraint.RatString()
+	case string:
+		return constraint
+	}
+
+	return fmt.Sprint(value)
+}
+
+// getTypeError returns the constraint and the message of a type error, with the expected types sorted
+//
+// Parameters:
+//   - expected: Types expected
+//   - given: Type of the value
+//
+// Returns:
+//   - string: Constraint of the error (e.g. null, string)
+//   - string: Message of the error (e.g. Invalid type. Expected: [null,string], given: integer)
+func getTypeError(expected []string, given string) (string, string) {
+	types := slices.Clone(expected)
+	sort.Strings(types)
+	text := types[0]
+	if len(types) > 1 {
+		text = "[" + strings.Join(types, ",") + "]"
+	}
+
+	return strings.Join(types, ", "), "Invalid type. Expected: " + text + ", given: " + given
+}
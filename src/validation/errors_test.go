@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+const testDraft2020Dialect = `"$schema":"https://json-schema.org/draft/2020-12/schema",`
+
+// validateTestDocument validates a document with the engine selected by the schema
+func validateTestDocument(t *testing.T, schema string, document string) *Result {
+	t.Helper()
+	var data DynamicStruct
+	if err := json.Unmarshal([]byte(document), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewValidator(log.NewLogger("PANIC"), schema, DefaultOptions()).Validate(data)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	return result
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name           string
+		schema         string // Schema without the braces, the dialect is added for the 2020-12 engine
+		document       string
+		wantCode       ErrorCode
+		wantPointer    string
+		wantConstraint string
+		wantMessage    string // Message of the error, the same for both engines
+	}{
+		{name: "required", schema: `"type":"object","required":["amount"]`, document: `{}`, wantCode: CodeRequired, wantPointer: "/amount", wantConstraint: "amount", wantMessage: "amount is required"},
+		{name: "type", schema: `"type":"object","properties":{"v":{"type":"string"}}`, document: `{"v":1}`, wantCode: CodeType, wantPointer: "/v", wantConstraint: "string", wantMessage: "Invalid type. Expected: string, given: integer"},
+		{name: "nullable type", schema: `"type":"object","properties":{"v":{"type":["string","null"]}}`, document: `{"v":1.5}`, wantCode: CodeType, wantPointer: "/v", wantConstraint: "null, string", wantMessage: "Invalid type. Expected: [null,string], given: number"},
+		{name: "enum", schema: `"type":"object","properties":{"v":{"enum":["A","B"]}}`, document: `{"v":"C"}`, wantCode: CodeEnum, wantPointer: "/v", wantMessage: `v must be one of the following: "A", "B"`},
+		{name: "const", schema: `"type":"object","properties":{"v":{"const":"A"}}`, document: `{"v":"C"}`, wantCode: CodeConst, wantPointer: "/v", wantMessage: `v does not match: "A"`},
+		{name: "pattern", schema: `"type":"object","properties":{"v":{"type":"string","pattern":"^[0-9]+$"}}`, document: `{"v":"a"}`, wantCode: CodePattern, wantPointer: "/v", wantConstraint: "^[0-9]+$", wantMessage: "Does not match pattern '^[0-9]+$'"},
+		{name: "format", schema: `"type":"object","properties":{"v":{"type":"string","format":"date"}}`, document: `{"v":"2024-13-45"}`, wantCode: CodeFormat, wantPointer: "/v", wantConstraint: "date", wantMessage: "Does not match format 'date'"},
+		{name: "minLength", schema: `"type":"object","properties":{"v":{"type":"string","minLength":3}}`, document: `{"v":"a"}`, wantCode: CodeMinLength, wantPointer: "/v", wantConstraint: "3", wantMessage: "String length must be greater than or equal to 3"},
+		{name: "maxLength", schema: `"type":"object","properties":{"v":{"type":"string","maxLength":1}}`, document: `{"v":"ab"}`, wantCode: CodeMaxLength, wantPointer: "/v", wantConstraint: "1", wantMessage: "String length must be less than or equal to 1"},
+		{name: "minimum", schema: `"type":"object","properties":{"v":{"type":"number","minimum":1.5}}`, document: `{"v":1}`, wantCode: CodeMinimum, wantPointer: "/v", wantConstraint: "1.5", wantMessage: "Must be greater than or equal to 1.5"},
+		{name: "maximum", schema: `"type":"object","properties":{"v":{"type":"number","maximum":2}}`, document: `{"v":3}`, wantCode: CodeMaximum, wantPointer: "/v", wantConstraint: "2", wantMessage: "Must be less than or equal to 2"},
+		{name: "exclusiveMinimum", schema: `"type":"object","properties":{"v":{"type":"number","exclusiveMinimum":0.5}}`, document: `{"v":0.5}`, wantCode: CodeExclusiveMinimum, wantPointer: "/v", wantConstraint: "0.5", wantMessage: "Must be greater than 0.5"},
+		{name: "exclusiveMaximum", schema: `"type":"object","properties":{"v":{"type":"number","exclusiveMaximum":1.2}}`, document: `{"v":1.2}`, wantCode: CodeExclusiveMaximum, wantPointer: "/v", wantConstraint: "1.2", wantMessage: "Must be less than 1.2"},
+		{name: "multipleOf", schema: `"type":"object","properties":{"v":{"type":"integer","multipleOf":7}}`, document: `{"v":8}`, wantCode: CodeMultipleOf, wantPointer: "/v", wantConstraint: "7", wantMessage: "Must be a multiple of 7"},
+		{name: "minItems", schema: `"type":"object","properties":{"v":{"type":"array","minItems":1}}`, document: `{"v":[]}`, wantCode: CodeMinItems, wantPointer: "/v", wantConstraint: "1", wantMessage: "Array must have at least 1 items"},
+		{name: "maxItems", schema: `"type":"object","properties":{"v":{"type":"array","maxItems":1}}`, document: `{"v":[1,2]}`, wantCode: CodeMaxItems, wantPointer: "/v", wantConstraint: "1", wantMessage: "Array must have at most 1 items"},
+		{name: "uniqueItems", schema: `"type":"object","properties":{"v":{"type":"array","uniqueItems":true}}`, document: `{"v":[1,1]}`, wantCode: CodeUniqueItems, wantPointer: "/v", wantMessage: "array items[0,1] must be unique"},
+		{name: "minProperties", schema: `"type":"object","minProperties":2`, document: `{"a":1}`, wantCode: CodeMinProperties, wantPointer: "", wantConstraint: "2", wantMessage: "Must have at least 2 properties"},
+		{name: "maxProperties", schema: `"type":"object","maxProperties":1`, document: `{"a":1,"b":2}`, wantCode: CodeMaxProperties, wantPointer: "", wantConstraint: "1", wantMessage: "Must have at most 1 properties"},
+		{name: "additionalProperties", schema: `"type":"object","additionalProperties":false`, document: `{"extra":1}`, wantCode: CodeAdditionalProperties, wantPointer: "/extra", wantConstraint: "extra", wantMessage: "Additional property extra is not allowed"},
+		{name: "not", schema: `"type":"object","properties":{"v":{"not":{"type":"string"}}}`, document: `{"v":"a"}`, wantCode: CodeNot, wantPointer: "/v", wantMessage: "Must not validate the schema (not)"},
+		{name: "false schema", schema: `"type":"object","properties":{"v":false}`, document: `{"v":1}`, wantCode: CodeFalseSchema, wantPointer: "/v", wantMessage: "False always fails validation"},
+		{name: "nested pointer", schema: `"type":"object","properties":{"data":{"type":"array","items":{"type":"object","required":["id"]}}}`, document: `{"data":[{}]}`, wantCode: CodeRequired, wantPointer: "/data/0/id", wantConstraint: "id", wantMessage: "id is required"},
+	}
+
+	engines := []struct {
+		name    string
+		dialect string
+	}{
+		{name: string(EngineDraft7)},
+		{name: string(EngineDraft2020), dialect: testDraft2020Dialect},
+	}
+
+	for _, engine := range engines {
+		for _, tt := range tests {
+			t.Run(engine.name+"/"+tt.name, func(t *testing.T) {
+				result := validateTestDocument(t, "{"+engine.dialect+tt.schema+"}", tt.document)
+				if result.Valid || len(result.Details) != 1 {
+					t.Fatalf("Validate() = %+v, want one error", result)
+				}
+
+				detail := result.Details[0]
+				if detail.Code != tt.wantCode || detail.Pointer != tt.wantPointer || (tt.wantConstraint != "" && detail.Constraint != tt.wantConstraint) {
+					t.Errorf("error = %+v, want code %s, pointer %s, constraint %s", detail, tt.wantCode, tt.wantPointer, tt.wantConstraint)
+				}
+
+				if detail.Message != tt.wantMessage || len(result.Errors[detail.Field]) != 1 || result.Errors[detail.Field][0] != tt.wantMessage {
+					t.Errorf("message = %q, errors = %v, want %q", detail.Message, result.Errors, tt.wantMessage)
+				}
+			})
+		}
+	}
+}
+
+func TestConditionalErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     string // Schema without the braces, the dialect is added for the 2020-12 engine
+		document   string
+		wantErrors []string // Code and pointer of the errors, the same for both engines
+	}{
+		{name: "condition met", schema: `"type":"object","if":{"properties":{"status":{"const":"PAID"}}},"then":{"required":["amount"]}`, document: `{"status":"PAID","amount":"1.00"}`},
+		{
+			name:       "then not valid",
+			schema:     `"type":"object","if":{"properties":{"status":{"const":"PAID"}}},"then":{"required":["amount"]}`,
+			document:   `{"status":"PAID"}`,
+			wantErrors: []string{"conditional ", "required /amount"},
+		},
+		{
+			name:       "else not valid",
+			schema:     `"type":"object","if":{"properties":{"status":{"const":"PAID"}}},"then":{"required":["amount"]},"else":{"properties":{"amount":{"maxLength":1}}}`,
+			document:   `{"status":"OPEN","amount":"10"}`,
+			wantErrors: []string{"conditional ", "maxLength /amount"},
+		},
+		{
+			name:       "then with several errors",
+			schema:     `"type":"object","if":{"properties":{"status":{"const":"PAID"}}},"then":{"required":["amount"],"properties":{"status":{"maxLength":2}}}`,
+			document:   `{"status":"PAID"}`,
+			wantErrors: []string{"conditional ", "required /amount", "maxLength /status"},
+		},
+		{
+			name:       "condition of a property",
+			schema:     `"type":"object","properties":{"data":{"if":{"properties":{"status":{"const":"PAID"}}},"then":{"properties":{"status":{"maxLength":2}}}}}`,
+			document:   `{"data":{"status":"PAID"}}`,
+			wantErrors: []string{"conditional /data", "maxLength /data/status"},
+		},
+	}
+
+	engines := []struct {
+		name    string
+		dialect string
+	}{
+		{name: string(EngineDraft7)},
+		{name: string(EngineDraft2020), dialect: testDraft2020Dialect},
+	}
+
+	for _, engine := range engines {
+		for _, tt := range tests {
+			t.Run(engine.name+"/"+tt.name, func(t *testing.T) {
+				result := validateTestDocument(t, "{"+engine.dialect+tt.schema+"}", tt.document)
+				errors := make([]string, 0)
+				for _, detail := range result.Details {
+					errors = append(errors, string(detail.Code)+" "+detail.Pointer)
+				}
+
+				sort.Strings(errors)
+				want := append([]string{}, tt.wantErrors...)
+				sort.Strings(want)
+				if strings.Join(errors, "|") != strings.Join(want, "|") || result.Valid != (len(want) == 0) {
+					t.Errorf("errors = %v, valid %v, want %v", errors, result.Valid, want)
+				}
+
+				// The conditional errors are only reported in the details
+				for _, messages := range result.Errors {
+					for _, message := range messages {
+						if strings.HasPrefix(message, "Must validate") {
+							t.Errorf("Errors = %v, want no conditional messages", result.Errors)
+						}
+					}
+				}
+			})
+		}
+	}
+}
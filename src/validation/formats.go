@@ -0,0 +1,300 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	FormatCPF              = "cpf"               // CPF with valid check digits, 11 digits without punctuation
+	FormatCNPJ             = "cnpj"              // CNPJ with valid check digits, 14 characters without punctuation, numeric or alphanumeric
+	FormatISPB             = "ispb"              // ISPB code of a financial institution, 8 digits, not checked against the list of the Central Bank
+	FormatCOMPE            = "compe"             // COMPE code of a bank, 3 digits, not checked against the list of the Central Bank
+	FormatBRLAmount        = "brl-amount"        // Amount with 2 to 4 decimal places (e.g. 1000.0400)
+	FormatCurrency         = "currency"          // Active ISO 4217 currency code (e.g. BRL)
+	FormatIBGEMunicipality = "ibge-municipality" // IBGE code of a municipality with valid check digit, 7 digits
+)
+
+var (
+	// ErrInvalidFormat is returned when a value does not match a custom format
+	ErrInvalidFormat = errors.New("value does not match the format")
+
+	ispbExpression   = regexp.MustCompile(`^\d{8}$`)
+	compeExpression  = regexp.MustCompile(`^\d{3}$`)
+	amountExpression = regexp.MustCompile(`^-?\d{1,15}\.\d{2,4}$`)
+	cnpjExpression   = regexp.MustCompile(`^[0-9A-Z]{12}\d{2}$`)
+	digitsExpression = regexp.MustCompile(`^\d+$`)
+)
+
+// customFormats are the checks of the custom formats, by name
+var customFormats = map[string]func(value string) bool{
+	FormatCPF:              IsValidCPF,
+	FormatCNPJ:             IsValidCNPJ,
+	FormatISPB:             ispbExpression.MatchString,
+	FormatCOMPE:            compeExpression.MatchString,
+	FormatBRLAmount:        amountExpression.MatchString,
+	FormatCurrency:         IsValidCurrency,
+	FormatIBGEMunicipality: IsValidIBGEMunicipality,
+}
+
+// currencies are the active ISO 4217 currency codes, the codes for testing and no currency (XTS, XXX) are not included
+var currencies = []string{
+	"AED", "AFN", "ALL", "AMD", "ANG", "AOA", "ARS", "AUD", "AWG", "AZN", "BAM", "BBD", "BDT", "BGN", "BHD", "BIF",
+	"BMD", "BND", "BOB", "BOV", "BRL", "BSD", "BTN", "BWP", "BYN", "BZD", "CAD", "CDF", "CHE", "CHF", "CHW", "CLF",
+	"CLP", "CNY", "COP", "COU", "CRC", "CUC", "CUP", "CVE", "CZK", "DJF", "DKK", "DOP", "DZD", "EGP", "ERN", "ETB",
+	"EUR", "FJD", "FKP", "GBP", "GEL", "GHS", "GIP", "GMD", "GNF", "GTQ", "GYD", "HKD", "HNL", "HTG", "HUF", "IDR",
+	"ILS", "INR", "IQD", "IRR", "ISK", "JMD", "JOD", "JPY", "KES", "KGS", "KHR", "KMF", "KPW", "KRW", "KWD", "KYD",
+	"KZT", "LAK", "LBP", "LKR", "LRD", "LSL", "LYD", "MAD", "MDL", "MGA", "MKD", "MMK", "MNT", "MOP", "MRU", "MUR",
+	"MVR", "MWK", "MXN", "MXV", "MYR", "MZN", "NAD", "NGN", "NIO", "NOK", "NPR", "NZD", "OMR", "PAB", "PEN", "PGK",
+	"PHP", "PKR", "PLN", "PYG", "QAR", "RON", "RSD", "RUB", "RWF", "SAR", "SBD", "SCR", "SDG", "SEK", "SGD", "SHP",
+	"SLE", "SLL", "SOS", "SRD", "SSP", "STN", "SVC", "SYP", "SZL", "THB", "TJS", "TMT", "TND", "TOP", "TRY", "TTD",
+	"TWD", "TZS", "UAH", "UGX", "USD", "USN", "UYI", "UYU", "UYW", "UZS", "VED", "VES", "VND", "VUV", "WST", "XAF",
+	"XAG", "XAU", "XBA", "XBB", "XBC", "XBD", "XCD", "XCG", "XDR", "XOF", "XPD", "XPF", "XPT", "XSU", "XUA", "YER",
+	"ZAR", "ZMW", "ZWG", "ZWL",
+}
+
+// ibgeStates are the IBGE codes of the states, the first two digits of the code of a municipality
+var ibgeStates = []string{
+	"11", "12", "13", "14", "15", "16", "17", "21", "22", "23", "24", "25", "26", "27", "28", "29", "31", "32", "33",
+	"35", "41", "42", "43", "50", "51", "52", "53",
+}
+
+// ibgeIrregularCodes are codes of municipalities assigned by IBGE that do not follow the check digit rule
+var ibgeIrregularCodes = []string{
+	"2201919", "2201988", "2202251", "2611533", "3117836", "3152131", "4305871", "5203939", "5203962",
+}
+
+// formatChecker checks a custom format for gojsonschema
+type formatChecker struct {
+	check func(value string) bool // Check of the format
+}
+
+// IsFormat indicates if a value matches the format, values that are not strings are not checked
+//
+// Parameters:
+//   - input: Value to check
+//
+// Returns:
+//   - bool: true if the value matches the format or is not a string
+func (fc formatChecker) IsFormat(input interface{}) bool {
+	value, ok := input.(string)
+	return !ok || fc.check(value)
+}
+
+// formatCheckerChain is the chain of the format checkers of gojsonschema
+type formatCheckerChain interface {
+	Has(name string) bool
+	Add(name string, checker gojsonschema.FormatChecker) *gojsonschema.FormatCheckerChain
+}
+
+// draft7FormatsOnce registers the custom formats in gojsonschema once
+var draft7FormatsOnce sync.Once
+
+// registerDraft7Formats Registers the custom formats for the draft-07 engine when the first validator is created, not
+// when the package is imported. gojsonschema only reads the formats from its global chain (gojsonschema.FormatCheckers),
+// it has no option to use a chain per schema, so the registration is shared by the process. The formats already
+// registered with the same name by the program are not replaced
+//
+// Parameters:
+//   - logger: Logger to be used
+//
+// Returns:
+func registerDraft7Formats(logger log.Logger) {
+	draft7FormatsOnce.Do(func() {
+		for _, name := range addFormatCheckers(&gojsonschema.FormatCheckers) {
+			logger.Warning("Format already registered in gojsonschema, the custom format is not used: "+name, "validation", "registerDraft7Formats")
+		}
+	})
+}
+
+// addFormatCheckers Adds the checkers of the custom formats to a chain of gojsonschema
+//
+// Parameters:
+//   - chain: Chain of the format checkers
+//
+// Returns:
+//   - []string: Names of the formats not added because the chain already has a checker with the name, sorted
+func addFormatCheckers(chain formatCheckerChain) []string {
+	skipped := make([]string, 0)
+	for name, check := range customFormats {
+		if chain.Has(name) {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		chain.Add(name, formatChecker{check: check})
+	}
+
+	sort.Strings(skipped)
+	return skipped
+}
+
+// IsCustomFormat indicates if a name is one of the custom formats
+//
+// Parameters:
+//   - name: Name of the format
+//
+// Returns:
+//   - bool: true if the format is a custom format
+func IsCustomFormat(name string) bool {
+	_, found := customFormats[name]
+	return found
+}
+
+// CheckFormat indicates if a value matches a custom format
+//
+// Parameters:
+//   - name: Name of the format
+//   - value: Value to check
+//
+// Returns:
+//   - bool: true if the value matches the format, false if the format is not a custom format
+func CheckFormat(name string, value string) bool {
+	check, found := customFormats[name]
+	return found && check(value)
+}
+
+// registerFormats Registers the custom formats in a compiler of the 2020-12 engine
+//
+// Parameters:
+//   - compiler: Compiler of the schemas
+//
+// Returns:
+func registerFormats(compiler *jsonschema.Compiler) {
+	for name, check := range customFormats {
+		compiler.RegisterFormat(&jsonschema.Format{
+			Name: name,
+			Validate: func(input any) error {
+				if value, ok := input.(string); ok && !check(value) {
+					return ErrInvalidFormat
+				}
+
+				return nil
+			},
+		})
+	}
+}
+
+// IsValidCPF indicates if a CPF has valid check digits
+//
+// Parameters:
+//   - value: CPF, 11 digits without punctuation
+//
+// Returns:
+//   - bool: true if the CPF is valid
+func IsValidCPF(value string) bool {
+	if len(value) != 11 || !digitsExpression.MatchString(value) || isRepeated(value) {
+		return false
+	}
+
+	for length := 9; length <= 10; length++ {
+		sum := 0
+		for i := 0; i < length; i++ {
+			sum += int(value[i]-'0') * (length + 1 - i)
+		}
+
+		digit := sum * 10 % 11 % 10
+		if digit != int(value[length]-'0') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsValidCNPJ indicates if a CNPJ has valid check digits, the alphanumeric CNPJ is supported
+//
+// Parameters:
+//   - value: CNPJ, 14 characters without punctuation
+//
+// Returns:
+//   - bool: true if the CNPJ is valid
+func IsValidCNPJ(value string) bool {
+	if !cnpjExpression.MatchString(value) || isRepeated(value) {
+		return false
+	}
+
+	for length := 12; length <= 13; length++ {
+		sum := 0
+		weight := length - 7
+		for i := 0; i < length; i++ {
+			// The value of the letters is their ASCII code minus 48, as defined for the alphanumeric CNPJ
+			sum += int(value[i]-'0') * weight
+			weight--
+			if weight < 2 {
+				weight = 9
+			}
+		}
+
+		digit := 0
+		if sum%11 >= 2 {
+			digit = 11 - sum%11
+		}
+
+		if digit != int(value[length]-'0') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsValidCurrency indicates if a value is an active ISO 4217 currency code
+//
+// Parameters:
+//   - value: Currency code
+//
+// Returns:
+//   - bool: true if the currency is valid
+func IsValidCurrency(value string) bool {
+	return slices.Contains(currencies, value)
+}
+
+// IsValidIBGEMunicipality indicates if a value is a valid IBGE code of a municipality
+//
+// Parameters:
+//   - value: IBGE code, 7 digits
+//
+// Returns:
+//   - bool: true if the code has a valid state and check digit
+func IsValidIBGEMunicipality(value string) bool {
+	if len(value) != 7 || !digitsExpression.MatchString(value) || !slices.Contains(ibgeStates, value[:2]) {
+		return false
+	}
+
+	if slices.Contains(ibgeIrregularCodes, value) {
+		return true
+	}
+
+	sum := 0
+	for i := 0; i < 6; i++ {
+		product := int(value[i]-'0') * (i%2 + 1)
+		sum += product/10 + product%10
+	}
+
+	return (10-sum%10)%10 == int(value[6]-'0')
+}
+
+// isRepeated indicates if all the characters of a value are the same
+//
+// Parameters:
+//   - value: Value to check
+//
+// Returns:
+//   - bool: true if the value is a single character repeated
+func isRepeated(value string) bool {
+	for i := 1; i < len(value); i++ {
+		if value[i] != value[0] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,127 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		value  string
+		want   bool
+	}{
+		{name: "valid cpf", format: FormatCPF, value: "52998224725", want: true},
+		{name: "cpf with invalid check digit", format: FormatCPF, value: "52998224724"},
+		{name: "cpf with repeated digits", format: FormatCPF, value: "11111111111"},
+		{name: "valid cnpj", format: FormatCNPJ, value: "11222333000181", want: true},
+		{name: "valid alphanumeric cnpj", format: FormatCNPJ, value: "12ABC34501DE35", want: true},
+		{name: "cnpj with invalid check digit", format: FormatCNPJ, value: "11222333000182"},
+		{name: "valid ispb", format: FormatISPB, value: "00000000", want: true},
+		{name: "ispb is only syntactic", format: FormatISPB, value: "99999999", want: true},
+		{name: "short ispb", format: FormatISPB, value: "0000000"},
+		{name: "valid compe", format: FormatCOMPE, value: "001", want: true},
+		{name: "compe is only syntactic", format: FormatCOMPE, value: "999", want: true},
+		{name: "compe with letters", format: FormatCOMPE, value: "00A"},
+		{name: "valid amount", format: FormatBRLAmount, value: "1000.0400", want: true},
+		{name: "amount without decimals", format: FormatBRLAmount, value: "1000"},
+		{name: "valid currency", format: FormatCurrency, value: "BRL", want: true},
+		{name: "testing currency", format: FormatCurrency, value: "XTS"},
+		{name: "valid municipality", format: FormatIBGEMunicipality, value: "3550308", want: true},
+		{name: "municipality with invalid state", format: FormatIBGEMunicipality, value: "9950308"},
+		{name: "unknown format", format: "unknown", value: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckFormat(tt.format, tt.value); got != tt.want {
+				t.Errorf("CheckFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCustomFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		document  string
+		wantValid bool
+	}{
+		{name: "valid", document: `{"cpf":"52998224725","compe":"001"}`, wantValid: true},
+		{name: "invalid cpf", document: `{"cpf":"52998224724","compe":"001"}`},
+		{name: "invalid compe", document: `{"cpf":"52998224725","compe":"1"}`},
+		{name: "not a string", document: `{"cpf":1,"compe":"001"}`, wantValid: true},
+	}
+
+	engines := []struct {
+		name    string
+		dialect string
+	}{
+		{name: string(EngineDraft7)},
+		{name: string(EngineDraft2020), dialect: testDraft2020Dialect},
+	}
+
+	schema := `"type":"object","properties":{"cpf":{"format":"cpf"},"compe":{"format":"compe"}}`
+	for _, engine := range engines {
+		for _, tt := range tests {
+			t.Run(engine.name+"/"+tt.name, func(t *testing.T) {
+				result := validateTestDocument(t, "{"+engine.dialect+schema+"}", tt.document)
+				if result.Valid != tt.wantValid {
+					t.Errorf("Validate() = %+v, want valid %v", result, tt.wantValid)
+				}
+			})
+		}
+	}
+}
+
+// testFormatCheckerChain is a chain of format checkers that records the formats added
+type testFormatCheckerChain struct {
+	formats map[string]gojsonschema.FormatChecker
+}
+
+func (tc *testFormatCheckerChain) Has(name string) bool {
+	_, found := tc.formats[name]
+	return found
+}
+
+func (tc *testFormatCheckerChain) Add(name string, checker gojsonschema.FormatChecker) *gojsonschema.FormatCheckerChain {
+	tc.formats[name] = checker
+	return nil
+}
+
+func TestAddFormatCheckers(t *testing.T) {
+	tests := []struct {
+		name        string
+		registered  []string
+		wantSkipped []string
+	}{
+		{name: "empty chain", wantSkipped: []string{}},
+		{name: "formats of the program are kept", registered: []string{FormatCPF, FormatCurrency}, wantSkipped: []string{FormatCPF, FormatCurrency}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &testFormatCheckerChain{formats: make(map[string]gojsonschema.FormatChecker)}
+			for _, name := range tt.registered {
+				chain.formats[name] = gojsonschema.UUIDFormatChecker{}
+			}
+
+			if got := addFormatCheckers(chain); !reflect.DeepEqual(got, tt.wantSkipped) {
+				t.Errorf("addFormatCheckers() = %v, want %v", got, tt.wantSkipped)
+			}
+
+			if len(chain.formats) != len(customFormats) {
+				t.Errorf("formats = %d, want %d", len(chain.formats), len(customFormats))
+			}
+
+			for _, name := range tt.registered {
+				if _, ok := chain.formats[name].(gojsonschema.UUIDFormatChecker); !ok {
+					t.Errorf("format %s of the program was replaced", name)
+				}
+			}
+		})
+	}
+}
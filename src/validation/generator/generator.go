@@ -0,0 +1,671 @@
+// Package generator creates synthetic payloads from the JSON schema of an endpoint (JSONBodySchema). The documents
+// honor the types, enums, patterns, formats and bounds of the schema, and can be mutated to break a specific
+// constraint, indicating the error that the schema validation is expected to report.
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting"
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// ErrNoMutationTarget is returned when the schema has no value where the mutation can be applied
+var ErrNoMutationTarget = errors.New("schema has no value where the mutation can be applied")
+
+// Options contains the settings of the generator
+type Options struct {
+	Seed            int64 // Seed of the random values, the same seed produces the same documents
+	IncludeOptional bool  // Includes the properties that are not required
+	MaxDepth        int   // Depth after which only the required properties are generated, by default 10
+}
+
+// site is a value of the generated document, with the schema that describes it
+type site struct {
+	path   []interface{}          // Path of the value, string keys and int indexes
+	schema map[string]interface{} // Resolved schema of the value
+	value  interface{}            // Value generated
+}
+
+// Generator creates documents from a JSON schema
+type Generator struct {
+	crosscutting.OFBStruct
+	root    map[string]interface{} // Root of the schema, used to resolve references
+	options Options                // Settings of the generator
+	random  *rand.Rand             // Source of the random values
+	sites   []site                 // Values of the last document generated
+}
+
+// NewGenerator creates a new generator for a schema
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - schema: JSON schema of the documents
+//   - options: Settings of the generator
+//
+// Returns:
+//   - *Generator: Generator created
+//   - error: error if the schema is not a valid JSON object
+func NewGenerator(logger log.Logger, schema string, options Options) (*Generator, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, err
+	}
+
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = 10
+	}
+
+	return &Generator{
+		OFBStruct: crosscutting.OFBStruct{
+			Pack:   "generator.Generator",
+			Logger: logger,
+		},
+		root:    root,
+		options: options,
+		random:  rand.New(rand.NewSource(options.Seed)),
+	}, nil
+}
+
+// Generate creates a document that conforms to the schema
+//
+// Parameters:
+//
+// Returns:
+//   - interface{}: Document generated
+func (g *Generator) Generate() interface{} {
+	g.sites = nil
+	return g.generate(g.root, nil, 0, false)
+}
+
+// generate creates a value for a schema
+//
+// Parameters:
+//   - schema: Schema of the value
+//   - path: Path of the value in the document
+//   - depth: Depth of the value in the document
+//   - combined: Indicates that the value is inside oneOf / anyOf, where the errors are reported by the parent
+//
+// Returns:
+//   - interface{}: Value generated
+func (g *Generator) generate(schema map[string]interface{}, path []interface{}, depth int, combined bool) interface{} {
+	schema = g.resolve(schema)
+	if options, ok := schema["oneOf"].([]interface{}); ok && len(options) > 0 {
+		return g.generate(g.merge(schema, options[0]), path, depth, true)
+	}
+
+	if options, ok := schema["anyOf"].([]interface{}); ok && len(options) > 0 {
+		return g.generate(g.merge(schema, options[0]), path, depth, true)
+	}
+
+	if parts, ok := schema["allOf"].([]interface{}); ok {
+		merged := g.merge(schema, nil)
+		delete(merged, "allOf")
+		for _, part := range parts {
+			merged = g.merge(merged, part)
+		}
+
+		return g.generate(merged, path, depth, combined)
+	}
+
+	var value interface{}
+	switch {
+	case schema["const"] != nil:
+		value = schema["const"]
+	case schema["enum"] != nil:
+		value = g.generateEnum(schema)
+	default:
+		switch getType(schema) {
+		case "object":
+			value = g.generateObject(schema, path, depth, combined)
+		case "array":
+			value = g.generateArray(schema, path, depth, combined)
+		case "integer":
+			value = g.generateNumber(schema, true)
+		case "number":
+			value = g.generateNumber(schema, false)
+		case "boolean":
+			value = g.random.Intn(2) == 0
+		case "null":
+			value = nil
+		default:
+			value = g.generateString(schema)
+		}
+	}
+
+	if !combined {
+		g.sites = append(g.sites, site{path: append([]interface{}(nil), path...), schema: schema, value: value})
+	}
+
+	return value
+}
+
+// generateObject creates an object with the required properties, and the optional ones if enabled
+//
+// Parameters:
+//   - schema: Schema of the object
+//   - path: Path of the object in the document
+//   - depth: Depth of the object in the document
+//   - combined: Indicates that the object is inside oneOf / anyOf
+//
+// Returns:
+//   - map[string]interface{}: Object generated
+func (g *Generator) generateObject(schema map[string]interface{}, path []interface{}, depth int, combined bool) map[string]interface{} {
+	result := make(map[string]interface{})
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := getRequired(schema)
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if !required[name] && (!g.options.IncludeOptional || depth >= g.options.MaxDepth) {
+			continue
+		}
+
+		propertySchema, _ := properties[name].(map[string]interface{})
+		result[name] = g.generate(propertySchema, append(path, name), depth+1, combined)
+	}
+
+	return result
+}
+
+// generateArray creates an array with a number of items within the bounds of the schema
+//
+// Parameters:
+//   - schema: Schema of the array
+//   - path: Path of the array in the document
+//   - depth: Depth of the array in the document
+//   - combined: Indicates that the array is inside oneOf / anyOf
+//
+// Returns:
+//   - []interface{}: Array generated
+func (g *Generator) generateArray(schema map[string]interface{}, path []interface{}, depth int, combined bool) []interface{} {
+	minItems := getInt(schema, "minItems", 0)
+	maxItems := getInt(schema, "maxItems", minItems+2)
+	count := minItems
+	if count == 0 && maxItems > 0 && depth < g.options.MaxDepth {
+		count = 1
+	}
+
+	if maxItems > count && depth < g.options.MaxDepth {
+		count += g.random.Intn(min(maxItems, count+2) - count + 1)
+	}
+
+	itemSchema, _ := schema["items"].(map[string]interface{})
+	unique, _ := schema["uniqueItems"].(bool)
+	result := make([]interface{}, 0, count)
+	seen := make(map[string]bool)
+	for i := 0; i < count; i++ {
+		sitesBefore := len(g.sites)
+		for attempt := 0; attempt < 10; attempt++ {
+			g.sites = g.sites[:sitesBefore]
+			item := g.generate(itemSchema, append(path, i), depth+1, combined)
+			key, _ := json.Marshal(item)
+			if !unique || !seen[string(key)] {
+				seen[string(key)] = true
+				result = append(result, item)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// generateEnum selects one of the values of the enum
+//
+// Parameters:
+//   - schema: Schema of the value
+//
+// Returns:
+//   - interface{}: Value selected
+func (g *Generator) generateEnum(schema map[string]interface{}) interface{} {
+	values, _ := schema["enum"].([]interface{})
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values[g.random.Intn(len(values))]
+}
+
+// generateNumber creates a number within the bounds of the schema
+//
+// Parameters:
+//   - schema: Schema of the number
+//   - integer: Indicates that the number must be an integer
+//
+// Returns:
+//   - interface{}: Number generated
+func (g *Generator) generateNumber(schema map[string]interface{}, integer bool) interface{} {
+	b := getBounds(schema)
+	step := 0.01
+	if integer {
+		step = 1
+	}
+
+	multipleOf, hasMultipleOf := getFloat(schema, "multipleOf")
+	if hasMultipleOf && multipleOf > 0 {
+		step = multipleOf
+		// The step of an integer with a fractional multipleOf is its lowest integer multiple (e.g. 3 for 0.75)
+		for n := 1.0; integer && step != math.Trunc(step) && n <= 100; n++ {
+			if math.Abs(n*multipleOf-math.Round(n*multipleOf)) < 1e-9 {
+				step = math.Round(n * multipleOf)
+			}
+		}
+
+		if integer && step != math.Trunc(step) {
+			g.Logger.Warning("Unable to find an integer multiple of the schema", g.Pack, "generateNumber")
+			step = 1
+		}
+	}
+
+	// The value is a multiple of the step, between the lowest and the highest multiples within the bounds
+	low, high := math.Ceil(b.lower/step), math.Floor(b.upper/step)
+	if low*step <= b.lower && b.exclusiveLower {
+		low++
+	}
+
+	if high*step >= b.upper && b.exclusiveUpper {
+		high--
+	}
+
+	if low <= high {
+		k := low + math.Floor(g.random.Float64()*(high-low+1))
+		for _, candidate := range []float64{k, low, high} {
+			if value := roundToStep(candidate*step, step); b.contains(value) {
+				return getNumber(value, integer)
+			}
+		}
+	}
+
+	// The bounds do not contain a multiple of the step, only a number without multipleOf can be generated
+	value := (b.lower + b.upper) / 2
+	if integer || hasMultipleOf || !b.contains(value) {
+		g.Logger.Warning("Unable to generate a number within the bounds of the schema", g.Pack, "generateNumber")
+		value = roundToStep(low*step, step)
+	}
+
+	return getNumber(value, integer)
+}
+
+// getNumber returns a number as generated on the documents
+//
+// Parameters:
+//   - value: Value of the number
+//   - integer: Indicates that the number must be an integer
+//
+// Returns:
+//   - interface{}: int64 for integers, float64 otherwise
+func getNumber(value float64, integer bool) interface{} {
+	if integer {
+		return int64(value)
+	}
+
+	return value
+}
+
+// generateString creates a string that honors the format, pattern and length of the schema
+//
+// Parameters:
+//   - schema: Schema of the string
+//
+// Returns:
+//   - string: String generated
+func (g *Generator) generateString(schema map[string]interface{}) string {
+	minLength := getInt(schema, "minLength", 0)
+	maxLength := getInt(schema, "maxLength", -1)
+	pattern, _ := schema["pattern"].(string)
+	format, _ := schema["format"].(string)
+
+	// The example of the format is only used if it honors the length of the schema
+	if value, ok := formatExamples[format]; ok {
+		length := len([]rune(value))
+		if length >= minLength && (maxLength < 0 || length <= maxLength) {
+			return value
+		}
+
+		g.Logger.Warning("Example of the format does not honor the length of the schema: "+format, g.Pack, "generateString")
+	}
+
+	if pattern != "" {
+		expression, err := regexp.Compile(pattern)
+		if err == nil {
+			for attempt := 0; attempt < 20; attempt++ {
+				value, err := g.generateFromPattern(pattern)
+				if err != nil {
+					break
+				}
+
+				length := len([]rune(value))
+				if expression.MatchString(value) && length >= minLength && (maxLength < 0 || length <= maxLength) {
+					return value
+				}
+			}
+		}
+
+		g.Logger.Warning("Unable to generate a value for pattern: "+pattern, g.Pack, "generateString")
+	}
+
+	length := minLength
+	if maxLength < 0 || maxLength > minLength {
+		upper := minLength + 10
+		if maxLength >= 0 {
+			upper = min(maxLength, upper)
+		}
+
+		length = max(minLength, 1) + g.random.Intn(upper-max(minLength, 1)+1)
+		if maxLength >= 0 {
+			length = min(length, maxLength)
+		}
+	}
+
+	return g.randomText(length)
+}
+
+// randomText creates a string of letters and digits
+//
+// Parameters:
+//   - length: Length of the string
+//
+// Returns:
+//   - string: String created
+func (g *Generator) randomText(length int) string {
+	const characters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	var builder strings.Builder
+	for i := 0; i < length; i++ {
+		builder.WriteByte(characters[g.random.Intn(len(characters))])
+	}
+
+	return builder.String()
+}
+
+// resolve returns the schema referenced by $ref, only references to the same document are supported
+//
+// Parameters:
+//   - schema: Schema to be resolved
+//
+// Returns:
+//   - map[string]interface{}: Schema referenced, or the same schema if it has no reference
+func (g *Generator) resolve(schema map[string]interface{}) map[string]interface{} {
+	for i := 0; i < 32; i++ {
+		reference, ok := schema["$ref"].(string)
+		if !ok || !strings.HasPrefix(reference, "#") {
+			return schema
+		}
+
+		var current interface{} = g.root
+		for _, part := range strings.Split(strings.TrimPrefix(reference, "#"), "/") {
+			if part == "" {
+				continue
+			}
+
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			object, _ := current.(map[string]interface{})
+			current = object[part]
+		}
+
+		resolved, ok := current.(map[string]interface{})
+		if !ok {
+			g.Logger.Warning("Reference not found: "+reference, g.Pack, "resolve")
+			return map[string]interface{}{}
+		}
+
+		schema = resolved
+	}
+
+	return schema
+}
+
+// merge combines two schemas, the properties and the required properties are joined, and the other keywords of
+// the second schema replace the keywords of the first
+//
+// Parameters:
+//   - base: First schema
+//   - other: Second schema, can be nil
+//
+// Returns:
+//   - map[string]interface{}: New schema
+func (g *Generator) merge(base map[string]interface{}, other interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		if key != "oneOf" && key != "anyOf" {
+			result[key] = value
+		}
+	}
+
+	otherSchema, ok := other.(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	otherSchema = g.resolve(otherSchema)
+	for key, value := range otherSchema {
+		switch key {
+		case "properties":
+			properties := make(map[string]interface{})
+			if current, ok := result["properties"].(map[string]interface{}); ok {
+				for name, property := range current {
+					properties[name] = property
+				}
+			}
+
+			if added, ok := value.(map[string]interface{}); ok {
+				for name, property := range added {
+					properties[name] = property
+				}
+			}
+
+			result[key] = properties
+		case "required":
+			current, _ := result["required"].([]interface{})
+			added, _ := value.([]interface{})
+			result[key] = append(append([]interface{}(nil), current...), added...)
+		default:
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// getType returns the type of a schema, the first type different from null when several types are allowed
+//
+// Parameters:
+//   - schema: Schema of the value
+//
+// Returns:
+//   - string: Type of the value
+func getType(schema map[string]interface{}) string {
+	switch value := schema["type"].(type) {
+	case string:
+		return value
+	case []interface{}:
+		for _, item := range value {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+
+	switch {
+	case schema["properties"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	}
+
+	return "string"
+}
+
+// getRequired returns the required properties of a schema
+//
+// Parameters:
+//   - schema: Schema of the object
+//
+// Returns:
+//   - map[string]bool: Names of the required properties
+func getRequired(schema map[string]interface{}) map[string]bool {
+	result := make(map[string]bool)
+	values, _ := schema["required"].([]interface{})
+	for _, value := range values {
+		if name, ok := value.(string); ok {
+			result[name] = true
+		}
+	}
+
+	return result
+}
+
+// bounds is the range of values allowed by a numeric schema
+type bounds struct {
+	lower          float64 // Lowest value allowed
+	upper          float64 // Highest value allowed
+	exclusiveLower bool    // Indicates that lower itself is not allowed
+	exclusiveUpper bool    // Indicates that upper itself is not allowed
+}
+
+// getBounds returns the range of a numeric schema, with the boolean (draft 4) and the numeric (draft 6 and later)
+// forms of exclusiveMinimum and exclusiveMaximum. A missing bound is set 1000 away from the other one
+//
+// Parameters:
+//   - schema: Schema of the number
+//
+// Returns:
+//   - bounds: Range of the values allowed
+func getBounds(schema map[string]interface{}) bounds {
+	var b bounds
+	hasLower, hasUpper := false, false
+	if minimum, ok := getFloat(schema, "minimum"); ok {
+		b.lower, hasLower = minimum, true
+		b.exclusiveLower, _ = schema["exclusiveMinimum"].(bool)
+	}
+
+	if minimum, ok := getFloat(schema, "exclusiveMinimum"); ok && (!hasLower || minimum >= b.lower) {
+		b.lower, b.exclusiveLower, hasLower = minimum, true, true
+	}
+
+	if maximum, ok := getFloat(schema, "maximum"); ok {
+		b.upper, hasUpper = maximum, true
+		b.exclusiveUpper, _ = schema["exclusiveMaximum"].(bool)
+	}
+
+	if maximum, ok := getFloat(schema, "exclusiveMaximum"); ok && (!hasUpper || maximum <= b.upper) {
+		b.upper, b.exclusiveUpper, hasUpper = maximum, true, true
+	}
+
+	switch {
+	case !hasLower && !hasUpper:
+		b.upper = 1000
+	case !hasUpper:
+		b.upper = b.lower + 1000
+	case !hasLower:
+		b.lower = b.upper - 1000
+	}
+
+	return b
+}
+
+// contains indicates if a value is within the bounds
+//
+// Parameters:
+//   - value: Value to be checked
+//
+// Returns:
+//   - bool: true if the value is allowed
+func (b bounds) contains(value float64) bool {
+	if value < b.lower || (b.exclusiveLower && value == b.lower) {
+		return false
+	}
+
+	return value < b.upper || (!b.exclusiveUpper && value == b.upper)
+}
+
+// roundToStep removes the floating point error of a multiple of a step, rounding it to the decimals of the step
+//
+// Parameters:
+//   - value: Multiple of the step
+//   - step: Step of the values
+//
+// Returns:
+//   - float64: Value rounded
+func roundToStep(value float64, step float64) float64 {
+	text := strconv.FormatFloat(step, 'f', -1, 64)
+	decimals := 0
+	if index := strings.IndexByte(text, '.'); index >= 0 {
+		decimals = len(text) - index - 1
+	}
+
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'f', decimals, 64), 64)
+	if err != nil {
+		return value
+	}
+
+	return rounded
+}
+
+// getFloat returns a numeric keyword of a schema
+//
+// Parameters:
+//   - schema: Schema of the value
+//   - key: Name of the keyword
+//
+// Returns:
+//   - float64: Value of the keyword
+//   - bool: true if the keyword is a number
+func getFloat(schema map[string]interface{}, key string) (float64, bool) {
+	value, ok := schema[key].(float64)
+	return value, ok
+}
+
+// getInt returns an integer keyword of a schema
+//
+// Parameters:
+//   - schema: Schema of the value
+//   - key: Name of the keyword
+//   - defaultValue: Value returned if the keyword is not found
+//
+// Returns:
+//   - int: Value of the keyword
+func getInt(schema map[string]interface{}, key string, defaultValue int) int {
+	if value, ok := getFloat(schema, key); ok {
+		return int(value)
+	}
+
+	return defaultValue
+}
+
+// getPathString returns the path of a value as reported by the schema validation (e.g. data.0.brandName)
+//
+// Parameters:
+//   - path: Path of the value
+//
+// Returns:
+//   - string: Path as text, (root) for the root of the document
+func getPathString(path []interface{}) string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+
+	parts := make([]string, len(path))
+	for i, part := range path {
+		switch value := part.(type) {
+		case int:
+			parts[i] = strconv.Itoa(value)
+		case string:
+			parts[i] = value
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+// verifyTestDocument validates a generated document as done by the -verify flag of the generate subcommand
+func verifyTestDocument(t *testing.T, validator validation.Validator, document interface{}) *validation.Result {
+	t.Helper()
+	content, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data validation.DynamicStruct
+	if err := json.Unmarshal(content, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := validator.Validate(data)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	return result
+}
+
+func TestGeneratorGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "fractional exclusive bounds", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","exclusiveMinimum":0.5,"exclusiveMaximum":1.2}}}`},
+		{name: "narrow exclusive bounds", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","exclusiveMinimum":0.001,"exclusiveMaximum":0.009}}}`},
+		{name: "fractional minimum with decimals", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","minimum":0.005,"maximum":0.02}}}`},
+		{name: "integer with fractional maximum", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","minimum":1,"maximum":2.5}}}`},
+		{name: "integer with fractional exclusive bounds", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","exclusiveMinimum":0.5,"exclusiveMaximum":1.5}}}`},
+		{name: "boolean exclusive bounds", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","minimum":1,"exclusiveMinimum":true,"maximum":3,"exclusiveMaximum":true}}}`},
+		{name: "multipleOf with upper bound", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","multipleOf":7,"minimum":1,"maximum":20}}}`},
+		{name: "fractional multipleOf", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","multipleOf":0.25,"minimum":0.3,"exclusiveMaximum":1}}}`},
+		{name: "integer with fractional multipleOf", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","multipleOf":0.75,"minimum":1,"maximum":10}}}`},
+		{name: "only maximum", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","maximum":-2000}}}`},
+		{name: "format example above maxLength", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"string","format":"hostname","maxLength":5}}}`},
+		{name: "format example below minLength", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"string","format":"hostname","minLength":20}}}`},
+		{name: "format example within the length", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"string","format":"date","minLength":10,"maxLength":10}}}`},
+		{name: "draft 2020-12", schema: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","required":["v"],"properties":{"v":{"type":"number","exclusiveMinimum":0.1,"exclusiveMaximum":0.15}}}`},
+	}
+
+	logger := log.NewLogger("PANIC")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := validation.NewValidator(logger, tt.schema, validation.DefaultOptions())
+			for seed := int64(0); seed < 50; seed++ {
+				gen, err := NewGenerator(logger, tt.schema, Options{Seed: seed, IncludeOptional: true})
+				if err != nil {
+					t.Fatalf("NewGenerator() error = %v", err)
+				}
+
+				document := gen.Generate()
+				if result := verifyTestDocument(t, validator, document); !result.Valid {
+					t.Fatalf("seed %d document %v is not valid: %+v", seed, document, result.Details)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   bounds
+	}{
+		{name: "no bounds", schema: `{}`, want: bounds{upper: 1000}},
+		{name: "only minimum", schema: `{"minimum":5}`, want: bounds{lower: 5, upper: 1005}},
+		{name: "only exclusiveMaximum", schema: `{"exclusiveMaximum":0.5}`, want: bounds{lower: -999.5, upper: 0.5, exclusiveUpper: true}},
+		{name: "boolean exclusive", schema: `{"minimum":1,"exclusiveMinimum":true,"maximum":2}`, want: bounds{lower: 1, upper: 2, exclusiveLower: true}},
+		{name: "exclusiveMinimum above minimum", schema: `{"minimum":1,"exclusiveMinimum":1.5,"maximum":2}`, want: bounds{lower: 1.5, upper: 2, exclusiveLower: true}},
+		{name: "exclusiveMaximum above maximum", schema: `{"maximum":1,"exclusiveMaximum":1.5}`, want: bounds{lower: -999, upper: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := getBounds(schema); got != tt.want {
+				t.Errorf("getBounds() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratorMutate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		mode   MutationMode
+	}{
+		{name: "below fractional exclusive minimum", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","exclusiveMinimum":0.5,"exclusiveMaximum":1.2}}}`, mode: BelowMinimum},
+		{name: "above fractional exclusive maximum", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"number","exclusiveMinimum":0.5,"exclusiveMaximum":1.2}}}`, mode: AboveMaximum},
+		{name: "above fractional maximum of an integer", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","minimum":1,"maximum":2.5}}}`, mode: AboveMaximum},
+		{name: "below fractional minimum of an integer", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","minimum":1.5,"maximum":3}}}`, mode: BelowMinimum},
+		{name: "below boolean exclusive minimum", schema: `{"type":"object","required":["v"],"properties":{"v":{"type":"integer","minimum":1,"exclusiveMinimum":true,"maximum":3}}}`, mode: BelowMinimum},
+	}
+
+	logger := log.NewLogger("PANIC")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := validation.NewValidator(logger, tt.schema, validation.DefaultOptions())
+			gen, err := NewGenerator(logger, tt.schema, Options{Seed: 1})
+			if err != nil {
+				t.Fatalf("NewGenerator() error = %v", err)
+			}
+
+			mutation, err := gen.Mutate(tt.mode)
+			if err != nil {
+				t.Fatalf("Mutate() error = %v", err)
+			}
+
+			result := verifyTestDocument(t, validator, mutation.Document)
+			found := false
+			for _, detail := range result.Details {
+				found = found || (detail.Code == mutation.ExpectedError.Code && detail.Pointer == mutation.ExpectedError.Pointer)
+			}
+
+			if !found {
+				t.Errorf("expected error %+v not reported, errors: %+v", mutation.ExpectedError, result.Details)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package generator
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/validation"
+)
+
+// MutationMode identifies the constraint broken by a mutation
+type MutationMode string
+
+const (
+	MissingRequired    MutationMode = "missing-required"    // Removes a required property
+	InvalidType        MutationMode = "invalid-type"        // Replaces a value with a value of another type
+	InvalidEnum        MutationMode = "invalid-enum"        // Replaces a value with a value out of the enum
+	PatternMismatch    MutationMode = "pattern-mismatch"    // Replaces a string with a string that does not match the pattern
+	InvalidFormat      MutationMode = "invalid-format"      // Replaces a string with a string that does not match the format
+	BelowMinLength     MutationMode = "below-min-length"    // Shortens a string below the minimum length
+	AboveMaxLength     MutationMode = "above-max-length"    // Extends a string beyond the maximum length
+	BelowMinimum       MutationMode = "below-minimum"       // Replaces a number with a number below the minimum
+	AboveMaximum       MutationMode = "above-maximum"       // Replaces a number with a number above the maximum
+	BelowMinItems      MutationMode = "below-min-items"     // Removes items of an array below the minimum
+	AboveMaxItems      MutationMode = "above-max-items"     // Adds items to an array beyond the maximum
+	AdditionalProperty MutationMode = "additional-property" // Adds a property to an object that does not allow it
+
+	invalidFormatValue = "invalid value!"
+	invalidEnumValue   = "MQD_INVALID_ENUM_VALUE"
+	invalidTypeValue   = "mqd-invalid-type"
+	additionalProperty = "mqdAdditionalProperty"
+)
+
+// formatExamples contains valid values for the formats checked by the schema validation
+var formatExamples = map[string]string{
+	"date":          "2024-01-15",
+	"date-time":     "2024-01-15T10:30:00Z",
+	"time":          "10:30:00",
+	"email":         "contato@example.com",
+	"uri":           "https://api.example.com/open-banking/resource",
+	"uri-reference": "https://api.example.com/open-banking/resource",
+	"uuid":          "4f5a1c7e-8d2b-4e3f-9a6c-1b2d3e4f5a6b",
+	"ipv4":          "192.0.2.10",
+	"ipv6":          "2001:db8::10",
+	"hostname":      "api.example.com",
+
+	validation.FormatCPF:              "52998224725",
+	validation.FormatCNPJ:             "11222333000181",
+	validation.FormatISPB:             "00000000",
+	validation.FormatCOMPE:            "001",
+	validation.FormatBRLAmount:        "1000.0400",
+	validation.FormatCurrency:         "BRL",
+	validation.FormatIBGEMunicipality: "3550308",
+}
+
+// ExpectedError is the error that the schema validation is expected to report for a mutation
+type ExpectedError struct {
+	Field   string               `json:"field"`   // Field of the error, as found on the keys of validation.Result.Errors
+	Pointer string               `json:"pointer"` // JSON pointer of the error, as found on validation.Error
+	Code    validation.ErrorCode `json:"code"`    // Code of the error (e.g. required, pattern, enum)
+}
+
+// Mutation is a document that breaks a specific constraint of the schema
+type Mutation struct {
+	Mode          MutationMode   `json:"mode"`                    // Constraint broken
+	Path          string         `json:"path,omitempty"`          // Path of the value changed
+	ExpectedError *ExpectedError `json:"expectedError,omitempty"` // Error expected from the validation
+	Document      interface{}    `json:"document"`                // Document with the mutation
+}
+
+// GetMutationModes returns all the mutation modes
+//
+// Parameters:
+//
+// Returns:
+//   - []MutationMode: Mutation modes supported
+func GetMutationModes() []MutationMode {
+	return []MutationMode{
+		MissingRequired, InvalidType, InvalidEnum, PatternMismatch, InvalidFormat, BelowMinLength, AboveMaxLength,
+		BelowMinimum, AboveMaximum, BelowMinItems, AboveMaxItems, AdditionalProperty,
+	}
+}
+
+// Mutate generates a valid document and breaks one constraint of the schema
+//
+// Parameters:
+//   - mode: Constraint to be broken
+//
+// Returns:
+//   - *Mutation: Document with the mutation and the error expected
+//   - error: ErrNoMutationTarget if the document has no value where the mutation can be applied
+func (g *Generator) Mutate(mode MutationMode) (*Mutation, error) {
+	document := g.Generate()
+
+	var candidates []site
+	for _, s := range g.sites {
+		if isApplicable(mode, s) {
+			candidates = append(candidates, s)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoMutationTarget
+	}
+
+	target := candidates[g.random.Intn(len(candidates))]
+	value, code, property := g.mutateValue(mode, target)
+	document = setValue(document, target.path, value)
+
+	path := getPathString(target.path)
+	pointer := getJSONPointer(target.path)
+	if property != "" {
+		pointer += "/" + escapePointer(property)
+	}
+
+	return &Mutation{
+		Mode:          mode,
+		Path:          path,
+		ExpectedError: &ExpectedError{Field: getErrorField(path), Pointer: pointer, Code: code},
+		Document:      document,
+	}, nil
+}
+
+// isApplicable indicates if a mutation can be applied to a value
+//
+// Parameters:
+//   - mode: Constraint to be broken
+//   - s: Value of the document
+//
+// Returns:
+//   - bool: true if the mutation can be applied
+func isApplicable(mode MutationMode, s site) bool {
+	object, isObject := s.value.(map[string]interface{})
+	array, isArray := s.value.([]interface{})
+	_, isString := s.value.(string)
+	_, isNumber := s.value.(float64)
+	_, isInteger := s.value.(int64)
+	_, hasEnum := s.schema["enum"]
+	_, hasFormat := formatExamples[asString(s.schema["format"])]
+	hasPattern := asString(s.schema["pattern"]) != ""
+
+	switch mode {
+	case MissingRequired:
+		for name := range getRequired(s.schema) {
+			if _, found := object[name]; found {
+				return true
+			}
+		}
+	case InvalidType:
+		return s.schema["type"] != nil
+	case InvalidEnum:
+		return hasEnum && !containsValue(s.schema["enum"], invalidEnumValue) && (s.schema["type"] == nil || allowsType(s.schema, "string"))
+	case PatternMismatch:
+		_, found := mismatchPattern(s.schema)
+		return isString && hasPattern && !hasEnum && !hasFormat && found
+	case InvalidFormat:
+		return isString && hasFormat && !hasEnum && !hasPattern
+	case BelowMinLength:
+		return isString && !hasEnum && !hasFormat && getInt(s.schema, "minLength", 0) > 0
+	case AboveMaxLength:
+		return isString && !hasEnum && !hasFormat && getInt(s.schema, "maxLength", -1) >= 0
+	case BelowMinimum:
+		return (isNumber || isInteger) && !hasEnum && (s.schema["minimum"] != nil || isFloat(s.schema["exclusiveMinimum"]))
+	case AboveMaximum:
+		return (isNumber || isInteger) && !hasEnum && (s.schema["maximum"] != nil || isFloat(s.schema["exclusiveMaximum"]))
+	case BelowMinItems:
+		return isArray && getInt(s.schema, "minItems", 0) > 0
+	case AboveMaxItems:
+		return isArray && len(array) > 0 && getInt(s.schema, "maxItems", -1) >= 0
+	case AdditionalProperty:
+		allowed, isBool := s.schema["additionalProperties"].(bool)
+		return isObject && isBool && !allowed
+	}
+
+	return false
+}
+
+// mutateValue returns the value that breaks the constraint
+//
+// Parameters:
+//   - mode: Constraint to be broken
+//   - s: Value of the document
+//
+// Returns:
+//   - interface{}: New value
+//   - validation.ErrorCode: Code of the error expected
+//   - string: Name of the property removed or added, empty if the mutation changes the value
+func (g *Generator) mutateValue(mode MutationMode, s site) (interface{}, validation.ErrorCode, string) {
+	switch mode {
+	case MissingRequired:
+		object := s.value.(map[string]interface{})
+		for _, name := range sortedKeys(getRequired(s.schema)) {
+			if _, found := object[name]; found {
+				delete(object, name)
+				return object, validation.CodeRequired, name
+			}
+		}
+
+		return object, validation.CodeRequired, ""
+	case InvalidType:
+		if !allowsType(s.schema, "boolean") {
+			return true, validation.CodeType, ""
+		}
+
+		if !allowsType(s.schema, "string") {
+			return invalidTypeValue, validation.CodeType, ""
+		}
+
+		return map[string]interface{}{}, validation.CodeType, ""
+	case InvalidEnum:
+		return invalidEnumValue, validation.CodeEnum, ""
+	case PatternMismatch:
+		value, _ := mismatchPattern(s.schema)
+		return value, validation.CodePattern, ""
+	case InvalidFormat:
+		return invalidFormatValue, validation.CodeFormat, ""
+	case BelowMinLength:
+		return string([]rune(s.value.(string))[:getInt(s.schema, "minLength", 0)-1]), validation.CodeMinLength, ""
+	case AboveMaxLength:
+		value := s.value.(string)
+		return value + strings.Repeat("a", getInt(s.schema, "maxLength", 0)+1-len([]rune(value))), validation.CodeMaxLength, ""
+	case BelowMinimum:
+		// The value of an integer stays an integer, so that only the bound is reported
+		_, integer := s.value.(int64)
+		if minimum, ok := getFloat(s.schema, "exclusiveMinimum"); ok {
+			return getMutatedBound(minimum, integer, math.Floor), validation.CodeExclusiveMinimum, ""
+		}
+
+		minimum, _ := getFloat(s.schema, "minimum")
+		if exclusive, _ := s.schema["exclusiveMinimum"].(bool); exclusive {
+			return getMutatedBound(minimum, integer, math.Floor), validation.CodeExclusiveMinimum, ""
+		}
+
+		return getMutatedBound(minimum, integer, math.Ceil) - 1, validation.CodeMinimum, ""
+	case AboveMaximum:
+		_, integer := s.value.(int64)
+		if maximum, ok := getFloat(s.schema, "exclusiveMaximum"); ok {
+			return getMutatedBound(maximum, integer, math.Ceil), validation.CodeExclusiveMaximum, ""
+		}
+
+		maximum, _ := getFloat(s.schema, "maximum")
+		if exclusive, _ := s.schema["exclusiveMaximum"].(bool); exclusive {
+			return getMutatedBound(maximum, integer, math.Ceil), validation.CodeExclusiveMaximum, ""
+		}
+
+		return getMutatedBound(maximum, integer, math.Floor) + 1, validation.CodeMaximum, ""
+	case BelowMinItems:
+		return s.value.([]interface{})[:getInt(s.schema, "minItems", 0)-1], validation.CodeMinItems, ""
+	case AboveMaxItems:
+		array := append([]interface{}(nil), s.value.([]interface{})...)
+		for len(array) <= getInt(s.schema, "maxItems", 0) {
+			array = append(array, array[0])
+		}
+
+		return array, validation.CodeMaxItems, ""
+	case AdditionalProperty:
+		object := s.value.(map[string]interface{})
+		object[additionalProperty] = invalidTypeValue
+		return object, validation.CodeAdditionalProperties, additionalProperty
+	}
+
+	return s.value, "", ""
+}
+
+// getMutatedBound returns a bound of a number as used by the mutations, rounded to an integer for integers
+//
+// Parameters:
+//   - bound: Value of the bound
+//   - integer: Indicates that the number is an integer
+//   - round: Rounding applied to the bound of an integer
+//
+// Returns:
+//   - float64: Bound, rounded for integers
+func getMutatedBound(bound float64, integer bool, round func(float64) float64) float64 {
+	if integer {
+		return round(bound)
+	}
+
+	return bound
+}
+
+// mismatchPattern returns a string with a valid length that does not match the pattern of the schema
+//
+// Parameters:
+//   - schema: Schema of the string
+//
+// Returns:
+//   - string: String that does not match the pattern
+//   - bool: false if no string was found, e.g. the pattern matches any string
+func mismatchPattern(schema map[string]interface{}) (string, bool) {
+	length := max(getInt(schema, "minLength", 0), 1)
+	if maxLength := getInt(schema, "maxLength", -1); maxLength >= 0 {
+		length = min(length, maxLength)
+	}
+
+	expression, err := regexp.Compile(asString(schema["pattern"]))
+	if err != nil {
+		return "", false
+	}
+
+	for _, character := range []string{"~", " ", "#", "-", "a", "Z", "0"} {
+		value := strings.Repeat(character, length)
+		if !expression.MatchString(value) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// setValue replaces a value of a document
+//
+// Parameters:
+//   - document: Document to be changed
+//   - path: Path of the value
+//   - value: New value
+//
+// Returns:
+//   - interface{}: Document changed
+func setValue(document interface{}, path []interface{}, value interface{}) interface{} {
+	if len(path) == 0 {
+		return value
+	}
+
+	parent := document
+	for _, part := range path[:len(path)-1] {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			parent = container[part.(string)]
+		case []interface{}:
+			parent = container[part.(int)]
+		}
+	}
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[path[len(path)-1].(string)] = value
+	case []interface{}:
+		container[path[len(path)-1].(int)] = value
+	}
+
+	return document
+}
+
+// getErrorField returns the field reported by the schema validation for a path, the array indexes are removed from
+// the paths that start on data, as done by validation.SchemaValidator
+//
+// Parameters:
+//   - path: Path of the value
+//
+// Returns:
+//   - string: Field of the error
+func getErrorField(path string) string {
+	if !strings.Contains(path, "data") {
+		return path
+	}
+
+	var parts []string
+	for _, part := range strings.Split(path, ".") {
+		if _, err := strconv.ParseFloat(part, 64); err != nil {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// allowsType indicates if a schema allows a type
+//
+// Parameters:
+//   - schema: Schema of the value
+//   - name: Name of the type
+//
+// Returns:
+//   - bool: true if the type is allowed
+func allowsType(schema map[string]interface{}, name string) bool {
+	switch value := schema["type"].(type) {
+	case string:
+		return value == name
+	case []interface{}:
+		return containsValue(value, name)
+	}
+
+	return false
+}
+
+// containsValue indicates if a list contains a value
+//
+// Parameters:
+//   - list: List of values
+//   - value: Value to be found
+//
+// Returns:
+//   - bool: true if the value is found
+func containsValue(list interface{}, value interface{}) bool {
+	values, _ := list.([]interface{})
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedKeys returns the keys of a set in order
+//
+// Parameters:
+//   - values: Set of values
+//
+// Returns:
+//   - []string: Keys in order
+func sortedKeys(values map[string]bool) []string {
+	result := make([]string, 0, len(values))
+	for key := range values {
+		result = append(result, key)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// asString returns a value as a string, empty if it is not a string
+//
+// Parameters:
+//   - value: Value to be converted
+//
+// Returns:
+//   - string: Value as string
+func asString(value interface{}) string {
+	result, _ := value.(string)
+	return result
+}
+
+// isFloat indicates if a value is a number
+//
+// Parameters:
+//   - value: Value to be checked
+//
+// Returns:
+//   - bool: true if the value is a number
+func isFloat(value interface{}) bool {
+	_, ok := value.(float64)
+	return ok
+}
+
+// getJSONPointer returns the JSON pointer of a path, as reported by the schema validation
+//
+// Parameters:
+//   - path: Path of the value
+//
+// Returns:
+//   - string: JSON pointer, empty for the root of the document
+func getJSONPointer(path []interface{}) string {
+	var builder strings.Builder
+	for _, part := range path {
+		switch value := part.(type) {
+		case int:
+			builder.WriteString("/" + strconv.Itoa(value))
+		case string:
+			builder.WriteString("/" + escapePointer(value))
+		}
+	}
+
+	return builder.String()
+}
+
+// escapePointer escapes a token of a JSON pointer
+//
+// Parameters:
+//   - token: Property name
+//
+// Returns:
+//   - string: Escaped token
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// maxRepetitions is the maximum number of extra repetitions generated for unbounded quantifiers (*, +, {n,})
+const maxRepetitions = 3
+
+// generateFromPattern creates a string that matches a regular expression
+//
+// Parameters:
+//   - pattern: Regular expression
+//
+// Returns:
+//   - string: String generated
+//   - error: error if the expression cannot be parsed
+func (g *Generator) generateFromPattern(pattern string) (string, error) {
+	expression, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	g.writePattern(&builder, expression.Simplify())
+	return builder.String(), nil
+}
+
+// writePattern writes a string that matches a node of a regular expression
+//
+// Parameters:
+//   - builder: Builder that receives the string
+//   - expression: Node of the regular expression
+//
+// Returns:
+func (g *Generator) writePattern(builder *strings.Builder, expression *syntax.Regexp) {
+	switch expression.Op {
+	case syntax.OpLiteral:
+		for _, r := range expression.Rune {
+			builder.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		builder.WriteRune(g.selectRune(expression.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		builder.WriteString(g.randomText(1))
+	case syntax.OpCapture:
+		g.writePattern(builder, expression.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range expression.Sub {
+			g.writePattern(builder, sub)
+		}
+	case syntax.OpAlternate:
+		g.writePattern(builder, expression.Sub[g.random.Intn(len(expression.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lower, upper := expression.Min, expression.Max
+		switch expression.Op {
+		case syntax.OpStar:
+			lower, upper = 0, maxRepetitions
+		case syntax.OpPlus:
+			lower, upper = 1, 1+maxRepetitions
+		case syntax.OpQuest:
+			lower, upper = 0, 1
+		}
+
+		if upper < 0 {
+			upper = lower + maxRepetitions
+		}
+
+		count := lower + g.random.Intn(upper-lower+1)
+		for i := 0; i < count; i++ {
+			g.writePattern(builder, expression.Sub[0])
+		}
+	}
+}
+
+// selectRune selects a character of a character class, printable ASCII characters are preferred
+//
+// Parameters:
+//   - ranges: Pairs of the first and last characters of the ranges of the class
+//
+// Returns:
+//   - rune: Character selected
+func (g *Generator) selectRune(ranges []rune) rune {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := max(ranges[i], ' '); r <= min(ranges[i+1], '~'); r++ {
+			printable = append(printable, r)
+		}
+	}
+
+	if len(printable) > 0 {
+		return printable[g.random.Intn(len(printable))]
+	}
+
+	if len(ranges) == 0 {
+		return 'a'
+	}
+
+	r := ranges[0]
+	if !unicode.IsPrint(r) {
+		r = ranges[1]
+	}
+
+	return r
+}
@@ -0,0 +1,353 @@
+package validation
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// versionSegmentExpression matches the version segment of an endpoint name (e.g. v2)
+var versionSegmentExpression = regexp.MustCompile(`^v\d+$`)
+
+// PaginationOptions are the values used by the pagination and links checks
+type PaginationOptions struct {
+	EndpointName string        // Name of the endpoint, the parameters match any value (e.g. /accounts/v2/accounts/{accountId}/transactions)
+	APIVersion   string        // Version of the API requested (e.g. 2.0.1), empty to use the version of the endpoint name
+	ReceivedAt   time.Time     // Time when the message was received, zero to skip the check of meta.requestDateTime
+	MaxSkew      time.Duration // Maximum difference between meta.requestDateTime and the receipt time
+}
+
+// PaginationValidator Validator that checks the consistency of the links and meta blocks of the paginated responses
+type PaginationValidator struct {
+	pack    string            // Package name
+	options PaginationOptions // Values used by the checks
+	logger  log.Logger        // Logger
+}
+
+// pageInfo contains the values of the pagination found in the payload
+type pageInfo struct {
+	page         int     // Number of the page in links.self, 0 if unknown
+	pageSize     int     // Size of the page in links.self, 0 if unknown
+	totalRecords float64 // meta.totalRecords, -1 if not found
+	totalPages   float64 // meta.totalPages, -1 if not found
+}
+
+// GetPaginationValidator is for creating a PaginationValidator
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - options: Values used by the checks
+//
+// Returns:
+//   - *PaginationValidator: Validator created
+func GetPaginationValidator(logger log.Logger, options PaginationOptions) *PaginationValidator {
+	return &PaginationValidator{
+		pack:    "PaginationValidator",
+		options: options,
+		logger:  logger,
+	}
+}
+
+// Validate Checks the links and meta blocks of a payload, the payloads without these blocks are valid. The values
+// with invalid types are ignored, the schema validation reports them
+//
+// Parameters:
+//   - data: Body of the message
+//
+// Returns:
+//   - *Result: Result of the validation
+//   - error: error if the validation cannot be executed
+func (pv *PaginationValidator) Validate(data DynamicStruct) (*Result, error) {
+	validationResult := Result{Valid: true, Errors: make(map[string][]string)}
+	links, _ := data["links"].(map[string]interface{})
+	meta, _ := data["meta"].(map[string]interface{})
+	if links == nil && meta == nil {
+		return &validationResult, nil
+	}
+
+	pv.logger.Info("Starting Validation of pagination", pv.pack, "Validate")
+	info := pageInfo{
+		totalRecords: getNumber(meta, "totalRecords"),
+		totalPages:   getNumber(meta, "totalPages"),
+	}
+
+	if self, ok := links["self"].(string); ok {
+		pv.validateSelfLink(&validationResult, self, &info)
+	}
+
+	if links != nil {
+		pv.validatePageLinks(&validationResult, links, info)
+	}
+
+	pv.validateTotals(&validationResult, data["data"], info)
+	if requestDateTime, ok := meta["requestDateTime"].(string); ok {
+		pv.validateRequestDateTime(&validationResult, requestDateTime)
+	}
+
+	return &validationResult, nil
+}
+
+// validateSelfLink Checks that links.self is a URI of the endpoint and the API version, and reads its page parameters
+//
+// Parameters:
+//   - validationResult: Result of the validation
+//   - self: Value of links.self
+//   - info: Pagination values, updated with the page and the page size of the link
+//
+// Returns:
+func (pv *PaginationValidator) validateSelfLink(validationResult *Result, self string, info *pageInfo) {
+	link, err := url.Parse(self)
+	if err != nil || !link.IsAbs() {
+		pv.addError(validationResult, Error{Code: CodeLinks, Field: "links.self", Pointer: "/links/self", Constraint: pv.options.EndpointName, Message: "Is not a valid URI"})
+		return
+	}
+
+	info.page, _ = strconv.Atoi(link.Query().Get("page"))
+	info.pageSize, _ = strconv.Atoi(link.Query().Get("page-size"))
+	if pv.options.EndpointName == "" {
+		return
+	}
+
+	templateSegments := strings.Split(strings.Trim(pv.options.EndpointName, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(link.Path, "/"), "/")
+	if len(pathSegments) < len(templateSegments) {
+		pv.addError(validationResult, Error{Code: CodeLinks, Field: "links.self", Pointer: "/links/self", Constraint: pv.options.EndpointName, Message: "Does not match the endpoint '" + pv.options.EndpointName + "'"})
+		return
+	}
+
+	// The link contains the prefix of the API (e.g. /open-banking), the endpoint name is compared with the end of the path
+	pathSegments = pathSegments[len(pathSegments)-len(templateSegments):]
+	for i, segment := range templateSegments {
+		if versionSegmentExpression.MatchString(segment) {
+			version := pv.getVersionSegment(segment)
+			if !strings.EqualFold(pathSegments[i], version) {
+				pv.addError(validationResult, Error{Code: CodeLinks, Field: "links.self", Pointer: "/links/self", Constraint: version, Message: "Does not match the API version '" + version + "'"})
+				return
+			}
+
+			continue
+		}
+
+		isParameter := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		if (isParameter && pathSegments[i] == "") || (!isParameter && !strings.EqualFold(pathSegments[i], segment)) {
+			pv.addError(validationResult, Error{Code: CodeLinks, Field: "links.self", Pointer: "/links/self", Constraint: pv.options.EndpointName, Message: "Does not match the endpoint '" + pv.options.EndpointName + "'"})
+			return
+		}
+	}
+}
+
+// getVersionSegment returns the version segment expected on the links, from the API version requested
+//
+// Parameters:
+//   - segment: Version segment of the endpoint name (e.g. v2)
+//
+// Returns:
+//   - string: Version segment of the API version (e.g. v2 for 2.0.1), the segment of the endpoint name if the
+//     version is empty
+func (pv *PaginationValidator) getVersionSegment(segment string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(pv.options.APIVersion), "v"), ".")
+	if _, err := strconv.Atoi(major); err != nil {
+		return segment
+	}
+
+	return "v" + major
+}
+
+// validatePageLinks Checks that the presence of links.prev and links.next agrees with the position of the page, and
+// that the page links point to the adjacent, first and last pages
+//
+// Parameters:
+//   - validationResult: Result of the validation
+//   - links: links block of the payload
+//   - info: Pagination values
+//
+// Returns:
+func (pv *PaginationValidator) validatePageLinks(validationResult *Result, links map[string]interface{}, info pageInfo) {
+	page := info.page
+	if page == 0 && info.totalPages >= 0 {
+		// Without page parameter the self link is the first page
+		page = 1
+	}
+
+	if page < 1 {
+		// Position unknown (e.g. pagination by cursor)
+		return
+	}
+
+	_, hasPrev := getLinkPage(links, "prev")
+	_, hasNext := getLinkPage(links, "next")
+	if page == 1 && hasPrev {
+		pv.addError(validationResult, Error{Code: CodePagination, Field: "links.prev", Pointer: "/links/prev", Constraint: "page=1", Message: "Must not be present on the first page"})
+	} else if page > 1 && !hasPrev {
+		pv.addError(validationResult, Error{Code: CodePagination, Field: "links.prev", Pointer: "/links/prev", Constraint: "page=" + strconv.Itoa(page), Message: "Is required after the first page"})
+	}
+
+	totalPages := int(info.totalPages)
+	if info.totalPages > 0 {
+		constraint := "totalPages=" + strconv.Itoa(totalPages)
+		if page > totalPages {
+			pv.addError(validationResult, Error{Code: CodePagination, Field: "links.self", Pointer: "/links/self", Constraint: constraint, Message: "Page is greater than meta.totalPages"})
+		} else if page < totalPages && !hasNext {
+			pv.addError(validationResult, Error{Code: CodePagination, Field: "links.next", Pointer: "/links/next", Constraint: constraint, Message: "Is required before the last page"})
+		} else if page == totalPages && hasNext {
+			pv.addError(validationResult, Error{Code: CodePagination, Field: "links.next", Pointer: "/links/next", Constraint: constraint, Message: "Must not be present on the last page"})
+		}
+	}
+
+	expectedPages := map[string]int{"prev": page - 1, "next": page + 1, "first": 1, "last": totalPages}
+	for _, name := range []string{"first", "prev", "next", "last"} {
+		linkPage, found := getLinkPage(links, name)
+		if !found || linkPage == 0 || expectedPages[name] < 1 || linkPage == expectedPages[name] {
+			continue
+		}
+
+		pv.addError(validationResult, Error{
+			Code:       CodePagination,
+			Field:      "links." + name,
+			Pointer:    "/links/" + name,
+			Constraint: "page=" + strconv.Itoa(expectedPages[name]),
+			Message:    "Does not point to page " + strconv.Itoa(expectedPages[name]),
+		})
+	}
+}
+
+// validateTotals Checks that meta.totalRecords, meta.totalPages, the page size and the number of records are coherent
+//
+// Parameters:
+//   - validationResult: Result of the validation
+//   - records: data of the payload
+//   - info: Pagination values
+//
+// Returns:
+func (pv *PaginationValidator) validateTotals(validationResult *Result, records interface{}, info pageInfo) {
+	if info.totalRecords >= 0 && info.totalPages >= 0 {
+		if info.totalRecords == 0 && info.totalPages > 1 {
+			pv.addError(validationResult, Error{Code: CodeTotals, Field: "meta.totalPages", Pointer: "/meta/totalPages", Constraint: "<= 1", Message: "Must be at most 1 when there are no records"})
+		} else if info.totalRecords > 0 && (info.totalPages < 1 || info.totalPages > info.totalRecords) {
+			pv.addError(validationResult, Error{Code: CodeTotals, Field: "meta.totalPages", Pointer: "/meta/totalPages", Constraint: "1 - " + formatCount(info.totalRecords), Message: "Is not coherent with meta.totalRecords"})
+		} else if info.totalRecords > 0 && info.pageSize > 0 {
+			expected := math.Ceil(info.totalRecords / float64(info.pageSize))
+			if info.totalPages != expected {
+				pv.addError(validationResult, Error{Code: CodeTotals, Field: "meta.totalPages", Pointer: "/meta/totalPages", Constraint: formatCount(expected), Message: "Is not coherent with meta.totalRecords and page-size"})
+			}
+		}
+	}
+
+	array, ok := records.([]interface{})
+	if !ok {
+		return
+	}
+
+	if info.totalRecords >= 0 && float64(len(array)) > info.totalRecords {
+		pv.addError(validationResult, Error{Code: CodeTotals, Field: "data", Pointer: "/data", Constraint: "<= " + formatCount(info.totalRecords), Message: "Has more records than meta.totalRecords"})
+	}
+
+	if info.pageSize > 0 && len(array) > info.pageSize {
+		pv.addError(validationResult, Error{Code: CodeTotals, Field: "data", Pointer: "/data", Constraint: "<= " + strconv.Itoa(info.pageSize), Message: "Has more records than page-size"})
+	}
+}
+
+// validateRequestDateTime Checks that meta.requestDateTime is close to the receipt time of the message
+//
+// Parameters:
+//   - validationResult: Result of the validation
+//   - requestDateTime: Value of meta.requestDateTime
+//
+// Returns:
+func (pv *PaginationValidator) validateRequestDateTime(validationResult *Result, requestDateTime string) {
+	if pv.options.ReceivedAt.IsZero() || pv.options.MaxSkew <= 0 {
+		return
+	}
+
+	value, err := time.Parse(time.RFC3339, requestDateTime)
+	if err != nil {
+		// The format is reported by the schema validation
+		return
+	}
+
+	skew := pv.options.ReceivedAt.Sub(value)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > pv.options.MaxSkew {
+		pv.addError(validationResult, Error{
+			Code:       CodeRequestDateTime,
+			Field:      "meta.requestDateTime",
+			Pointer:    "/meta/requestDateTime",
+			Constraint: pv.options.MaxSkew.String(),
+			Message:    "Differs from the receipt time by more than " + pv.options.MaxSkew.String(),
+		})
+	}
+}
+
+// addError Adds an error to the result of the validation
+//
+// Parameters:
+//   - validationResult: Result of the validation
+//   - validationError: Error found
+//
+// Returns:
+func (pv *PaginationValidator) addError(validationResult *Result, validationError Error) {
+	pv.logger.Debug(validationError.Field+": "+validationError.Message, pv.pack, "addError")
+	validationResult.Valid = false
+	validationResult.Errors[validationError.Field] = append(validationResult.Errors[validationError.Field], validationError.Message)
+	validationResult.Details = append(validationResult.Details, validationError)
+}
+
+// getLinkPage returns the page parameter of a link
+//
+// Parameters:
+//   - links: links block of the payload
+//   - name: Name of the link (e.g. next)
+//
+// Returns:
+//   - int: Page of the link, 0 if the link has no page parameter
+//   - bool: true if the link is present
+func getLinkPage(links map[string]interface{}, name string) (int, bool) {
+	value, ok := links[name].(string)
+	if !ok || value == "" {
+		return 0, false
+	}
+
+	link, err := url.Parse(value)
+	if err != nil {
+		return 0, true
+	}
+
+	page, _ := strconv.Atoi(link.Query().Get("page"))
+	return page, true
+}
+
+// getNumber returns a non-negative integer of an object
+//
+// Parameters:
+//   - object: Object where the value is searched, can be nil
+//   - name: Name of the property
+//
+// Returns:
+//   - float64: Value found, -1 if it is not found or is not a non-negative integer
+func getNumber(object map[string]interface{}, name string) float64 {
+	value, ok := object[name].(float64)
+	if !ok || value < 0 || value != math.Trunc(value) {
+		return -1
+	}
+
+	return value
+}
+
+// formatCount returns the text of a count
+//
+// Parameters:
+//   - value: Count
+//
+// Returns:
+//   - string: Text of the count
+func formatCount(value float64) string {
+	return strconv.FormatFloat(value, 'f', 0, 64)
+}
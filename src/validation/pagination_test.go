@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+func TestPaginationValidatorValidate(t *testing.T) {
+	const (
+		endpoint = "/accounts/v2/accounts/{accountId}/transactions"
+		base     = "https://api.bank.com/open-banking/accounts/v2/accounts/1/transactions"
+	)
+
+	receivedAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		body       string
+		apiVersion string
+		wantErrors []string // Code and pointer of the errors
+	}{
+		{name: "no links nor meta", body: `{"data":[]}`},
+		{
+			name: "first page",
+			body: `{"data":[1,2],"links":{"self":"` + base + `?page=1&page-size=2","next":"` + base + `?page=2&page-size=2","last":"` + base + `?page=2&page-size=2"},
+				"meta":{"totalRecords":3,"totalPages":2,"requestDateTime":"2024-01-15T10:01:00Z"}}`,
+		},
+		{
+			name: "last page",
+			body: `{"data":[1],"links":{"self":"` + base + `?page=2&page-size=2","prev":"` + base + `?page=1&page-size=2","first":"` + base + `?page=1&page-size=2"},
+				"meta":{"totalRecords":3,"totalPages":2}}`,
+		},
+		{name: "self link of another endpoint", body: `{"links":{"self":"https://api.bank.com/open-banking/accounts/v2/accounts/1/balances"}}`, wantErrors: []string{"links /links/self"}},
+		{name: "self link of another version", body: `{"links":{"self":"https://api.bank.com/open-banking/accounts/v1/accounts/1/transactions"}}`, wantErrors: []string{"links /links/self"}},
+		{name: "self link of the requested version", body: `{"links":{"self":"https://api.bank.com/open-banking/accounts/v3/accounts/1/transactions"}}`, apiVersion: "3.0.0"},
+		{name: "relative self link", body: `{"links":{"self":"/accounts/v2/accounts/1/transactions"}}`, wantErrors: []string{"links /links/self"}},
+		{name: "prev on the first page", body: `{"links":{"self":"` + base + `?page=1","prev":"` + base + `?page=1"},"meta":{"totalRecords":1,"totalPages":1}}`, wantErrors: []string{"pagination /links/prev"}},
+		{name: "missing prev after the first page", body: `{"links":{"self":"` + base + `?page=2"},"meta":{"totalRecords":4,"totalPages":2}}`, wantErrors: []string{"pagination /links/prev"}},
+		{name: "missing next before the last page", body: `{"links":{"self":"` + base + `?page=1"},"meta":{"totalRecords":4,"totalPages":2}}`, wantErrors: []string{"pagination /links/next"}},
+		{name: "next on the last page", body: `{"links":{"self":"` + base + `","next":"` + base + `?page=2"},"meta":{"totalRecords":1,"totalPages":1}}`, wantErrors: []string{"pagination /links/next"}},
+		{name: "next to the wrong page", body: `{"links":{"self":"` + base + `?page=1","next":"` + base + `?page=3"},"meta":{"totalRecords":4,"totalPages":3}}`, wantErrors: []string{"pagination /links/next"}},
+		{name: "page after the last page", body: `{"links":{"self":"` + base + `?page=3","prev":"` + base + `?page=2"},"meta":{"totalRecords":4,"totalPages":2}}`, wantErrors: []string{"pagination /links/self"}},
+		{name: "cursor pagination", body: `{"links":{"self":"` + base + `?cursor=a","next":"` + base + `?cursor=b"},"meta":{"requestDateTime":"2024-01-15T10:00:00Z"}}`},
+		{name: "pages without records", body: `{"meta":{"totalRecords":0,"totalPages":2}}`, wantErrors: []string{"totals /meta/totalPages"}},
+		{name: "more pages than records", body: `{"meta":{"totalRecords":1,"totalPages":2}}`, wantErrors: []string{"totals /meta/totalPages"}},
+		{name: "totalPages not coherent with page-size", body: `{"links":{"self":"` + base + `?page=1&page-size=2","next":"` + base + `?page=2&page-size=2"},"meta":{"totalRecords":4,"totalPages":3}}`, wantErrors: []string{"totals /meta/totalPages"}},
+		{name: "more records than totalRecords", body: `{"data":[1,2],"meta":{"totalRecords":1,"totalPages":1}}`, wantErrors: []string{"totals /data"}},
+		{name: "more records than page-size", body: `{"data":[1,2],"links":{"self":"` + base + `?page-size=1","next":"` + base + `?page=2&page-size=1"},"meta":{"totalRecords":2,"totalPages":2}}`, wantErrors: []string{"totals /data"}},
+		{name: "requestDateTime out of the skew", body: `{"meta":{"requestDateTime":"2024-01-15T09:50:00Z"}}`, wantErrors: []string{"requestDateTime /meta/requestDateTime"}},
+		{name: "requestDateTime with invalid format", body: `{"meta":{"requestDateTime":"15/01/2024"}}`},
+		{name: "values with invalid types", body: `{"links":{"self":1},"meta":{"totalRecords":"1","totalPages":1.5}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := PaginationOptions{EndpointName: endpoint, APIVersion: tt.apiVersion, ReceivedAt: receivedAt, MaxSkew: 5 * time.Minute}
+			result, err := GetPaginationValidator(log.NewLogger("PANIC"), options).Validate(readTestBody(t, tt.body))
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			errors := make([]string, 0)
+			for _, detail := range result.Details {
+				errors = append(errors, string(detail.Code)+" "+detail.Pointer)
+			}
+
+			if strings.Join(errors, "|") != strings.Join(tt.wantErrors, "|") || result.Valid != (len(tt.wantErrors) == 0) {
+				t.Errorf("errors = %v, valid %v, want %v", errors, result.Valid, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestPaginationValidatorRequestDateTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		receivedAt time.Time
+		maxSkew    time.Duration
+		wantValid  bool
+	}{
+		{name: "within the skew", receivedAt: time.Date(2024, 1, 15, 10, 4, 0, 0, time.UTC), maxSkew: 5 * time.Minute, wantValid: true},
+		{name: "before requestDateTime", receivedAt: time.Date(2024, 1, 15, 9, 54, 0, 0, time.UTC), maxSkew: 5 * time.Minute},
+		{name: "receipt time unknown", maxSkew: 5 * time.Minute, wantValid: true},
+		{name: "check disabled", receivedAt: time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC), wantValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := PaginationOptions{ReceivedAt: tt.receivedAt, MaxSkew: tt.maxSkew}
+			result, err := GetPaginationValidator(log.NewLogger("PANIC"), options).Validate(DynamicStruct{"meta": map[string]interface{}{"requestDateTime": "2024-01-15T10:00:00Z"}})
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("Validate() = %+v, want valid %v", result, tt.wantValid)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Rule is a business rule, a CEL expression that is true when the value is valid. The expressions can use the
+// variables body (the body of the message), headers (the metadata of the message, map of strings) and item (the
+// value of the field), and the function date(string) for dates without time (e.g. 2024-01-15)
+type Rule struct {
+	ID         string      `json:"id"`                // Identifier of the rule, reported on the errors
+	Field      string      `json:"field,omitempty"`   // Path of the values checked, the arrays are traversed, empty for the root
+	Expression string      `json:"expression"`        // CEL expression, true when the value is valid
+	Message    string      `json:"message,omitempty"` // Description of the violation, by default a text with the identifier
+	program    cel.Program // Compiled expression
+}
+
+// getEnvironment returns the environment of the expressions of the rules, created on the first compilation
+//
+// Parameters:
+//
+// Returns:
+//   - *cel.Env: Environment with the variables and functions of the rules
+//   - error: error if the environment cannot be created
+func (rc *RulesCache) getEnvironment() (*cel.Env, error) {
+	rc.environmentOnce.Do(func() {
+		rc.environment, rc.environmentError = cel.NewEnv(
+			cel.Variable("body", cel.DynType),
+			cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("item", cel.DynType),
+			cel.Function("date",
+				cel.Overload("date_string", []*cel.Type{cel.StringType}, cel.TimestampType,
+					cel.UnaryBinding(parseDate))),
+		)
+	})
+
+	return rc.environment, rc.environmentError
+}
+
+// parseDate converts a date without time to a timestamp, for the function date of the expressions
+//
+// Parameters:
+//   - value: Date (e.g. 2024-01-15)
+//
+// Returns:
+//   - ref.Val: Timestamp of the date at 00:00 UTC, or an error value if the date is invalid
+func parseDate(value ref.Val) ref.Val {
+	text, ok := value.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(value)
+	}
+
+	date, err := time.Parse(time.DateOnly, string(text))
+	if err != nil {
+		return types.NewErr("invalid date: %s", string(text))
+	}
+
+	return types.Timestamp{Time: date}
+}
+
+// compile Compiles the expression of the rule
+//
+// Parameters:
+//   - environment: Environment of the expressions
+//
+// Returns:
+//   - error: error if the expression is invalid or does not return a boolean
+func (rule *Rule) compile(environment *cel.Env) error {
+	ast, issues := environment.Compile(rule.Expression)
+	if issues != nil && issues.Err() != nil {
+		return issues.Err()
+	}
+
+	if !ast.OutputType().IsExactType(cel.BoolType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return errors.New("expression must return a boolean, found: " + ast.OutputType().String())
+	}
+
+	program, err := environment.Program(ast)
+	rule.program = program
+	return err
+}
+
+// evaluate Evaluates the expression of the rule on a value
+//
+// Parameters:
+//   - body: Body of the message
+//   - headers: Headers of the message
+//   - item: Value of the field of the rule
+//
+// Returns:
+//   - bool: true if the value meets the rule
+//   - error: error if the expression cannot be evaluated (e.g. a field used by the expression is not found)
+func (rule *Rule) evaluate(body DynamicStruct, headers map[string]string, item interface{}) (bool, error) {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	output, _, err := rule.program.Eval(map[string]interface{}{
+		"body":    map[string]interface{}(body),
+		"headers": headers,
+		"item":    item,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	valid, ok := output.Value().(bool)
+	if !ok {
+		return false, errors.New("expression did not return a boolean")
+	}
+
+	return valid, nil
+}
+
+// getMessage returns the description of a violation of the rule
+//
+// Parameters:
+//
+// Returns:
+//   - string: Message of the rule, or a text with the identifier
+func (rule *Rule) getMessage() string {
+	if rule.Message != "" {
+		return rule.Message
+	}
+
+	return "Does not meet rule '" + rule.ID + "'"
+}
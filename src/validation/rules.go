@@ -0,0 +1,292 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+	"github.com/google/cel-go/cel"
+)
+
+// maxParsedRules is the maximum number of parsed rules kept in a cache
+const maxParsedRules = 256
+
+// Rules are the validation rules of an endpoint, declared as JSON in APIEndpointSetting.BodyValidationRules for the
+// body and in APIEndpointSetting.HeaderValidationRules for the headers. The headers are the metadata of the message
+// known by MQD (endpoint, version, server organisation, consent, etc.), not the HTTP headers of the response, e.g.
+// {"formats": [{"field": "data.cpfNumber", "format": "cpf"}],
+// "rules": [{"id": "total-records", "expression": "size(body.data) == body.meta.totalRecords"}]}
+type Rules struct {
+	Formats []FieldFormat `json:"formats"` // Custom formats checked on the fields
+	Rules   []Rule        `json:"rules"`   // Business rules checked on the fields
+}
+
+// FieldFormat declares the custom format of a field
+type FieldFormat struct {
+	Field  string `json:"field"`  // Path of the field as the keys of Result.Errors (e.g. data.brandName), the arrays are traversed
+	Format string `json:"format"` // Name of the custom format (e.g. cpf, cnpj, currency)
+}
+
+// RulesCache stores the parsed rules of the endpoints and the environment of their expressions, so the rules are
+// compiled once, when the endpoint settings are loaded. The compiled expressions are safe for concurrent use
+type RulesCache struct {
+	mutex            sync.Mutex        // Mutex for the rules
+	rules            map[string]*Rules // Parsed rules by JSON document
+	environmentOnce  sync.Once         // Creates the environment on the first compilation
+	environment      *cel.Env          // Environment of the expressions
+	environmentError error             // Error creating the environment
+}
+
+// NewRulesCache creates a new cache of parsed rules
+//
+// Parameters:
+//
+// Returns:
+//   - *RulesCache: Cache created
+func NewRulesCache() *RulesCache {
+	return &RulesCache{rules: make(map[string]*Rules)}
+}
+
+// Parse reads the validation rules of an endpoint and compiles their expressions, the rules are kept in the cache
+//
+// Parameters:
+//   - rules: JSON document with the rules, can be empty
+//
+// Returns:
+//   - *Rules: Rules read, empty if there are no rules
+//   - error: error if the document is invalid, a rule uses an unknown format or an expression cannot be compiled
+func (rc *RulesCache) Parse(rules string) (*Rules, error) {
+	if strings.TrimSpace(rules) == "" {
+		return &Rules{}, nil
+	}
+
+	rc.mutex.Lock()
+	result, found := rc.rules[rules]
+	rc.mutex.Unlock()
+	if found {
+		return result, nil
+	}
+
+	result = &Rules{}
+	err := json.Unmarshal([]byte(rules), result)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fieldFormat := range result.Formats {
+		if fieldFormat.Field == "" {
+			return nil, errors.New("validation rules: field is required for format: " + fieldFormat.Format)
+		}
+
+		if !IsCustomFormat(fieldFormat.Format) {
+			return nil, errors.New("validation rules: unknown format: " + fieldFormat.Format + ", field: " + fieldFormat.Field)
+		}
+	}
+
+	environment, err := rc.getEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool)
+	for i := range result.Rules {
+		rule := &result.Rules[i]
+		if rule.ID == "" || ids[rule.ID] {
+			return nil, errors.New("validation rules: rule id is required and must be unique: " + rule.ID)
+		}
+
+		ids[rule.ID] = true
+		err = rule.compile(environment)
+		if err != nil {
+			return nil, errors.Join(errors.New("validation rules: invalid expression for rule: "+rule.ID), err)
+		}
+	}
+
+	rc.mutex.Lock()
+	if len(rc.rules) >= maxParsedRules {
+		// The rules of previous settings are discarded with the rest of the cache
+		clear(rc.rules)
+	}
+
+	rc.rules[rules] = result
+	rc.mutex.Unlock()
+	return result, nil
+}
+
+// Len returns the number of parsed rules in the cache
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of rules documents
+func (rc *RulesCache) Len() int {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	return len(rc.rules)
+}
+
+// IsEmpty indicates if there are no rules to check
+//
+// Parameters:
+//
+// Returns:
+//   - bool: true if there are no formats nor rules
+func (r *Rules) IsEmpty() bool {
+	return len(r.Formats) == 0 && len(r.Rules) == 0
+}
+
+// RulesValidator Validator that checks the validation rules of an endpoint
+type RulesValidator struct {
+	pack    string            // Package name
+	rules   *Rules            // Rules to check
+	body    DynamicStruct     // Body of the message, available to the expressions as body
+	headers map[string]string // Headers of the message, available to the expressions as headers
+	logger  log.Logger        // Logger
+}
+
+// GetRulesValidator is for creating a RulesValidator
+//
+// Parameters:
+//   - logger: Logger to be used
+//   - rules: Rules to check
+//   - body: Body of the message, available to the expressions as body
+//   - headers: Headers of the message, available to the expressions as headers
+//
+// Returns:
+//   - *RulesValidator: Validator created
+func GetRulesValidator(logger log.Logger, rules *Rules, body DynamicStruct, headers map[string]string) *RulesValidator {
+	return &RulesValidator{
+		pack:    "RulesValidator",
+		rules:   rules,
+		body:    body,
+		headers: headers,
+		logger:  logger,
+	}
+}
+
+// Validate Checks the rules on a dynamic structure (the body for the body rules, the headers for the header rules).
+// The fields not found are ignored, the schema validation reports them
+//
+// Parameters:
+//   - data: DynamicStruct where the fields of the rules are searched
+//
+// Returns:
+//   - *Result: Result of the validation
+//   - error: error if the validation cannot be executed
+func (rv *RulesValidator) Validate(data DynamicStruct) (*Result, error) {
+	rv.logger.Info("Starting Validation With Rules", rv.pack, "Validate")
+
+	validationResult := Result{Valid: true, Errors: make(map[string][]string)}
+	for _, fieldFormat := range rv.rules.Formats {
+		visitField(map[string]interface{}(data), getFieldPath(fieldFormat.Field), "", func(pointer string, value interface{}) {
+			text, ok := value.(string)
+			if !ok || CheckFormat(fieldFormat.Format, text) {
+				return
+			}
+
+			rv.addError(&validationResult, Error{
+				Code:       CodeFormat,
+				Field:      fieldFormat.Field,
+				Pointer:    pointer,
+				Constraint: fieldFormat.Format,
+				Message:    "Does not match format '" + fieldFormat.Format + "'",
+			})
+		})
+	}
+
+	for _, rule := range rv.rules.Rules {
+		visitField(map[string]interface{}(data), getFieldPath(rule.Field), "", func(pointer string, value interface{}) {
+			valid, err := rule.evaluate(rv.body, rv.headers, value)
+			if err != nil {
+				// Missing fields and wrong types are reported by the schema validation, the rule is not reported
+				rv.logger.Debug("Rule "+rule.ID+" not evaluated: "+err.Error(), rv.pack, "Validate")
+				return
+			}
+
+			if valid {
+				return
+			}
+
+			field := rule.Field
+			if field == "" {
+				field = "(root)"
+			}
+
+			rv.addError(&validationResult, Error{
+				Code:       CodeRule,
+				Field:      field,
+				Pointer:    pointer,
+				Constraint: rule.Expression,
+				Message:    rule.getMessage(),
+				RuleID:     rule.ID,
+			})
+		})
+	}
+
+	return &validationResult, nil
+}
+
+// addError Adds an error to the result of the validation
+//
+// Parameters:
+//   - validationResult: Result of the validation
+//   - validationError: Error found
+//
+// Returns:
+func (rv *RulesValidator) addError(validationResult *Result, validationError Error) {
+	rv.logger.Debug(validationError.Field+": "+validationError.Message, rv.pack, "addError")
+	validationResult.Valid = false
+	validationResult.Errors[validationError.Field] = append(validationResult.Errors[validationError.Field], validationError.Message)
+	validationResult.Details = append(validationResult.Details, validationError)
+}
+
+// getFieldPath returns the property names of the path of a field
+//
+// Parameters:
+//   - field: Path of the field, empty for the root of the document
+//
+// Returns:
+//   - []string: Property names of the path
+func getFieldPath(field string) []string {
+	if field == "" {
+		return nil
+	}
+
+	return strings.Split(field, ".")
+}
+
+// visitField Calls a function for each value found on a path, the arrays found are traversed
+//
+// Parameters:
+//   - value: Value where the path is searched
+//   - path: Property names of the path
+//   - pointer: JSON pointer of the value
+//   - visit: Function called for each value found, with its JSON pointer
+//
+// Returns:
+func visitField(value interface{}, path []string, pointer string, visit func(pointer string, value interface{})) {
+	if array, ok := value.([]interface{}); ok {
+		for i, item := range array {
+			visitField(item, path, pointer+"/"+strconv.Itoa(i), visit)
+		}
+
+		return
+	}
+
+	if len(path) == 0 {
+		visit(pointer, value)
+		return
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if child, found := object[path[0]]; found {
+		visitField(child, path[1:], pointer+"/"+escapePointer(path[0]), visit)
+	}
+}
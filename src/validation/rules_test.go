@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/OpenBanking-Brasil/MQD_Client/crosscutting/log"
+)
+
+// testDebugLogger is a logger that records the debug messages of the rules not evaluated
+type testDebugLogger struct {
+	log.Logger
+	debugs []string
+}
+
+func (tl *testDebugLogger) Debug(message string, pack string, component string) {
+	if strings.Contains(message, " not evaluated") {
+		tl.debugs = append(tl.debugs, message)
+	}
+}
+
+// readTestBody reads the body of a message
+func readTestBody(t *testing.T, body string) DynamicStruct {
+	t.Helper()
+	var data DynamicStruct
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestRulesCacheParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     string
+		wantErr   bool
+		wantRules int
+		wantLen   int
+	}{
+		{name: "empty", rules: " ", wantLen: 0},
+		{name: "formats and rules", rules: `{"formats":[{"field":"data.cpf","format":"cpf"}],"rules":[{"id":"r1","expression":"size(body.data) > 0"}]}`, wantRules: 1, wantLen: 1},
+		{name: "invalid JSON", rules: `{`, wantErr: true},
+		{name: "unknown format", rules: `{"formats":[{"field":"data.cpf","format":"rg"}]}`, wantErr: true},
+		{name: "format without field", rules: `{"formats":[{"format":"cpf"}]}`, wantErr: true},
+		{name: "rule without id", rules: `{"rules":[{"expression":"true"}]}`, wantErr: true},
+		{name: "repeated rule id", rules: `{"rules":[{"id":"r1","expression":"true"},{"id":"r1","expression":"true"}]}`, wantErr: true},
+		{name: "invalid expression", rules: `{"rules":[{"id":"r1","expression":"body.data ==="}]}`, wantErr: true},
+		{name: "expression not boolean", rules: `{"rules":[{"id":"r1","expression":"'a'"}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewRulesCache()
+			rules, err := cache.Parse(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && len(rules.Rules) != tt.wantRules {
+				t.Errorf("rules = %d, want %d", len(rules.Rules), tt.wantRules)
+			}
+
+			if cache.Len() != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", cache.Len(), tt.wantLen)
+			}
+
+			if again, _ := cache.Parse(tt.rules); err == nil && tt.wantLen > 0 && again != rules {
+				t.Errorf("Parse() compiled the rules again")
+			}
+		})
+	}
+}
+
+func TestRulesValidatorValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      string
+		body       string
+		headers    map[string]string
+		header     bool // The rules are checked on the headers
+		wantErrors []string
+		wantDebugs []string
+	}{
+		{
+			name:       "format of the items of an array",
+			rules:      `{"formats":[{"field":"data.cpf","format":"cpf"}]}`,
+			body:       `{"data":[{"cpf":"52998224725"},{"cpf":"52998224724"}]}`,
+			wantErrors: []string{"format /data/1/cpf"},
+		},
+		{
+			name:       "rule of the items of an array",
+			rules:      `{"rules":[{"id":"due-date","field":"data","expression":"date(item.dueDate) > date(item.issueDate)"}]}`,
+			body:       `{"data":[{"dueDate":"2024-01-02","issueDate":"2024-01-01"},{"dueDate":"2024-01-01","issueDate":"2024-01-02"}]}`,
+			wantErrors: []string{"rule /data/1 due-date"},
+		},
+		{
+			name:       "rule of the root",
+			rules:      `{"rules":[{"id":"total-records","expression":"size(body.data) == body.meta.totalRecords"}]}`,
+			body:       `{"data":[1],"meta":{"totalRecords":2}}`,
+			wantErrors: []string{"rule  total-records"},
+		},
+		{
+			name:       "rule not evaluated is logged",
+			rules:      `{"rules":[{"id":"total-records","expression":"size(body.data) == body.meta.totalRecords"}]}`,
+			body:       `{"data":[1]}`,
+			wantDebugs: []string{"Rule total-records not evaluated"},
+		},
+		{
+			name:       "header rule",
+			rules:      `{"rules":[{"id":"role","field":"role","expression":"item in ['DADOS', 'PAGTO']"}]}`,
+			body:       `{}`,
+			headers:    map[string]string{"role": "OTHER"},
+			header:     true,
+			wantErrors: []string{"rule /role role"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewRulesCache().Parse(tt.rules)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			body := readTestBody(t, tt.body)
+			content := body
+			if tt.header {
+				content = DynamicStruct{}
+				for name, value := range tt.headers {
+					content[name] = value
+				}
+			}
+
+			logger := &testDebugLogger{Logger: log.NewLogger("PANIC")}
+			result, err := GetRulesValidator(logger, rules, body, tt.headers).Validate(content)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			errors := make([]string, 0)
+			for _, detail := range result.Details {
+				errors = append(errors, strings.TrimSpace(string(detail.Code)+" "+detail.Pointer+" "+detail.RuleID))
+			}
+
+			if strings.Join(errors, "|") != strings.Join(tt.wantErrors, "|") || result.Valid != (len(tt.wantErrors) == 0) {
+				t.Errorf("errors = %v, valid %v, want %v", errors, result.Valid, tt.wantErrors)
+			}
+
+			if len(logger.debugs) != len(tt.wantDebugs) {
+				t.Fatalf("debug messages = %v, want %v", logger.debugs, tt.wantDebugs)
+			}
+
+			for i, message := range tt.wantDebugs {
+				if !strings.HasPrefix(logger.debugs[i], message) {
+					t.Errorf("debug message = %q, want %q", logger.debugs[i], message)
+				}
+			}
+		})
+	}
+}
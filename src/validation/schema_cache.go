@@ -0,0 +1,76 @@
+package validation
+
+import (
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// maxCompiledSchemas is the maximum number of compiled schemas kept in a cache
+const maxCompiledSchemas = 256
+
+// compiledSchemaKey identifies a compiled schema in the cache
+type compiledSchemaKey struct {
+	schema        string // JSON Schema
+	assertFormats bool   // Indicates if the formats were asserted when the schema was compiled
+}
+
+// SchemaCache stores the compiled schemas of the draft 2020-12 engine, to compile each schema once when a validator
+// is created for each message. The compiled schemas are safe for concurrent use
+type SchemaCache struct {
+	mutex   sync.Mutex                               // Mutex for the schemas
+	schemas map[compiledSchemaKey]*jsonschema.Schema // Compiled schemas
+}
+
+// NewSchemaCache creates a new cache of compiled schemas
+//
+// Parameters:
+//
+// Returns:
+//   - *SchemaCache: Cache created
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{schemas: make(map[compiledSchemaKey]*jsonschema.Schema)}
+}
+
+// get returns a compiled schema
+//
+// Parameters:
+//   - key: Schema and options of the compilation
+//
+// Returns:
+//   - *jsonschema.Schema: Compiled schema, nil if not found
+func (sc *SchemaCache) get(key compiledSchemaKey) *jsonschema.Schema {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	return sc.schemas[key]
+}
+
+// add Adds a compiled schema, when the cache is full the schemas of the previous settings are discarded with the
+// rest of the cache
+//
+// Parameters:
+//   - key: Schema and options of the compilation
+//   - schema: Compiled schema
+//
+// Returns:
+func (sc *SchemaCache) add(key compiledSchemaKey, schema *jsonschema.Schema) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	if len(sc.schemas) >= maxCompiledSchemas {
+		clear(sc.schemas)
+	}
+
+	sc.schemas[key] = schema
+}
+
+// Len returns the number of compiled schemas in the cache
+//
+// Parameters:
+//
+// Returns:
+//   - int: Number of schemas
+func (sc *SchemaCache) Len() int {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	return len(sc.schemas)
+}
@@ -13,9 +13,10 @@ type DynamicStruct map[string]interface{}
 
 // SchemaValidator Validator that uses JSON Schemas
 type SchemaValidator struct {
-	pack   string     // Package name
-	schema string     // JSON Schema
-	logger log.Logger // Logger
+	pack    string     // Package name
+	schema  string     // JSON Schema
+	logger  log.Logger // Logger
+	options Options    // Options of the validation
 }
 
 // GetSchemaValidator is for creating a SchemaValidator
@@ -26,10 +27,12 @@ type SchemaValidator struct {
 // @return
 // SchemaValidator instance
 func GetSchemaValidator(logger log.Logger, schema string) *SchemaValidator {
+	registerDraft7Formats(logger)
 	return &SchemaValidator{
-		pack:   "SchemaValidator",
-		schema: schema,
-		logger: logger,
+		pack:    "SchemaValidator",
+		schema:  schema,
+		logger:  logger,
+		options: DefaultOptions(),
 	}
 }
 
@@ -57,34 +60,45 @@ func (sm *SchemaValidator) Validate(data DynamicStruct) (*Result, error) {
 	}
 
 	if !result.Valid() {
-		validationResult.Errors = sm.cleanErrors(result.Errors())
-		validationResult.Valid = false
+		validationResult.Errors, validationResult.Details = sm.cleanErrors(result.Errors())
+		validationResult.Valid = len(validationResult.Details) == 0
 		return &validationResult, nil
 	}
 
 	return &validationResult, nil
 }
 
-// cleanErrors Creates an array or clean error based on the validations
-// @author AB
-// @params
-// error: List of errors generated during the validation
-// @return
-// ErrorDetail: List of errors found
-func (sm *SchemaValidator) cleanErrors(errors []gojsonschema.ResultError) map[string][]string {
+// cleanErrors Creates the errors of the result based on the validations, the conditional errors (if / then / else)
+// are only included in the structured errors, the errors of the branch are reported separately. The format errors
+// are ignored if the formats are not asserted
+//
+// Parameters:
+//   - errors: List of errors generated during the validation
+//
+// Returns:
+//   - map[string][]string: Messages of the errors by field
+//   - []Error: Structured errors
+func (sm *SchemaValidator) cleanErrors(errors []gojsonschema.ResultError) (map[string][]string, []Error) {
 	result := make(map[string][]string)
-	for _, desc := range errors {
-		if strings.Contains(desc.String(), "\"if\"") {
+	details := make([]Error, 0, len(errors))
+	for _, resultError := range errors {
+		field := cleanString(resultError.Field())
+		desc := cleanString(resultError.Description())
+		detail := newError(resultError, field, desc)
+		if detail.Code == CodeFormat && !sm.options.AssertFormats {
 			continue
 		}
 
-		field := sm.cleanString(desc.Field())
-		desc := sm.cleanString(desc.Description())
-		result[field] = append(result[field], desc)
-		sm.logger.Debug(field+": "+desc, sm.pack, "cleanErrors")
+		details = append(details, detail)
+		sm.logger.Debug(field+": "+detail.Message, sm.pack, "cleanErrors")
+		if detail.Code == CodeConditional {
+			continue
+		}
+
+		result[field] = append(result[field], detail.Message)
 	}
 
-	return result
+	return result, details
 }
 
 // cleanString removes unnecessary information from the field an error fields
@@ -94,7 +108,7 @@ func (sm *SchemaValidator) cleanErrors(errors []gojsonschema.ResultError) map[st
 //
 // Returns:
 //   - string: clean string
-func (sm *SchemaValidator) cleanString(value string) string {
+func cleanString(value string) string {
 	if !strings.Contains(value, "data") {
 		return value
 	}
@@ -102,7 +116,7 @@ func (sm *SchemaValidator) cleanString(value string) string {
 	values := strings.Split(value, ".")
 	result := ""
 	for _, v := range values {
-		if !sm.isNumeric(v) {
+		if !isNumeric(v) {
 			if result == "" {
 				result = v
 			} else {
@@ -121,7 +135,7 @@ func (sm *SchemaValidator) cleanString(value string) string {
 //
 // Returns:
 //   - bool: tru if value is numeric
-func (sm *SchemaValidator) isNumeric(s string) bool {
+func isNumeric(s string) bool {
 	_, err := strconv.ParseFloat(s, 64)
 	return err == nil
 }
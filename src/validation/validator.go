@@ -2,11 +2,35 @@ package validation
 
 // Result stores the results for the validations
 type Result struct {
-	Valid  bool                // Indicates the result of the validation
-	Errors map[string][]string // Stores the error details for the validation
+	Valid   bool                // Indicates the result of the validation
+	Errors  map[string][]string // Messages of the errors by field, kept for compatibility, conditional errors are not included
+	Details []Error             // Structured errors of the validation
 }
 
 // Validator is the Interface that exposes the methods to validate structures
 type Validator interface {
 	Validate(data DynamicStruct) (*Result, error)
 }
+
+// Append Adds the errors of another result, the result is not valid if the other result is not valid
+//
+// Parameters:
+//   - other: Result with the errors to add
+//
+// Returns:
+func (r *Result) Append(other *Result) {
+	if other.Valid {
+		return
+	}
+
+	if r.Errors == nil {
+		r.Errors = make(map[string][]string)
+	}
+
+	for key, value := range other.Errors {
+		r.Errors[key] = append(r.Errors[key], value...)
+	}
+
+	r.Details = append(r.Details, other.Details...)
+	r.Valid = false
+}